@@ -4,6 +4,7 @@ import (
 	"log/slog"
 	"sync"
 
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/handlers"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
 )
@@ -14,14 +15,16 @@ type Application struct {
 	handlers *handlers.HandlerRepo
 	logger   *slog.Logger
 	queries  *store.Queries
+	worker   *executor.WorkerPool
 }
 
-func NewApplication(cfg *Config, logger *slog.Logger, queries *store.Queries, handlerRepo *handlers.HandlerRepo) *Application {
+func NewApplication(cfg *Config, logger *slog.Logger, queries *store.Queries, handlerRepo *handlers.HandlerRepo, worker *executor.WorkerPool) *Application {
 	return &Application{
 		cfg:      cfg,
 		logger:   logger,
 		queries:  queries,
 		handlers: handlerRepo,
+		worker:   worker,
 	}
 }
 
@@ -3,6 +3,8 @@ package api
 import (
 	"net/http"
 
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/auth"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/ratelimit"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
 )
@@ -11,44 +13,107 @@ func (app *Application) routes() http.Handler {
 	mux := chi.NewRouter()
 
 	mux.Use(cors.AllowAll().Handler)
+	mux.Use(app.handlers.RequestID)
+
+	readLimit := app.handlers.RateLimit(ratelimit.BudgetRead)
 
 	mux.Route("/events", func(r chi.Router) {
 		// Public routes for events
-		r.Get("/", app.handlers.GetEventsHandler)
-		r.Get("/{event_id}/rooms", app.handlers.GetEventRoomsHandler)
+		r.With(readLimit).Get("/", app.handlers.GetEventsHandler)
+		r.With(readLimit).Get("/{event_id}/rooms", app.handlers.GetEventRoomsHandler)
 
-		// Auth-protected routes for event interaction
+		// SSE streams authenticate with a stream ticket, restricted events require one to spectate
 		r.Get("/{event_id}/leaderboard", app.handlers.SpectateEventHandler)
 		r.Get("/{event_id}/rooms/{room_id}/leaderboard", app.handlers.JoinRoomHandler)
-		r.Post("/{event_id}/rooms/{room_id}/submit", app.handlers.SubmitSolutionInRoomHandler)
-		r.Get("/{event_id}/rooms/{room_id}/problems", app.handlers.GetRoomProblemsHandler)
+
+		// Auth-protected routes for event interaction
+		r.Group(func(r chi.Router) {
+			r.Use(app.handlers.AuthMiddleware)
+			r.Post("/{event_id}/leaderboard/ticket", app.handlers.IssueSpectateTicketHandler)
+			r.Post("/{event_id}/rooms/{room_id}/leaderboard/ticket", app.handlers.IssueRoomTicketHandler)
+			r.With(app.handlers.RequirePermission(auth.PermSubmitSolutions), app.handlers.RateLimit(ratelimit.BudgetSubmit)).
+				Post("/{event_id}/rooms/{room_id}/submit", app.handlers.SubmitSolutionInRoomHandler)
+			r.With(readLimit).Get("/{event_id}/rooms/{room_id}/problems", app.handlers.GetRoomProblemsHandler)
+			r.With(app.handlers.RequirePermission(auth.PermSubmitSolutions), readLimit).
+				Post("/{event_id}/rooms/{room_id}/integrity", app.handlers.ReportIntegrityHandler)
+
+			// Guild leader: register their guild to an event
+			r.With(app.handlers.RequirePermission(auth.PermRegisterGuild)).
+				Post("/{event_id}/guilds/{guild_id}", app.handlers.RegisterGuildToEventHandler)
+		})
 	})
 
 	// Routes for managing event creation requests
 	mux.Route("/event-requests", func(r chi.Router) {
+		r.Use(app.handlers.AuthMiddleware)
+		r.Use(app.handlers.RequirePermission(auth.PermRequestEvents))
+
 		// Requester: Submit a new request to create an event
 		r.Post("/", app.handlers.CreateEventHandler)
 
 		// Requester: View their own submitted requests
-		r.Get("/my", app.handlers.GetMyEventRequestsHandler) // Assumes guild_id is a query param
+		r.Get("/my", app.handlers.GetMyEventRequestsHandler)
 	})
 
 	mux.Route("/admin", func(r chi.Router) {
-		r.Get("/event-requests", app.handlers.GetEventRequestsHandler)
-		r.Post("/event-requests/{request_id}/process", app.handlers.ProcessEventRequestHandler)
+		r.Use(app.handlers.AuthMiddleware)
+		r.Use(app.handlers.RequireRole(auth.RolePlatformAdmin))
+
+		r.With(app.handlers.RequirePermission(auth.PermApproveEventRequests)).
+			Get("/event-requests", app.handlers.GetEventRequestsHandler)
+		r.With(app.handlers.RequirePermission(auth.PermApproveEventRequests)).
+			Post("/event-requests/{request_id}/process", app.handlers.ProcessEventRequestHandler)
+
+		r.With(app.handlers.RequirePermission(auth.PermManageEvents)).
+			Put("/events/{event_id}/rate-limits", app.handlers.SetEventRateLimitsHandler)
+		r.With(app.handlers.RequirePermission(auth.PermManageEvents)).
+			Put("/events/{event_id}/integrity-rules", app.handlers.SetEventIntegrityRulesHandler)
+		r.With(app.handlers.RequirePermission(auth.PermReviewSubmissions)).
+			Get("/events/{event_id}/integrity", app.handlers.GetIntegritySummariesHandler)
+
+		r.With(app.handlers.RequirePermission(auth.PermManageStandings)).
+			Post("/rejudge", app.handlers.RejudgeHandler)
+		r.With(app.handlers.RequirePermission(auth.PermManageStandings)).
+			Post("/events/{event_id}/rooms/{room_id}/adjustments", app.handlers.AdjustScoreHandler)
+		r.With(app.handlers.RequirePermission(auth.PermManageStandings)).
+			Get("/events/{event_id}/adjustments", app.handlers.GetScoreAdjustmentsHandler)
+		r.With(app.handlers.RequirePermission(auth.PermManageStandings)).
+			Post("/events/{event_id}/disqualifications", app.handlers.DisqualifyHandler)
+		r.With(app.handlers.RequirePermission(auth.PermManageStandings)).
+			Get("/events/{event_id}/disqualifications", app.handlers.GetDisqualificationsHandler)
+		r.With(app.handlers.RequirePermission(auth.PermManageStandings)).
+			Delete("/events/{event_id}/disqualifications/{disqualification_id}", app.handlers.ReinstateHandler)
+
+		r.With(app.handlers.RequirePermission(auth.PermReviewSubmissions)).
+			Post("/similarities/scan", app.handlers.ScanSimilarityHandler)
+		r.With(app.handlers.RequirePermission(auth.PermReviewSubmissions)).
+			Get("/similarities", app.handlers.GetSimilaritiesHandler)
+
+		r.With(app.handlers.RequirePermission(auth.PermViewAuditLog)).
+			Get("/audit", app.handlers.GetAuditLogsHandler)
+
+		r.With(app.handlers.RequirePermission(auth.PermMonitorWorkers)).
+			Get("/worker-pool", app.handlers.GetWorkerPoolStatsHandler)
+
+		r.With(app.handlers.RequirePermission(auth.PermManageProblems)).
+			Post("/problems/{problem_id}/drivers", app.handlers.GenerateDriversHandler)
 	})
 
 	mux.Route("/submissions", func(r chi.Router) {
-		r.Post("/", app.handlers.SubmitSolutionHandler)
+		r.Use(app.handlers.AuthMiddleware)
+		r.Use(app.handlers.RequirePermission(auth.PermSubmitSolutions))
+
+		r.With(app.handlers.RateLimit(ratelimit.BudgetSubmit)).Post("/", app.handlers.SubmitSolutionHandler)
 	})
 
 	mux.Route("/problems", func(r chi.Router) {
 		// Public routes for problems
-		r.Get("/", app.handlers.GetProblemsHandler)
-		r.Get("/{problem_id}", app.handlers.GetProblemHandler)
+		r.With(readLimit).Get("/", app.handlers.GetProblemsHandler)
+		r.With(readLimit).Get("/{problem_id}", app.handlers.GetProblemHandler)
 
 		// Auth-protected routes for problem details
-		r.Get("/{problem_id}/details", app.handlers.GetProblemDetails)
+		r.With(app.handlers.AuthMiddleware, app.handlers.RequirePermission(auth.PermViewProblems), readLimit).
+			Get("/{problem_id}/details", app.handlers.GetProblemDetails)
 	})
 	return mux
 }
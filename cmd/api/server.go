@@ -31,11 +31,21 @@ func (app *Application) Run() error {
 
 	shutdownErrorChan := make(chan error)
 
+	// background tasks live as long as the server does
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	app.wg.Add(2)
+	go app.worker.MonitorContainers(bgCtx, &app.wg)
+	go app.worker.Autoscale(bgCtx, &app.wg)
+
 	go func() {
 		quitChan := make(chan os.Signal, 1)
 		signal.Notify(quitChan, syscall.SIGINT, syscall.SIGTERM)
 		<-quitChan
 
+		stopBackground()
+
 		ctx, cancel := context.WithTimeout(context.Background(), defaultShutdownPeriod)
 		defer cancel()
 
@@ -57,5 +67,6 @@ func (app *Application) Run() error {
 	app.logger.Info("stopped server", slog.Group("server", "addr", srv.Addr))
 
 	app.wg.Wait()
+	app.worker.Shutdown()
 	return nil
 }
@@ -1,23 +1,29 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"log/slog"
 	"net"
 	"os"
 	"runtime/debug"
+	"strings"
+	"time"
 
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/cmd/api"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/database"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/protos"
 	"google.golang.org/grpc"
 
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/audit"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/handlers"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/ratelimit"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/service"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/env"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/jwt"
 	"github.com/joho/godotenv"
 	"github.com/lmittmann/tint"
 )
@@ -51,30 +57,51 @@ func main() {
 	slog.SetDefault(logger) // Set default for any library using slog's default logger
 
 	worker, err := executor.NewWorkerPool(logger, queries, &executor.WorkerPoolOptions{
-		MaxWorkers:       5,
-		MemoryLimitBytes: 512,
-		MaxJobCount:      3,
-		CpuNanoLimit:     1000,
+		MinWorkers:           env.GetInt("WORKER_POOL_MIN_WORKERS", 2),
+		MaxWorkers:           env.GetInt("WORKER_POOL_MAX_WORKERS", 8),
+		MemoryLimitBytes:     512,
+		MaxJobCount:          env.GetInt("WORKER_POOL_MAX_JOB_COUNT", 20),
+		CpuNanoLimit:         1000,
+		HealthCheckInterval:  executor.DefaultHealthCheckInterval,
+		QueueTimeout:         executor.DefaultQueueTimeout,
+		ScaleUpWaitThreshold: executor.DefaultScaleUpWaitThreshold,
+		ScaleDownCooldown:    executor.DefaultScaleDownCooldown,
+		ScaleCheckInterval:   executor.DefaultScaleCheckInterval,
+		MaxInFlightPerPlayer: env.GetInt("WORKER_POOL_MAX_IN_FLIGHT_PER_PLAYER", executor.DefaultMaxInFlightPerPlayer),
+		BuildCacheSize:       env.GetInt("WORKER_POOL_BUILD_CACHE_SIZE", executor.DefaultBuildCacheSize),
 	})
 	if err != nil {
 		panic(err)
 	}
 
+	languages, err := executor.LoadLanguageRegistry(context.Background(), queries, env.GetString("LANGUAGES_CONFIG_PATH", ""))
+	if err != nil {
+		panic(err)
+	}
+
 	pkgAnalyzer := executor.NewGoPackageAnalyzer()
 	codeBuilder := executor.NewCodeBuilder([]executor.PackageAnalyzer{pkgAnalyzer}, logger)
 
-	handlerRepo := handlers.NewHandlerRepo(logger, db, queries, codeBuilder, worker)
+	jwtParser := newJWTParser(logger)
+
+	// shared by both servers so a player has one budget over HTTP and gRPC
+	rateLimits := newRateLimits(queries, logger)
 
-	app := api.NewApplication(cfg, logger, queries, handlerRepo)
+	// one audit trail for admin, scoring and gRPC actions
+	recorder := audit.NewRecorder(queries, logger)
+
+	handlerRepo := handlers.NewHandlerRepo(logger, db, queries, codeBuilder, worker, languages, jwtParser, rateLimits, recorder)
+
+	app := api.NewApplication(cfg, logger, queries, handlerRepo, worker)
 
 	// run grpc server
 	lis, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", cfg.GrpcPort))
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
-	var opts []grpc.ServerOption
-	grpcServer := grpc.NewServer(opts...)
-	protos.RegisterCodeBattleServiceServer(grpcServer, service.NewCodeBattleServer(queries, logger))
+	interceptors := service.NewInterceptors(jwtParser, service.DefaultMethodPolicies, rateLimits, logger)
+	grpcServer := grpc.NewServer(interceptors.ServerOptions()...)
+	protos.RegisterCodeBattleServiceServer(grpcServer, service.NewCodeBattleServer(queries, recorder, logger))
 
 	go grpcServer.Serve(lis)
 
@@ -90,3 +117,46 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// newJWTParser verifies HMAC tokens with JWT_SECRET_KEY and asymmetric tokens with keys from
+// JWT_JWKS_URL or, e.g. for local testing, the JWT_JWKS_FILE key set
+func newJWTParser(logger *slog.Logger) *jwt.JWTParser {
+	secKey := env.GetString("JWT_SECRET_KEY", "")
+	jwksURL := env.GetString("JWT_JWKS_URL", "")
+	jwksFile := env.GetString("JWT_JWKS_FILE", "")
+	if secKey == "" && jwksURL == "" && jwksFile == "" {
+		panic("one of JWT_SECRET_KEY, JWT_JWKS_URL or JWT_JWKS_FILE env must be set")
+	}
+
+	opts := []jwt.Option{
+		jwt.WithIssuer(env.GetString("JWT_ISSUER", "")),
+		jwt.WithAudience(env.GetString("JWT_AUDIENCE", "")),
+		jwt.WithLeeway(time.Duration(env.GetInt("JWT_LEEWAY_SECONDS", 30)) * time.Second),
+	}
+
+	cacheTTL := time.Duration(env.GetInt("JWT_JWKS_CACHE_SECONDS", 300)) * time.Second
+	switch {
+	case jwksURL != "":
+		opts = append(opts, jwt.WithKeySource(jwt.NewRemoteJWKS(jwksURL, nil, cacheTTL, logger)))
+	case jwksFile != "":
+		opts = append(opts, jwt.WithKeySource(jwt.NewFileJWKS(jwksFile, cacheTTL, logger)))
+	}
+
+	return jwt.NewJWTParser(secKey, logger, opts...)
+}
+
+// newRateLimits reads the default budgets from RATE_LIMIT_<BUDGET>_PER_MINUTE and
+// RATE_LIMIT_<BUDGET>_BURST, e.g. RATE_LIMIT_SUBMIT_PER_MINUTE. Events can override them.
+func newRateLimits(queries *store.Queries, logger *slog.Logger) *ratelimit.Policy {
+	defaults := make(ratelimit.Limits, len(ratelimit.DefaultLimits))
+	for budget, limit := range ratelimit.DefaultLimits {
+		prefix := "RATE_LIMIT_" + strings.ToUpper(string(budget))
+		defaults[budget] = ratelimit.Limit{
+			PerMinute: float64(env.GetInt(prefix+"_PER_MINUTE", int(limit.PerMinute))),
+			Burst:     env.GetInt(prefix+"_BURST", limit.Burst),
+		}
+	}
+
+	cacheTTL := time.Duration(env.GetInt("RATE_LIMIT_EVENT_CACHE_SECONDS", 60)) * time.Second
+	return ratelimit.NewPolicy(defaults, queries, cacheTTL, logger)
+}
@@ -0,0 +1,180 @@
+// Package audit records administrative and scoring actions to the append-only audit_logs
+// table, so disputes about events and leaderboards can be investigated after the fact.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"unicode"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/auth"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Action is what happened, named <target>.<verb>
+type Action string
+
+const (
+	ActionEventRequestApproved       Action = "event_request.approved"
+	ActionEventRequestDeclined       Action = "event_request.declined"
+	ActionEventRateLimitsUpdated     Action = "event.rate_limits_updated"
+	ActionEventIntegrityRulesUpdated Action = "event.integrity_rules_updated"
+	ActionProblemDriversGenerated    Action = "code_problem.drivers_generated"
+	ActionSimilarityScanned          Action = "similarity.scanned"
+	ActionRoomDeleted                Action = "room.deleted"
+	ActionRoomPlayerScoreAdded       Action = "room_player.score_added"
+	ActionRoomPlayerFlagChanged      Action = "room_player.flag_changed"
+	ActionSubmissionCreated          Action = "submission.created"
+	ActionSubmissionRejudged         Action = "submission.rejudged"
+	ActionProblemRejudged            Action = "code_problem.rejudged"
+	ActionRoomPlayerScoreAdjusted    Action = "room_player.score_adjusted"
+	ActionDisqualificationCreated    Action = "disqualification.created"
+	ActionDisqualificationDeleted    Action = "disqualification.deleted"
+)
+
+// TargetType is the kind of entity an action changed
+type TargetType string
+
+const (
+	TargetEventRequest TargetType = "event_request"
+	TargetEvent        TargetType = "event"
+	TargetCodeProblem  TargetType = "code_problem"
+	TargetRoom         TargetType = "room"
+	// TargetRoomPlayer IDs are "<room_id>/<user_id>"
+	TargetRoomPlayer       TargetType = "room_player"
+	TargetSubmission       TargetType = "submission"
+	TargetDisqualification TargetType = "disqualification"
+)
+
+// RoomPlayerTarget is the target ID of a player in a room
+func RoomPlayerTarget(roomID, userID uuid.UUID) string {
+	return roomID.String() + "/" + userID.String()
+}
+
+// Entry is one audited action
+type Entry struct {
+	Action     Action
+	TargetType TargetType
+	TargetID   string
+	// EventID ties the action to an event, uuid.Nil when it is not about one
+	EventID uuid.UUID
+	// Before and After are stored as JSON, nil stores NULL. A json.RawMessage, like a jsonb
+	// column, is stored as is.
+	Before any
+	After  any
+	// ActorID is who acted. When it is uuid.Nil the authenticated caller in the context is
+	// used, and without one the action is recorded as done by the system.
+	ActorID uuid.UUID
+}
+
+// Store persists entries, it is implemented by *store.Queries
+type Store interface {
+	CreateAuditLog(ctx context.Context, arg store.CreateAuditLogParams) (store.AuditLog, error)
+}
+
+type Recorder struct {
+	store  Store
+	logger *slog.Logger
+}
+
+func NewRecorder(s Store, logger *slog.Logger) *Recorder {
+	return &Recorder{store: s, logger: logger}
+}
+
+// Record appends e to the audit log. The action has already happened when it is recorded,
+// so a failure does not undo it and is only logged, with the entry, to keep the trail.
+func (rc *Recorder) Record(ctx context.Context, e Entry) {
+	params, err := rc.params(ctx, e)
+	if err == nil {
+		_, err = rc.store.CreateAuditLog(ctx, params)
+	}
+	if err != nil {
+		rc.logger.Error("failed to record audit entry",
+			"err", err,
+			"action", e.Action,
+			"target_type", e.TargetType,
+			"target_id", e.TargetID,
+			"actor_id", params.ActorID,
+			"request_id", params.RequestID.String)
+	}
+}
+
+func (rc *Recorder) params(ctx context.Context, e Entry) (store.CreateAuditLogParams, error) {
+	params := store.CreateAuditLogParams{
+		ActorID:    toPgtypeUUID(e.ActorID),
+		Action:     string(e.Action),
+		TargetType: string(e.TargetType),
+		TargetID:   e.TargetID,
+		EventID:    toPgtypeUUID(e.EventID),
+	}
+
+	if !params.ActorID.Valid {
+		if claims, ok := auth.FromContext(ctx); ok {
+			if id, err := uuid.Parse(claims.ID); err == nil {
+				params.ActorID = toPgtypeUUID(id)
+			}
+		}
+	}
+
+	if id := RequestID(ctx); id != "" {
+		params.RequestID = pgtype.Text{String: id, Valid: true}
+	}
+
+	var err error
+	if params.Before, err = encode(e.Before); err != nil {
+		return params, err
+	}
+	if params.After, err = encode(e.After); err != nil {
+		return params, err
+	}
+	return params, nil
+}
+
+func encode(v any) ([]byte, error) {
+	if raw, ok := v.(json.RawMessage); ok {
+		if len(raw) == 0 {
+			return nil, nil
+		}
+		return raw, nil
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+func toPgtypeUUID(id uuid.UUID) pgtype.UUID {
+	return pgtype.UUID{Bytes: id, Valid: id != uuid.Nil}
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the ID of the request being served
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the ID stored by WithRequestID, or an empty string
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// maxRequestIDLength bounds the request IDs accepted from clients
+const maxRequestIDLength = 128
+
+// NormalizeRequestID keeps a request ID sent by a client or proxy, so entries can be
+// matched with their logs, and generates one when it is missing or unusable
+func NormalizeRequestID(id string) string {
+	id = strings.TrimSpace(id)
+	if id == "" || len(id) > maxRequestIDLength || strings.IndexFunc(id, func(r rune) bool {
+		return r > unicode.MaxASCII || !unicode.IsPrint(r)
+	}) >= 0 {
+		return uuid.NewString()
+	}
+	return id
+}
@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/auth"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/jwt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	logs []store.CreateAuditLogParams
+	err  error
+}
+
+func (s *fakeStore) CreateAuditLog(_ context.Context, arg store.CreateAuditLogParams) (store.AuditLog, error) {
+	if s.err != nil {
+		return store.AuditLog{}, s.err
+	}
+	s.logs = append(s.logs, arg)
+	return store.AuditLog{}, nil
+}
+
+func TestRecord(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	adminID, eventID := uuid.New(), uuid.New()
+
+	t.Run("Caller and request ID come from the context", func(t *testing.T) {
+		s := &fakeStore{}
+		ctx := auth.NewContext(context.Background(), &jwt.UserClaims{ID: adminID.String()})
+		ctx = WithRequestID(ctx, "req-1")
+
+		NewRecorder(s, logger).Record(ctx, Entry{
+			Action:     ActionEventRateLimitsUpdated,
+			TargetType: TargetEvent,
+			TargetID:   eventID.String(),
+			EventID:    eventID,
+			Before:     json.RawMessage(nil),
+			After:      map[string]int{"burst": 3},
+		})
+
+		require.Len(t, s.logs, 1)
+		log := s.logs[0]
+		assert.Equal(t, adminID, uuid.UUID(log.ActorID.Bytes))
+		assert.True(t, log.ActorID.Valid)
+		assert.Equal(t, "event.rate_limits_updated", log.Action)
+		assert.Equal(t, "event", log.TargetType)
+		assert.Equal(t, eventID, uuid.UUID(log.EventID.Bytes))
+		assert.Nil(t, log.Before, "an empty jsonb column is stored as NULL")
+		assert.JSONEq(t, `{"burst": 3}`, string(log.After))
+		assert.Equal(t, "req-1", log.RequestID.String)
+	})
+
+	t.Run("System actions have no actor", func(t *testing.T) {
+		s := &fakeStore{}
+		NewRecorder(s, logger).Record(context.Background(), Entry{
+			Action:     ActionRoomPlayerScoreAdded,
+			TargetType: TargetRoomPlayer,
+			TargetID:   RoomPlayerTarget(uuid.New(), uuid.New()),
+			Before:     json.RawMessage(`{"score": 10}`),
+		})
+
+		require.Len(t, s.logs, 1)
+		assert.False(t, s.logs[0].ActorID.Valid)
+		assert.False(t, s.logs[0].EventID.Valid)
+		assert.False(t, s.logs[0].RequestID.Valid)
+		assert.JSONEq(t, `{"score": 10}`, string(s.logs[0].Before))
+	})
+
+	t.Run("Explicit actor wins over the caller", func(t *testing.T) {
+		s := &fakeStore{}
+		playerID := uuid.New()
+		ctx := auth.NewContext(context.Background(), &jwt.UserClaims{ID: adminID.String()})
+
+		NewRecorder(s, logger).Record(ctx, Entry{Action: ActionSubmissionCreated, ActorID: playerID})
+		assert.Equal(t, playerID, uuid.UUID(s.logs[0].ActorID.Bytes))
+	})
+
+	t.Run("Failures do not panic", func(t *testing.T) {
+		s := &fakeStore{err: errors.New("db is down")}
+		assert.NotPanics(t, func() {
+			NewRecorder(s, logger).Record(context.Background(), Entry{Action: ActionRoomDeleted, After: func() {}})
+			NewRecorder(s, logger).Record(context.Background(), Entry{Action: ActionRoomDeleted})
+		})
+	})
+}
+
+func TestNormalizeRequestID(t *testing.T) {
+	assert.Equal(t, "abc-123", NormalizeRequestID(" abc-123 "))
+
+	for _, id := range []string{"", "bad\nid", "ünïcode", strings.Repeat("a", maxRequestIDLength+1)} {
+		generated := NormalizeRequestID(id)
+		_, err := uuid.Parse(generated)
+		assert.NoError(t, err, "a new ID replaces %q", id)
+	}
+}
@@ -0,0 +1,109 @@
+// Package auth holds the role and permission model shared by the HTTP and gRPC servers,
+// and carries the caller's verified claims through a context.
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/jwt"
+)
+
+// Role is a role name carried in UserClaims.Roles
+type Role string
+
+const (
+	RolePlatformAdmin Role = "platform_admin"
+	RoleGuildLeader   Role = "guild_leader"
+	// RolePlayer is held by every authenticated user, whether or not the token lists it
+	RolePlayer Role = "player"
+)
+
+// Permission is an action a role may perform
+type Permission string
+
+const (
+	PermApproveEventRequests Permission = "event_requests:approve"
+	PermManageEvents         Permission = "events:manage"
+	PermManageProblems       Permission = "problems:manage"
+	PermManageStandings      Permission = "standings:manage"
+	PermViewAuditLog         Permission = "audit:view"
+	PermMonitorWorkers       Permission = "workers:monitor"
+	PermRequestEvents        Permission = "events:request"
+	PermReviewSubmissions    Permission = "submissions:review"
+	PermRegisterGuild        Permission = "guilds:register"
+	PermSubmitSolutions      Permission = "submissions:create"
+	PermViewProblems         Permission = "problems:view"
+)
+
+var playerPermissions = []Permission{PermSubmitSolutions, PermViewProblems}
+
+var guildLeaderPermissions = append([]Permission{PermRequestEvents, PermRegisterGuild}, playerPermissions...)
+
+// rolePermissions lists what each role may do. Higher roles include the permissions of the
+// roles below them.
+var rolePermissions = map[Role][]Permission{
+	RolePlayer:      playerPermissions,
+	RoleGuildLeader: guildLeaderPermissions,
+	RolePlatformAdmin: append([]Permission{
+		PermApproveEventRequests,
+		PermManageEvents,
+		PermManageProblems,
+		PermManageStandings,
+		PermMonitorWorkers,
+		PermReviewSubmissions,
+		PermViewAuditLog,
+	}, guildLeaderPermissions...),
+}
+
+// NormalizeRole lets tokens spell roles as "Platform Admin", "guild-leader" or "GUILD_LEADER"
+func NormalizeRole(role string) Role {
+	role = strings.ToLower(strings.TrimSpace(role))
+	role = strings.NewReplacer(" ", "_", "-", "_").Replace(role)
+	return Role(role)
+}
+
+// Roles returns the roles held by claims, always including RolePlayer
+func Roles(claims *jwt.UserClaims) []Role {
+	roles := []Role{RolePlayer}
+	for _, role := range claims.Roles {
+		roles = append(roles, NormalizeRole(role))
+	}
+	return roles
+}
+
+// HasRole reports whether claims hold any of want
+func HasRole(claims *jwt.UserClaims, want ...Role) bool {
+	for _, role := range Roles(claims) {
+		for _, w := range want {
+			if role == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func HasPermission(claims *jwt.UserClaims, perm Permission) bool {
+	for _, role := range Roles(claims) {
+		for _, p := range rolePermissions[role] {
+			if p == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the caller's verified claims
+func NewContext(ctx context.Context, claims *jwt.UserClaims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// FromContext returns the claims stored by NewContext
+func FromContext(ctx context.Context) (*jwt.UserClaims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*jwt.UserClaims)
+	return claims, ok && claims != nil
+}
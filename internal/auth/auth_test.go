@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasPermission(t *testing.T) {
+	t.Run("Every user has player permissions", func(t *testing.T) {
+		claims := &jwt.UserClaims{ID: "p"}
+		assert.True(t, HasPermission(claims, PermSubmitSolutions))
+		assert.True(t, HasPermission(claims, PermViewProblems))
+		assert.False(t, HasPermission(claims, PermRequestEvents))
+		assert.False(t, HasPermission(claims, PermApproveEventRequests))
+	})
+
+	t.Run("Guild leader can request events and register guilds", func(t *testing.T) {
+		claims := &jwt.UserClaims{ID: "g", Roles: []string{"guild_leader"}}
+		assert.True(t, HasPermission(claims, PermRequestEvents))
+		assert.True(t, HasPermission(claims, PermRegisterGuild))
+		assert.True(t, HasPermission(claims, PermSubmitSolutions))
+		assert.False(t, HasPermission(claims, PermManageProblems))
+		assert.False(t, HasPermission(claims, PermManageStandings))
+	})
+
+	t.Run("Platform admin has every permission", func(t *testing.T) {
+		claims := &jwt.UserClaims{ID: "a", Roles: []string{"Platform Admin"}}
+		for _, perm := range []Permission{
+			PermApproveEventRequests, PermManageProblems, PermManageStandings, PermMonitorWorkers,
+			PermRequestEvents, PermRegisterGuild, PermSubmitSolutions, PermViewProblems,
+		} {
+			assert.True(t, HasPermission(claims, perm), perm)
+		}
+	})
+
+	t.Run("Role names are normalized", func(t *testing.T) {
+		assert.Equal(t, RoleGuildLeader, NormalizeRole(" Guild-Leader "))
+		assert.Equal(t, RolePlatformAdmin, NormalizeRole("PLATFORM_ADMIN"))
+	})
+}
+
+func TestContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	claims := &jwt.UserClaims{ID: "p"}
+	got, ok := FromContext(NewContext(context.Background(), claims))
+	assert.True(t, ok)
+	assert.Same(t, claims, got)
+}
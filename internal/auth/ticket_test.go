@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTicketIssuer(t *testing.T) {
+	now := time.Now()
+	issuer := NewTicketIssuer([]byte("ticket-secret"), time.Minute)
+	issuer.now = func() time.Time { return now }
+
+	want := StreamTicket{UserID: "user", Username: "alice", EventID: "event", RoomID: "room"}
+	ticket, expiresAt, err := issuer.Issue(want)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(time.Minute).Unix(), expiresAt.Unix())
+
+	t.Run("Round trip", func(t *testing.T) {
+		got, err := issuer.Verify(ticket)
+		require.NoError(t, err)
+		want.ExpiresAt = expiresAt.Unix()
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("Tampered payload", func(t *testing.T) {
+		forged, _, err := NewTicketIssuer([]byte("ticket-secret"), time.Minute).Issue(StreamTicket{UserID: "mallory", EventID: "event"})
+		require.NoError(t, err)
+		forgedPayload, _, _ := strings.Cut(forged, ".")
+		_, sig, _ := strings.Cut(ticket, ".")
+		_, err = issuer.Verify(forgedPayload + "." + sig)
+		assert.ErrorIs(t, err, ErrInvalidTicket)
+	})
+
+	t.Run("Other key", func(t *testing.T) {
+		_, err := NewTicketIssuer([]byte("other-secret"), time.Minute).Verify(ticket)
+		assert.ErrorIs(t, err, ErrInvalidTicket)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		_, err := issuer.Verify(ticket)
+		assert.ErrorIs(t, err, ErrTicketExpired)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		_, err := issuer.Verify("not-a-ticket")
+		assert.ErrorIs(t, err, ErrInvalidTicket)
+	})
+}
@@ -0,0 +1,133 @@
+package drivergen
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLanguages holds the placeholders of the seeded languages rows
+var testLanguages = executor.NewLanguageRegistry(
+	executor.LanguageSpec{Name: executor.LanguageGo, CodePlaceholder: "// USER_CODE_HERE", ImportPlaceholder: "// IMPORTS_HERE"},
+	executor.LanguageSpec{Name: executor.LanguagePython, CodePlaceholder: "# USER_CODE_HERE"},
+	executor.LanguageSpec{Name: executor.LanguageJavascript, CodePlaceholder: "// USER_CODE_HERE"},
+	executor.LanguageSpec{Name: executor.LanguageRust, CodePlaceholder: "// USER_CODE_HERE"},
+)
+
+func TestParseType(t *testing.T) {
+	t.Run("Scalars and arrays", func(t *testing.T) {
+		for _, s := range []string{"int", "long", "double", "bool", "string", "int[]", "string[][]"} {
+			typ, err := ParseType(s)
+			require.NoError(t, err)
+			assert.Equal(t, s, typ.String())
+		}
+	})
+
+	t.Run("Invalid types", func(t *testing.T) {
+		for _, s := range []string{"", "float", "int[][][]", "[]int"} {
+			_, err := ParseType(s)
+			assert.Error(t, err, s)
+		}
+	})
+}
+
+func TestSignatureValidate(t *testing.T) {
+	t.Run("Decoded from JSON", func(t *testing.T) {
+		var sig Signature
+		err := json.Unmarshal([]byte(`{"function_name": "twoSum", "params": [{"name": "nums", "type": "int[]"}, {"name": "target", "type": "int"}], "return_type": "int[]"}`), &sig)
+		require.NoError(t, err)
+		require.NoError(t, sig.Validate())
+
+		assert.Equal(t, Type{Base: Int, Dims: 1}, sig.Params[0].Type)
+		assert.Equal(t, FormatJSON, sig.Format)
+	})
+
+	t.Run("Rejected names", func(t *testing.T) {
+		tests := map[string]Signature{
+			"keyword":   {FunctionName: "def", ReturnType: Type{Base: Int}},
+			"driver":    {FunctionName: "driverMain", ReturnType: Type{Base: Int}},
+			"invalid":   {FunctionName: "two-sum", ReturnType: Type{Base: Int}},
+			"duplicate": {FunctionName: "f", Params: []Param{{"a", Type{Base: Int}}, {"a", Type{Base: Int}}}, ReturnType: Type{Base: Int}},
+			"shadowing": {FunctionName: "f", Params: []Param{{"f", Type{Base: Int}}}, ReturnType: Type{Base: Int}},
+			"no return": {FunctionName: "f"},
+			"format":    {FunctionName: "f", ReturnType: Type{Base: Int}, Format: "xml"},
+		}
+		for name, sig := range tests {
+			assert.Error(t, sig.Validate(), name)
+		}
+	})
+}
+
+func TestGenerate(t *testing.T) {
+	registry := testLanguages
+	builder := executor.NewCodeBuilder([]executor.PackageAnalyzer{executor.NewGoPackageAnalyzer()}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	sig := Signature{
+		FunctionName: "twoSum",
+		Params:       []Param{{"nums", Type{Base: Int, Dims: 1}}, {"target", Type{Base: Int}}},
+		ReturnType:   Type{Base: Int, Dims: 1},
+	}
+
+	t.Run("Stubs", func(t *testing.T) {
+		stubs := map[string]string{
+			executor.LanguageGo:         "func twoSum(nums []int, target int) []int {\n\t// write your code here\n\treturn nil\n}\n",
+			executor.LanguagePython:     "def twoSum(nums: list[int], target: int) -> list[int]:\n    # write your code here\n    pass\n",
+			executor.LanguageJavascript: "/**\n * @param {number[]} nums\n * @param {number} target\n * @return {number[]}\n */\nfunction twoSum(nums, target) {\n  // write your code here\n}\n",
+		}
+		for lang, stub := range stubs {
+			spec, _ := registry.Get(lang)
+			code, err := Generate(spec, sig)
+			require.NoError(t, err)
+			assert.Equal(t, stub, code.SolutionStub, lang)
+		}
+	})
+
+	// the generated stub must build into the generated driver and declare the function it calls
+	for _, format := range []Format{FormatJSON, FormatPlain} {
+		sig := sig
+		sig.Format = format
+		for _, lang := range Languages() {
+			t.Run(string(format)+" "+lang, func(t *testing.T) {
+				spec, _ := registry.Get(lang)
+				code, err := Generate(spec, sig)
+				require.NoError(t, err)
+
+				_, err = builder.Build(executor.BuildRequest{
+					Language:        spec,
+					DriverCode:      code.DriverCode,
+					UserCode:        code.SolutionStub,
+					Mode:            executor.SolutionFunction,
+					RequiredSymbols: []string{sig.FunctionName},
+				})
+				assert.NoError(t, err)
+			})
+		}
+	}
+
+	t.Run("Unsupported language", func(t *testing.T) {
+		spec, _ := registry.Get(executor.LanguageRust)
+		_, err := Generate(spec, sig)
+		assert.ErrorIs(t, err, ErrUnsupportedLanguage)
+	})
+}
+
+func TestGoDriver(t *testing.T) {
+	spec, _ := testLanguages.Get(executor.LanguageGo)
+	sig := Signature{
+		FunctionName: "transpose",
+		Params:       []Param{{"grid", Type{Base: Int, Dims: 2}}},
+		ReturnType:   Type{Base: Int, Dims: 2},
+		Format:       FormatPlain,
+	}
+
+	code, err := Generate(spec, sig)
+	require.NoError(t, err)
+	assert.Contains(t, code.DriverCode, "\tgrid := driverReadSlice(driverScanner, func(s *bufio.Scanner) []int { return driverReadSlice(s, driverReadInt) })\n")
+	assert.Contains(t, code.DriverCode, `driverFormatSlice(v, strconv.Itoa, " ", "", "")`)
+	assert.Contains(t, code.DriverCode, `"\n", "", "")`)
+}
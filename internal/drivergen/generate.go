@@ -0,0 +1,61 @@
+package drivergen
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
+)
+
+var ErrUnsupportedLanguage = errors.New("driver generation is not supported for this language")
+
+// Code is the generated stub and driver for one language
+type Code struct {
+	Language     string `json:"language"`
+	SolutionStub string `json:"solution_stub"`
+	DriverCode   string `json:"driver_code"`
+}
+
+// generator renders the stub and driver of one language. Drivers read the parameters from
+// stdin in the signature's format, call the player's function and print the result.
+type generator interface {
+	stub(sig Signature) string
+	driver(sig Signature, spec executor.LanguageSpec) string
+}
+
+// generators are keyed by canonical language name
+var generators = map[string]generator{
+	executor.LanguageGo:         goGenerator{},
+	executor.LanguagePython:     pythonGenerator{},
+	executor.LanguageJavascript: javascriptGenerator{},
+}
+
+// Languages lists the canonical names of the languages drivers can be generated for
+func Languages() []string {
+	names := make([]string, 0, len(generators))
+	for name := range generators {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Generate renders the stub and driver for spec. The driver contains the language's code
+// placeholder, and for Go its import placeholder, so it is built like a hand-written one.
+func Generate(spec executor.LanguageSpec, sig Signature) (Code, error) {
+	if err := sig.Validate(); err != nil {
+		return Code{}, err
+	}
+
+	g, ok := generators[spec.Name]
+	if !ok {
+		return Code{}, fmt.Errorf("%w: %s", ErrUnsupportedLanguage, spec.Name)
+	}
+
+	return Code{
+		Language:     spec.Name,
+		SolutionStub: g.stub(sig),
+		DriverCode:   g.driver(sig, spec),
+	}, nil
+}
@@ -0,0 +1,200 @@
+package drivergen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
+)
+
+type goGenerator struct{}
+
+var goScalars = map[BaseType]string{Int: "int", Long: "int64", Double: "float64", Bool: "bool", String: "string"}
+
+func goType(t Type) string {
+	return strings.Repeat("[]", t.Dims) + goScalars[t.Base]
+}
+
+func goZero(t Type) string {
+	switch {
+	case t.Dims > 0:
+		return "nil"
+	case t.Base == Bool:
+		return "false"
+	case t.Base == String:
+		return `""`
+	default:
+		return "0"
+	}
+}
+
+func (goGenerator) stub(sig Signature) string {
+	params := make([]string, len(sig.Params))
+	for i, p := range sig.Params {
+		params[i] = p.Name + " " + goType(p.Type)
+	}
+
+	return fmt.Sprintf("func %s(%s) %s {\n\t// write your code here\n\treturn %s\n}\n",
+		sig.FunctionName, strings.Join(params, ", "), goType(sig.ReturnType), goZero(sig.ReturnType))
+}
+
+// goDriverJSON reads one JSON value per non-empty line
+const goDriverJSON = `
+func driverRead(scanner *bufio.Scanner, v any) {
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		if err := json.Unmarshal(scanner.Bytes(), v); err != nil {
+			panic(err)
+		}
+		return
+	}
+	panic("driver: missing input")
+}
+
+func driverFormatString(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+`
+
+// goDriverPlain reads whitespace separated tokens, arrays are preceded by their length
+const goDriverPlain = `
+func driverToken(scanner *bufio.Scanner) string {
+	if !scanner.Scan() {
+		panic("driver: missing input")
+	}
+	return scanner.Text()
+}
+
+func driverReadInt(scanner *bufio.Scanner) int {
+	v, err := strconv.Atoi(driverToken(scanner))
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func driverReadLong(scanner *bufio.Scanner) int64 {
+	v, err := strconv.ParseInt(driverToken(scanner), 10, 64)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func driverReadDouble(scanner *bufio.Scanner) float64 {
+	v, err := strconv.ParseFloat(driverToken(scanner), 64)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func driverReadBool(scanner *bufio.Scanner) bool {
+	return driverToken(scanner) == "true"
+}
+
+func driverReadString(scanner *bufio.Scanner) string {
+	return driverToken(scanner)
+}
+
+func driverReadSlice[T any](scanner *bufio.Scanner, read func(*bufio.Scanner) T) []T {
+	out := make([]T, driverReadInt(scanner))
+	for i := range out {
+		out[i] = read(scanner)
+	}
+	return out
+}
+`
+
+const goDriverFormat = `
+func driverFormatSlice[T any](xs []T, format func(T) string, sep, open, close string) string {
+	parts := make([]string, len(xs))
+	for i, x := range xs {
+		parts[i] = format(x)
+	}
+	return open + strings.Join(parts, sep) + close
+}
+`
+
+// goReader returns a func(*bufio.Scanner) T reading a value of t in plain format
+func goReader(t Type) string {
+	if t.Dims == 0 {
+		return "driverRead" + strings.ToUpper(string(t.Base[:1])) + string(t.Base[1:])
+	}
+	return fmt.Sprintf("func(s *bufio.Scanner) %s { return driverReadSlice(s, %s) }", goType(t), goReader(t.Elem()))
+}
+
+// goFormatter returns a func(T) string printing a value of t
+func goFormatter(t Type, format Format) string {
+	if t.Dims == 0 {
+		switch t.Base {
+		case Int:
+			return "strconv.Itoa"
+		case Long:
+			return "func(v int64) string { return strconv.FormatInt(v, 10) }"
+		case Double:
+			return "func(v float64) string { return strconv.FormatFloat(v, 'f', 5, 64) }"
+		case Bool:
+			return "strconv.FormatBool"
+		case String:
+			if format == FormatJSON {
+				return "driverFormatString"
+			}
+			return "func(v string) string { return v }"
+		}
+	}
+
+	sep, open, close := ",", "[", "]"
+	if format == FormatPlain {
+		sep, open, close = " ", "", ""
+		if t.Dims > 1 {
+			sep = "\n"
+		}
+	}
+	return fmt.Sprintf("func(v %s) string { return driverFormatSlice(v, %s, %q, %q, %q) }",
+		goType(t), goFormatter(t.Elem(), format), sep, open, close)
+}
+
+func (goGenerator) driver(sig Signature, spec executor.LanguageSpec) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "package main\n\n%s\n\n%s\n\nfunc main() {\n", spec.ImportPlaceholder, spec.CodePlaceholder)
+	b.WriteString("\tdriverScanner := bufio.NewScanner(os.Stdin)\n")
+	b.WriteString("\tdriverScanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)\n")
+	if sig.Format == FormatPlain {
+		b.WriteString("\tdriverScanner.Split(bufio.ScanWords)\n")
+	}
+	b.WriteString("\n")
+
+	args := make([]string, len(sig.Params))
+	for i, p := range sig.Params {
+		args[i] = p.Name
+		if sig.Format == FormatJSON {
+			fmt.Fprintf(&b, "\tvar %s %s\n\tdriverRead(driverScanner, &%s)\n", p.Name, goType(p.Type), p.Name)
+			continue
+		}
+		if p.Type.Dims > 0 {
+			fmt.Fprintf(&b, "\t%s := driverReadSlice(driverScanner, %s)\n", p.Name, goReader(p.Type.Elem()))
+			continue
+		}
+		fmt.Fprintf(&b, "\t%s := %s(driverScanner)\n", p.Name, goReader(p.Type))
+	}
+
+	fmt.Fprintf(&b, "\n\tdriverResult := %s(%s)\n", sig.FunctionName, strings.Join(args, ", "))
+	fmt.Fprintf(&b, "\tfmt.Println((%s)(driverResult))\n}\n", goFormatter(sig.ReturnType, sig.Format))
+
+	if sig.Format == FormatJSON {
+		b.WriteString(goDriverJSON)
+	} else {
+		b.WriteString(goDriverPlain)
+	}
+	b.WriteString(goDriverFormat)
+
+	return b.String()
+}
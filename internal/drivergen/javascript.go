@@ -0,0 +1,102 @@
+package drivergen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
+)
+
+type javascriptGenerator struct{}
+
+var javascriptScalars = map[BaseType]string{Int: "number", Long: "number", Double: "number", Bool: "boolean", String: "string"}
+
+func javascriptType(t Type) string {
+	return javascriptScalars[t.Base] + strings.Repeat("[]", t.Dims)
+}
+
+// stub documents the types with JSDoc, since JavaScript has no type annotations
+func (javascriptGenerator) stub(sig Signature) string {
+	var b strings.Builder
+
+	b.WriteString("/**\n")
+	names := make([]string, len(sig.Params))
+	for i, p := range sig.Params {
+		names[i] = p.Name
+		fmt.Fprintf(&b, " * @param {%s} %s\n", javascriptType(p.Type), p.Name)
+	}
+	fmt.Fprintf(&b, " * @return {%s}\n */\n", javascriptType(sig.ReturnType))
+	fmt.Fprintf(&b, "function %s(%s) {\n  // write your code here\n}\n", sig.FunctionName, strings.Join(names, ", "))
+
+	return b.String()
+}
+
+// javascriptReader returns an expression reading a value of t from the tokens.
+// Array.from reads the length before calling the element function.
+func javascriptReader(t Type) string {
+	if t.Dims > 0 {
+		return fmt.Sprintf("Array.from({ length: Number(driverNext()) }, () => %s)", javascriptReader(t.Elem()))
+	}
+
+	switch t.Base {
+	case Bool:
+		return `driverNext() === "true"`
+	case String:
+		return "driverNext()"
+	default:
+		return "Number(driverNext())"
+	}
+}
+
+// javascriptFormatter returns an expression printing the value named v
+func javascriptFormatter(t Type, format Format, v string) string {
+	if t.Dims > 0 {
+		x := fmt.Sprintf("x%d", t.Dims)
+		elem := javascriptFormatter(t.Elem(), format, x)
+		switch {
+		case format == FormatJSON:
+			return fmt.Sprintf(`"[" + %s.map((%s) => %s).join(",") + "]"`, v, x, elem)
+		case t.Dims > 1:
+			return fmt.Sprintf(`%s.map((%s) => %s).join("\n")`, v, x, elem)
+		default:
+			return fmt.Sprintf(`%s.map((%s) => %s).join(" ")`, v, x, elem)
+		}
+	}
+
+	switch {
+	case t.Base == Double:
+		return fmt.Sprintf("Number(%s).toFixed(5)", v)
+	case t.Base == String && format == FormatJSON:
+		return fmt.Sprintf("JSON.stringify(%s)", v)
+	default:
+		return fmt.Sprintf("String(%s)", v)
+	}
+}
+
+func (javascriptGenerator) driver(sig Signature, spec executor.LanguageSpec) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s\n\nfunction driverMain() {\n", spec.CodePlaceholder)
+	b.WriteString("  const driverInput = require(\"fs\").readFileSync(0, \"utf8\");\n")
+	if sig.Format == FormatJSON {
+		b.WriteString("  const driverLines = driverInput.split(\"\\n\").filter((line) => line.trim() !== \"\");\n")
+	} else {
+		b.WriteString("  const driverTokens = driverInput.split(/\\s+/).filter(Boolean);\n")
+		b.WriteString("  let driverPos = 0;\n  const driverNext = () => driverTokens[driverPos++];\n")
+	}
+
+	args := make([]string, len(sig.Params))
+	for i, p := range sig.Params {
+		args[i] = p.Name
+		if sig.Format == FormatJSON {
+			fmt.Fprintf(&b, "  const %s = JSON.parse(driverLines[%d]);\n", p.Name, i)
+			continue
+		}
+		fmt.Fprintf(&b, "  const %s = %s;\n", p.Name, javascriptReader(p.Type))
+	}
+
+	fmt.Fprintf(&b, "\n  const driverResult = %s(%s);\n", sig.FunctionName, strings.Join(args, ", "))
+	fmt.Fprintf(&b, "  console.log(%s);\n}\n\ndriverMain();\n", javascriptFormatter(sig.ReturnType, sig.Format, "driverResult"))
+
+	return b.String()
+}
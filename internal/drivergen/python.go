@@ -0,0 +1,105 @@
+package drivergen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
+)
+
+type pythonGenerator struct{}
+
+var pythonScalars = map[BaseType]string{Int: "int", Long: "int", Double: "float", Bool: "bool", String: "str"}
+
+func pythonType(t Type) string {
+	if t.Dims == 0 {
+		return pythonScalars[t.Base]
+	}
+	return "list[" + pythonType(t.Elem()) + "]"
+}
+
+func (pythonGenerator) stub(sig Signature) string {
+	params := make([]string, len(sig.Params))
+	for i, p := range sig.Params {
+		params[i] = p.Name + ": " + pythonType(p.Type)
+	}
+
+	return fmt.Sprintf("def %s(%s) -> %s:\n    # write your code here\n    pass\n",
+		sig.FunctionName, strings.Join(params, ", "), pythonType(sig.ReturnType))
+}
+
+// pythonReader returns an expression reading a value of t from the token iterator.
+// Comprehensions evaluate their range first, so the length is read before the elements.
+func pythonReader(t Type) string {
+	if t.Dims > 0 {
+		return fmt.Sprintf("[%s for _ in range(int(next(driver_tokens)))]", pythonReader(t.Elem()))
+	}
+
+	switch t.Base {
+	case Int, Long:
+		return "int(next(driver_tokens))"
+	case Double:
+		return "float(next(driver_tokens))"
+	case Bool:
+		return `next(driver_tokens) == "true"`
+	default:
+		return "next(driver_tokens)"
+	}
+}
+
+// pythonFormatter returns an expression printing the value named v
+func pythonFormatter(t Type, format Format, v string) string {
+	if t.Dims > 0 {
+		x := fmt.Sprintf("x%d", t.Dims)
+		elem := pythonFormatter(t.Elem(), format, x)
+		switch {
+		case format == FormatJSON:
+			return fmt.Sprintf(`"[" + ",".join(%s for %s in %s) + "]"`, elem, x, v)
+		case t.Dims > 1:
+			return fmt.Sprintf(`"\n".join(%s for %s in %s)`, elem, x, v)
+		default:
+			return fmt.Sprintf(`" ".join(%s for %s in %s)`, elem, x, v)
+		}
+	}
+
+	switch t.Base {
+	case Double:
+		return fmt.Sprintf(`f"{%s:.5f}"`, v)
+	case Bool:
+		return fmt.Sprintf(`("true" if %s else "false")`, v)
+	case String:
+		if format == FormatJSON {
+			return fmt.Sprintf("json.dumps(%s, ensure_ascii=False)", v)
+		}
+		return v
+	default:
+		return fmt.Sprintf("str(%s)", v)
+	}
+}
+
+func (pythonGenerator) driver(sig Signature, spec executor.LanguageSpec) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "import json\nimport sys\n\n%s\n\n\ndef driver_main():\n", spec.CodePlaceholder)
+	if sig.Format == FormatJSON {
+		b.WriteString("    driver_lines = [line for line in sys.stdin.read().splitlines() if line.strip()]\n")
+	} else {
+		b.WriteString("    driver_tokens = iter(sys.stdin.read().split())\n")
+	}
+
+	args := make([]string, len(sig.Params))
+	for i, p := range sig.Params {
+		args[i] = p.Name
+		if sig.Format == FormatJSON {
+			fmt.Fprintf(&b, "    %s = json.loads(driver_lines[%d])\n", p.Name, i)
+			continue
+		}
+		fmt.Fprintf(&b, "    %s = %s\n", p.Name, pythonReader(p.Type))
+	}
+
+	fmt.Fprintf(&b, "\n    driver_result = %s(%s)\n", sig.FunctionName, strings.Join(args, ", "))
+	fmt.Fprintf(&b, "    print(%s)\n", pythonFormatter(sig.ReturnType, sig.Format, "driver_result"))
+	b.WriteString("\n\nif __name__ == \"__main__\":\n    driver_main()\n")
+
+	return b.String()
+}
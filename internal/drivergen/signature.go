@@ -0,0 +1,171 @@
+// Package drivergen generates solution stubs and driver code from a language-neutral
+// function signature, so problem authors do not have to write a driver per language.
+package drivergen
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type BaseType string
+
+const (
+	Int    BaseType = "int"
+	Long   BaseType = "long"
+	Double BaseType = "double"
+	Bool   BaseType = "bool"
+	String BaseType = "string"
+)
+
+// MaxDims is the deepest array nesting a signature may use, e.g. int[][]
+const MaxDims = 2
+
+// Type is a scalar base type with zero or more array dimensions. In JSON it is written
+// the way authors declare it, e.g. "int[][]".
+type Type struct {
+	Base BaseType
+	Dims int
+}
+
+func ParseType(s string) (Type, error) {
+	s = strings.TrimSpace(s)
+	t := Type{}
+	for strings.HasSuffix(s, "[]") {
+		t.Dims++
+		s = strings.TrimSpace(strings.TrimSuffix(s, "[]"))
+	}
+	t.Base = BaseType(s)
+
+	switch t.Base {
+	case Int, Long, Double, Bool, String:
+	default:
+		return Type{}, fmt.Errorf("unknown type %q", s)
+	}
+	if t.Dims > MaxDims {
+		return Type{}, fmt.Errorf("type %q has more than %d dimensions", t, MaxDims)
+	}
+	return t, nil
+}
+
+func (t Type) String() string {
+	return string(t.Base) + strings.Repeat("[]", t.Dims)
+}
+
+// Elem returns the element type of an array
+func (t Type) Elem() Type {
+	return Type{Base: t.Base, Dims: t.Dims - 1}
+}
+
+func (t Type) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *Type) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := ParseType(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+type Param struct {
+	Name string `json:"name"`
+	Type Type   `json:"type"`
+}
+
+// Format is how test case input and expected output are serialized
+type Format string
+
+const (
+	// FormatJSON puts one JSON value per parameter on its own line, e.g. `[2,7,11,15]` then `9`.
+	// Results are printed as compact JSON.
+	FormatJSON Format = "json"
+	// FormatPlain separates values by whitespace. Arrays are preceded by their length and
+	// strings cannot contain whitespace. Results print 1D arrays space separated and the
+	// rows of 2D arrays on separate lines.
+	FormatPlain Format = "plain"
+)
+
+// Signature is the function the player implements
+type Signature struct {
+	FunctionName string  `json:"function_name"`
+	Params       []Param `json:"params"`
+	ReturnType   Type    `json:"return_type"`
+	Format       Format  `json:"format,omitempty"`
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// reservedNames are keywords or builtins in one of the generated languages, or names the
+// generated drivers use themselves
+var reservedNames = map[string]bool{
+	// Go
+	"break": true, "case": true, "chan": true, "const": true, "continue": true, "default": true,
+	"defer": true, "else": true, "fallthrough": true, "for": true, "func": true, "go": true,
+	"goto": true, "if": true, "import": true, "interface": true, "map": true, "package": true,
+	"range": true, "return": true, "select": true, "struct": true, "switch": true, "type": true,
+	"var": true, "main": true, "len": true, "make": true, "new": true, "append": true,
+	"bufio": true, "bytes": true, "fmt": true, "os": true, "strconv": true, "strings": true,
+	// Python
+	"and": true, "as": true, "assert": true, "async": true, "await": true, "class": true,
+	"def": true, "del": true, "elif": true, "except": true, "finally": true, "from": true,
+	"global": true, "in": true, "is": true, "lambda": true, "nonlocal": true, "not": true,
+	"or": true, "pass": true, "raise": true, "try": true, "while": true, "with": true,
+	"yield": true, "None": true, "True": true, "False": true, "print": true, "input": true,
+	"int": true, "str": true, "float": true, "bool": true, "list": true, "json": true, "sys": true,
+	// JavaScript
+	"catch": true, "debugger": true, "delete": true, "do": true, "enum": true, "export": true,
+	"extends": true, "function": true, "instanceof": true, "let": true, "static": true,
+	"super": true, "this": true, "throw": true, "typeof": true, "void": true, "require": true,
+	"null": true, "true": true, "false": true, "undefined": true, "arguments": true, "eval": true,
+}
+
+func checkName(kind, name string) error {
+	switch {
+	case !identifier.MatchString(name):
+		return fmt.Errorf("%s name %q must be a letter followed by letters, digits or underscores", kind, name)
+	case reservedNames[name] || strings.HasPrefix(strings.ToLower(name), "driver"):
+		return fmt.Errorf("%s name %q is reserved", kind, name)
+	}
+	return nil
+}
+
+// Validate checks the signature and fills in the default format
+func (s *Signature) Validate() error {
+	if err := checkName("function", s.FunctionName); err != nil {
+		return err
+	}
+	if s.ReturnType.Base == "" {
+		return fmt.Errorf("return type is required")
+	}
+
+	seen := map[string]bool{s.FunctionName: true}
+	for _, p := range s.Params {
+		if err := checkName("parameter", p.Name); err != nil {
+			return err
+		}
+		if p.Type.Base == "" {
+			return fmt.Errorf("parameter %q has no type", p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("name %q is used twice", p.Name)
+		}
+		seen[p.Name] = true
+	}
+
+	switch s.Format {
+	case "":
+		s.Format = FormatJSON
+	case FormatJSON, FormatPlain:
+	default:
+		return fmt.Errorf("unknown format %q", s.Format)
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package events
 import (
 	"time"
 
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/integrity"
 	"github.com/google/uuid"
 )
 
@@ -18,6 +20,7 @@ const (
 	COMPILATION_TEST           EventType = "COMPILATION_TEST"
 	LEADERBOARD_UPDATED        EventType = "LEADERBOARD_UPDATED"
 	GUILD_LEADERBOARD_UPDATED  EventType = "GUILD_LEADERBOARD_UPDATED"
+	SUBMISSION_REJECTED        EventType = "SUBMISSION_REJECTED"
 )
 
 // Event wrapper for the listener
@@ -35,6 +38,9 @@ const (
 	CompilationError    JudgeStatus = "Compilation Error"
 	TimeLimitExceeded   JudgeStatus = "Time Limit Exceeded"   // For the future
 	MemoryLimitExceeded JudgeStatus = "Memory Limit Exceeded" // For the future
+	// NotJudged means the job could not run, e.g. no worker was free. It is not a verdict,
+	// the submission stays pending.
+	NotJudged JudgeStatus = "Not Judged"
 )
 
 type SolutionSubmitted struct {
@@ -44,6 +50,7 @@ type SolutionSubmitted struct {
 	RoomID        uuid.UUID
 	ProblemID     uuid.UUID
 	Code          string
+	Sections      map[string]string // named code sections, see executor.BuildRequest
 	Language      string
 	SubmittedTime time.Time
 }
@@ -53,6 +60,21 @@ type SolutionResult struct {
 	Score             int
 	Status            JudgeStatus
 	Message           string
+	ExecutionTimeMs   int64
+	PeakMemoryKB      int64
+}
+
+// SubmissionRejected is sent to a player whose code was rejected before it was run,
+// e.g. because it failed sanitization or its language is not supported.
+// SubmissionNotJudged is the SubmissionRejected kind of a submission the server could not run
+const SubmissionNotJudged = "NOT_JUDGED"
+
+type SubmissionRejected struct {
+	ProblemID uuid.UUID          `json:"problem_id"`
+	Language  string             `json:"language"`
+	Kind      string             `json:"kind"`
+	Message   string             `json:"message"`
+	Findings  []executor.Finding `json:"findings,omitempty"`
 }
 
 type LeaderboardUpdated struct {
@@ -61,7 +83,10 @@ type LeaderboardUpdated struct {
 
 type PlayerJoined struct {
 	PlayerID uuid.UUID
+	Username string
 	RoomID   uuid.UUID
+	// GuildID is the guild the player scores for, uuid.Nil when they have none
+	GuildID uuid.UUID
 }
 
 type PlayerLeft struct {
@@ -69,6 +94,20 @@ type PlayerLeft struct {
 	RoomID   uuid.UUID
 }
 
+// IntegritySignalsReported carries the anti-cheat signals a player's client reported
+// during a battle
+type IntegritySignalsReported struct {
+	PlayerID uuid.UUID
+	RoomID   uuid.UUID
+	Signals  []integrity.Signal
+}
+
+// StandingsChanged asks a room to recalculate its scores, e.g. after a rejudge, a manual
+// adjustment or a disqualification
+type StandingsChanged struct {
+	RoomID uuid.UUID
+}
+
 type RoomDeleted struct {
 	RoomID uuid.UUID
 }
@@ -0,0 +1,178 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultScaleUpWaitThreshold = 2 * time.Second
+	DefaultScaleDownCooldown    = 2 * time.Minute
+	DefaultScaleCheckInterval   = 5 * time.Second
+)
+
+// poolScaler is the pool the autoscaler resizes, implemented by *WorkerPool
+type poolScaler interface {
+	queuedJobs() int
+	// scaleUp and scaleDown report whether the pool size changed
+	scaleUp(reason string) bool
+	scaleDown() bool
+}
+
+// autoscaler grows the container pool when jobs wait too long in the queue and
+// shrinks it back to the minimum after the pool has been idle for a cooldown.
+// The number of worker goroutines always follows the number of containers.
+type autoscaler struct {
+	pool               poolScaler
+	now                func() time.Time
+	waitThreshold      time.Duration
+	cooldown           time.Duration
+	interval           time.Duration
+	maxWaitNanos       atomic.Int64 // longest queue wait observed since the last check
+	lastBusyNanos      atomic.Int64 // unix nanos of the last finished job
+	lastScaleNanos     atomic.Int64
+	scaleUpRequestChan chan struct{}
+}
+
+func newAutoscaler(pool poolScaler, opts *WorkerPoolOptions) *autoscaler {
+	a := &autoscaler{
+		pool:               pool,
+		now:                time.Now,
+		waitThreshold:      opts.ScaleUpWaitThreshold,
+		cooldown:           opts.ScaleDownCooldown,
+		interval:           opts.ScaleCheckInterval,
+		scaleUpRequestChan: make(chan struct{}, 1),
+	}
+
+	if a.waitThreshold <= 0 {
+		a.waitThreshold = DefaultScaleUpWaitThreshold
+	}
+	if a.cooldown <= 0 {
+		a.cooldown = DefaultScaleDownCooldown
+	}
+	if a.interval <= 0 {
+		a.interval = DefaultScaleCheckInterval
+	}
+
+	now := a.now().UnixNano()
+	a.lastBusyNanos.Store(now)
+	a.lastScaleNanos.Store(now)
+
+	return a
+}
+
+// observeWait records how long a job waited in the queue before a worker picked it
+func (a *autoscaler) observeWait(wait time.Duration) {
+	for {
+		current := a.maxWaitNanos.Load()
+		if int64(wait) <= current || a.maxWaitNanos.CompareAndSwap(current, int64(wait)) {
+			return
+		}
+	}
+}
+
+func (a *autoscaler) markBusy() {
+	a.lastBusyNanos.Store(a.now().UnixNano())
+}
+
+// requestScaleUp wakes the scaling loop immediately, e.g. when the queue is full
+func (a *autoscaler) requestScaleUp() {
+	select {
+	case a.scaleUpRequestChan <- struct{}{}:
+	default:
+	}
+}
+
+// Autoscale runs the scaling loop until ctx is cancelled
+func (w *WorkerPool) Autoscale(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	a := w.scaler
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	w.logger.Info("Worker pool autoscaler started",
+		"scale_up_wait_threshold", a.waitThreshold,
+		"scale_down_cooldown", a.cooldown)
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("Worker pool autoscaler stopped")
+			return
+		case <-a.scaleUpRequestChan:
+			a.scaleUp("queue full")
+		case <-ticker.C:
+			a.evaluate()
+		}
+	}
+}
+
+func (a *autoscaler) evaluate() {
+	maxWait := time.Duration(a.maxWaitNanos.Swap(0))
+	queued := a.pool.queuedJobs()
+
+	if maxWait > a.waitThreshold || (queued > 0 && maxWait == 0 && a.sinceLastBusy() > a.waitThreshold) {
+		a.scaleUp("queue wait above threshold")
+		return
+	}
+
+	if queued == 0 && a.sinceLastBusy() > a.cooldown && a.sinceLastScale() > a.cooldown {
+		if a.pool.scaleDown() {
+			a.lastScaleNanos.Store(a.now().UnixNano())
+		}
+	}
+}
+
+func (a *autoscaler) scaleUp(reason string) {
+	if a.pool.scaleUp(reason) {
+		a.lastScaleNanos.Store(a.now().UnixNano())
+	}
+}
+
+func (a *autoscaler) sinceLastBusy() time.Duration {
+	return a.now().Sub(time.Unix(0, a.lastBusyNanos.Load()))
+}
+
+func (a *autoscaler) sinceLastScale() time.Duration {
+	return a.now().Sub(time.Unix(0, a.lastScaleNanos.Load()))
+}
+
+func (w *WorkerPool) queuedJobs() int {
+	return w.sched.Len()
+}
+
+func (w *WorkerPool) scaleUp(reason string) bool {
+	added, err := w.cm.ScaleUp()
+	if err != nil {
+		w.logger.Error("Failed to scale worker pool up", "reason", reason, "err", err)
+		return false
+	}
+	if !added {
+		return false
+	}
+
+	w.startWorker()
+	w.logger.Info("Worker pool scaled up",
+		"reason", reason,
+		"workers", w.activeWorkers.Load())
+	return true
+}
+
+func (w *WorkerPool) scaleDown() bool {
+	removed, err := w.cm.ScaleDown()
+	if err != nil {
+		w.logger.Error("Failed to scale worker pool down", "err", err)
+		return false
+	}
+	if !removed {
+		return false
+	}
+
+	w.retireWorker()
+	w.logger.Info("Worker pool scaled down",
+		"workers", w.activeWorkers.Load()-1)
+	return true
+}
@@ -0,0 +1,141 @@
+package executor
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeScaler struct {
+	queued     int
+	canScale   bool
+	ups, downs int
+}
+
+func (f *fakeScaler) queuedJobs() int { return f.queued }
+
+func (f *fakeScaler) scaleUp(string) bool {
+	if f.canScale {
+		f.ups++
+	}
+	return f.canScale
+}
+
+func (f *fakeScaler) scaleDown() bool {
+	if f.canScale {
+		f.downs++
+	}
+	return f.canScale
+}
+
+// newTestAutoscaler returns an autoscaler whose clock only moves when the test moves it
+func newTestAutoscaler(pool poolScaler, now *time.Time) *autoscaler {
+	a := newAutoscaler(pool, &WorkerPoolOptions{
+		ScaleUpWaitThreshold: 2 * time.Second,
+		ScaleDownCooldown:    time.Minute,
+	})
+	a.now = func() time.Time { return *now }
+	a.lastBusyNanos.Store(now.UnixNano())
+	a.lastScaleNanos.Store(now.UnixNano())
+	return a
+}
+
+func TestAutoscalerEvaluate(t *testing.T) {
+	tests := []struct {
+		name      string
+		queued    int
+		wait      time.Duration // longest wait observed since the last check
+		idleFor   time.Duration // since the last finished job
+		scaledFor time.Duration // since the last scale
+		canScale  bool
+		wantUps   int
+		wantDowns int
+	}{
+		{name: "Long wait scales up", wait: 3 * time.Second, canScale: true, wantUps: 1},
+		{name: "Short wait does nothing", queued: 1, wait: time.Second, canScale: true},
+		{name: "Stuck queue scales up", queued: 2, idleFor: 3 * time.Second, canScale: true, wantUps: 1},
+		{name: "Idle past cooldown scales down", idleFor: 2 * time.Minute, scaledFor: 2 * time.Minute, canScale: true, wantDowns: 1},
+		{name: "Recent job keeps the pool", idleFor: 30 * time.Second, scaledFor: 2 * time.Minute, canScale: true},
+		{name: "Recent scale keeps the pool", idleFor: 2 * time.Minute, scaledFor: 30 * time.Second, canScale: true},
+		{name: "Queued jobs keep the pool", queued: 1, wait: time.Second, idleFor: 2 * time.Minute, scaledFor: 2 * time.Minute, canScale: true},
+		{name: "At max workers nothing changes", wait: 3 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &fakeScaler{queued: tt.queued, canScale: tt.canScale}
+			now := time.Unix(1_000_000, 0)
+			a := newTestAutoscaler(pool, &now)
+			a.lastBusyNanos.Store(now.Add(-tt.idleFor).UnixNano())
+			a.lastScaleNanos.Store(now.Add(-tt.scaledFor).UnixNano())
+			if tt.wait > 0 {
+				a.observeWait(tt.wait)
+			}
+
+			a.evaluate()
+
+			assert.Equal(t, tt.wantUps, pool.ups, "scale ups")
+			assert.Equal(t, tt.wantDowns, pool.downs, "scale downs")
+		})
+	}
+}
+
+func TestAutoscalerCooldown(t *testing.T) {
+	t.Run("A scale restarts the cooldown", func(t *testing.T) {
+		pool := &fakeScaler{canScale: true}
+		now := time.Unix(1_000_000, 0)
+		a := newTestAutoscaler(pool, &now)
+
+		now = now.Add(2 * time.Minute)
+		a.evaluate()
+		assert.Equal(t, 1, pool.downs)
+
+		now = now.Add(30 * time.Second)
+		a.evaluate()
+		assert.Equal(t, 1, pool.downs, "still cooling down")
+
+		now = now.Add(time.Minute)
+		a.evaluate()
+		assert.Equal(t, 2, pool.downs)
+	})
+
+	t.Run("A refused scale does not restart it", func(t *testing.T) {
+		pool := &fakeScaler{}
+		now := time.Unix(1_000_000, 0)
+		a := newTestAutoscaler(pool, &now)
+
+		now = now.Add(2 * time.Minute)
+		a.evaluate()
+		assert.Equal(t, now.Add(-2*time.Minute).UnixNano(), a.lastScaleNanos.Load())
+	})
+
+	t.Run("A wait is only counted once", func(t *testing.T) {
+		pool := &fakeScaler{queued: 1, canScale: true}
+		now := time.Unix(1_000_000, 0)
+		a := newTestAutoscaler(pool, &now)
+
+		a.observeWait(3 * time.Second)
+		a.evaluate()
+		a.evaluate()
+		assert.Equal(t, 1, pool.ups)
+	})
+}
+
+func TestObserveWait(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	a := newTestAutoscaler(&fakeScaler{}, &now)
+
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.observeWait(time.Duration(i) * time.Millisecond)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(99*time.Millisecond), a.maxWaitNanos.Load(), "the longest wait wins")
+}
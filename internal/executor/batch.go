@@ -0,0 +1,79 @@
+package executor
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+)
+
+// BatchCaseDelimiter is the line that separates test cases in batched mode.
+//
+// Batched drivers run every test case in one process. stdin holds each case's input
+// followed by a delimiter line. For each case the driver must print the output
+// followed by a delimiter line. It may append the CPU time the case took, in
+// microseconds, to that line, e.g.
+//
+//	#__ROGUELEARN_CASE_END__# 1532
+const BatchCaseDelimiter = "#__ROGUELEARN_CASE_END__#"
+
+type batchCaseOutput struct {
+	Output  string
+	CPUTime time.Duration
+}
+
+// encodeBatchInput joins the inputs of all test cases into one stdin stream
+func encodeBatchInput(tcs []store.TestCase) string {
+	var sb strings.Builder
+	for _, tc := range tcs {
+		sb.WriteString(tc.Input)
+		if !strings.HasSuffix(tc.Input, "\n") {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(BatchCaseDelimiter)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// parseBatchOutput splits the stdout of a batched run into per-case outputs.
+// Output after the last delimiter belongs to an unfinished case and is dropped.
+// Cases without a reported CPU time get an equal share of total.
+func parseBatchOutput(stdout string, total time.Duration) []batchCaseOutput {
+	var (
+		outputs  []batchCaseOutput
+		current  []string
+		reported time.Duration
+		missing  []int // indexes of cases without a reported CPU time
+	)
+
+	for line := range strings.Lines(stdout) {
+		line = strings.TrimRight(line, "\r\n")
+		rest, isDelimiter := strings.CutPrefix(line, BatchCaseDelimiter)
+		if !isDelimiter {
+			current = append(current, line)
+			continue
+		}
+
+		out := batchCaseOutput{Output: strings.Join(current, "\n")}
+		if us, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64); err == nil {
+			out.CPUTime = time.Duration(us) * time.Microsecond
+			reported += out.CPUTime
+		} else {
+			missing = append(missing, len(outputs))
+		}
+
+		outputs = append(outputs, out)
+		current = nil
+	}
+
+	if len(missing) > 0 && total > reported {
+		share := (total - reported) / time.Duration(len(missing))
+		for _, i := range missing {
+			outputs[i].CPUTime = share
+		}
+	}
+
+	return outputs
+}
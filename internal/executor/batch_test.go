@@ -0,0 +1,42 @@
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchProtocol(t *testing.T) {
+	t.Run("Inputs are terminated by the delimiter", func(t *testing.T) {
+		input := encodeBatchInput([]store.TestCase{{Input: "1 2"}, {Input: "3\n4\n"}})
+		assert.Equal(t, "1 2\n"+BatchCaseDelimiter+"\n3\n4\n"+BatchCaseDelimiter+"\n", input)
+	})
+
+	t.Run("Outputs are split per case with reported CPU time", func(t *testing.T) {
+		stdout := "3\n" + BatchCaseDelimiter + " 1500\n7\n8\n" + BatchCaseDelimiter + " 250\n"
+		outputs := parseBatchOutput(stdout, time.Second)
+
+		require.Len(t, outputs, 2)
+		assert.Equal(t, "3", outputs[0].Output)
+		assert.Equal(t, 1500*time.Microsecond, outputs[0].CPUTime)
+		assert.Equal(t, "7\n8", outputs[1].Output)
+		assert.Equal(t, 250*time.Microsecond, outputs[1].CPUTime)
+	})
+
+	t.Run("Unreported CPU time is shared from the total", func(t *testing.T) {
+		stdout := "a\n" + BatchCaseDelimiter + "\nb\n" + BatchCaseDelimiter + "\n"
+		outputs := parseBatchOutput(stdout, 10*time.Millisecond)
+
+		require.Len(t, outputs, 2)
+		assert.Equal(t, 5*time.Millisecond, outputs[0].CPUTime)
+		assert.Equal(t, 5*time.Millisecond, outputs[1].CPUTime)
+	})
+
+	t.Run("Output of an unfinished case is dropped", func(t *testing.T) {
+		stdout := "a\n" + BatchCaseDelimiter + " 10\npartial"
+		assert.Len(t, parseBatchOutput(stdout, time.Millisecond), 1)
+	})
+}
@@ -0,0 +1,150 @@
+package executor
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+)
+
+const (
+	DefaultBuildCacheSize = 32
+
+	buildArtifactPath = "/app/temp/exe"   // where compile commands write their output
+	buildCacheDir     = "/app/temp/cache" // where cached artifacts are kept inside a container
+)
+
+// buildCacheKey returns the content address of a build: the same language and the
+// same final code always produce the same artifact.
+func buildCacheKey(lang store.Language, code string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", lang.Name, lang.CompileCmd)
+	h.Write([]byte(code))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// buildCache is an LRU index of compiled artifacts stored inside one container.
+// It tracks keys and the digest each artifact had when it was stored; the artifacts
+// themselves live in buildCacheDir, where the user's program can reach them, so they
+// are checked against the digest before being restored.
+type buildCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // front is most recently used
+}
+
+type buildCacheEntry struct {
+	key    string
+	digest string // sha256 of the artifact
+}
+
+func newBuildCache(capacity int) *buildCache {
+	if capacity <= 0 {
+		capacity = DefaultBuildCacheSize
+	}
+
+	return &buildCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Lookup returns the digest of the artifact cached under key and marks it as recently used
+func (c *buildCache) Lookup(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(buildCacheEntry).digest, true
+}
+
+// Add records key as cached with the digest of its artifact and returns the keys evicted
+// to stay within capacity
+func (c *buildCache) Add(key, digest string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		e.Value = buildCacheEntry{key: key, digest: digest}
+		c.order.MoveToFront(e)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(buildCacheEntry{key: key, digest: digest})
+
+	var evicted []string
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		k := oldest.Value.(buildCacheEntry).key
+		delete(c.entries, k)
+		evicted = append(evicted, k)
+	}
+
+	return evicted
+}
+
+// Remove forgets key, e.g. when its artifact turned out to be missing
+func (c *buildCache) Remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.order.Remove(e)
+		delete(c.entries, key)
+	}
+}
+
+// Len returns the number of cached artifacts
+func (c *buildCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// restoreArtifactCmd copies a cached artifact back to where the run command expects it.
+// It fails without copying when the artifact no longer matches digest, e.g. because an
+// earlier submission overwrote it.
+func restoreArtifactCmd(key, digest string) string {
+	return fmt.Sprintf("echo '%s  %s/%s' | sha256sum -c - >/dev/null && cp %s/%s %s",
+		digest, buildCacheDir, key, buildCacheDir, key, buildArtifactPath)
+}
+
+// storeArtifactCmd saves the fresh artifact under key and prints its digest
+func storeArtifactCmd(key string) string {
+	return fmt.Sprintf("mkdir -p %s && cp %s %s/%s && sha256sum %s/%s",
+		buildCacheDir, buildArtifactPath, buildCacheDir, key, buildCacheDir, key)
+}
+
+// removeArtifactsCmd deletes evicted artifacts
+func removeArtifactsCmd(keys []string) string {
+	cmd := "rm -f"
+	for _, k := range keys {
+		cmd += fmt.Sprintf(" %s/%s", buildCacheDir, k)
+	}
+	return cmd
+}
+
+// parseArtifactDigest reads the digest printed by storeArtifactCmd
+func parseArtifactDigest(stdout string) (string, bool) {
+	fields := strings.Fields(stdout)
+	if len(fields) == 0 {
+		return "", false
+	}
+
+	digest := fields[0]
+	if _, err := hex.DecodeString(digest); err != nil || len(digest) != 2*sha256.Size {
+		return "", false
+	}
+	return digest, true
+}
@@ -0,0 +1,104 @@
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCache(t *testing.T) {
+	t.Run("Same language and code give the same key", func(t *testing.T) {
+		lang := store.Language{Name: "Golang", CompileCmd: "go build"}
+		assert.Equal(t, buildCacheKey(lang, "package main"), buildCacheKey(lang, "package main"))
+		assert.NotEqual(t, buildCacheKey(lang, "package main"), buildCacheKey(lang, "package main\n"))
+		assert.NotEqual(t, buildCacheKey(lang, "x"), buildCacheKey(store.Language{Name: "C++"}, "x"))
+	})
+
+	t.Run("Least recently used entry is evicted", func(t *testing.T) {
+		c := newBuildCache(2)
+		assert.Empty(t, c.Add("a", "da"))
+		assert.Empty(t, c.Add("b", "db"))
+		digest, ok := c.Lookup("a")
+		assert.True(t, ok)
+		assert.Equal(t, "da", digest)
+
+		assert.Equal(t, []string{"b"}, c.Add("c", "dc"))
+		_, ok = c.Lookup("b")
+		assert.False(t, ok)
+		_, ok = c.Lookup("a")
+		assert.True(t, ok)
+		_, ok = c.Lookup("c")
+		assert.True(t, ok)
+		assert.Equal(t, 2, c.Len())
+	})
+
+	t.Run("Removed entry is a miss", func(t *testing.T) {
+		c := newBuildCache(2)
+		c.Add("a", "da")
+		c.Remove("a")
+		_, ok := c.Lookup("a")
+		assert.False(t, ok)
+		assert.Equal(t, 0, c.Len())
+	})
+}
+
+// runArtifactCmd runs a build cache command against a scratch copy of the worker's temp dir
+func runArtifactCmd(t *testing.T, root, cmd string) (string, error) {
+	t.Helper()
+	out, err := exec.Command("sh", "-c", strings.ReplaceAll(cmd, "/app/temp", root)).Output()
+	return string(out), err
+}
+
+func TestBuildArtifactIntegrity(t *testing.T) {
+	if _, err := exec.LookPath("sha256sum"); err != nil {
+		t.Skip("sha256sum is not available")
+	}
+
+	root := t.TempDir()
+	exe := filepath.Join(root, "exe")
+	require.NoError(t, os.WriteFile(exe, []byte("compiled"), 0o755))
+
+	out, err := runArtifactCmd(t, root, storeArtifactCmd("key"))
+	require.NoError(t, err)
+	digest, ok := parseArtifactDigest(out)
+	require.True(t, ok, out)
+
+	t.Run("Unchanged artifact is restored", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(exe, []byte("other build"), 0o755))
+
+		_, err := runArtifactCmd(t, root, restoreArtifactCmd("key", digest))
+		require.NoError(t, err)
+		got, _ := os.ReadFile(exe)
+		assert.Equal(t, "compiled", string(got))
+	})
+
+	t.Run("Changed artifact is not restored", func(t *testing.T) {
+		// a submission running in the same container overwrites the cached build
+		require.NoError(t, os.WriteFile(filepath.Join(root, "cache", "key"), []byte("planted"), 0o755))
+		require.NoError(t, os.WriteFile(exe, []byte("other build"), 0o755))
+
+		_, err := runArtifactCmd(t, root, restoreArtifactCmd("key", digest))
+		assert.Error(t, err)
+		got, _ := os.ReadFile(exe)
+		assert.Equal(t, "other build", string(got))
+	})
+
+	t.Run("Evicted artifacts are removed", func(t *testing.T) {
+		_, err := runArtifactCmd(t, root, removeArtifactsCmd([]string{"key"}))
+		require.NoError(t, err)
+		assert.NoFileExists(t, filepath.Join(root, "cache", "key"))
+	})
+
+	t.Run("Digest output is validated", func(t *testing.T) {
+		_, ok := parseArtifactDigest("")
+		assert.False(t, ok)
+		_, ok = parseArtifactDigest("sha256sum: can't open file")
+		assert.False(t, ok)
+	})
+}
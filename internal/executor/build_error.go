@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+)
+
+type BuildErrorKind string
+
+const (
+	BuildErrorSanitize            BuildErrorKind = "SANITIZE_VIOLATION"
+	BuildErrorSyntax              BuildErrorKind = "SYNTAX_ERROR"
+	BuildErrorCodeTooLong         BuildErrorKind = "CODE_TOO_LONG"
+	BuildErrorLanguageUnsupported BuildErrorKind = "LANGUAGE_UNSUPPORTED"
+	BuildErrorMissingSymbol       BuildErrorKind = "MISSING_SYMBOL"
+	BuildErrorInvalidSubmission   BuildErrorKind = "INVALID_SUBMISSION"
+)
+
+// BuildError is returned for code that is rejected before it reaches the sandbox.
+// It is caused by the submission, so it is safe to show to the player.
+type BuildError struct {
+	Kind     BuildErrorKind `json:"kind"`
+	Message  string         `json:"message"`
+	Findings []Finding      `json:"findings,omitempty"`
+	err      error
+}
+
+func (e *BuildError) Error() string {
+	return e.Message
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.err
+}
+
+// Is matches build errors of the same kind, e.g. errors.Is(err, ErrCodeTooLong)
+func (e *BuildError) Is(target error) bool {
+	t, ok := target.(*BuildError)
+	return ok && t.Kind == e.Kind
+}
+
+var (
+	ErrSanitizeViolation   = &BuildError{Kind: BuildErrorSanitize, Message: "Code contains prohibited operations"}
+	ErrSyntax              = &BuildError{Kind: BuildErrorSyntax, Message: "Code could not be parsed"}
+	ErrCodeTooLong         = &BuildError{Kind: BuildErrorCodeTooLong, Message: "Code is too long"}
+	ErrLanguageUnsupported = &BuildError{Kind: BuildErrorLanguageUnsupported, Message: "Programming language is not supported"}
+	ErrMissingSymbol       = &BuildError{Kind: BuildErrorMissingSymbol, Message: "Code does not declare what the problem requires"}
+	ErrInvalidSubmission   = &BuildError{Kind: BuildErrorInvalidSubmission, Message: "Submission does not match the problem"}
+)
+
+func NewLanguageUnsupportedError(language string) *BuildError {
+	return &BuildError{
+		Kind:    BuildErrorLanguageUnsupported,
+		Message: fmt.Sprintf("Programming language %q is not supported", language),
+	}
+}
+
+func newCodeTooLongError(length, maxCodeLength int) *BuildError {
+	return &BuildError{
+		Kind:    BuildErrorCodeTooLong,
+		Message: fmt.Sprintf("Code is %d characters long, the limit is %d", length, maxCodeLength),
+	}
+}
+
+func newUnknownSectionError(section string) *BuildError {
+	return &BuildError{
+		Kind:    BuildErrorInvalidSubmission,
+		Message: fmt.Sprintf("Problem has no code section %q", section),
+	}
+}
+
+func newMissingSymbolError(findings []Finding) *BuildError {
+	return &BuildError{Kind: BuildErrorMissingSymbol, Message: ErrMissingSymbol.Message, Findings: findings}
+}
+
+// newSanitizeBuildError classifies an error returned by Sanitize. Code the lexer could not
+// tokenize is reported as a syntax error, anything else as a sanitize violation.
+func newSanitizeBuildError(err error) *BuildError {
+	var sanitizeErr *SanitizationError
+	if !errors.As(err, &sanitizeErr) {
+		return &BuildError{Kind: BuildErrorSanitize, Message: err.Error(), err: err}
+	}
+
+	kind := BuildErrorSyntax
+	for _, f := range sanitizeErr.Findings {
+		if f.Rule != "syntax" {
+			kind = BuildErrorSanitize
+			break
+		}
+	}
+
+	message := sanitizeErr.Message
+	if kind == BuildErrorSyntax {
+		message = ErrSyntax.Message
+	}
+
+	return &BuildError{Kind: kind, Message: message, Findings: sanitizeErr.Findings, err: err}
+}
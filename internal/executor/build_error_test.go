@@ -0,0 +1,56 @@
+package executor
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildErrors(t *testing.T) {
+	builder := NewCodeBuilder([]PackageAnalyzer{NewGoPackageAnalyzer()}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	registry := NewLanguageRegistry(testLanguageSpecs...)
+	golang, _ := registry.Get(LanguageGo)
+	python, _ := registry.Get(LanguagePython)
+
+	build := func(lang LanguageSpec, driver, code string, maxCodeLength int) *BuildError {
+		_, err := builder.Build(BuildRequest{Language: lang, DriverCode: driver, UserCode: code, MaxCodeLength: maxCodeLength})
+		require.Error(t, err)
+
+		var buildErr *BuildError
+		require.ErrorAs(t, err, &buildErr)
+		return buildErr
+	}
+
+	t.Run("Code too long", func(t *testing.T) {
+		buildErr := build(python, "# USER_CODE_HERE", strings.Repeat("x", 101), 100)
+		assert.ErrorIs(t, buildErr, ErrCodeTooLong)
+		assert.Equal(t, "Code is 101 characters long, the limit is 100", buildErr.Message)
+	})
+
+	t.Run("Sanitize violation", func(t *testing.T) {
+		buildErr := build(python, "# USER_CODE_HERE", "import os", 0)
+		assert.ErrorIs(t, buildErr, ErrSanitizeViolation)
+		assert.Equal(t, []Finding{{Rule: "import", Message: `module "os" is not allowed`, Line: 1, Column: 8}}, buildErr.Findings)
+	})
+
+	t.Run("Code the lexer cannot read", func(t *testing.T) {
+		buildErr := build(python, "# USER_CODE_HERE", "s = 'abc", 0)
+		assert.ErrorIs(t, buildErr, ErrSyntax)
+		assert.Len(t, buildErr.Findings, 1)
+	})
+
+	t.Run("Go code that does not parse", func(t *testing.T) {
+		buildErr := build(golang, "package main\n\n// IMPORTS_HERE\n\n// USER_CODE_HERE\n", "func solve( {", 0)
+		assert.ErrorIs(t, buildErr, ErrSyntax)
+		assert.ErrorIs(t, buildErr, ErrParsed)
+	})
+
+	t.Run("Unsupported language", func(t *testing.T) {
+		assert.ErrorIs(t, NewLanguageUnsupportedError("cobol"), ErrLanguageUnsupported)
+		assert.NotErrorIs(t, NewLanguageUnsupportedError("cobol"), ErrSyntax)
+	})
+}
@@ -1,55 +1,157 @@
 package executor
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"regexp"
+	"slices"
 	"strings"
 )
 
 const (
-	DefaultMaxCodeLength = 1000
+	DefaultMaxCodeLength = 10000
 
-	goCodePlaceHolder   = "// USER_CODE_HERE"
-	goImportPlaceHolder = "// IMPORTS_HERE"
+	tempFileDirHolder  = "{{temp_file_dir}}"
+	tempFileNameHolder = "{{temp_file_name}}"
+)
 
-	pythonCodePlaceHolder = "# USER_CODE_HERE"
+type CodeBuilder interface {
+	Build(req BuildRequest) (BuildResult, error)
+}
 
-	jsCodePlaceHolder = "// USER_CODE_HERE"
+// SolutionMode is what the player is asked to submit for a problem
+type SolutionMode string
 
-	tempFileDirHolder  = "{{temp_file_dir}}"
-	tempFileNameHolder = "{{temp_file_name}}"
+const (
+	SolutionSnippet  SolutionMode = "snippet"  // code pasted into the driver as is
+	SolutionFunction SolutionMode = "function" // functions the driver calls by name
+	SolutionClass    SolutionMode = "class"    // a whole class, e.g. `class Solution` in Java
 )
 
-type LanguagePlaceHolder struct {
-	codePlaceHolder   string
-	importPlaceHolder string
+// MainSection is the section of the unnamed placeholder, which BuildRequest.UserCode fills
+const MainSection = ""
+
+// BuildRequest is the problem's driver code and the user's code to splice into it
+type BuildRequest struct {
+	Language   LanguageSpec
+	DriverCode string
+	UserCode   string
+	// Sections fill named placeholders such as `// USER_CODE_HERE:helpers`
+	Sections map[string]string
+	Mode     SolutionMode
+	// RequiredSymbols must be declared by the user's code. Methods are written "Type.method".
+	RequiredSymbols []string
+	// MaxCodeLength limits the total length of the user's code, DefaultMaxCodeLength when not set
+	MaxCodeLength int
 }
 
-func getLanguagePlaceHolder(lang string) LanguagePlaceHolder {
-	var result LanguagePlaceHolder
-	switch lang {
-	case "Golang":
-		result = LanguagePlaceHolder{
-			codePlaceHolder:   goCodePlaceHolder,
-			importPlaceHolder: goImportPlaceHolder,
-		}
-	case "Python":
-		result = LanguagePlaceHolder{
-			codePlaceHolder:   pythonCodePlaceHolder,
-			importPlaceHolder: "",
-		}
-	case "Javascript":
-		result = LanguagePlaceHolder{
-			codePlaceHolder:   jsCodePlaceHolder,
-			importPlaceHolder: "",
+// sections returns every section of the user's code, keyed by section name
+func (r BuildRequest) sections() map[string]string {
+	sections := make(map[string]string, len(r.Sections)+1)
+	for name, code := range r.Sections {
+		sections[name] = code
+	}
+	if r.UserCode != "" || sections[MainSection] == "" {
+		sections[MainSection] = r.UserCode
+	}
+	return sections
+}
+
+// EncodeSections stores the named sections of a submission, so it can be built again later
+func EncodeSections(sections map[string]string) []byte {
+	if sections == nil {
+		sections = map[string]string{}
+	}
+	// a map of strings always marshals
+	data, _ := json.Marshal(sections)
+	return data
+}
+
+// DecodeSections reads the sections stored by EncodeSections
+func DecodeSections(data []byte) (map[string]string, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var sections map[string]string
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("invalid stored sections: %w", err)
+	}
+	return sections, nil
+}
+
+// BuildResult is the program sent to the worker and where the user's code sits inside it
+type BuildResult struct {
+	Code      string
+	SourceMap SourceMap
+}
+
+// SourceMap locates the user's code inside the built program, so diagnostics
+// can be reported against the lines the player actually wrote.
+type SourceMap struct {
+	Regions     []SourceRegion // empty if the driver has no placeholder
+	ImportLines int            // lines of the generated import block
+}
+
+// SourceRegion is where one section of the user's code sits inside the built program
+type SourceRegion struct {
+	Section   string
+	StartLine int // 1-based line of the first line of the section
+	LineCount int
+}
+
+// Locate converts a line of the built program to the section and 1-based line of the
+// user's code it belongs to. ok is false when the line belongs to the driver.
+func (m SourceMap) Locate(line int) (section string, userLine int, ok bool) {
+	for _, r := range m.Regions {
+		if line >= r.StartLine && line < r.StartLine+r.LineCount {
+			return r.Section, line - r.StartLine + 1, true
 		}
 	}
+	return "", 0, false
+}
 
-	return result
+// UserLine is Locate without the section
+func (m SourceMap) UserLine(line int) (int, bool) {
+	_, userLine, ok := m.Locate(line)
+	return userLine, ok
 }
 
-type CodeBuilder interface {
-	Build(lang, driverCode, userCode string) (string, error)
+// placeholder is a section placeholder found in the driver code
+type placeholder struct {
+	start, end int
+	section    string
+}
+
+// findPlaceholders returns the code placeholders of the driver, in order. A placeholder may be
+// followed by `:name` to declare a named section, e.g. `# USER_CODE_HERE:helpers`.
+func findPlaceholders(lang LanguageSpec, driverCode string) []placeholder {
+	if lang.CodePlaceholder == "" {
+		return nil
+	}
+
+	pattern := regexp.MustCompile(regexp.QuoteMeta(lang.CodePlaceholder) + `(?::(\w+))?`)
+	var placeholders []placeholder
+	for _, m := range pattern.FindAllStringSubmatchIndex(driverCode, -1) {
+		p := placeholder{start: m[0], end: m[1]}
+		if m[2] >= 0 {
+			p.section = driverCode[m[2]:m[3]]
+		}
+		placeholders = append(placeholders, p)
+	}
+	return placeholders
+}
+
+// SectionNames lists the named sections the driver code expects, in order of appearance
+func SectionNames(lang LanguageSpec, driverCode string) []string {
+	var names []string
+	for _, p := range findPlaceholders(lang, driverCode) {
+		if p.section != MainSection && !slices.Contains(names, p.section) {
+			names = append(names, p.section)
+		}
+	}
+	return names
 }
 
 // ConcreteCodeBuilder is a struct for building complete and functionable code.
@@ -73,40 +175,232 @@ func NewCodeBuilder(pkgAnalyzer []PackageAnalyzer, logger *slog.Logger) CodeBuil
 }
 
 // Build will generate the code after sanitizing, dynamically imports, and combining driverCode, userCode.
-func (c *ConcreteCodeBuilder) Build(lang, driverCode, userCode string) (string, error) {
+// User code that is rejected is reported as a *BuildError.
+func (c *ConcreteCodeBuilder) Build(req BuildRequest) (BuildResult, error) {
+	lang, driverCode := req.Language, req.DriverCode
+
+	maxCodeLength := req.MaxCodeLength
+	if maxCodeLength <= 0 {
+		maxCodeLength = DefaultMaxCodeLength
+	}
+
+	sections := req.sections()
+	placeholders := findPlaceholders(lang, driverCode)
+
+	length := 0
+	for name, code := range sections {
+		known := slices.ContainsFunc(placeholders, func(p placeholder) bool { return p.section == name })
+		if !known && name != MainSection {
+			return BuildResult{}, newUnknownSectionError(name)
+		}
+		length += len(code)
+	}
+
+	if length > maxCodeLength {
+		return BuildResult{}, newCodeTooLongError(length, maxCodeLength)
+	}
+
 	// Sanitize code
-	err := Sanitize(userCode, lang, DefaultMaxCodeLength)
-	if err != nil {
-		return "", err
+	if err := sanitizeSections(sections, lang.Name, maxCodeLength); err != nil {
+		return BuildResult{}, err
 	}
 
-	// only generate imports for compiled languages
-	placeholder := getLanguagePlaceHolder(lang)
+	if err := checkSymbols(sections, lang.Name, req.Mode, req.RequiredSymbols); err != nil {
+		return BuildResult{}, err
+	}
+
+	spans := make([]span, len(placeholders))
+	for i, p := range placeholders {
+		spans[i] = span{placeholder: p, text: sections[p.section], user: true}
+	}
 
-	finalCode := strings.Replace(driverCode, placeholder.codePlaceHolder, userCode, 1)
+	finalCode, regions := splice(driverCode, spans)
 	c.logger.Info("User code added to Driver code", "final_code", finalCode)
 
-	var imports string
-	if analyzer := c.pkgAnalyzers[lang]; analyzer != nil {
+	sourceMap := SourceMap{Regions: regions}
+
+	// only generate imports for compiled languages
+	if analyzer := c.pkgAnalyzers[lang.Name]; analyzer != nil && lang.ImportPlaceholder != "" {
 		pkgs, err := analyzer.Analyze(finalCode)
 		if err != nil && err == ErrParsed {
 			c.logger.Error("Wrong syntax")
-			return "", ErrParsed
+			return BuildResult{}, &BuildError{Kind: BuildErrorSyntax, Message: ErrSyntax.Message, err: ErrParsed}
 		}
 
-		imports = c.generateImports(pkgs)
+		imports := c.generateImports(pkgs)
 		c.logger.Info("imports generated", "imports", imports)
 
-		finalCode = strings.Replace(finalCode, placeholder.importPlaceHolder, imports, 1)
+		if imports != "" {
+			sourceMap.ImportLines = strings.Count(imports, "\n") + 1
+		}
+
+		// splice again so imports placed above the user's code shift its regions
+		if importIdx := strings.Index(driverCode, lang.ImportPlaceholder); importIdx >= 0 {
+			importSpan := placeholder{start: importIdx, end: importIdx + len(lang.ImportPlaceholder)}
+			finalCode, sourceMap.Regions = splice(driverCode, append(spans, span{placeholder: importSpan, text: imports}))
+		}
 	}
 
 	// combining altogether
 	c.logger.Info("Code built", "final_code", finalCode)
 
-	return finalCode, nil
+	return BuildResult{Code: finalCode, SourceMap: sourceMap}, nil
+}
+
+// span replaces a placeholder of the driver with text
+type span struct {
+	placeholder
+	text string
+	user bool // text is the user's code and gets a region in the source map
+}
+
+// splice replaces every span of driverCode in a single pass and records where the user's code lands
+func splice(driverCode string, spans []span) (string, []SourceRegion) {
+	spans = slices.Clone(spans)
+	slices.SortFunc(spans, func(a, b span) int { return a.start - b.start })
+
+	var (
+		builder strings.Builder
+		regions []SourceRegion
+		line    = 1
+		last    = 0
+	)
+	for _, s := range spans {
+		builder.WriteString(driverCode[last:s.start])
+		line += strings.Count(driverCode[last:s.start], "\n")
+
+		if s.user {
+			regions = append(regions, SourceRegion{
+				Section:   s.section,
+				StartLine: line,
+				LineCount: strings.Count(s.text, "\n") + 1,
+			})
+		}
+
+		builder.WriteString(s.text)
+		line += strings.Count(s.text, "\n")
+		last = s.end
+	}
+	builder.WriteString(driverCode[last:])
+
+	return builder.String(), regions
+}
+
+// sanitizeSections sanitizes every section and reports all findings in one *BuildError
+func sanitizeSections(sections map[string]string, language string, maxCodeLength int) error {
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var combined *SanitizationError
+	for _, name := range names {
+		err := Sanitize(sections[name], language, maxCodeLength)
+		if err == nil {
+			continue
+		}
+
+		var sanitizeErr *SanitizationError
+		if !errors.As(err, &sanitizeErr) {
+			return newSanitizeBuildError(err)
+		}
+		for i := range sanitizeErr.Findings {
+			sanitizeErr.Findings[i].Section = name
+		}
+
+		if combined == nil {
+			combined = sanitizeErr
+			continue
+		}
+		combined.Details += "\n" + sanitizeErr.Details
+		combined.Findings = append(combined.Findings, sanitizeErr.Findings...)
+	}
+
+	if combined != nil {
+		return newSanitizeBuildError(combined)
+	}
+	return nil
+}
+
+// checkSymbols makes sure the user's code declares the symbols the driver calls. In function
+// mode they must be functions; in class mode a plain name must be a type and "Type.method" a
+// method, and the code must declare at least one type. Languages without a symbol extractor
+// are left to the compiler.
+func checkSymbols(sections map[string]string, language string, mode SolutionMode, required []string) error {
+	if len(required) == 0 && mode != SolutionClass {
+		return nil
+	}
+
+	declared := make(map[string]Symbol)
+	hasType := false
+	for _, code := range sections {
+		symbols, ok, err := declaredSymbols(code, language)
+		if !ok {
+			return nil
+		}
+		if err != nil {
+			return &BuildError{Kind: BuildErrorSyntax, Message: ErrSyntax.Message, err: err}
+		}
+
+		for _, s := range symbols {
+			if s.Kind == SymbolType {
+				hasType = true
+			}
+			if _, found := declared[s.Name]; !found {
+				declared[s.Name] = s
+			}
+		}
+	}
+
+	var findings []Finding
+	if mode == SolutionClass && !hasType {
+		findings = append(findings, Finding{Rule: "missingSymbol", Message: "a class is expected"})
+	}
+
+	for _, name := range required {
+		want := expectedKind(mode, name)
+		s, found := declared[name]
+		switch {
+		case !found:
+			findings = append(findings, Finding{Rule: "missingSymbol", Message: fmt.Sprintf("%s %q is not declared", symbolNoun(want), name)})
+		case want == SymbolFunction && s.Kind == SymbolType, want == SymbolType && s.Kind != SymbolType:
+			findings = append(findings, Finding{Rule: "missingSymbol", Message: fmt.Sprintf("%q must be a %s", name, symbolNoun(want)), Line: s.Line})
+		}
+	}
+
+	if len(findings) == 0 {
+		return nil
+	}
+	return newMissingSymbolError(findings)
+}
+
+// expectedKind is the kind a required symbol must have. Variables can hold functions,
+// e.g. `const solve = (nums) => ...`, so they satisfy function requirements.
+func expectedKind(mode SolutionMode, name string) SymbolKind {
+	switch {
+	case mode == SolutionFunction || strings.Contains(name, "."):
+		return SymbolFunction
+	case mode == SolutionClass:
+		return SymbolType
+	default:
+		return ""
+	}
+}
+
+func symbolNoun(kind SymbolKind) string {
+	switch kind {
+	case SymbolFunction:
+		return "function"
+	case SymbolType:
+		return "class"
+	default:
+		return "symbol"
+	}
 }
 
-func (c *ConcreteCodeBuilder) generateImports(pkgs map[string]bool) string {
+// generateImports renders the import block for pkgs, which are expected to be sorted
+func (c *ConcreteCodeBuilder) generateImports(pkgs []string) string {
 	if len(pkgs) == 0 {
 		return ""
 	}
@@ -115,8 +409,8 @@ func (c *ConcreteCodeBuilder) generateImports(pkgs map[string]bool) string {
 
 	builder.WriteString("import (\n")
 
-	for pkg, _ := range pkgs {
-		builder.WriteString(fmt.Sprintf(" \t\"%s\"\n ", pkg))
+	for _, pkg := range pkgs {
+		builder.WriteString(fmt.Sprintf("\t%q\n", pkg))
 	}
 
 	builder.WriteString(")")
@@ -0,0 +1,140 @@
+package executor
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSections(t *testing.T) {
+	builder := NewCodeBuilder([]PackageAnalyzer{NewGoPackageAnalyzer()}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	registry := NewLanguageRegistry(testLanguageSpecs...)
+	golang, _ := registry.Get(LanguageGo)
+	python, _ := registry.Get(LanguagePython)
+
+	t.Run("Named placeholders", func(t *testing.T) {
+		driver := "# USER_CODE_HERE:helpers\n\n# USER_CODE_HERE\n\nprint(solve([1, 2]))\n"
+		assert.Equal(t, []string{"helpers"}, SectionNames(python, driver))
+
+		build, err := builder.Build(BuildRequest{
+			Language:   python,
+			DriverCode: driver,
+			UserCode:   "def solve(nums):\n    return total(nums)",
+			Sections:   map[string]string{"helpers": "def total(nums):\n    return sum(nums)"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "def total(nums):\n    return sum(nums)\n\ndef solve(nums):\n    return total(nums)\n\nprint(solve([1, 2]))\n", build.Code)
+		assert.Equal(t, []SourceRegion{
+			{Section: "helpers", StartLine: 1, LineCount: 2},
+			{Section: MainSection, StartLine: 4, LineCount: 2},
+		}, build.SourceMap.Regions)
+		assert.Equal(t, "Line 2 in helpers", formatLocation(build.SourceMap, 2, ""))
+		assert.Equal(t, "Line 1, Column 5", formatLocation(build.SourceMap, 4, "5"))
+	})
+
+	t.Run("Imports shift every section below them", func(t *testing.T) {
+		driver := "package main\n\n// IMPORTS_HERE\n\n// USER_CODE_HERE:types\n\n// USER_CODE_HERE\n\nfunc main() { fmt.Println(solve(nil)) }\n"
+		build, err := builder.Build(BuildRequest{
+			Language:   golang,
+			DriverCode: driver,
+			UserCode:   "func solve(xs []int) int { return len(xs) }",
+			Sections:   map[string]string{"types": "type pair struct{ a, b int }"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, build.SourceMap.ImportLines)
+		assert.Equal(t, []SourceRegion{
+			{Section: "types", StartLine: 7, LineCount: 1},
+			{Section: MainSection, StartLine: 9, LineCount: 1},
+		}, build.SourceMap.Regions)
+	})
+
+	t.Run("Unknown sections are rejected", func(t *testing.T) {
+		_, err := builder.Build(BuildRequest{
+			Language:   python,
+			DriverCode: "# USER_CODE_HERE",
+			Sections:   map[string]string{"helpers": "x = 1"},
+		})
+		assert.ErrorIs(t, err, ErrInvalidSubmission)
+	})
+
+	t.Run("Findings name their section", func(t *testing.T) {
+		_, err := builder.Build(BuildRequest{
+			Language:   python,
+			DriverCode: "# USER_CODE_HERE:helpers\n# USER_CODE_HERE",
+			UserCode:   "x = 1",
+			Sections:   map[string]string{"helpers": "import os"},
+		})
+		var buildErr *BuildError
+		require.ErrorAs(t, err, &buildErr)
+		assert.Equal(t, []Finding{{Rule: "import", Message: `module "os" is not allowed`, Line: 1, Column: 8, Section: "helpers"}}, buildErr.Findings)
+	})
+
+	t.Run("Stored sections round trip", func(t *testing.T) {
+		assert.Equal(t, "{}", string(EncodeSections(nil)))
+
+		sections, err := DecodeSections(EncodeSections(map[string]string{"helpers": "x = 1"}))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"helpers": "x = 1"}, sections)
+
+		sections, err = DecodeSections(nil)
+		require.NoError(t, err)
+		assert.Empty(t, sections)
+
+		_, err = DecodeSections([]byte("not json"))
+		assert.Error(t, err)
+	})
+}
+
+func TestBuildRequiredSymbols(t *testing.T) {
+	builder := NewCodeBuilder(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	registry := NewLanguageRegistry(testLanguageSpecs...)
+	python, _ := registry.Get(LanguagePython)
+	java, _ := registry.Get(LanguageJava)
+	cobol := LanguageSpec{Name: "COBOL", CodePlaceholder: "*> USER_CODE_HERE"}
+
+	build := func(lang LanguageSpec, mode SolutionMode, code string, required ...string) error {
+		_, err := builder.Build(BuildRequest{
+			Language:        lang,
+			DriverCode:      lang.CodePlaceholder,
+			UserCode:        code,
+			Mode:            mode,
+			RequiredSymbols: required,
+		})
+		return err
+	}
+
+	t.Run("Function mode", func(t *testing.T) {
+		assert.NoError(t, build(python, SolutionFunction, "def solve(nums):\n    return 0", "solve"))
+
+		err := build(python, SolutionFunction, "solve = 1\nclass solver:\n    pass", "solve", "solver", "parse")
+		var buildErr *BuildError
+		require.ErrorAs(t, err, &buildErr)
+		assert.ErrorIs(t, err, ErrMissingSymbol)
+		assert.Equal(t, []Finding{
+			{Rule: "missingSymbol", Message: `"solver" must be a function`, Line: 2},
+			{Rule: "missingSymbol", Message: `function "parse" is not declared`},
+		}, buildErr.Findings)
+	})
+
+	t.Run("Class mode", func(t *testing.T) {
+		code := "class Solution {\n    public int[] twoSum(int[] nums, int target) { return nums; }\n}"
+		assert.NoError(t, build(java, SolutionClass, code, "Solution", "Solution.twoSum"))
+
+		err := build(java, SolutionClass, code, "Solution.threeSum")
+		assert.ErrorIs(t, err, ErrMissingSymbol)
+
+		err = build(java, SolutionClass, "int twoSum(int[] nums) { return 0; }")
+		var buildErr *BuildError
+		require.ErrorAs(t, err, &buildErr)
+		assert.Equal(t, "a class is expected", buildErr.Findings[0].Message)
+	})
+
+	t.Run("Languages without an extractor are left to the compiler", func(t *testing.T) {
+		assert.NoError(t, build(cobol, SolutionFunction, "DISPLAY 'HI'.", "solve"))
+	})
+}
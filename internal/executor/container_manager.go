@@ -17,32 +17,72 @@ import (
 )
 
 const (
-	StateIdle    container.ContainerState = "idle"
-	StateBusy    container.ContainerState = "busy"
-	StateError   container.ContainerState = "error"
-	StateRunning container.ContainerState = "running"
+	StateIdle        container.ContainerState = "idle"
+	StateBusy        container.ContainerState = "busy"
+	StateError       container.ContainerState = "error"
+	StateQuarantined container.ContainerState = "quarantined"
+	// StateRemoving keeps a container out of rotation while docker removes it
+	StateRemoving container.ContainerState = "removing"
+	StateRunning  container.ContainerState = "running"
 
 	MB int64 = 1024 * 1024
 
+	workerImage string = "worker"
+
 	maxRetries   int = 10
 	retryDelayMS int = 200
+
+	// maxContainerFailures is the number of consecutive infrastructure failures
+	// after which a container is quarantined and replaced by the health monitor.
+	maxContainerFailures int = 3
+
+	// maxBusyDuration is how long a container may stay busy before the health
+	// monitor considers it hung. Jobs are cancelled after CodeRunTimeOutSecond,
+	// so anything well beyond that is stuck in docker itself.
+	maxBusyDuration    = 2 * CodeRunTimeOutSecond
+	healthCheckTimeout = 10 * time.Second
 )
 
 var (
-	ErrContainerNotFound error = errors.New("Container not found")
+	ErrContainerNotFound    error = errors.New("Container not found")
+	ErrNoContainerAvailable error = errors.New("No container available")
 )
 
 type ContainerInfo struct {
-	ID    string
-	State container.ContainerState
+	ID        string
+	State     container.ContainerState
+	BusySince time.Time
+	Failures  int // consecutive infrastructure failures
+	builds    *buildCache
+}
+
+// PoolStats is a snapshot of the container pool state.
+type PoolStats struct {
+	Total       int `json:"total"`
+	Idle        int `json:"idle"`
+	Busy        int `json:"busy"`
+	Error       int `json:"error"`
+	Quarantined int `json:"quarantined"`
+	MinWorkers  int `json:"min_workers"`
+	MaxWorkers  int `json:"max_workers"`
+	Target      int `json:"target"`
+	Workers     int `json:"workers"`     // worker goroutines, filled in by WorkerPool
+	QueuedJobs  int `json:"queued_jobs"` // filled in by WorkerPool
+
+	CachedBuilds     int   `json:"cached_builds"`
+	BuildCacheHits   int64 `json:"build_cache_hits"`   // filled in by WorkerPool
+	BuildCacheMisses int64 `json:"build_cache_misses"` // filled in by WorkerPool
 }
 
 type DockerContainerManager struct {
 	mu               sync.Mutex
 	logger           *slog.Logger // this logger both writes to terminal and to log file
-	cli              *client.Client
+	cli              client.ContainerAPIClient
 	containers       map[string]*ContainerInfo
+	minWorkers       int
 	maxWorkers       int
+	targetWorkers    int // desired pool size, kept within [minWorkers, maxWorkers]
+	buildCacheSize   int // max cached artifacts per container
 	memoryLimitBytes int64
 	cpunanoLimit     int64
 }
@@ -55,7 +95,7 @@ func NewDockerClient() (*client.Client, error) {
 	return cli, nil
 }
 
-func NewDockerContainerManager(maxWorkers int, memoryLimitBytes, cpunanoLimit int64) (*DockerContainerManager, error) {
+func NewDockerContainerManager(minWorkers, maxWorkers, buildCacheSize int, memoryLimitBytes, cpunanoLimit int64) (*DockerContainerManager, error) {
 	dockerClient, err := NewDockerClient()
 	if err != nil {
 		return nil, err
@@ -76,7 +116,10 @@ func NewDockerContainerManager(maxWorkers int, memoryLimitBytes, cpunanoLimit in
 		logger:           logger,
 		cli:              dockerClient,
 		containers:       make(map[string]*ContainerInfo),
+		minWorkers:       minWorkers,
 		maxWorkers:       maxWorkers,
+		targetWorkers:    minWorkers,
+		buildCacheSize:   buildCacheSize,
 		cpunanoLimit:     cpunanoLimit,
 		memoryLimitBytes: memoryLimitBytes,
 	}, nil
@@ -92,7 +135,7 @@ func (d *DockerContainerManager) InitializePool() error {
 
 	// Register existing worker container
 	for _, c := range containers {
-		if c.Image == "worker" {
+		if c.Image == workerImage {
 			state := StateIdle
 			if c.State != StateRunning {
 				state = StateError
@@ -100,8 +143,9 @@ func (d *DockerContainerManager) InitializePool() error {
 
 			d.mu.Lock()
 			d.containers[c.ID] = &ContainerInfo{
-				ID:    c.ID,
-				State: state,
+				ID:     c.ID,
+				State:  state,
+				builds: newBuildCache(d.buildCacheSize),
 			}
 			d.mu.Unlock()
 
@@ -128,7 +172,7 @@ func (d *DockerContainerManager) StartContainer() error {
 	d.mu.Unlock()
 
 	cfg := &container.Config{
-		Image: "worker",
+		Image: workerImage,
 		Tty:   true,
 	}
 
@@ -163,8 +207,9 @@ func (d *DockerContainerManager) StartContainer() error {
 	// add to in-memory map
 	d.mu.Lock()
 	d.containers[resp.ID] = &ContainerInfo{
-		ID:    resp.ID,
-		State: StateIdle,
+		ID:     resp.ID,
+		State:  StateIdle,
+		builds: newBuildCache(d.buildCacheSize),
 	}
 	d.logger.Info("Container started",
 		"container_id", resp.ID)
@@ -173,17 +218,17 @@ func (d *DockerContainerManager) StartContainer() error {
 	return nil
 }
 
-// removeExcessContainer remove excess containers beyond maxWorkers
+// removeExcessContainer remove excess containers beyond the target, idle ones first
 func (d *DockerContainerManager) removeExcessContainer(amount int) error {
 	d.mu.Lock()
-	defer d.mu.Unlock()
-
 	var removes []string
-	for id := range d.containers {
-		if len(removes) < amount {
+	for id, info := range d.containers {
+		if len(removes) < amount && info.State != StateBusy && info.State != StateRemoving {
+			info.State = StateRemoving
 			removes = append(removes, id)
 		}
 	}
+	d.mu.Unlock()
 
 	for _, id := range removes {
 		if err := d.RemoveContainer(id); err != nil {
@@ -194,7 +239,8 @@ func (d *DockerContainerManager) removeExcessContainer(amount int) error {
 	return nil
 }
 
-// RemoveContainer safely remove a Container
+// RemoveContainer safely remove a Container.
+// The caller must not hold d.mu, docker is called without it so jobs are not held up.
 func (d *DockerContainerManager) RemoveContainer(id string) error {
 	ctx := context.Background()
 
@@ -207,24 +253,37 @@ func (d *DockerContainerManager) RemoveContainer(id string) error {
 	}
 
 	// remove container from in-memory map
+	d.mu.Lock()
 	delete(d.containers, id)
+	d.mu.Unlock()
 
 	return nil
 }
 
-// MonitorContainers run in a loop to check containers health
-func (d *DockerContainerManager) MonitorContainers(wg *sync.WaitGroup, intervalSecond int) {
+// MonitorContainers run in a loop to check containers health until ctx is cancelled
+func (d *DockerContainerManager) MonitorContainers(ctx context.Context, wg *sync.WaitGroup, interval time.Duration) {
 	defer wg.Done()
-	ticker := time.NewTicker(time.Duration(intervalSecond) * time.Second)
-
-	for range ticker.C {
-		d.checkHealth()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	d.logger.Info("Container health monitor started", "interval", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.logger.Info("Container health monitor stopped")
+			return
+		case <-ticker.C:
+			d.checkHealth(ctx)
+		}
 	}
 }
 
-// checkHealth check the state of each Container
-func (d *DockerContainerManager) checkHealth() {
-	ctx := context.Background()
+// checkHealth removes dead, hung and quarantined containers, then tops the pool back up
+func (d *DockerContainerManager) checkHealth(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
 	containers, err := d.cli.ContainerList(ctx, container.ListOptions{All: true})
 	if err != nil {
 		d.logger.Error("Failed to **list** containers",
@@ -234,40 +293,71 @@ func (d *DockerContainerManager) checkHealth() {
 
 	runningWorkers := make(map[string]bool)
 	for _, c := range containers {
-		if d.isRunningContainer(c) {
+		if isRunningWorker(c) {
 			runningWorkers[c.ID] = true
 		}
 	}
 
 	d.mu.Lock()
-	for id := range d.containers {
-		if !runningWorkers[id] {
-			d.logger.Warn("Container not running, removing...",
-				"container_id", id)
+	var unhealthy []string
+	for id, info := range d.containers {
+		reason := ""
+		switch {
+		case info.State == StateRemoving:
+			continue
+		case !runningWorkers[id]:
+			reason = "not running"
+		case info.State == StateQuarantined:
+			reason = "quarantined"
+		case info.State == StateError:
+			reason = "error state"
+		case info.State == StateBusy && time.Since(info.BusySince) > maxBusyDuration:
+			reason = "hung"
+		default:
+			continue
+		}
 
-			if err := d.RemoveContainer(id); err != nil {
-				d.logger.Error("Failed to **remove** container, continuing delete others...",
-					"container_id", id)
-				continue
-			}
+		d.logger.Warn("Unhealthy container detected, removing...",
+			"container_id", id,
+			"reason", reason,
+			"state", info.State)
+		info.State = StateRemoving
+		unhealthy = append(unhealthy, id)
+	}
+	d.mu.Unlock()
+
+	for _, id := range unhealthy {
+		if err := d.RemoveContainer(id); err != nil {
+			d.logger.Error("Failed to **remove** container, continuing delete others...",
+				"container_id", id)
+			// forget it anyway so that a replacement gets started
+			d.mu.Lock()
+			delete(d.containers, id)
+			d.mu.Unlock()
 		}
 	}
+
+	if err := d.balanceWorker(); err != nil {
+		d.logger.Error("Failed to rebalance container pool", "err", err)
+	}
 }
 
-func (d *DockerContainerManager) isRunningContainer(c container.Summary) bool {
-	_, exists := d.containers[c.ID]
-	running := c.State != StateError
-	return c.Image == "worker" && exists && running
+func isRunningWorker(c container.Summary) bool {
+	return c.Image == workerImage && c.State == StateRunning
 }
 
-// balanceWorker ensure the number of workers is exactly equal to `maxWorkers`
+// balanceWorker ensure the number of workers is exactly equal to `targetWorkers`
 func (d *DockerContainerManager) balanceWorker() error {
+	d.mu.Lock()
 	currentCount := len(d.containers)
-	if currentCount < d.maxWorkers {
-		d.logger.Info("Current workers is not at the limit",
+	target := d.targetWorkers
+	d.mu.Unlock()
+
+	if currentCount < target {
+		d.logger.Info("Current workers is not at the target",
 			"current", currentCount,
-			"limit", d.maxWorkers)
-		needed := d.maxWorkers - currentCount
+			"target", target)
+		needed := target - currentCount
 		for range needed {
 			if err := d.StartContainer(); err != nil {
 				d.logger.Error("Failed to start Container",
@@ -275,11 +365,11 @@ func (d *DockerContainerManager) balanceWorker() error {
 				return err
 			}
 		}
-	} else if currentCount > d.maxWorkers {
-		excess := currentCount - d.maxWorkers
-		d.logger.Warn("Current workers is beyond the limit, removing...",
+	} else if currentCount > target {
+		excess := currentCount - target
+		d.logger.Warn("Current workers is beyond the target, removing...",
 			"current", currentCount,
-			"limit", d.maxWorkers)
+			"target", target)
 		if err := d.removeExcessContainer(excess); err != nil {
 			return err
 		}
@@ -287,6 +377,70 @@ func (d *DockerContainerManager) balanceWorker() error {
 	return nil
 }
 
+// ScaleUp raises the pool target by one container, up to maxWorkers.
+// It reports whether a container was added.
+func (d *DockerContainerManager) ScaleUp() (bool, error) {
+	d.mu.Lock()
+	if d.targetWorkers >= d.maxWorkers {
+		d.mu.Unlock()
+		return false, nil
+	}
+	d.targetWorkers++
+	target := d.targetWorkers
+	d.mu.Unlock()
+
+	d.logger.Info("Scaling container pool up", "target", target)
+
+	if err := d.StartContainer(); err != nil {
+		d.mu.Lock()
+		d.targetWorkers--
+		d.mu.Unlock()
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ScaleDown removes one idle container and lowers the pool target, down to minWorkers.
+// It reports whether a container was removed.
+func (d *DockerContainerManager) ScaleDown() (bool, error) {
+	d.mu.Lock()
+	if d.targetWorkers <= d.minWorkers {
+		d.mu.Unlock()
+		return false, nil
+	}
+
+	var removed *ContainerInfo
+	for _, info := range d.containers {
+		if info.State == StateIdle {
+			removed = info
+			break
+		}
+	}
+	if removed == nil {
+		d.mu.Unlock()
+		return false, nil
+	}
+
+	removed.State = StateRemoving
+	d.targetWorkers--
+	target := d.targetWorkers
+	d.mu.Unlock()
+
+	if err := d.RemoveContainer(removed.ID); err != nil {
+		d.mu.Lock()
+		removed.State = StateIdle
+		d.targetWorkers++
+		d.mu.Unlock()
+		return false, err
+	}
+
+	d.logger.Info("Scaled container pool down",
+		"container_id", removed.ID,
+		"target", target)
+	return true, nil
+}
+
 // GetAvailableContainer finds an Idle Container
 func (d *DockerContainerManager) GetAvailableContainer() (string, error) {
 	for range maxRetries {
@@ -295,6 +449,7 @@ func (d *DockerContainerManager) GetAvailableContainer() (string, error) {
 		for id, info := range d.containers {
 			if info.State == StateIdle {
 				info.State = StateBusy
+				info.BusySince = time.Now()
 				d.mu.Unlock()
 				d.logger.Info("Container is assigned to job",
 					"container_id", id)
@@ -305,16 +460,22 @@ func (d *DockerContainerManager) GetAvailableContainer() (string, error) {
 		time.Sleep(time.Duration(retryDelayMS) * time.Millisecond)
 	}
 
-	return "", nil
+	return "", ErrNoContainerAvailable
 }
 
 // ShutDown cleans up all containers
 func (d *DockerContainerManager) ShutDown() {
 	d.mu.Lock()
-	defer d.mu.Unlock()
+	ids := make([]string, 0, len(d.containers))
+	for id, c := range d.containers {
+		c.State = StateRemoving
+		ids = append(ids, id)
+	}
+	d.mu.Unlock()
+
 	d.logger.Info("Shutting down all Containers...")
-	for _, c := range d.containers {
-		d.RemoveContainer(c.ID)
+	for _, id := range ids {
+		d.RemoveContainer(id)
 	}
 	d.logger.Info("Shutdown process is done")
 }
@@ -360,7 +521,10 @@ func (d *DockerContainerManager) copyCodeToContainer(
 }
 
 // SetContainerState set the status of a specific Container
-func (d *DockerContainerManager) SetContainerState(containerID, state container.ContainerState) error {
+func (d *DockerContainerManager) SetContainerState(containerID string, state container.ContainerState) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	c, exists := d.containers[containerID]
 	if !exists {
 		d.logger.Error("Failed to find Container",
@@ -369,9 +533,102 @@ func (d *DockerContainerManager) SetContainerState(containerID, state container.
 	}
 
 	c.State = state
+	if state == StateBusy {
+		c.BusySince = time.Now()
+	}
 	d.logger.Info("Container state is set",
 		"container_id", containerID,
 		"state", state)
 
 	return nil
 }
+
+// ReleaseContainer hands a container back to the pool after a job.
+// Quarantined containers stay out of rotation until the health monitor replaces them,
+// and containers being removed stay out for good.
+func (d *DockerContainerManager) ReleaseContainer(containerID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	c, exists := d.containers[containerID]
+	if !exists {
+		return ErrContainerNotFound
+	}
+
+	if c.State != StateQuarantined && c.State != StateRemoving {
+		c.State = StateIdle
+	}
+	c.BusySince = time.Time{}
+
+	return nil
+}
+
+// ReportFailure records an infrastructure failure (not a user code failure) on a container.
+// After maxContainerFailures consecutive failures the container is quarantined.
+func (d *DockerContainerManager) ReportFailure(containerID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	c, exists := d.containers[containerID]
+	if !exists {
+		return
+	}
+
+	c.Failures++
+	if c.Failures >= maxContainerFailures && c.State != StateQuarantined {
+		c.State = StateQuarantined
+		d.logger.Warn("Container quarantined after repeated failures",
+			"container_id", containerID,
+			"failures", c.Failures)
+	}
+}
+
+// ReportSuccess resets the consecutive failure counter of a container.
+func (d *DockerContainerManager) ReportSuccess(containerID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if c, exists := d.containers[containerID]; exists {
+		c.Failures = 0
+	}
+}
+
+// BuildCache returns the build cache of a container
+func (d *DockerContainerManager) BuildCache(containerID string) (*buildCache, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	c, exists := d.containers[containerID]
+	if !exists {
+		return nil, ErrContainerNotFound
+	}
+	return c.builds, nil
+}
+
+// Stats returns a snapshot of the pool state
+func (d *DockerContainerManager) Stats() PoolStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := PoolStats{
+		Total:      len(d.containers),
+		MinWorkers: d.minWorkers,
+		MaxWorkers: d.maxWorkers,
+		Target:     d.targetWorkers,
+	}
+	for _, c := range d.containers {
+		stats.CachedBuilds += c.builds.Len()
+		switch c.State {
+		case StateIdle:
+			stats.Idle++
+		case StateBusy:
+			stats.Busy++
+		case StateError:
+			stats.Error++
+		case StateQuarantined:
+			stats.Quarantined++
+		}
+	}
+
+	return stats
+}
@@ -0,0 +1,202 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDocker stands in for the docker API. Containers it lists are running workers unless
+// they are in stopped.
+type fakeDocker struct {
+	client.ContainerAPIClient
+
+	mu       sync.Mutex
+	ids      []string
+	stopped  map[string]bool
+	removed  []string
+	created  int
+	onRemove func(id string)
+}
+
+func (f *fakeDocker) ContainerList(_ context.Context, _ container.ListOptions) ([]container.Summary, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var list []container.Summary
+	for _, id := range f.ids {
+		state := StateRunning
+		if f.stopped[id] {
+			state = "exited"
+		}
+		list = append(list, container.Summary{ID: id, Image: workerImage, State: state})
+	}
+	return list, nil
+}
+
+func (f *fakeDocker) ContainerCreate(_ context.Context, _ *container.Config, _ *container.HostConfig, _ *network.NetworkingConfig, _ *ocispec.Platform, _ string) (container.CreateResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.created++
+	id := fmt.Sprintf("new-%d", f.created)
+	f.ids = append(f.ids, id)
+	return container.CreateResponse{ID: id}, nil
+}
+
+func (f *fakeDocker) ContainerStart(_ context.Context, _ string, _ container.StartOptions) error {
+	return nil
+}
+
+func (f *fakeDocker) ContainerRemove(_ context.Context, id string, _ container.RemoveOptions) error {
+	if f.onRemove != nil {
+		f.onRemove(id)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.removed = append(f.removed, id)
+	return nil
+}
+
+func newTestContainerManager(cli *fakeDocker, minWorkers, maxWorkers int, states map[string]container.ContainerState) *DockerContainerManager {
+	d := &DockerContainerManager{
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+		cli:           cli,
+		containers:    make(map[string]*ContainerInfo),
+		minWorkers:    minWorkers,
+		maxWorkers:    maxWorkers,
+		targetWorkers: len(states),
+	}
+	for id, state := range states {
+		cli.ids = append(cli.ids, id)
+		d.containers[id] = &ContainerInfo{ID: id, State: state, builds: newBuildCache(1)}
+	}
+	return d
+}
+
+func TestContainerFailures(t *testing.T) {
+	t.Run("Quarantined after consecutive failures", func(t *testing.T) {
+		d := newTestContainerManager(&fakeDocker{}, 1, 1, map[string]container.ContainerState{"a": StateBusy})
+
+		for range maxContainerFailures - 1 {
+			d.ReportFailure("a")
+		}
+		assert.Equal(t, StateBusy, d.containers["a"].State)
+
+		d.ReportFailure("a")
+		assert.Equal(t, StateQuarantined, d.containers["a"].State)
+	})
+
+	t.Run("A success resets the count", func(t *testing.T) {
+		d := newTestContainerManager(&fakeDocker{}, 1, 1, map[string]container.ContainerState{"a": StateBusy})
+
+		for range maxContainerFailures - 1 {
+			d.ReportFailure("a")
+		}
+		d.ReportSuccess("a")
+		d.ReportFailure("a")
+
+		assert.Equal(t, 1, d.containers["a"].Failures)
+		assert.Equal(t, StateBusy, d.containers["a"].State)
+	})
+
+	t.Run("Unknown containers are ignored", func(t *testing.T) {
+		d := newTestContainerManager(&fakeDocker{}, 1, 1, nil)
+		assert.NotPanics(t, func() {
+			d.ReportFailure("missing")
+			d.ReportSuccess("missing")
+		})
+	})
+}
+
+func TestReleaseContainer(t *testing.T) {
+	d := newTestContainerManager(&fakeDocker{}, 1, 3, map[string]container.ContainerState{
+		"busy":        StateBusy,
+		"quarantined": StateQuarantined,
+		"removing":    StateRemoving,
+	})
+
+	for id := range d.containers {
+		require.NoError(t, d.ReleaseContainer(id))
+		assert.True(t, d.containers[id].BusySince.IsZero(), id)
+	}
+	assert.Equal(t, StateIdle, d.containers["busy"].State)
+	assert.Equal(t, StateQuarantined, d.containers["quarantined"].State, "stays out of rotation")
+	assert.Equal(t, StateRemoving, d.containers["removing"].State, "stays out of rotation")
+
+	assert.ErrorIs(t, d.ReleaseContainer("missing"), ErrContainerNotFound)
+}
+
+func TestCheckHealth(t *testing.T) {
+	cli := &fakeDocker{stopped: map[string]bool{"dead": true}}
+	d := newTestContainerManager(cli, 1, 5, map[string]container.ContainerState{
+		"idle":        StateIdle,
+		"busy":        StateBusy,
+		"hung":        StateBusy,
+		"dead":        StateIdle,
+		"quarantined": StateQuarantined,
+	})
+	d.containers["busy"].BusySince = time.Now()
+	d.containers["hung"].BusySince = time.Now().Add(-maxBusyDuration - time.Second)
+
+	cli.onRemove = func(id string) {
+		// docker is slow, jobs must still get and release containers meanwhile
+		locked := d.mu.TryLock()
+		if locked {
+			d.mu.Unlock()
+		}
+		assert.True(t, locked, "the lock is held while removing %s", id)
+	}
+
+	d.checkHealth(context.Background())
+
+	assert.ElementsMatch(t, []string{"hung", "dead", "quarantined"}, cli.removed)
+	assert.Contains(t, d.containers, "idle")
+	assert.Contains(t, d.containers, "busy")
+	assert.Len(t, d.containers, 5, "replacements are started up to the target")
+	assert.Equal(t, 3, cli.created)
+}
+
+func TestScaleBounds(t *testing.T) {
+	cli := &fakeDocker{}
+	d := newTestContainerManager(cli, 1, 2, map[string]container.ContainerState{"a": StateIdle})
+
+	added, err := d.ScaleUp()
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	added, err = d.ScaleUp()
+	require.NoError(t, err)
+	assert.False(t, added, "already at max workers")
+	assert.Equal(t, 2, d.targetWorkers)
+
+	cli.onRemove = func(string) {
+		locked := d.mu.TryLock()
+		if locked {
+			d.mu.Unlock()
+		}
+		assert.True(t, locked, "the lock is held while scaling down")
+	}
+
+	removed, err := d.ScaleDown()
+	require.NoError(t, err)
+	assert.True(t, removed)
+
+	removed, err = d.ScaleDown()
+	require.NoError(t, err)
+	assert.False(t, removed, "already at min workers")
+	assert.Equal(t, 1, d.targetWorkers)
+	assert.Len(t, d.containers, 1)
+}
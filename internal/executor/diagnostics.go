@@ -0,0 +1,183 @@
+package executor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+)
+
+var (
+	// tscDiagnostic matches the location prefix of `tsc --pretty false` output, e.g. `code.ts(12,5)`
+	tscDiagnostic = regexp.MustCompile(`(?m)^[\w./-]+\.ts\((\d+),(\d+)\)`)
+
+	// goLocation matches compiler errors (`./code.go:12:5: ...`) and panic frames (`\tcode.go:12 +0x1d`)
+	goLocation = regexp.MustCompile(`^(\s*)(?:\./)?[\w.-]+\.go:(\d+)(?::(\d+))?(.*)$`)
+
+	// pythonFrame matches a traceback frame, e.g. `  File "code.py", line 12, in solve`
+	pythonFrame = regexp.MustCompile(`^(\s*)File "[^"]*\.py", line (\d+)(.*)$`)
+
+	// nodeHeader is the first line Node prints for an uncaught error, e.g. `code.js:12`,
+	// or `code.ts:12` when the error is mapped back to TypeScript through a source map
+	nodeHeader = regexp.MustCompile(`^[\w./-]+\.[jt]s:(\d+)$`)
+
+	// nodeFrame matches a stack frame, e.g. `    at solve (code.js:12:5)` or `    at code.js:12:5`
+	nodeFrame = regexp.MustCompile(`^(\s+at )(?:(.+) \()?([^()\s]+):(\d+):(\d+)\)?$`)
+)
+
+// mapDiagnostics rewrites compiler and runtime locations in output, which must already be
+// cleaned by cleanCompilerOutput, so they refer to the user's code instead of the built program.
+// Stack frames inside the driver are hidden. Languages without a known format are returned as is.
+func mapDiagnostics(lang store.Language, sourceMap SourceMap, output string) string {
+	switch lang.Name {
+	case LanguageGo:
+		return mapGoDiagnostics(sourceMap, output)
+	case LanguagePython:
+		return mapPythonTraceback(sourceMap, output)
+	case LanguageJavascript:
+		return mapNodeStackTrace(sourceMap, output)
+	case LanguageTypescript:
+		// tsc errors at compile time, Node stack traces pointing at code.ts at runtime
+		return mapNodeStackTrace(sourceMap, mapTypescriptDiagnostics(sourceMap, output))
+	default:
+		return output
+	}
+}
+
+// formatLocation renders a location in the user's code, or "Driver code" when it is outside of it.
+// Locations in a named section end with "in <section>".
+func formatLocation(sourceMap SourceMap, line int, column string) string {
+	section, userLine, ok := sourceMap.Locate(line)
+	if !ok {
+		return "Driver code"
+	}
+
+	location := fmt.Sprintf("Line %d", userLine)
+	if column != "" {
+		location += ", Column " + column
+	}
+	if section != MainSection {
+		location += " in " + section
+	}
+	return location
+}
+
+// mapTypescriptDiagnostics turns `code.ts(12,5): error TS2322: ...` into
+// `Line 3, Column 5: error TS2322: ...`, or `Driver code: error ...` for lines outside the user's code.
+func mapTypescriptDiagnostics(sourceMap SourceMap, output string) string {
+	return tscDiagnostic.ReplaceAllStringFunc(output, func(loc string) string {
+		m := tscDiagnostic.FindStringSubmatch(loc)
+		line, _ := strconv.Atoi(m[1])
+		return formatLocation(sourceMap, line, m[2])
+	})
+}
+
+// mapGoDiagnostics rewrites `go build` errors and panic stack traces. A panic frame is a
+// function line followed by an indented file line; frames in the driver are dropped as a pair.
+func mapGoDiagnostics(sourceMap SourceMap, output string) string {
+	lines := strings.Split(output, "\n")
+	mapped := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		// package header printed by go build, e.g. `# command-line-arguments`
+		if strings.HasPrefix(line, "# ") {
+			continue
+		}
+
+		m := goLocation.FindStringSubmatch(line)
+		if m == nil {
+			mapped = append(mapped, line)
+			continue
+		}
+
+		n, _ := strconv.Atoi(m[2])
+		if m[1] == "" {
+			mapped = append(mapped, formatLocation(sourceMap, n, m[3])+m[4])
+			continue
+		}
+
+		if _, ok := sourceMap.UserLine(n); !ok {
+			if len(mapped) > 0 {
+				mapped = mapped[:len(mapped)-1]
+			}
+			continue
+		}
+		mapped = append(mapped, m[1]+formatLocation(sourceMap, n, ""))
+	}
+
+	return strings.Join(mapped, "\n")
+}
+
+// mapPythonTraceback rewrites traceback frames. Driver frames are dropped together with
+// the source and caret lines Python prints below them.
+func mapPythonTraceback(sourceMap SourceMap, output string) string {
+	lines := strings.Split(output, "\n")
+	mapped := make([]string, 0, len(lines))
+
+	hiding := false
+	for _, line := range lines {
+		if m := pythonFrame.FindStringSubmatch(line); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			_, ok := sourceMap.UserLine(n)
+			hiding = !ok
+			if !hiding {
+				mapped = append(mapped, m[1]+formatLocation(sourceMap, n, "")+m[3])
+			}
+			continue
+		}
+
+		if hiding && strings.HasPrefix(line, "    ") {
+			continue
+		}
+		hiding = false
+		mapped = append(mapped, line)
+	}
+
+	return strings.Join(mapped, "\n")
+}
+
+// mapNodeStackTrace rewrites the location header and stack frames of an uncaught Node error.
+// Driver frames and Node internals are dropped; a header in the driver is dropped together
+// with the source excerpt that follows it.
+func mapNodeStackTrace(sourceMap SourceMap, output string) string {
+	lines := strings.Split(output, "\n")
+	mapped := make([]string, 0, len(lines))
+
+	hiding := false
+	for _, line := range lines {
+		if hiding {
+			hiding = line != ""
+			continue
+		}
+
+		if m := nodeHeader.FindStringSubmatch(line); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			if _, ok := sourceMap.UserLine(n); !ok {
+				hiding = true
+				continue
+			}
+			mapped = append(mapped, formatLocation(sourceMap, n, ""))
+			continue
+		}
+
+		if m := nodeFrame.FindStringSubmatch(line); m != nil {
+			n, _ := strconv.Atoi(m[4])
+			if _, ok := sourceMap.UserLine(n); !ok || strings.HasPrefix(m[3], "node:") {
+				continue
+			}
+
+			location := formatLocation(sourceMap, n, m[5])
+			if m[2] != "" {
+				location = fmt.Sprintf("%s (%s)", m[2], location)
+			}
+			mapped = append(mapped, m[1]+location)
+			continue
+		}
+
+		mapped = append(mapped, line)
+	}
+
+	return strings.Join(mapped, "\n")
+}
@@ -0,0 +1,132 @@
+package executor
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapTypescriptDiagnostics(t *testing.T) {
+	lang := store.Language{Name: LanguageTypescript}
+	sourceMap := SourceMap{Regions: []SourceRegion{{StartLine: 10, LineCount: 5}}}
+
+	t.Run("Lines inside the user's code are rebased", func(t *testing.T) {
+		output := "code.ts(12,7): error TS2322: Type 'string' is not assignable to type 'number'."
+		assert.Equal(t,
+			"Line 3, Column 7: error TS2322: Type 'string' is not assignable to type 'number'.",
+			mapDiagnostics(lang, sourceMap, output))
+	})
+
+	t.Run("Lines outside the user's code point at the driver", func(t *testing.T) {
+		output := "code.ts(20,1): error TS2304: Cannot find name 'solve'.\ncode.ts(10,1): error TS1005: ';' expected."
+		assert.Equal(t,
+			"Driver code: error TS2304: Cannot find name 'solve'.\nLine 1, Column 1: error TS1005: ';' expected.",
+			mapDiagnostics(lang, sourceMap, output))
+	})
+
+	t.Run("Runtime errors are mapped through the source map of the emitted JavaScript", func(t *testing.T) {
+		output := "code.ts:12\n  return x.a.b;\n             ^\n\n\nTypeError: Cannot read properties of undefined (reading 'b')\n" +
+			"    at solve (code.ts:12:14)\n    at Object.<anonymous> (code.ts:20:1)\n    at Module._compile (node:internal/modules/cjs/loader:1521:14)\n\nNode.js v20.19.5"
+		assert.Equal(t,
+			"Line 3\n  return x.a.b;\n             ^\n\n\nTypeError: Cannot read properties of undefined (reading 'b')\n"+
+				"    at solve (Line 3, Column 14)\n\nNode.js v20.19.5",
+			mapDiagnostics(lang, sourceMap, output))
+	})
+
+	t.Run("Other languages are untouched", func(t *testing.T) {
+		output := "code.cpp:12:5: error: expected ';'"
+		assert.Equal(t, output, mapDiagnostics(store.Language{Name: LanguageCpp}, sourceMap, output))
+	})
+}
+
+func TestMapGoDiagnostics(t *testing.T) {
+	lang := store.Language{Name: LanguageGo}
+	sourceMap := SourceMap{Regions: []SourceRegion{{StartLine: 8, LineCount: 5}}}
+
+	t.Run("Compiler errors", func(t *testing.T) {
+		output := "# command-line-arguments\n./code.go:9:2: undefined: x\n./code.go:20:5: declared and not used: y"
+		assert.Equal(t,
+			"Line 2, Column 2: undefined: x\nDriver code: declared and not used: y",
+			mapDiagnostics(lang, sourceMap, output))
+	})
+
+	t.Run("Driver frames are hidden from panics", func(t *testing.T) {
+		output := "panic: runtime error: index out of range [5] with length 3\n\n" +
+			"goroutine 1 [running]:\nmain.solve(...)\n\tcode.go:10\nmain.main()\n\tcode.go:22 +0x1d\nexit status 2"
+		assert.Equal(t,
+			"panic: runtime error: index out of range [5] with length 3\n\n"+
+				"goroutine 1 [running]:\nmain.solve(...)\n\tLine 3\nexit status 2",
+			mapDiagnostics(lang, sourceMap, output))
+	})
+}
+
+func TestMapPythonTraceback(t *testing.T) {
+	output := `Traceback (most recent call last):
+  File "code.py", line 20, in <module>
+    print(solve(nums))
+          ^^^^^^^^^^^
+  File "code.py", line 4, in solve
+    return nums[10]
+IndexError: list index out of range`
+
+	expected := `Traceback (most recent call last):
+  Line 2, in solve
+    return nums[10]
+IndexError: list index out of range`
+
+	sourceMap := SourceMap{Regions: []SourceRegion{{StartLine: 3, LineCount: 4}}}
+	assert.Equal(t, expected, mapDiagnostics(store.Language{Name: LanguagePython}, sourceMap, output))
+}
+
+func TestMapNodeStackTrace(t *testing.T) {
+	output := `code.js:5
+    return a.b.c;
+               ^
+
+TypeError: Cannot read properties of undefined (reading 'c')
+    at solve (code.js:5:16)
+    at Object.<anonymous> (code.js:20:13)
+    at Module._compile (node:internal/modules/cjs/loader:1256:14)
+
+Node.js v20.11.1`
+
+	expected := `Line 2
+    return a.b.c;
+               ^
+
+TypeError: Cannot read properties of undefined (reading 'c')
+    at solve (Line 2, Column 16)
+
+Node.js v20.11.1`
+
+	sourceMap := SourceMap{Regions: []SourceRegion{{StartLine: 4, LineCount: 3}}}
+	assert.Equal(t, expected, mapDiagnostics(store.Language{Name: LanguageJavascript}, sourceMap, output))
+
+	t.Run("Errors thrown by the driver are hidden", func(t *testing.T) {
+		output := "code.js:20\n    throw new Error('bad input');\n    ^\n\nError: bad input\n    at code.js:20:11"
+		assert.Equal(t, "Error: bad input", mapDiagnostics(store.Language{Name: LanguageJavascript}, sourceMap, output))
+	})
+}
+
+func TestBuildSourceMap(t *testing.T) {
+	builder := NewCodeBuilder(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	spec, ok := NewLanguageRegistry(testLanguageSpecs...).Get(LanguageTypescript)
+	require.True(t, ok)
+
+	driver := "const lines: string[] = [];\n// USER_CODE_HERE\nconsole.log(solve(lines));\n"
+	user := "function solve(lines: string[]): number {\n  return lines.length;\n}"
+
+	build, err := builder.Build(BuildRequest{Language: spec, DriverCode: driver, UserCode: user})
+	require.NoError(t, err)
+
+	assert.Equal(t, SourceMap{Regions: []SourceRegion{{StartLine: 2, LineCount: 3}}}, build.SourceMap)
+	userLine, ok := build.SourceMap.UserLine(3)
+	assert.True(t, ok)
+	assert.Equal(t, 2, userLine)
+	_, ok = build.SourceMap.UserLine(5)
+	assert.False(t, ok)
+}
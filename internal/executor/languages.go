@@ -1,46 +1,207 @@
 package executor
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
 	"strings"
+	"sync"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Canonical language names, as stored in languages.name
+const (
+	LanguageGo         = "Golang"
+	LanguagePython     = "Python"
+	LanguageJavascript = "Javascript"
+	LanguageCpp        = "C++"
+	LanguageJava       = "Java"
+	LanguageRust       = "Rust"
+	LanguageTypescript = "TypeScript"
 )
 
-// NormalizeLanguage returns the standard name for the input progrmaming language
-func NormalizeLanguage(lang string) (name string, found bool) {
-	found = true
-	lang = strings.ToLower(lang)
-
-	languageMap := map[string]string{
-
-		"js":          "Javascript",
-		"jscript":     "Javascript",
-		"javscript":   "Javascript",
-		"javsscript":  "Javascript",
-		"javascipt":   "Javascript",
-		"javasript":   "Javascript",
-		"javascript":  "Javascript",
-		"java script": "Javascript",
-		"jscipt":      "Javascript",
-
-		"python":  "Python",
-		"pyt":     "Python",
-		"pyn":     "Python",
-		"pythn":   "Python",
-		"phyton":  "Python",
-		"py":      "Python",
-		"py thon": "Python",
-		"pthon":   "Python",
-
-		"go":      "Golang",
-		"golang":  "Golang",
-		"gol":     "Golang",
-		"goo":     "Golang",
-		"g o":     "Golang",
-		"golangg": "Golang",
-	}
-
-	if normalized, ok := languageMap[lang]; ok {
-		return normalized, found
-	}
-
-	return lang, !found
+// LanguageSpec is everything needed to build and run code in one language
+type LanguageSpec struct {
+	ID                pgtype.UUID `json:"-"`
+	Name              string      `json:"name"`
+	Aliases           []string    `json:"aliases"`
+	CodePlaceholder   string      `json:"code_placeholder"`
+	ImportPlaceholder string      `json:"import_placeholder"`
+	CompileCmd        string      `json:"compile_cmd"`
+	RunCmd            string      `json:"run_cmd"`
+	TempFileDir       string      `json:"temp_file_dir"`
+	TempFileName      string      `json:"temp_file_name"`
+}
+
+// Language returns the spec in the shape the worker pool executes
+func (s LanguageSpec) Language() store.Language {
+	return store.Language{
+		ID:                s.ID,
+		Name:              s.Name,
+		CompileCmd:        s.CompileCmd,
+		RunCmd:            s.RunCmd,
+		TempFileDir:       pgtype.Text{String: s.TempFileDir, Valid: s.TempFileDir != ""},
+		TempFileName:      pgtype.Text{String: s.TempFileName, Valid: s.TempFileName != ""},
+		Aliases:           s.Aliases,
+		CodePlaceholder:   s.CodePlaceholder,
+		ImportPlaceholder: s.ImportPlaceholder,
+	}
+}
+
+func specFromLanguage(l store.Language) LanguageSpec {
+	return LanguageSpec{
+		ID:                l.ID,
+		Name:              l.Name,
+		Aliases:           l.Aliases,
+		CodePlaceholder:   l.CodePlaceholder,
+		ImportPlaceholder: l.ImportPlaceholder,
+		CompileCmd:        l.CompileCmd,
+		RunCmd:            l.RunCmd,
+		TempFileDir:       l.TempFileDir.String,
+		TempFileName:      l.TempFileName.String,
+	}
+}
+
+// merge fills s with the non-empty fields of other
+func (s *LanguageSpec) merge(other LanguageSpec) {
+	if other.ID.Valid {
+		s.ID = other.ID
+	}
+	if len(other.Aliases) > 0 {
+		s.Aliases = append(s.Aliases, other.Aliases...)
+	}
+	if other.CodePlaceholder != "" {
+		s.CodePlaceholder = other.CodePlaceholder
+	}
+	if other.ImportPlaceholder != "" {
+		s.ImportPlaceholder = other.ImportPlaceholder
+	}
+	if other.CompileCmd != "" {
+		s.CompileCmd = other.CompileCmd
+	}
+	if other.RunCmd != "" {
+		s.RunCmd = other.RunCmd
+	}
+	if other.TempFileDir != "" {
+		s.TempFileDir = other.TempFileDir
+	}
+	if other.TempFileName != "" {
+		s.TempFileName = other.TempFileName
+	}
+}
+
+// LanguageRegistry resolves user supplied language names and aliases to a LanguageSpec.
+// It is the single source of truth for supported languages.
+type LanguageRegistry struct {
+	mu      sync.RWMutex
+	specs   map[string]LanguageSpec // by canonical name
+	aliases map[string]string       // normalized alias -> canonical name
+}
+
+func NewLanguageRegistry(specs ...LanguageSpec) *LanguageRegistry {
+	r := &LanguageRegistry{
+		specs:   make(map[string]LanguageSpec),
+		aliases: make(map[string]string),
+	}
+
+	for _, spec := range specs {
+		r.Register(spec)
+	}
+
+	return r
+}
+
+// LoadLanguageRegistry builds the registry from the languages table, which holds the aliases
+// and placeholders of every language. If configPath is set, the JSON array of LanguageSpec
+// in that file is applied on top, overriding fields by language name.
+func LoadLanguageRegistry(ctx context.Context, queries *store.Queries, configPath string) (*LanguageRegistry, error) {
+	languages, err := queries.GetAllLanguages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load languages: %w", err)
+	}
+
+	r := NewLanguageRegistry()
+	for _, l := range languages {
+		r.Register(specFromLanguage(l))
+	}
+
+	if configPath == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read language config: %w", err)
+	}
+
+	var overrides []LanguageSpec
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse language config: %w", err)
+	}
+
+	for _, o := range overrides {
+		spec, _ := r.Get(o.Name)
+		spec.Name = o.Name
+		spec.merge(o)
+		r.Register(spec)
+	}
+
+	return r, nil
+}
+
+// Register adds or replaces a language. Its name is always an alias of itself.
+func (r *LanguageRegistry) Register(spec LanguageSpec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.specs[spec.Name] = spec
+	r.aliases[normalizeAlias(spec.Name)] = spec.Name
+	for _, alias := range spec.Aliases {
+		r.aliases[normalizeAlias(alias)] = spec.Name
+	}
+}
+
+// Resolve returns the language for a name or any of its aliases, case-insensitively
+func (r *LanguageRegistry) Resolve(nameOrAlias string) (LanguageSpec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	name, ok := r.aliases[normalizeAlias(nameOrAlias)]
+	if !ok {
+		return LanguageSpec{}, false
+	}
+
+	spec, ok := r.specs[name]
+	return spec, ok
+}
+
+// Get returns the language with the given canonical name
+func (r *LanguageRegistry) Get(name string) (LanguageSpec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	spec, ok := r.specs[name]
+	return spec, ok
+}
+
+// All returns every registered language sorted by name
+func (r *LanguageRegistry) All() []LanguageSpec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	specs := make([]LanguageSpec, 0, len(r.specs))
+	for _, spec := range r.specs {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+
+	return specs
+}
+
+func normalizeAlias(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
 }
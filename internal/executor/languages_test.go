@@ -0,0 +1,61 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLanguageSpecs mirror the rows of sqlc/seed_languages.sql
+var testLanguageSpecs = []LanguageSpec{
+	{Name: LanguageGo, Aliases: []string{"go", "golang"}, CodePlaceholder: "// USER_CODE_HERE", ImportPlaceholder: "// IMPORTS_HERE"},
+	{Name: LanguagePython, Aliases: []string{"python", "py", "phyton"}, CodePlaceholder: "# USER_CODE_HERE"},
+	{Name: LanguageJavascript, Aliases: []string{"js", "javascript"}, CodePlaceholder: "// USER_CODE_HERE"},
+	{Name: LanguageCpp, Aliases: []string{"cpp", "c++"}, CodePlaceholder: "// USER_CODE_HERE"},
+	{Name: LanguageJava, Aliases: []string{"java"}, CodePlaceholder: "// USER_CODE_HERE"},
+	{Name: LanguageRust, Aliases: []string{"rust", "rs"}, CodePlaceholder: "// USER_CODE_HERE"},
+	{Name: LanguageTypescript, Aliases: []string{"ts", "typescript"}, CodePlaceholder: "// USER_CODE_HERE"},
+}
+
+func TestLanguageRegistry(t *testing.T) {
+	registry := NewLanguageRegistry(testLanguageSpecs...)
+
+	t.Run("Aliases resolve to the canonical language", func(t *testing.T) {
+		for alias, expected := range map[string]string{
+			"golang":     LanguageGo,
+			"  GO ":      LanguageGo,
+			"Golang":     LanguageGo,
+			"py":         LanguagePython,
+			"phyton":     LanguagePython,
+			"JavaScript": LanguageJavascript,
+			"js":         LanguageJavascript,
+			"cpp":        LanguageCpp,
+			"C++":        LanguageCpp,
+			"java":       LanguageJava,
+			"rs":         LanguageRust,
+			"ts":         LanguageTypescript,
+			"TypeScript": LanguageTypescript,
+		} {
+			spec, ok := registry.Resolve(alias)
+			require.True(t, ok, alias)
+			assert.Equal(t, expected, spec.Name, alias)
+		}
+	})
+
+	t.Run("Unknown language is not resolved", func(t *testing.T) {
+		_, ok := registry.Resolve("cobol")
+		assert.False(t, ok)
+	})
+
+	t.Run("Merged fields override defaults", func(t *testing.T) {
+		spec, _ := registry.Get(LanguagePython)
+		spec.merge(LanguageSpec{RunCmd: "python3 {{temp_file_dir}}/{{temp_file_name}}", Aliases: []string{"python3"}})
+		registry.Register(spec)
+
+		resolved, ok := registry.Resolve("python3")
+		require.True(t, ok)
+		assert.Equal(t, "# USER_CODE_HERE", resolved.CodePlaceholder)
+		assert.Equal(t, "python3 {{temp_file_dir}}/{{temp_file_name}}", resolved.RunCmd)
+	})
+}
@@ -5,16 +5,56 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"path"
+	"sort"
+	"strconv"
 )
 
 var (
 	ErrParsed error = errors.New("Failed to analyze code.")
 )
 
-// PackageAnalyzer helps finding which packages are being imported in a piece of code
+// PackageAnalyzer helps finding which packages a piece of code uses but does not import
 type PackageAnalyzer interface {
 	GetNormalizedName() string
-	Analyze(code string) (map[string]bool, error)
+	// Analyze returns the sorted import paths the code needs in addition to its own imports
+	Analyze(code string) ([]string, error)
+}
+
+// goStdlibIndex maps the name a package is referred by in code to its import path.
+// Only these packages are imported automatically; anything else is left to the compiler.
+var goStdlibIndex = map[string]string{
+	"atomic":  "sync/atomic",
+	"base64":  "encoding/base64",
+	"big":     "math/big",
+	"binary":  "encoding/binary",
+	"bits":    "math/bits",
+	"bufio":   "bufio",
+	"bytes":   "bytes",
+	"cmp":     "cmp",
+	"cmplx":   "math/cmplx",
+	"csv":     "encoding/csv",
+	"errors":  "errors",
+	"fmt":     "fmt",
+	"heap":    "container/heap",
+	"hex":     "encoding/hex",
+	"io":      "io",
+	"json":    "encoding/json",
+	"list":    "container/list",
+	"maps":    "maps",
+	"math":    "math",
+	"os":      "os",
+	"rand":    "math/rand",
+	"regexp":  "regexp",
+	"ring":    "container/ring",
+	"slices":  "slices",
+	"sort":    "sort",
+	"strconv": "strconv",
+	"strings": "strings",
+	"sync":    "sync",
+	"time":    "time",
+	"unicode": "unicode",
+	"utf8":    "unicode/utf8",
 }
 
 type GoPackageAnalyzer struct {
@@ -22,48 +62,63 @@ type GoPackageAnalyzer struct {
 }
 
 func NewGoPackageAnalyzer() *GoPackageAnalyzer {
-	lang, _ := NormalizeLanguage("golang")
 	return &GoPackageAnalyzer{
-		lang: lang,
+		lang: LanguageGo,
 	}
 }
 
-func (p *GoPackageAnalyzer) Analyze(code string) (map[string]bool, error) {
-	// a map to store which pkgs to import
-	pkgs := make(map[string]bool)
+func (p *GoPackageAnalyzer) Analyze(code string) ([]string, error) {
 	fset := token.NewFileSet()
 
-	// 0 means parse everything
+	// 0 means parse everything, including resolving identifiers to their declarations
 	node, err := parser.ParseFile(fset, "code.go", code, 0)
 	if err != nil {
 		return nil, ErrParsed
 	}
 
-	// this find all the packages that are EXPLICITLY imported (import statement)
+	// names already bound by the code's own import statements
+	imported := make(map[string]bool)
 	for _, i := range node.Imports {
-		// strip the double quote ("fmt", "strings")
-		p := i.Path.Value[1 : len(i.Path.Value)-1]
-		pkgs[p] = true
+		importPath, err := strconv.Unquote(i.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		name := path.Base(importPath)
+		if i.Name != nil {
+			name = i.Name.Name
+		}
+		imported[name] = true
 	}
 
-	// this find all the packages that are IMPLICITLY imported (not appeard in import statement)
+	// a selector `X.Y` refers to a package only if X is not declared anywhere in the file:
+	// the parser links every identifier it can resolve (locals, params, receivers,
+	// top-level declarations) to its object, so package references are left with Obj == nil.
+	pkgs := make(map[string]bool)
 	ast.Inspect(node, func(n ast.Node) bool {
-
 		selExpr, ok := n.(*ast.SelectorExpr)
 		if !ok {
 			return true // continue traversing
 		}
 
 		ident, ok := selExpr.X.(*ast.Ident)
-		if !ok {
+		if !ok || ident.Obj != nil || imported[ident.Name] {
 			return true
 		}
 
-		pkgs[ident.Name] = true
+		if importPath, ok := goStdlibIndex[ident.Name]; ok {
+			pkgs[importPath] = true
+		}
 		return true
 	})
 
-	return pkgs, nil
+	paths := make([]string, 0, len(pkgs))
+	for p := range pkgs {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	return paths, nil
 }
 
 func (p *GoPackageAnalyzer) GetNormalizedName() string {
@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoPackageAnalyzer(t *testing.T) {
+	analyzer := NewGoPackageAnalyzer()
+
+	t.Run("Local identifiers are not packages", func(t *testing.T) {
+		code := `package main
+
+type stack struct{ items []int }
+
+func (s *stack) Len() int { return len(s.items) }
+
+func solve(nums []int) string {
+	var sb strings.Builder
+	st := &stack{}
+	sb.WriteString(strconv.Itoa(st.Len()))
+	return sb.String()
+}
+`
+		pkgs, err := analyzer.Analyze(code)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"strconv", "strings"}, pkgs)
+	})
+
+	t.Run("Short names map to full import paths", func(t *testing.T) {
+		code := `package main
+
+func solve() {
+	_ = rand.Intn(10)
+	_, _ = json.Marshal(heap.Interface(nil))
+	_ = fmt.Sprint(math.MaxInt)
+}
+`
+		pkgs, err := analyzer.Analyze(code)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"container/heap", "encoding/json", "fmt", "math", "math/rand"}, pkgs)
+	})
+
+	t.Run("Packages imported by the driver are skipped", func(t *testing.T) {
+		code := `package main
+
+import (
+	"fmt"
+	str "strings"
+)
+
+func main() {
+	fmt.Println(str.ToUpper("x"), sort.IntsAreSorted(nil))
+}
+`
+		pkgs, err := analyzer.Analyze(code)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"sort"}, pkgs)
+	})
+
+	t.Run("Unknown packages are left to the compiler", func(t *testing.T) {
+		pkgs, err := analyzer.Analyze("package main\n\nfunc f() { foo.Bar() }\n")
+		require.NoError(t, err)
+		assert.Empty(t, pkgs)
+	})
+
+	t.Run("Invalid code", func(t *testing.T) {
+		_, err := analyzer.Analyze("package main\nfunc {")
+		assert.ErrorIs(t, err, ErrParsed)
+	})
+}
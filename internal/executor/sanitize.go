@@ -3,17 +3,50 @@ package executor
 import (
 	"fmt"
 	"regexp"
+	"strings"
 )
 
+// Finding is a single sanitization policy violation in the user's code
+type Finding struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Section string `json:"section,omitempty"` // named code section, empty for the main one
+}
+
+func (f Finding) String() string {
+	if f.Section != "" {
+		return fmt.Sprintf("%s, line %d, column %d: %s", f.Section, f.Line, f.Column, f.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s", f.Line, f.Column, f.Message)
+}
+
 type SanitizationError struct {
-	Message string
-	Details string
+	Message  string
+	Details  string
+	Findings []Finding
 }
 
 func (se *SanitizationError) Error() string {
 	return fmt.Sprintf("Message:%s\n,Details:%s", se.Message, se.Details)
 }
 
+// SanitizePolicy decides what user code is allowed to do in one language
+type SanitizePolicy interface {
+	Check(code string) []Finding
+}
+
+// sanitizePolicies are keyed by canonical language name. Go is checked on its AST, Python and
+// JavaScript on tokens, so comments and string literals never trigger a rule. The remaining
+// languages fall back to the regex patterns in DangerousPatterns.
+var sanitizePolicies = map[string]SanitizePolicy{
+	LanguageGo:         goPolicy{},
+	LanguagePython:     pythonPolicy{},
+	LanguageJavascript: javascriptPolicy{},
+	LanguageTypescript: javascriptPolicy{},
+}
+
 type PatternCategory struct {
 	Name        string   `json:"name"`
 	Description string   `json:"description"`
@@ -25,7 +58,9 @@ type LanguagePatterns struct {
 	Language map[string][]PatternCategory `json:"language"`
 }
 
-// Sanitize is the process of removing dangerous component (exploiting) in code
+// Sanitize checks the user's code against the language's policy and returns a *SanitizationError
+// listing every violation. CPU time and memory are limited by the sandbox, so loops and large
+// allocations are only rejected when they cannot possibly terminate.
 func Sanitize(code, language string, maxCodeLength int) error {
 	if len(code) > maxCodeLength {
 		return &SanitizationError{
@@ -34,36 +69,61 @@ func Sanitize(code, language string, maxCodeLength int) error {
 		}
 	}
 
-	// Check common pattern
-	for _, category := range DangerousPatterns.Common {
-		if hasMatchingPattern(category.Patterns, code) {
-			return &SanitizationError{
-				Message: fmt.Sprintf("Prohibited %s operation detected: %s", language, category.Name),
-				Details: category.Description,
-			}
-		}
+	policy, ok := sanitizePolicies[language]
+	if !ok {
+		policy = regexPolicy{categories: append(DangerousPatterns.Common, DangerousPatterns.Language[language]...)}
 	}
 
-	// Check language-specific pattern
-	for _, category := range DangerousPatterns.Language[language] {
-		if hasMatchingPattern(category.Patterns, code) {
-			return &SanitizationError{
-				Message: fmt.Sprintf("Prohibited %s operation detected: %s", language, category.Name),
-				Details: category.Description,
-			}
-		}
+	findings := policy.Check(code)
+	if len(findings) == 0 {
+		return nil
 	}
 
-	return nil
+	details := make([]string, len(findings))
+	for i, f := range findings {
+		details[i] = f.String()
+	}
+
+	return &SanitizationError{
+		Message:  fmt.Sprintf("Prohibited %s operation detected: %s", language, findings[0].Rule),
+		Details:  strings.Join(details, "\n"),
+		Findings: findings,
+	}
+}
+
+// regexPolicy reports the first match of every category
+type regexPolicy struct {
+	categories []PatternCategory
 }
 
-func hasMatchingPattern(patterns []string, code string) bool {
-	for _, pattern := range patterns {
-		if matched, err := regexp.MatchString(pattern, code); err == nil && matched {
-			return true
+func (p regexPolicy) Check(code string) []Finding {
+	var findings []Finding
+	for _, category := range p.categories {
+		for _, pattern := range category.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				continue
+			}
+
+			loc := re.FindStringIndex(code)
+			if loc == nil {
+				continue
+			}
+
+			line, column := position(code, loc[0])
+			findings = append(findings, Finding{Rule: category.Name, Message: category.Description, Line: line, Column: column})
+			break
 		}
 	}
-	return false
+	return findings
+}
+
+// position converts a byte offset in code to a 1-based line and column
+func position(code string, offset int) (int, int) {
+	before := code[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := len([]rune(before[strings.LastIndex(before, "\n")+1:])) + 1
+	return line, column
 }
 
 // DangerousPatterns contains all the patterns as a struct constant
@@ -87,21 +147,6 @@ var DangerousPatterns = LanguagePatterns{
 				"exec\\(",
 			},
 		},
-		{
-			Name:        "resourceDepletion",
-			Description: "Resource depletion attacks",
-			Patterns: []string{
-				"(?i)while\\s*\\(\\s*true\\s*\\)",
-				"(?i)while\\s*\\(\\s*1\\s*\\)",
-				"(?i)for\\s*\\(\\s*;;\\s*\\)",
-				"(?i)for\\s*\\(;\\s*true\\s*;\\)",
-				"(?i)\\.repeat\\s*\\(\\s*Infinity\\s*\\)",
-				"\\[\\s*\\.\\.\\.Array\\s*\\(\\s*1e\\d+\\s*\\)\\s*\\]",
-				"Array\\s*\\(\\s*1e\\d+\\s*\\)",
-				"BigInt\\s*\\(\\s*Number\\.MAX_SAFE_INTEGER\\s*\\)\\s*\\*\\s*BigInt",
-				"(?i)setTimeout\\s*\\(\\s*function\\s*\\(\\s*\\)\\s*{\\s*while\\s*\\(\\s*true\\s*\\)",
-			},
-		},
 		{
 			Name:        "forkBombs",
 			Description: "Fork bomb attacks",
@@ -119,154 +164,100 @@ var DangerousPatterns = LanguagePatterns{
 		},
 	},
 	Language: map[string][]PatternCategory{
-		"python": {
-			{
-				Name:        "dangerousModules",
-				Description: "Dangerous Python modules",
-				Patterns: []string{
-					"import\\s+os\\s*$",
-					"from\\s+os\\s+import\\s+(system|popen|execl|execle|execlp|execv|execve|execvp|execvpe|spawn)",
-					"import\\s+subprocess",
-					"import\\s+shutil",
-					"import\\s+ctypes",
-					"import\\s+sys",
-					"__import__\\(['\"]os['\"]",
-				},
-			},
+		LanguageCpp: {
 			{
 				Name:        "dangerousOperations",
-				Description: "Dangerous Python operations",
-				Patterns: []string{
-					"open\\(.+,\\s*['\"]w['\"]",
-					"__import__\\(",
-					"globals\\(\\)\\.",
-					"locals\\(\\)\\.",
-					"os\\.system\\(",
-					"os\\.exec\\(",
-					"subprocess\\.Popen\\(",
-					"os\\.fork\\(",
-					"threading\\.Thread\\s*\\(.*bomb\\(\\)",
-					"for\\s*\\(.*\\s*os\\.fork\\(\\)",
-					"while\\s*True\\s*:\\s*os\\.fork\\(\\)",
-				},
-			},
-			{
-				Name:        "pythonResourceDepletion",
-				Description: "Python resource depletion attacks",
-				Patterns: []string{
-					"while\\s+True\\s*:",
-					"[[]\\s*0\\s*\\]\\s*\\*\\s*10\\*\\*\\d+",
-					"range\\s*\\(\\s*10\\s*\\*\\*\\s*\\d{2,}\\s*\\)",
-					"'\\s*'\\s*\\.join\\s*\\(\\s*\\[\\s*'A'\\s*\\]\\s*\\*\\s*10\\*\\*\\d+\\s*\\)",
-					"multiprocessing\\.Pool\\s*\\(\\s*processes\\s*=\\s*\\d{3,}\\s*\\)",
-					"threading\\.Thread\\s*\\(\\s*target\\s*=\\s*.+\\s*\\)\\s*\\.start\\s*\\(\\s*\\)",
-					"\\{\\s*\\.\\*\\s*\\.\\*\\s*\\.\\*\\s*\\.\\*\\s*\\}", // Regex denial of service
-				},
-			},
-		},
-		"go": {
-			{
-				Name:        "infiniteLoops",
-				Description: "Potential infinite loops",
+				Description: "Dangerous C++ operations",
 				Patterns: []string{
-					"for\\s*{",
-					"for\\s+true\\s*{",
-					"for\\s+;\\s*;\\s*{",
+					"system\\(",
+					"exec\\(",
+					"fork\\(",
+					"popen\\(",
+					"std::system",
+					"\\bremove\\s*\\(",
+					"\\brename\\s*\\(",
+					"__asm__|\\basm\\s*\\(",
 				},
 			},
 			{
-				Name:        "dangerousOsFunctions",
-				Description: "Dangerous OS functions",
+				Name:        "dangerousHeaders",
+				Description: "Dangerous C++ headers",
 				Patterns: []string{
-					"os\\.Remove", "os\\.RemoveAll",
-					"os\\.Chdir", "os\\.Chmod",
-					"os\\.Chown", "os\\.Exit",
-					"os\\.Link", "os\\.MkdirAll",
-					"os\\.Rename", "os\\.Symlink",
+					"#include\\s*<(unistd\\.h|sys/\\w+\\.h|fstream|filesystem|dlfcn\\.h|signal\\.h|csignal)>",
 				},
 			},
 			{
-				Name:        "goResourceDepletion",
-				Description: "Go resource depletion attacks",
+				Name:        "cppResourceDepletion",
+				Description: "C++ resource depletion attacks",
 				Patterns: []string{
-					"make\\s*\\(\\s*\\[\\]\\w+\\s*,\\s*\\d{8,}\\s*\\)",
-					"go\\s+func\\s*\\(\\s*\\)\\s*{\\s*for\\s*{",
-					"for\\s*\\i\\s*:=\\s*0\\s*;\\s*;\\s*i\\+\\+",
-					"runtime\\.GOMAXPROCS\\s*\\(\\s*\\d{3,}\\s*\\)",
-					"len\\s*\\(\\s*make\\s*\\(\\s*\\[\\]byte\\s*,\\s*1<<\\d{2,}\\s*\\)\\s*\\)",
+					"malloc\\s*\\(\\s*UINT_MAX\\s*\\)",
+					"calloc\\s*\\(\\s*UINT_MAX",
+					"new\\s+char\\s*\\[\\s*UINT_MAX\\s*\\]",
+					"std::vector<\\w+>\\s*\\(\\s*\\d{9,}\\s*\\)",
+					"std::thread\\s*\\(\\s*\\[\\]\\s*\\(\\s*\\)\\s*{\\s*while\\s*\\(\\s*true\\s*\\)",
+					"#include\\s*<fork.h>",
 				},
 			},
 		},
-		"js": {
+		LanguageJava: {
 			{
-				Name:        "dangerousModules",
-				Description: "Dangerous JS modules",
+				Name:        "dangerousOperations",
+				Description: "Dangerous Java operations",
 				Patterns: []string{
-					"require\\(['\"]fs['\"]",
-					"require\\(['\"]child_process['\"]",
-					"require\\(['\"]http['\"]",
-					"require\\(['\"]https['\"]",
-					"require\\(['\"]os['\"]",
-					"import\\s+.*\\s+from\\s+['\"]fs['\"]",
-					"import\\s+.*\\s+from\\s+['\"]child_process['\"]",
+					"Runtime\\s*\\.\\s*getRuntime\\s*\\(",
+					"ProcessBuilder",
+					"System\\s*\\.\\s*exit\\s*\\(",
+					"System\\s*\\.\\s*(load|loadLibrary)\\s*\\(",
+					"Class\\s*\\.\\s*forName\\s*\\(",
+					"\\.getDeclared(Method|Field|Constructor)s?\\s*\\(",
+					"\\.setAccessible\\s*\\(",
+					"sun\\.misc\\.Unsafe",
 				},
 			},
 			{
-				Name:        "dangerousOperations",
-				Description: "Dangerous JS operations",
+				Name:        "dangerousPackages",
+				Description: "Dangerous Java packages",
 				Patterns: []string{
-					"process\\.exit",
-					"Function\\(.*\\)",
-					"new Function",
-					"window\\.",
-					"document\\.",
-					"localStorage",
-					"sessionStorage",
-					"indexedDB",
-					"WebSocket",
+					"java\\.(io\\.File\\b|io\\.FileOutputStream|io\\.FileWriter|io\\.RandomAccessFile)",
+					"java\\.nio\\.file",
+					"java\\.net\\.",
+					"java\\.lang\\.reflect",
 				},
 			},
 			{
-				Name:        "jsResourceDepletion",
-				Description: "JavaScript resource depletion attacks",
+				Name:        "javaResourceDepletion",
+				Description: "Java resource depletion attacks",
 				Patterns: []string{
-					"while\\s*\\(\\s*true\\s*\\)",
-					"for\\s*\\(\\s*;;\\s*\\)",
-					"setTimeout\\s*\\(\\s*function\\s*\\(\\s*\\)\\s*{\\s*location\\.reload\\s*\\(\\s*\\)",
-					"\\.repeat\\s*\\(\\s*1e\\d+\\s*\\)",
-					"Array\\s*\\(\\s*1e\\d+\\s*\\)",
-					"new\\s+Array\\s*\\(\\s*1e\\d+\\s*\\)",
-					"\\[\\s*\\.\\.\\.Array\\s*\\(\\s*1e\\d+\\s*\\)\\s*\\]",
-					"(?i)\\(\\+\\[\\]\\+\\[\\]\\+\\[\\]\\+\\[\\]\\+\\[\\]\\+\\[\\]\\+\\[\\]",
+					"new\\s+Thread\\s*\\(",
+					"Executors\\s*\\.\\s*new\\w*ThreadPool",
+					"new\\s+\\w+\\s*\\[\\s*Integer\\.MAX_VALUE\\s*\\]",
 				},
 			},
 		},
-		"cpp": {
+		LanguageRust: {
 			{
 				Name:        "dangerousOperations",
-				Description: "Dangerous C++ operations",
+				Description: "Dangerous Rust operations",
 				Patterns: []string{
-					"system\\(",
-					"exec\\(",
-					"fork\\(",
-					"popen\\(",
-					"delete\\s+.*\\s+;",
-					"new\\s+.*\\s*;",
-					"std::system",
+					"std::process::(Command|exit)",
+					"process::Command",
+					"\\bunsafe\\b",
+					"std::fs\\b",
+					"std::net\\b",
+					"std::env\\b",
+					"extern\\s+\\\"C\\\"",
+					"#!\\[\\s*no_std\\s*\\]",
+					"include_(str|bytes)!\\s*\\(",
 				},
 			},
 			{
-				Name:        "cppResourceDepletion",
-				Description: "C++ resource depletion attacks",
+				Name:        "rustResourceDepletion",
+				Description: "Rust resource depletion attacks",
 				Patterns: []string{
-					"while\\s*\\(\\s*true\\s*\\)",
-					"for\\s*\\(\\s*;;\\s*\\)",
-					"malloc\\s*\\(\\s*UINT_MAX\\s*\\)",
-					"calloc\\s*\\(\\s*UINT_MAX",
-					"new\\s+char\\s*\\[\\s*UINT_MAX\\s*\\]",
-					"std::vector<\\w+>\\s*\\(\\s*\\d{9,}\\s*\\)",
-					"std::thread\\s*\\(\\s*\\[\\]\\s*\\(\\s*\\)\\s*{\\s*while\\s*\\(\\s*true\\s*\\)",
-					"#include\\s*<fork.h>",
+					"std::thread::spawn",
+					"thread::spawn",
+					"vec!\\s*\\[[^;\\]]*;\\s*\\d{9,}\\s*\\]",
+					"Vec::with_capacity\\s*\\(\\s*\\d{9,}\\s*\\)",
 				},
 			},
 		},
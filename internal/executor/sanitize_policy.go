@@ -0,0 +1,432 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/lexer"
+)
+
+// goAllowedSelectors are the only members user code may use of these packages, even when the
+// driver imports them. Solutions need the standard streams of os, not the file system.
+var goAllowedSelectors = map[string]map[string]bool{
+	"os": {
+		"Stdin": true, "Stdout": true, "Stderr": true, "Args": true,
+		"ErrClosed": true, "ErrDeadlineExceeded": true,
+	},
+}
+
+// goDeniedPackages may not be referenced at all, even when the driver imports them
+var goDeniedPackages = map[string]bool{
+	"exec": true, "syscall": true, "unsafe": true, "net": true, "http": true,
+	"runtime": true, "debug": true, "reflect": true, "signal": true, "plugin": true,
+}
+
+// goPolicy checks Go code on its AST. Only packages that the code builder imports
+// automatically may be imported explicitly.
+type goPolicy struct{}
+
+// parseGoFragment parses user code, which is a fragment of package main, as a file on its own.
+// lineOffset is the number of lines added in front of the code to make it a file.
+func parseGoFragment(code string) (fset *token.FileSet, file *ast.File, lineOffset int, err error) {
+	const header = "package main\n"
+	fset = token.NewFileSet()
+	file, err = parser.ParseFile(fset, "code.go", header+code, 0)
+	if err == nil {
+		return fset, file, 1, nil
+	}
+	file, err = parser.ParseFile(fset, "code.go", code, 0)
+	return fset, file, 0, err
+}
+
+func (goPolicy) Check(code string) []Finding {
+	// code that does not parse is left to the compiler, which rejects it before anything runs
+	fset, file, lineOffset, err := parseGoFragment(code)
+	if err != nil {
+		return nil
+	}
+
+	var findings []Finding
+	report := func(pos token.Pos, rule, format string, args ...any) {
+		p := fset.Position(pos)
+		findings = append(findings, Finding{Rule: rule, Message: fmt.Sprintf(format, args...), Line: p.Line - lineOffset, Column: p.Column})
+	}
+
+	allowed := make(map[string]bool, len(goStdlibIndex))
+	for _, importPath := range goStdlibIndex {
+		allowed[importPath] = true
+	}
+
+	// the denylists are keyed by package name, so renamed imports are checked under their real name
+	renamed := make(map[string]string)
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		if !allowed[importPath] {
+			report(spec.Pos(), "import", "package %q is not allowed", importPath)
+		}
+
+		if spec.Name == nil {
+			continue
+		}
+		switch spec.Name.Name {
+		case "_":
+		case ".":
+			// members of a dot import can't be told apart from the user's own identifiers
+			report(spec.Pos(), "import", "dot import of %q is not allowed", importPath)
+		default:
+			renamed[spec.Name.Name] = path.Base(importPath)
+		}
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			pkg, ok := n.X.(*ast.Ident)
+			if !ok || pkg.Obj != nil {
+				return true
+			}
+			name := pkg.Name
+			if real, ok := renamed[name]; ok {
+				name = real
+			}
+			allowed, restricted := goAllowedSelectors[name]
+			if goDeniedPackages[name] || (restricted && !allowed[n.Sel.Name]) {
+				report(n.Pos(), "dangerousOperation", "%s.%s is not allowed", name, n.Sel.Name)
+			}
+
+		case *ast.ForStmt:
+			if isAlwaysTrue(n.Cond) && !loopCanExit(n.Body) {
+				report(n.Pos(), "infiniteLoop", "loop has no condition and never breaks, returns or panics")
+			}
+		}
+		return true
+	})
+
+	return findings
+}
+
+func isAlwaysTrue(cond ast.Expr) bool {
+	if cond == nil {
+		return true
+	}
+	ident, ok := cond.(*ast.Ident)
+	return ok && ident.Name == "true"
+}
+
+// loopCanExit reports whether a loop body contains a statement that leaves the loop.
+// A plain break only counts when it is not nested in another loop, switch or select.
+func loopCanExit(body *ast.BlockStmt) bool {
+	exits := false
+
+	var visit func(root ast.Node, nested bool)
+	visit = func(root ast.Node, nested bool) {
+		ast.Inspect(root, func(n ast.Node) bool {
+			if exits {
+				return false
+			}
+
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.ReturnStmt:
+				exits = true
+			case *ast.BranchStmt:
+				if n.Tok == token.GOTO || (n.Tok == token.BREAK && (n.Label != nil || !nested)) {
+					exits = true
+				}
+			case *ast.CallExpr:
+				if ident, ok := n.Fun.(*ast.Ident); ok && ident.Name == "panic" {
+					exits = true
+				}
+			case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+				if n != root {
+					visit(n, true)
+					return false
+				}
+			}
+			return true
+		})
+	}
+
+	visit(body, false)
+	return exits
+}
+
+// pythonAllowedModules are the modules user code may import
+var pythonAllowedModules = map[string]bool{
+	"array": true, "bisect": true, "cmath": true, "collections": true, "copy": true, "dataclasses": true,
+	"decimal": true, "enum": true, "fractions": true, "functools": true, "heapq": true, "itertools": true,
+	"math": true, "numbers": true, "operator": true, "random": true, "re": true, "statistics": true,
+	"string": true, "typing": true,
+}
+
+// pythonDeniedNames may not be used as names. Modules are included because the driver's
+// imports are visible to user code.
+var pythonDeniedNames = map[string]bool{
+	"eval": true, "exec": true, "compile": true, "open": true, "globals": true, "locals": true,
+	"getattr": true, "setattr": true, "delattr": true, "breakpoint": true, "__import__": true, "__builtins__": true,
+	"vars": true, "dir": true,
+	"os": true, "sys": true, "subprocess": true, "shutil": true, "socket": true, "ctypes": true,
+	"importlib": true, "builtins": true, "signal": true, "threading": true, "multiprocessing": true,
+}
+
+// pythonDeniedAttributes give access to interpreter internals. Attributes starting with an
+// underscore are denied as well, allowed modules keep theirs there (random._os).
+var pythonDeniedAttributes = map[string]bool{
+	"__subclasses__": true, "__globals__": true, "__code__": true, "__closure__": true, "__bases__": true,
+	"__base__": true, "__mro__": true, "__class__": true, "__dict__": true, "__loader__": true, "__spec__": true,
+	"__getattribute__": true, "__reduce__": true, "__reduce_ex__": true, "__builtins__": true, "__import__": true,
+	"f_globals": true, "f_locals": true, "f_back": true, "gi_frame": true, "tb_frame": true,
+}
+
+// pythonPolicy checks Python code on its tokens
+type pythonPolicy struct{}
+
+func (pythonPolicy) Check(code string) []Finding {
+	tokens, err := lexer.Tokenize(code, lexer.Python)
+	if err != nil {
+		return []Finding{lexerFinding(err)}
+	}
+	return checkPythonTokens(tokens, nil)
+}
+
+// checkPythonTokens checks tokens; when at is set, findings are reported at its position
+// (used for expressions inside f-strings)
+func checkPythonTokens(tokens []lexer.Token, at *lexer.Token) []Finding {
+	var findings []Finding
+	report := func(t lexer.Token, rule, format string, args ...any) {
+		if at != nil {
+			t = *at
+		}
+		findings = append(findings, Finding{Rule: rule, Message: fmt.Sprintf(format, args...), Line: t.Line, Column: t.Column})
+	}
+
+	// module names of import statements are only checked against the allowlist
+	moduleNames := make(map[int]bool)
+
+	for i, t := range tokens {
+		var prev *lexer.Token
+		if i > 0 {
+			prev = &tokens[i-1]
+		}
+		afterDot := prev != nil && prev.Text == "."
+
+		switch t.Kind {
+		case lexer.Ident:
+			switch {
+			case moduleNames[i]:
+			case t.Text == "import" && startsStatement(prev, t):
+				for _, j := range pythonImportedModules(tokens, i+1) {
+					moduleNames[j] = true
+					if !pythonAllowedModules[tokens[j].Text] {
+						report(tokens[j], "import", "module %q is not allowed", tokens[j].Text)
+					}
+				}
+			case t.Text == "from" && !afterDot && startsStatement(prev, t):
+				if i+1 < len(tokens) && tokens[i+1].Text == "." {
+					report(t, "import", "relative imports are not allowed")
+				} else if i+1 < len(tokens) {
+					moduleNames[i+1] = true
+					if !pythonAllowedModules[tokens[i+1].Text] {
+						report(tokens[i+1], "import", "module %q is not allowed", tokens[i+1].Text)
+					}
+				}
+			case afterDot && pythonAttributeDenied(t.Text):
+				report(t, "dangerousOperation", "attribute %s is not allowed", t.Text)
+			case !afterDot && (pythonDeniedNames[t.Text] || pythonDeniedAttributes[t.Text]):
+				report(t, "dangerousOperation", "%s is not allowed", t.Text)
+			}
+
+		case lexer.String:
+			if pythonDeniedAttributes[t.Value()] {
+				report(t, "dangerousOperation", "attribute %s is not allowed", t.Value())
+			}
+			// str.format reads attributes named in its fields: "{0.__init__.__globals__}"
+			for _, attr := range pythonFormatAttributes(t.Value()) {
+				if pythonAttributeDenied(attr) {
+					report(t, "dangerousOperation", "attribute %s is not allowed", attr)
+				}
+			}
+			for _, expr := range lexer.Interpolations(t) {
+				inner, err := lexer.Tokenize(expr, lexer.Python)
+				if err != nil {
+					report(t, "syntax", "invalid f-string expression")
+					continue
+				}
+				findings = append(findings, checkPythonTokens(inner, &t)...)
+			}
+		}
+	}
+
+	return findings
+}
+
+// pythonAttributeDenied reports whether user code may not read the attribute name
+func pythonAttributeDenied(name string) bool {
+	return pythonDeniedAttributes[name] || strings.HasPrefix(name, "_")
+}
+
+// pythonFormatField matches the field name of a str.format replacement field
+var pythonFormatField = regexp.MustCompile(`\{([^{}!:]*)`)
+
+// pythonFormatAttributes returns the attribute names read by the replacement fields of a
+// format string, e.g. x and y for "{0.x[1].y}"
+func pythonFormatAttributes(s string) []string {
+	var attrs []string
+	for _, m := range pythonFormatField.FindAllStringSubmatch(s, -1) {
+		parts := strings.Split(m[1], ".")
+		for _, part := range parts[1:] {
+			if i := strings.IndexByte(part, '['); i >= 0 {
+				part = part[:i]
+			}
+			attrs = append(attrs, part)
+		}
+	}
+	return attrs
+}
+
+// pythonImportedModules returns the indexes of the top-level module names of
+// `import a.b as c, d`, whose names start at tokens[i]
+func pythonImportedModules(tokens []lexer.Token, i int) []int {
+	var modules []int
+	for i < len(tokens) && tokens[i].Kind == lexer.Ident {
+		modules = append(modules, i)
+		i++
+
+		// skip submodules and the alias
+		for i < len(tokens) && tokens[i].Text == "." {
+			i += 2
+		}
+		if i < len(tokens) && tokens[i].Text == "as" {
+			i += 2
+		}
+
+		if i >= len(tokens) || tokens[i].Text != "," {
+			break
+		}
+		i++
+	}
+	return modules
+}
+
+// startsStatement reports whether t is the first token of a statement, which tells the
+// `from` of an import apart from `yield from` and `raise ... from`
+func startsStatement(prev *lexer.Token, t lexer.Token) bool {
+	return prev == nil || prev.Line != t.Line || prev.Text == ";" || prev.Text == ":"
+}
+
+// javascriptAllowedModules are the modules user code may require or import
+var javascriptAllowedModules = map[string]bool{
+	"assert": true, "util": true,
+}
+
+// javascriptDeniedNames may not be used as names
+var javascriptDeniedNames = map[string]bool{
+	"eval": true, "Function": true, "process": true, "globalThis": true, "global": true, "module": true,
+	"WebSocket": true, "fetch": true, "XMLHttpRequest": true, "Worker": true, "importScripts": true,
+	"Deno": true, "Bun": true,
+}
+
+// javascriptDeniedProperties give access to the Function constructor or prototypes
+var javascriptDeniedProperties = map[string]bool{
+	"constructor": true, "__proto__": true, "__defineGetter__": true, "__defineSetter__": true,
+}
+
+// javascriptPolicy checks JavaScript and TypeScript code on its tokens
+type javascriptPolicy struct{}
+
+func (javascriptPolicy) Check(code string) []Finding {
+	tokens, err := lexer.Tokenize(code, lexer.JavaScript)
+	if err != nil {
+		return []Finding{lexerFinding(err)}
+	}
+	return checkJavascriptTokens(tokens, nil)
+}
+
+func checkJavascriptTokens(tokens []lexer.Token, at *lexer.Token) []Finding {
+	var findings []Finding
+	report := func(t lexer.Token, rule, format string, args ...any) {
+		if at != nil {
+			t = *at
+		}
+		findings = append(findings, Finding{Rule: rule, Message: fmt.Sprintf(format, args...), Line: t.Line, Column: t.Column})
+	}
+
+	// checkModule validates the module name at tokens[i]
+	checkModule := func(t lexer.Token, i int) {
+		if i >= len(tokens) || tokens[i].Kind != lexer.String {
+			report(t, "import", "modules must be loaded by name")
+			return
+		}
+		if module := strings.TrimPrefix(tokens[i].Value(), "node:"); !javascriptAllowedModules[module] {
+			report(tokens[i], "import", "module %q is not allowed", module)
+		}
+	}
+
+	for i, t := range tokens {
+		afterDot := i > 0 && (tokens[i-1].Text == "." || tokens[i-1].Text == "?.")
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1].Text
+		}
+
+		switch t.Kind {
+		case lexer.Ident:
+			switch {
+			case afterDot:
+				if javascriptDeniedProperties[t.Text] {
+					report(t, "dangerousOperation", "property %s is not allowed", t.Text)
+				}
+			case t.Text == "require":
+				if next != "(" {
+					report(t, "import", "require can only be called")
+					continue
+				}
+				checkModule(t, i+2)
+			case t.Text == "import" && next == "(":
+				checkModule(t, i+2)
+			case t.Text == "import" && next == ".":
+				report(t, "dangerousOperation", "import.meta is not allowed")
+			case t.Text == "import" && i+1 < len(tokens) && tokens[i+1].Kind == lexer.String:
+				checkModule(t, i+1)
+			case t.Text == "from" && i+1 < len(tokens) && tokens[i+1].Kind == lexer.String:
+				checkModule(t, i+1)
+			case javascriptDeniedNames[t.Text] || javascriptDeniedProperties[t.Text]:
+				report(t, "dangerousOperation", "%s is not allowed", t.Text)
+			}
+
+		case lexer.String, lexer.Template:
+			if javascriptDeniedProperties[t.Value()] {
+				report(t, "dangerousOperation", "property %s is not allowed", t.Value())
+			}
+			for _, expr := range lexer.Interpolations(t) {
+				inner, err := lexer.Tokenize(expr, lexer.JavaScript)
+				if err != nil {
+					report(t, "syntax", "invalid template expression")
+					continue
+				}
+				findings = append(findings, checkJavascriptTokens(inner, &t)...)
+			}
+		}
+	}
+
+	return findings
+}
+
+func lexerFinding(err error) Finding {
+	finding := Finding{Rule: "syntax", Message: err.Error()}
+	var lexErr *lexer.Error
+	if errors.As(err, &lexErr) {
+		finding.Message = lexErr.Message
+		finding.Line = lexErr.Line
+		finding.Column = lexErr.Column
+	}
+	return finding
+}
@@ -0,0 +1,215 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// findings returns the findings of Sanitize, or nil when the code is allowed
+func findings(t *testing.T, code, language string) []Finding {
+	t.Helper()
+
+	err := Sanitize(code, language, DefaultMaxCodeLength)
+	if err == nil {
+		return nil
+	}
+
+	var sanitizeErr *SanitizationError
+	require.ErrorAs(t, err, &sanitizeErr)
+	require.NotEmpty(t, sanitizeErr.Findings)
+	return sanitizeErr.Findings
+}
+
+func TestSanitizeGo(t *testing.T) {
+	t.Run("Loops that can exit are allowed", func(t *testing.T) {
+		code := `func solve(n int) int {
+	i := 0
+	for {
+		switch {
+		case i > n:
+			return i
+		}
+		i++
+	}
+}
+
+func count(xs []int) (c int) {
+	for true {
+		if c == len(xs) {
+			break
+		}
+		c++
+	}
+	return
+}`
+		assert.Nil(t, findings(t, code, LanguageGo))
+	})
+
+	t.Run("Loops that never exit are rejected", func(t *testing.T) {
+		code := "func solve() {\n\tfor {\n\t\tfor i := 0; i < 3; i++ {\n\t\t\tbreak\n\t\t}\n\t}\n}"
+		assert.Equal(t, []Finding{{
+			Rule:    "infiniteLoop",
+			Message: "loop has no condition and never breaks, returns or panics",
+			Line:    2,
+			Column:  2,
+		}}, findings(t, code, LanguageGo))
+	})
+
+	t.Run("Dangerous packages", func(t *testing.T) {
+		code := "func solve() {\n\t// os.Exit(1) in a comment is fine\n\tos.Exit(1)\n\t_ = exec.Command(\"ls\")\n}"
+		got := findings(t, code, LanguageGo)
+		require.Len(t, got, 2)
+		assert.Equal(t, "os.Exit is not allowed", got[0].Message)
+		assert.Equal(t, 3, got[0].Line)
+		assert.Equal(t, "exec.Command is not allowed", got[1].Message)
+	})
+
+	t.Run("Only the standard streams of os", func(t *testing.T) {
+		code := "func solve() {\n\tfmt.Fprintln(os.Stderr, len(os.Args))\n\tos.DirFS(\"/\").Open(\"etc/passwd\")\n\tos.FindProcess(1)\n\t_, _ = os.Stat(\"/\")\n}"
+		got := findings(t, code, LanguageGo)
+		require.Len(t, got, 3)
+		assert.Equal(t, "os.DirFS is not allowed", got[0].Message)
+		assert.Equal(t, "os.FindProcess is not allowed", got[1].Message)
+		assert.Equal(t, "os.Stat is not allowed", got[2].Message)
+	})
+
+	t.Run("Renamed imports are checked under their real name", func(t *testing.T) {
+		code := "import o \"os\"\n\nfunc solve() []byte {\n\tb, _ := o.ReadFile(\"/etc/passwd\")\n\treturn b\n}"
+		got := findings(t, code, LanguageGo)
+		require.Len(t, got, 1)
+		assert.Equal(t, "os.ReadFile is not allowed", got[0].Message)
+		assert.Equal(t, 4, got[0].Line)
+	})
+
+	t.Run("Dot imports are rejected", func(t *testing.T) {
+		code := "import . \"os\"\n\nfunc solve() []byte {\n\tb, _ := ReadFile(\"/etc/passwd\")\n\treturn b\n}"
+		got := findings(t, code, LanguageGo)
+		require.Len(t, got, 1)
+		assert.Equal(t, Finding{Rule: "import", Message: `dot import of "os" is not allowed`, Line: 1, Column: 8}, got[0])
+	})
+
+	t.Run("Local variables named like packages", func(t *testing.T) {
+		code := "func solve(net []int) int {\n\treturn len(net)\n}\n\ntype graph struct{ debug bool }\n\nfunc (g graph) on() bool { return g.debug }"
+		assert.Nil(t, findings(t, code, LanguageGo))
+	})
+}
+
+func TestSanitizePython(t *testing.T) {
+	t.Run("Common solutions are allowed", func(t *testing.T) {
+		code := `from collections import deque
+import heapq, itertools as it
+import re
+
+def solve(nums):
+    # import os would be rejected, but not in a comment
+    pattern = re.compile(r"\d+")
+    q = deque(nums)
+    while True:
+        if not q:
+            break
+        q.popleft()
+    return "os.system"`
+		assert.Nil(t, findings(t, code, LanguagePython))
+	})
+
+	t.Run("Imports outside the allowlist", func(t *testing.T) {
+		got := findings(t, "import math, os.path\nfrom subprocess import run\nfrom . import x", LanguagePython)
+		assert.Equal(t, []Finding{
+			{Rule: "import", Message: `module "os" is not allowed`, Line: 1, Column: 14},
+			{Rule: "import", Message: `module "subprocess" is not allowed`, Line: 2, Column: 6},
+			{Rule: "import", Message: "relative imports are not allowed", Line: 3, Column: 1},
+		}, got)
+	})
+
+	t.Run("Dangerous names and attributes", func(t *testing.T) {
+		code := "def solve():\n    x = ().__class__.__bases__[0]\n    return f\"{eval('1')}\""
+		got := findings(t, code, LanguagePython)
+		require.Len(t, got, 3)
+		assert.Equal(t, Finding{Rule: "dangerousOperation", Message: "attribute __class__ is not allowed", Line: 2, Column: 12}, got[0])
+		assert.Equal(t, Finding{Rule: "dangerousOperation", Message: "eval is not allowed", Line: 3, Column: 12}, got[2])
+	})
+
+	t.Run("Private attributes of allowed modules", func(t *testing.T) {
+		got := findings(t, "import random\n\ndef solve():\n    random._os.system('ls')", LanguagePython)
+		assert.Equal(t, []Finding{{Rule: "dangerousOperation", Message: "attribute _os is not allowed", Line: 4, Column: 12}}, got)
+	})
+
+	t.Run("Attributes read by format strings", func(t *testing.T) {
+		code := "def solve(f):\n    return \"{0.__init__.__globals__}\".format(f) + \"{0.real:>4} {{x}}\".format(1)"
+		got := findings(t, code, LanguagePython)
+		require.Len(t, got, 2)
+		assert.Equal(t, Finding{Rule: "dangerousOperation", Message: "attribute __init__ is not allowed", Line: 2, Column: 12}, got[0])
+		assert.Equal(t, "attribute __globals__ is not allowed", got[1].Message)
+	})
+
+	t.Run("Introspection builtins", func(t *testing.T) {
+		got := findings(t, "def solve(f):\n    return vars(f), dir(f)", LanguagePython)
+		require.Len(t, got, 2)
+		assert.Equal(t, "vars is not allowed", got[0].Message)
+		assert.Equal(t, "dir is not allowed", got[1].Message)
+	})
+
+	t.Run("Sys is visible from the driver", func(t *testing.T) {
+		got := findings(t, "def solve():\n    return sys.modules", LanguagePython)
+		require.Len(t, got, 1)
+		assert.Equal(t, "sys is not allowed", got[0].Message)
+	})
+}
+
+func TestSanitizeJavascript(t *testing.T) {
+	t.Run("Common solutions are allowed", func(t *testing.T) {
+		code := `// require('fs') in a comment is fine
+function callFunction(fn) { return fn(); }
+function solve(nums) {
+  const seen = new Set();
+  while (true) {
+    if (seen.size === nums.length) break;
+    seen.add(nums[seen.size]);
+  }
+  return Array.from(seen).join(" ") + "process";
+}`
+		assert.Nil(t, findings(t, code, LanguageJavascript))
+	})
+
+	t.Run("Modules outside the allowlist", func(t *testing.T) {
+		code := "const fs = require('fs');\nconst util = require(\"util\");\nimport cp from 'node:child_process';"
+		got := findings(t, code, LanguageJavascript)
+		require.Len(t, got, 2)
+		assert.Equal(t, Finding{Rule: "import", Message: `module "fs" is not allowed`, Line: 1, Column: 20}, got[0])
+		assert.Equal(t, Finding{Rule: "import", Message: `module "child_process" is not allowed`, Line: 3, Column: 16}, got[1])
+	})
+
+	t.Run("Dangerous operations", func(t *testing.T) {
+		code := "const f = [].constructor.constructor;\nconst s = `${process.exit(1)}`;\nconst r = require;"
+		got := findings(t, code, LanguageTypescript)
+		require.Len(t, got, 4)
+		assert.Equal(t, Finding{Rule: "dangerousOperation", Message: "property constructor is not allowed", Line: 1, Column: 14}, got[0])
+		assert.Equal(t, Finding{Rule: "dangerousOperation", Message: "process is not allowed", Line: 2, Column: 11}, got[2])
+		assert.Equal(t, Finding{Rule: "import", Message: "require can only be called", Line: 3, Column: 11}, got[3])
+	})
+
+	t.Run("Unterminated strings cannot be checked", func(t *testing.T) {
+		got := findings(t, "const s = 'abc\n", LanguageJavascript)
+		assert.Equal(t, []Finding{{Rule: "syntax", Message: "unterminated string", Line: 1, Column: 11}}, got)
+	})
+}
+
+func TestSanitizeRegexPolicy(t *testing.T) {
+	code := "int solve() {\n  while (true) { break; }\n  system(\"ls\");\n}"
+	assert.Equal(t, []Finding{{Rule: "dangerousOperations", Message: "Dangerous C++ operations", Line: 3, Column: 3}}, findings(t, code, LanguageCpp))
+}
+
+func TestSanitizeCodeLength(t *testing.T) {
+	code := "def solve(nums):\n" + strings.Repeat("    total = sum(nums)\n", 100)
+	require.Greater(t, len(code), 1000)
+
+	assert.NoError(t, Sanitize(code, LanguagePython, DefaultMaxCodeLength))
+
+	err := Sanitize(code, LanguagePython, 500)
+	var sanitizeErr *SanitizationError
+	require.ErrorAs(t, err, &sanitizeErr)
+	assert.Equal(t, "Code length exceeds maximum limit", sanitizeErr.Message)
+}
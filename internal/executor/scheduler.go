@@ -0,0 +1,279 @@
+package executor
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const DefaultMaxInFlightPerPlayer = 1
+
+var errSchedulerClosed = errors.New("scheduler is closed")
+
+// JobPriority decides which queue tier a job goes to. Higher tiers are always drained first.
+type JobPriority int
+
+const (
+	PriorityPractice JobPriority = iota // submissions from /submissions
+	PriorityEvent                       // live event submissions from a room
+
+	priorityLevels = int(PriorityEvent) + 1
+)
+
+// JobMeta identifies who a job belongs to so the scheduler can share workers fairly,
+// and how the job's test cases should be run.
+type JobMeta struct {
+	PlayerID uuid.UUID
+	RoomID   uuid.UUID // uuid.Nil for practice submissions
+	Priority JobPriority
+	// BatchMode runs all test cases in one process, see BatchCaseDelimiter.
+	// The problem's driver code must support it.
+	BatchMode bool
+	// TimeLimit and MemoryLimitKB are enforced per test case. Zero means no limit.
+	TimeLimit     time.Duration
+	MemoryLimitKB int64
+}
+
+// scheduler sits in front of the workers. Within a priority tier it round-robins
+// across rooms, and within a room across players, so that one room or player
+// spamming submissions cannot starve the others. A player never has more than
+// maxInFlight jobs running at once.
+type scheduler struct {
+	mu          sync.Mutex
+	capacity    int
+	size        int
+	maxInFlight int
+	tiers       [priorityLevels]*tierQueue
+	inFlight    map[uuid.UUID]int
+	closed      bool
+
+	ready chan struct{} // signalled when a job may have become runnable
+	freed chan struct{} // closed and replaced whenever a queued job leaves the queue
+}
+
+type tierQueue struct {
+	rooms map[uuid.UUID]*roomQueue
+	order []uuid.UUID // round-robin ring of rooms
+	next  int
+}
+
+type roomQueue struct {
+	players map[uuid.UUID][]Job
+	order   []uuid.UUID // round-robin ring of players
+	next    int
+}
+
+func newScheduler(capacity, maxInFlight int) *scheduler {
+	if maxInFlight <= 0 {
+		maxInFlight = DefaultMaxInFlightPerPlayer
+	}
+
+	s := &scheduler{
+		capacity:    capacity,
+		maxInFlight: maxInFlight,
+		inFlight:    make(map[uuid.UUID]int),
+		ready:       make(chan struct{}, 1),
+		freed:       make(chan struct{}),
+	}
+	for i := range s.tiers {
+		s.tiers[i] = &tierQueue{rooms: make(map[uuid.UUID]*roomQueue)}
+	}
+
+	return s
+}
+
+// Push queues a job. It returns ErrQueueFull when the scheduler is at capacity and
+// errSchedulerClosed once it has been closed.
+func (s *scheduler) Push(job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errSchedulerClosed
+	}
+	if s.size >= s.capacity {
+		return ErrQueueFull
+	}
+
+	t := s.tiers[clampPriority(job.Meta.Priority)]
+	room, ok := t.rooms[job.Meta.RoomID]
+	if !ok {
+		room = &roomQueue{players: make(map[uuid.UUID][]Job)}
+		t.rooms[job.Meta.RoomID] = room
+		t.order = append(t.order, job.Meta.RoomID)
+	}
+
+	if _, ok := room.players[job.Meta.PlayerID]; !ok {
+		room.order = append(room.order, job.Meta.PlayerID)
+	}
+	room.players[job.Meta.PlayerID] = append(room.players[job.Meta.PlayerID], job)
+	s.size++
+
+	s.signalReady()
+	return nil
+}
+
+// Pop takes the next runnable job and marks its player as in flight.
+// It returns false when nothing can run right now.
+func (s *scheduler) Pop() (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for p := priorityLevels - 1; p >= 0; p-- {
+		if job, ok := s.popFromTier(s.tiers[p]); ok {
+			s.size--
+			s.inFlight[job.Meta.PlayerID]++
+			close(s.freed)
+			s.freed = make(chan struct{})
+			return job, true
+		}
+	}
+
+	return Job{}, false
+}
+
+func (s *scheduler) popFromTier(t *tierQueue) (Job, bool) {
+	for i := range len(t.order) {
+		idx := (t.next + i) % len(t.order)
+		roomID := t.order[idx]
+		room := t.rooms[roomID]
+
+		job, ok := s.popFromRoom(room)
+		if !ok {
+			continue
+		}
+
+		if len(room.order) == 0 {
+			delete(t.rooms, roomID)
+			t.order = append(t.order[:idx], t.order[idx+1:]...)
+			t.next = idx
+		} else {
+			t.next = idx + 1
+		}
+		if len(t.order) > 0 {
+			t.next %= len(t.order)
+		} else {
+			t.next = 0
+		}
+
+		return job, true
+	}
+
+	return Job{}, false
+}
+
+func (s *scheduler) popFromRoom(room *roomQueue) (Job, bool) {
+	for i := range len(room.order) {
+		idx := (room.next + i) % len(room.order)
+		playerID := room.order[idx]
+
+		if !s.canRun(playerID) {
+			continue
+		}
+
+		queue := room.players[playerID]
+		job := queue[0]
+
+		if len(queue) == 1 {
+			delete(room.players, playerID)
+			room.order = append(room.order[:idx], room.order[idx+1:]...)
+			room.next = idx
+		} else {
+			room.players[playerID] = queue[1:]
+			room.next = idx + 1
+		}
+		if len(room.order) > 0 {
+			room.next %= len(room.order)
+		} else {
+			room.next = 0
+		}
+
+		return job, true
+	}
+
+	return Job{}, false
+}
+
+// canRun reports whether a player is below the in-flight limit.
+// Anonymous jobs (uuid.Nil) are not limited.
+func (s *scheduler) canRun(playerID uuid.UUID) bool {
+	return playerID == uuid.Nil || s.inFlight[playerID] < s.maxInFlight
+}
+
+// Close refuses new jobs and returns the jobs still queued, which will never run
+func (s *scheduler) Close() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+
+	var jobs []Job
+	for _, t := range s.tiers {
+		for _, roomID := range t.order {
+			room := t.rooms[roomID]
+			for _, playerID := range room.order {
+				jobs = append(jobs, room.players[playerID]...)
+			}
+		}
+		t.rooms = make(map[uuid.UUID]*roomQueue)
+		t.order = nil
+		t.next = 0
+	}
+	s.size = 0
+
+	close(s.freed)
+	s.freed = make(chan struct{})
+	return jobs
+}
+
+// Done releases the in-flight slot taken by a job of the given player
+func (s *scheduler) Done(playerID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.inFlight[playerID] <= 1 {
+		delete(s.inFlight, playerID)
+	} else {
+		s.inFlight[playerID]--
+	}
+
+	s.signalReady()
+}
+
+// Len returns the number of queued (not yet running) jobs
+func (s *scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+// Ready returns a channel that is signalled when a job may have become runnable
+func (s *scheduler) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Freed returns a channel that is closed the next time a queued job leaves the queue
+func (s *scheduler) Freed() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.freed
+}
+
+func (s *scheduler) signalReady() {
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+func clampPriority(p JobPriority) int {
+	if p < 0 {
+		return 0
+	}
+	if int(p) >= priorityLevels {
+		return priorityLevels - 1
+	}
+	return int(p)
+}
@@ -0,0 +1,123 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestJob(playerID, roomID uuid.UUID, priority JobPriority, code string) Job {
+	return Job{
+		Meta: JobMeta{PlayerID: playerID, RoomID: roomID, Priority: priority},
+		Code: code,
+	}
+}
+
+func popCodes(t *testing.T, s *scheduler, n int) []string {
+	t.Helper()
+	codes := make([]string, 0, n)
+	for range n {
+		job, ok := s.Pop()
+		require.True(t, ok)
+		codes = append(codes, job.Code)
+		s.Done(job.Meta.PlayerID)
+	}
+	return codes
+}
+
+func TestScheduler(t *testing.T) {
+	t.Run("Event jobs run before practice jobs", func(t *testing.T) {
+		s := newScheduler(10, 1)
+		require.NoError(t, s.Push(newTestJob(uuid.New(), uuid.Nil, PriorityPractice, "practice")))
+		require.NoError(t, s.Push(newTestJob(uuid.New(), uuid.New(), PriorityEvent, "event")))
+
+		assert.Equal(t, []string{"event", "practice"}, popCodes(t, s, 2))
+	})
+
+	t.Run("Rooms are served round-robin", func(t *testing.T) {
+		s := newScheduler(10, 1)
+		roomA, roomB := uuid.New(), uuid.New()
+		for _, code := range []string{"a1", "a2", "a3"} {
+			require.NoError(t, s.Push(newTestJob(uuid.New(), roomA, PriorityEvent, code)))
+		}
+		require.NoError(t, s.Push(newTestJob(uuid.New(), roomB, PriorityEvent, "b1")))
+
+		assert.Equal(t, []string{"a1", "b1", "a2", "a3"}, popCodes(t, s, 4))
+	})
+
+	t.Run("Players in a room are served round-robin", func(t *testing.T) {
+		s := newScheduler(10, 1)
+		room := uuid.New()
+		spammer, other := uuid.New(), uuid.New()
+		for _, code := range []string{"s1", "s2", "s3"} {
+			require.NoError(t, s.Push(newTestJob(spammer, room, PriorityEvent, code)))
+		}
+		require.NoError(t, s.Push(newTestJob(other, room, PriorityEvent, "o1")))
+
+		assert.Equal(t, []string{"s1", "o1", "s2", "s3"}, popCodes(t, s, 4))
+	})
+
+	t.Run("A player cannot exceed the in-flight limit", func(t *testing.T) {
+		s := newScheduler(10, 1)
+		player := uuid.New()
+		require.NoError(t, s.Push(newTestJob(player, uuid.Nil, PriorityPractice, "first")))
+		require.NoError(t, s.Push(newTestJob(player, uuid.Nil, PriorityPractice, "second")))
+
+		job, ok := s.Pop()
+		require.True(t, ok)
+		assert.Equal(t, "first", job.Code)
+
+		_, ok = s.Pop()
+		assert.False(t, ok)
+		assert.Equal(t, 1, s.Len())
+
+		s.Done(player)
+		job, ok = s.Pop()
+		require.True(t, ok)
+		assert.Equal(t, "second", job.Code)
+	})
+
+	t.Run("Push fails when the queue is full", func(t *testing.T) {
+		s := newScheduler(1, 1)
+		require.NoError(t, s.Push(newTestJob(uuid.New(), uuid.Nil, PriorityPractice, "one")))
+		assert.ErrorIs(t, s.Push(newTestJob(uuid.New(), uuid.Nil, PriorityPractice, "two")), ErrQueueFull)
+	})
+
+	t.Run("Close returns queued jobs and refuses new ones", func(t *testing.T) {
+		s := newScheduler(3, 1)
+		player := uuid.New()
+		require.NoError(t, s.Push(newTestJob(player, uuid.Nil, PriorityPractice, "one")))
+		require.NoError(t, s.Push(newTestJob(player, uuid.New(), PriorityEvent, "two")))
+		freed := s.Freed()
+
+		jobs := s.Close()
+		assert.Len(t, jobs, 2)
+		assert.Equal(t, 0, s.Len())
+		assert.ErrorIs(t, s.Push(newTestJob(player, uuid.Nil, PriorityPractice, "three")), errSchedulerClosed)
+
+		_, ok := s.Pop()
+		assert.False(t, ok)
+		select {
+		case <-freed:
+		default:
+			t.Fatal("expected waiting pushes to be woken up")
+		}
+	})
+
+	t.Run("Freed is closed when a job leaves the queue", func(t *testing.T) {
+		s := newScheduler(1, 1)
+		require.NoError(t, s.Push(newTestJob(uuid.New(), uuid.Nil, PriorityPractice, "one")))
+		freed := s.Freed()
+
+		_, ok := s.Pop()
+		require.True(t, ok)
+
+		select {
+		case <-freed:
+		default:
+			t.Fatal("expected freed channel to be closed")
+		}
+	})
+}
@@ -0,0 +1,338 @@
+package executor
+
+import (
+	"go/ast"
+	"go/token"
+	"slices"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/lexer"
+)
+
+type SymbolKind string
+
+const (
+	SymbolFunction SymbolKind = "function" // functions and methods
+	SymbolType     SymbolKind = "type"     // classes, structs, interfaces, enums and traits
+	SymbolVariable SymbolKind = "variable" // top level variables, constants and fields
+
+	// symbolImpl opens a Rust impl block, it is not reported as a symbol
+	symbolImpl SymbolKind = "impl"
+)
+
+// Symbol is a declaration in the user's code. Methods are named "Type.method".
+type Symbol struct {
+	Name string
+	Kind SymbolKind
+	Line int
+}
+
+// declaredSymbols lists the top level declarations and methods in code. Languages without
+// an extractor return ok false, so their required symbols are left to the compiler.
+func declaredSymbols(code, language string) (symbols []Symbol, ok bool, err error) {
+	switch language {
+	case LanguageGo:
+		symbols, err = goSymbols(code)
+	case LanguagePython:
+		symbols, err = pythonSymbols(code)
+	case LanguageJavascript, LanguageTypescript:
+		symbols, err = braceSymbols(code, lexer.JavaScript, javascriptDeclarations)
+	case LanguageJava, LanguageCpp:
+		symbols, err = braceSymbols(code, lexer.C, cDeclarations)
+	case LanguageRust:
+		symbols, err = braceSymbols(code, lexer.Rust, rustDeclarations)
+	default:
+		return nil, false, nil
+	}
+	return symbols, true, err
+}
+
+func goSymbols(code string) ([]Symbol, error) {
+	fset, file, lineOffset, err := parseGoFragment(code)
+	if err != nil {
+		return nil, err
+	}
+
+	line := func(pos token.Pos) int {
+		return fset.Position(pos).Line - lineOffset
+	}
+
+	var symbols []Symbol
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = receiverType(d.Recv.List[0].Type) + "." + name
+			}
+			symbols = append(symbols, Symbol{Name: name, Kind: SymbolFunction, Line: line(d.Pos())})
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					symbols = append(symbols, Symbol{Name: s.Name.Name, Kind: SymbolType, Line: line(s.Pos())})
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						symbols = append(symbols, Symbol{Name: n.Name, Kind: SymbolVariable, Line: line(n.Pos())})
+					}
+				}
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// receiverType returns the type name of a method receiver such as `*List[T]`
+func receiverType(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// pythonSymbols relies on indentation: declarations in column 1 are top level, and a def
+// indented one level below a top level class is a method of that class.
+func pythonSymbols(code string) ([]Symbol, error) {
+	tokens, err := lexer.Tokenize(code, lexer.Python)
+	if err != nil {
+		return nil, err
+	}
+
+	var symbols []Symbol
+	class, bodyColumn, lastLine := "", 0, 0
+	for i, t := range tokens {
+		newLine := t.Line != lastLine
+		lastLine = t.Line
+		if !newLine {
+			continue
+		}
+
+		if t.Column == 1 {
+			class = ""
+		} else if class != "" && bodyColumn == 0 {
+			bodyColumn = t.Column
+		}
+
+		name := identAt(tokens, i+1)
+
+		switch {
+		case t.Column == 1 && t.Text == "class" && name != "":
+			symbols = append(symbols, Symbol{Name: name, Kind: SymbolType, Line: t.Line})
+			class, bodyColumn = name, 0
+		case t.Column == 1 && t.Text == "def" && name != "":
+			symbols = append(symbols, Symbol{Name: name, Kind: SymbolFunction, Line: t.Line})
+		case t.Column == 1 && t.Kind == lexer.Ident && textAt(tokens, i+1) == "=":
+			symbols = append(symbols, Symbol{Name: t.Text, Kind: SymbolVariable, Line: t.Line})
+		case class != "" && t.Column == bodyColumn && t.Text == "def" && name != "":
+			symbols = append(symbols, Symbol{Name: class + "." + name, Kind: SymbolFunction, Line: t.Line})
+		}
+	}
+	return symbols, nil
+}
+
+// declarationFunc recognizes a declaration starting at tokens[i]. class is the type whose
+// body directly encloses the token, or "" at the top level.
+type declarationFunc func(tokens []lexer.Token, i int, class string) (Symbol, bool)
+
+// braceSymbols scans languages whose blocks are delimited by braces, tracking which type
+// body each brace opens so that methods can be attributed to their type.
+func braceSymbols(code string, syntax lexer.Syntax, declaration declarationFunc) ([]Symbol, error) {
+	tokens, err := lexer.Tokenize(code, syntax)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		symbols []Symbol
+		blocks  []string // for each open brace, the type it belongs to or ""
+		pending string   // a type declaration whose body has not been opened yet
+	)
+	for i, t := range tokens {
+		switch t.Text {
+		case "{":
+			blocks = append(blocks, pending)
+			pending = ""
+			continue
+		case "}":
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+			}
+			continue
+		case ";":
+			pending = ""
+			continue
+		}
+
+		class := ""
+		if len(blocks) > 0 {
+			class = blocks[len(blocks)-1]
+			if class == "" {
+				// inside a function body or another block
+				continue
+			}
+		}
+
+		symbol, ok := declaration(tokens, i, class)
+		if !ok {
+			continue
+		}
+		if symbol.Kind == SymbolType || symbol.Kind == symbolImpl {
+			pending = symbol.Name
+		}
+		if symbol.Kind == symbolImpl {
+			continue
+		}
+		if class != "" {
+			symbol.Name = class + "." + symbol.Name
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, nil
+}
+
+// identAt returns the identifier at tokens[i], or "" if there is none
+func identAt(tokens []lexer.Token, i int) string {
+	if i < 0 || i >= len(tokens) || tokens[i].Kind != lexer.Ident {
+		return ""
+	}
+	return tokens[i].Text
+}
+
+func textAt(tokens []lexer.Token, i int) string {
+	if i < 0 || i >= len(tokens) {
+		return ""
+	}
+	return tokens[i].Text
+}
+
+var javascriptTypeKeywords = []string{"class", "interface", "type", "enum"}
+
+var javascriptMethodModifiers = []string{
+	"static", "async", "get", "set", "public", "private", "protected", "readonly", "override", "abstract", "*",
+}
+
+func javascriptDeclarations(tokens []lexer.Token, i int, class string) (Symbol, bool) {
+	t := tokens[i]
+	name := identAt(tokens, i+1)
+
+	if class == "" {
+		switch {
+		case t.Text == "function" && name != "":
+			return Symbol{Name: name, Kind: SymbolFunction, Line: t.Line}, true
+		case t.Text == "function" && textAt(tokens, i+1) == "*" && identAt(tokens, i+2) != "":
+			return Symbol{Name: identAt(tokens, i+2), Kind: SymbolFunction, Line: t.Line}, true
+		case slices.Contains(javascriptTypeKeywords, t.Text) && name != "":
+			return Symbol{Name: name, Kind: SymbolType, Line: t.Line}, true
+		case (t.Text == "const" || t.Text == "let" || t.Text == "var") && name != "":
+			return Symbol{Name: name, Kind: SymbolVariable, Line: t.Line}, true
+		}
+		return Symbol{}, false
+	}
+
+	// class members: `name(` or `name =` after the start of the body, a member or a modifier
+	prev := textAt(tokens, i-1)
+	if t.Kind != lexer.Ident || prev != "{" && prev != "}" && prev != ";" && !slices.Contains(javascriptMethodModifiers, prev) {
+		return Symbol{}, false
+	}
+	switch textAt(tokens, i+1) {
+	case "(", "<":
+		return Symbol{Name: t.Text, Kind: SymbolFunction, Line: t.Line}, true
+	case "=":
+		return Symbol{Name: t.Text, Kind: SymbolVariable, Line: t.Line}, true
+	}
+	return Symbol{}, false
+}
+
+var cTypeKeywords = []string{"class", "struct", "interface", "enum", "record"}
+
+// cNotFunctions are keywords that can be followed by `(` without declaring a function
+var cNotFunctions = []string{"if", "for", "while", "switch", "catch", "return", "sizeof", "new", "throw", "synchronized"}
+
+func cDeclarations(tokens []lexer.Token, i int, class string) (Symbol, bool) {
+	t := tokens[i]
+	if slices.Contains(cTypeKeywords, t.Text) && identAt(tokens, i+1) != "" && textAt(tokens, i-1) != "." {
+		return Symbol{Name: identAt(tokens, i+1), Kind: SymbolType, Line: t.Line}, true
+	}
+
+	// a function is a name followed by `(` and preceded by its return type,
+	// or by the start of the member for constructors
+	if t.Kind != lexer.Ident || textAt(tokens, i+1) != "(" || slices.Contains(cNotFunctions, t.Text) {
+		return Symbol{}, false
+	}
+	prev := tokens[max(i-1, 0)]
+	switch {
+	case i == 0:
+	case slices.Contains(cTypeKeywords, prev.Text):
+		return Symbol{}, false
+	case prev.Kind == lexer.Ident && !slices.Contains(cNotFunctions, prev.Text):
+	case prev.Text == ">" || prev.Text == "]" || prev.Text == "*" || prev.Text == "&" || prev.Text == "::":
+	case class != "" && t.Text == class && (prev.Text == "{" || prev.Text == "}" || prev.Text == ";" || prev.Text == ":"):
+	default:
+		return Symbol{}, false
+	}
+
+	// skip call expressions in initializers, e.g. `int x = f(1);`
+	for j := i - 1; j >= 0 && tokens[j].Text != ";" && tokens[j].Text != "{" && tokens[j].Text != "}"; j-- {
+		if tokens[j].Text == "=" || tokens[j].Text == "(" {
+			return Symbol{}, false
+		}
+	}
+	return Symbol{Name: t.Text, Kind: SymbolFunction, Line: t.Line}, true
+}
+
+var rustTypeKeywords = []string{"struct", "enum", "trait", "type", "union"}
+
+func rustDeclarations(tokens []lexer.Token, i int, class string) (Symbol, bool) {
+	t := tokens[i]
+	if class == "" && t.Text == "impl" {
+		return rustImpl(tokens, i)
+	}
+
+	name := identAt(tokens, i+1)
+	if name == "" {
+		return Symbol{}, false
+	}
+
+	switch {
+	case t.Text == "fn":
+		return Symbol{Name: name, Kind: SymbolFunction, Line: t.Line}, true
+	case class == "" && slices.Contains(rustTypeKeywords, t.Text):
+		return Symbol{Name: name, Kind: SymbolType, Line: t.Line}, true
+	case class == "" && (t.Text == "const" || t.Text == "static"):
+		if name == "mut" {
+			name = identAt(tokens, i+2)
+		}
+		return Symbol{Name: name, Kind: SymbolVariable, Line: t.Line}, name != ""
+	}
+	return Symbol{}, false
+}
+
+// rustImpl opens the body of `impl Type`, `impl<T> Type<T>` or `impl Trait for Type`, so
+// functions inside it become methods of Type. The impl itself is not reported.
+func rustImpl(tokens []lexer.Token, i int) (Symbol, bool) {
+	name, depth := "", 0
+	for j := i + 1; j < len(tokens) && tokens[j].Text != "{"; j++ {
+		switch tokens[j].Text {
+		case "<":
+			depth++
+		case ">":
+			depth--
+		case "for":
+			name = ""
+		default:
+			if depth == 0 && tokens[j].Kind == lexer.Ident && name == "" {
+				name = tokens[j].Text
+			}
+		}
+	}
+	return Symbol{Name: name, Kind: symbolImpl, Line: tokens[i].Line}, name != ""
+}
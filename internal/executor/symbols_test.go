@@ -0,0 +1,115 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// symbolNames returns the declared symbols as "kind name"
+func symbolNames(t *testing.T, code, language string) []string {
+	t.Helper()
+
+	symbols, ok, err := declaredSymbols(code, language)
+	require.True(t, ok)
+	require.NoError(t, err)
+
+	names := make([]string, len(symbols))
+	for i, s := range symbols {
+		names[i] = string(s.Kind) + " " + s.Name
+	}
+	return names
+}
+
+func TestDeclaredSymbols(t *testing.T) {
+	t.Run("Go", func(t *testing.T) {
+		code := "type Stack[T any] struct{ items []T }\n\nfunc (s *Stack[T]) Push(v T) {}\n\nvar memo = map[int]int{}\n\nfunc solve(n int) int { return n }"
+		assert.Equal(t, []string{"type Stack", "function Stack.Push", "variable memo", "function solve"}, symbolNames(t, code, LanguageGo))
+	})
+
+	t.Run("Python", func(t *testing.T) {
+		code := `MOD = 10**9 + 7
+
+class Solution:
+    """def not_a_method(self): ..."""
+    def twoSum(self, nums, target):
+        def helper(i):
+            return i
+        return helper(0)
+
+    @staticmethod
+    def parse(line):
+        return line.split()
+
+def solve(nums):
+    return Solution().twoSum(nums, 0)`
+		assert.Equal(t,
+			[]string{"variable MOD", "type Solution", "function Solution.twoSum", "function Solution.parse", "function solve"},
+			symbolNames(t, code, LanguagePython))
+	})
+
+	t.Run("JavaScript and TypeScript", func(t *testing.T) {
+		code := `interface Point { x: number; y: number }
+class Solution {
+  private seen = new Set<number>();
+  static create(): Solution { return new Solution(); }
+  twoSum(nums: number[], target: number): number[] {
+    if (nums.length) { return [0, 1]; }
+    return [];
+  }
+}
+const solve = (nums: number[]) => new Solution().twoSum(nums, 0);
+function* ids() { yield 1; }`
+		assert.Equal(t,
+			[]string{"type Point", "type Solution", "variable Solution.seen", "function Solution.create", "function Solution.twoSum", "variable solve", "function ids"},
+			symbolNames(t, code, LanguageTypescript))
+	})
+
+	t.Run("Java", func(t *testing.T) {
+		code := `class Solution {
+    private int calls = count(0);
+    public Solution() {}
+    public int[] twoSum(int[] nums, int target) {
+        for (int i = 0; i < nums.length; i++) { helper(i); }
+        return new int[]{0, 1};
+    }
+    static List<Integer> helper(int i) { return List.of(i); }
+}
+record Pair(int a, int b) {}`
+		assert.Equal(t,
+			[]string{"type Solution", "function Solution.Solution", "function Solution.twoSum", "function Solution.helper", "type Pair"},
+			symbolNames(t, code, LanguageJava))
+	})
+
+	t.Run("C++", func(t *testing.T) {
+		code := "struct Node {\n  int val;\n  Node(int v) : val(v) {}\n};\n\nvector<int> solve(const vector<int>& nums) {\n  return nums;\n}\nint main() { return solve({}).size(); }"
+		assert.Equal(t, []string{"type Node", "function Node.Node", "function solve", "function main"}, symbolNames(t, code, LanguageCpp))
+	})
+
+	t.Run("Rust", func(t *testing.T) {
+		code := `struct Solution;
+
+impl<'a> Parser<'a> for Solution {
+    fn parse(s: &'a str) -> Vec<char> { s.chars().collect() }
+}
+
+impl Solution {
+    pub fn two_sum(nums: Vec<i32>, target: i32) -> Vec<i32> {
+        fn inner() {}
+        vec![]
+    }
+}
+
+fn main() {}`
+		assert.Equal(t,
+			[]string{"type Solution", "function Solution.parse", "function Solution.two_sum", "function main"},
+			symbolNames(t, code, LanguageRust))
+	})
+
+	t.Run("Unknown language", func(t *testing.T) {
+		_, ok, err := declaredSymbols("anything", "cobol")
+		assert.False(t, ok)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,97 @@
+package executor
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// usageMarkerPrefix starts the line GNU time writes to stderr after the program exits.
+// Each run appends a random nonce so the program can't print a usage line of its own.
+const usageMarkerPrefix = "#__ROGUELEARN_USAGE__"
+
+// newUsageMarker returns a marker unique to one run
+func newUsageMarker() string {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	return usageMarkerPrefix + hex.EncodeToString(nonce) + "#"
+}
+
+// ResourceUsage is what a run consumed inside the sandbox
+type ResourceUsage struct {
+	CPUTime      time.Duration // user + sys
+	PeakMemoryKB int64         // peak resident set size
+}
+
+// wrapWithUsage wraps a run command in GNU time so that CPU time and peak memory are
+// measured inside the container instead of around the docker CLI call.
+// A positive cpuLimit also caps the process' CPU time with ulimit so runaway
+// programs are killed by the kernel rather than by our context timeout.
+func wrapWithUsage(cmd string, cpuLimit time.Duration, marker string) string {
+	if cpuLimit > 0 {
+		seconds := int(math.Ceil(cpuLimit.Seconds())) + 1
+		cmd = fmt.Sprintf("ulimit -t %d; %s", seconds, cmd)
+	}
+
+	return fmt.Sprintf("/usr/bin/time -f '%s %%U %%S %%M' sh -c '%s'",
+		marker, strings.ReplaceAll(cmd, "'", "'\\''"))
+}
+
+// parseUsage extracts the usage line carrying marker from stderr and returns stderr without it.
+// ok is false when no usage line was found, e.g. because the run was killed from outside.
+func parseUsage(stderr, marker string) (usage ResourceUsage, cleaned string, ok bool) {
+	lines := strings.Split(stderr, "\n")
+
+	idx := -1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.HasPrefix(lines[i], marker+" ") {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return ResourceUsage{}, stderr, false
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(lines[idx], marker))
+	if len(fields) != 3 {
+		return ResourceUsage{}, stderr, false
+	}
+
+	user, err1 := strconv.ParseFloat(fields[0], 64)
+	sys, err2 := strconv.ParseFloat(fields[1], 64)
+	peak, err3 := strconv.ParseInt(fields[2], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return ResourceUsage{}, stderr, false
+	}
+
+	usage = ResourceUsage{
+		CPUTime:      time.Duration((user + sys) * float64(time.Second)),
+		PeakMemoryKB: peak,
+	}
+
+	// GNU time reports abnormal exits on the line before the usage line
+	start := idx
+	if start > 0 && (strings.HasPrefix(lines[start-1], "Command exited with non-zero status") ||
+		strings.HasPrefix(lines[start-1], "Command terminated by signal")) {
+		start--
+	}
+	lines = append(lines[:start], lines[idx+1:]...)
+
+	return usage, strings.Join(lines, "\n"), true
+}
+
+// exceedsLimits returns the limit error a run hit, if any
+func exceedsLimits(meta JobMeta, usage ResourceUsage, timedOut bool) CodeErr {
+	if timedOut || (meta.TimeLimit > 0 && usage.CPUTime > meta.TimeLimit) {
+		return TimeLimitExceeded
+	}
+	if meta.MemoryLimitKB > 0 && usage.PeakMemoryKB > meta.MemoryLimitKB {
+		return MemoryLimitExceeded
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsage(t *testing.T) {
+	t.Run("Usage line is parsed and stripped from stderr", func(t *testing.T) {
+		marker := newUsageMarker()
+		stderr := "panic: boom\nCommand exited with non-zero status 2\n" + marker + " 0.12 0.03 2048\n"
+		usage, cleaned, ok := parseUsage(stderr, marker)
+
+		assert.True(t, ok)
+		assert.Equal(t, 150*time.Millisecond, usage.CPUTime.Round(time.Millisecond))
+		assert.Equal(t, int64(2048), usage.PeakMemoryKB)
+		assert.Equal(t, "panic: boom\n", cleaned)
+	})
+
+	t.Run("Missing usage line leaves stderr untouched", func(t *testing.T) {
+		_, cleaned, ok := parseUsage("some error", newUsageMarker())
+		assert.False(t, ok)
+		assert.Equal(t, "some error", cleaned)
+	})
+
+	t.Run("Usage lines printed by the program are ignored", func(t *testing.T) {
+		marker := newUsageMarker()
+		stderr := marker + " 1.50 0.20 65536\n" + usageMarkerPrefix + "# 0 0 1\n" + newUsageMarker() + " 0 0 1\n"
+		usage, cleaned, ok := parseUsage(stderr, marker)
+
+		assert.True(t, ok)
+		assert.Equal(t, 1700*time.Millisecond, usage.CPUTime.Round(time.Millisecond))
+		assert.Equal(t, int64(65536), usage.PeakMemoryKB)
+		assert.NotContains(t, cleaned, marker)
+	})
+
+	t.Run("Each run gets its own marker", func(t *testing.T) {
+		assert.NotEqual(t, newUsageMarker(), newUsageMarker())
+	})
+
+	t.Run("Run command is wrapped with a CPU limit", func(t *testing.T) {
+		marker := newUsageMarker()
+		cmd := wrapWithUsage("echo 'hi'", 1500*time.Millisecond, marker)
+		assert.Equal(t, "/usr/bin/time -f '"+marker+" %U %S %M' sh -c 'ulimit -t 3; echo '\\''hi'\\'''", cmd)
+	})
+
+	t.Run("Limits are enforced", func(t *testing.T) {
+		meta := JobMeta{TimeLimit: time.Second, MemoryLimitKB: 1024}
+		assert.Nil(t, exceedsLimits(meta, ResourceUsage{CPUTime: time.Second, PeakMemoryKB: 1024}, false))
+		assert.Equal(t, TimeLimitExceeded, exceedsLimits(meta, ResourceUsage{CPUTime: 2 * time.Second}, false))
+		assert.Equal(t, TimeLimitExceeded, exceedsLimits(meta, ResourceUsage{}, true))
+		assert.Equal(t, MemoryLimitExceeded, exceedsLimits(meta, ResourceUsage{PeakMemoryKB: 4096}, false))
+		assert.Nil(t, exceedsLimits(JobMeta{}, ResourceUsage{CPUTime: time.Hour, PeakMemoryKB: 1 << 30}, false))
+	})
+}
@@ -8,8 +8,10 @@ import (
 	"io"
 	"log/slog"
 	"os/exec"
+	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
@@ -18,13 +20,23 @@ import (
 const (
 	QueryTimeOutSecond   = 30 * time.Second
 	CodeRunTimeOutSecond = 15 * time.Second
+
+	DefaultHealthCheckInterval = 30 * time.Second
+	DefaultQueueTimeout        = 10 * time.Second
+
+	maxCompilerOutputLength = 4096
 )
 
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
 type Job struct {
-	Language  store.Language
-	Code      string
-	TestCases []store.TestCase // we will run all test cases in a job
-	Result    chan Result
+	Meta       JobMeta
+	Language   store.Language
+	Code       string
+	SourceMap  SourceMap
+	TestCases  []store.TestCase // we will run all test cases in a job
+	Result     chan Result
+	enqueuedAt time.Time
 }
 
 type CodeErr error
@@ -32,6 +44,9 @@ type CodeErr error
 var CompileError CodeErr = errors.New("Failed to compile code")
 var RunTimeError CodeErr = errors.New("Failed to compile code")
 var FailTestCase CodeErr = errors.New("Test case failed")
+var ErrQueueFull CodeErr = errors.New("Job queue is full")
+var TimeLimitExceeded CodeErr = errors.New("Time limit exceeded")
+var MemoryLimitExceeded CodeErr = errors.New("Memory limit exceeded")
 
 type Result struct {
 	Stdout        string
@@ -40,6 +55,30 @@ type Result struct {
 	Success       bool
 	Error         CodeErr
 	ExecutionTime string
+	CPUTimeMs     int64 // user + sys time of all test cases, measured in the sandbox
+	PeakMemoryKB  int64 // highest peak RSS among the test cases
+	Metrics       JobMetrics
+}
+
+// Judged reports whether the result is a verdict on the code. It is false when the job could
+// not be run at all, e.g. the queue was full or the container failed.
+func (r Result) Judged() bool {
+	if r.Success {
+		return true
+	}
+
+	switch r.Error {
+	case CompileError, RunTimeError, FailTestCase, TimeLimitExceeded, MemoryLimitExceeded:
+		return true
+	}
+	return false
+}
+
+// JobMetrics describes how a job was executed, for logging and monitoring.
+type JobMetrics struct {
+	CacheHit      bool  `json:"cache_hit"`
+	CompileTimeMs int64 `json:"compile_time_ms"`
+	Batched       bool  `json:"batched"` // all test cases ran in one process
 }
 
 type ExecuteCommandResult struct {
@@ -47,26 +86,58 @@ type ExecuteCommandResult struct {
 	Stderr   string
 	Err      error
 	Duration time.Duration
+	Usage    ResourceUsage // only set by runInSandbox
+	TimedOut bool
 }
 
 type WorkerPool struct {
-	cm           *DockerContainerManager
-	queries      *store.Queries
-	logger       *slog.Logger
-	jobs         chan Job
-	wg           sync.WaitGroup
-	shutdownChan chan any
+	cm                  *DockerContainerManager
+	queries             *store.Queries
+	logger              *slog.Logger
+	sched               *scheduler
+	jobs                chan Job // unbuffered, fed by the dispatcher
+	wg                  sync.WaitGroup
+	shutdownChan        chan any
+	shutdownOnce        sync.Once
+	healthCheckInterval time.Duration
+	queueTimeout        time.Duration
+	scaler              *autoscaler
+	retire              chan struct{} // each receive retires one worker goroutine
+	nextWorkerID        atomic.Int64
+	activeWorkers       atomic.Int64
+	cacheHits           atomic.Int64
+	cacheMisses         atomic.Int64
 }
 
 type WorkerPoolOptions struct {
-	MaxWorkers       int
-	MemoryLimitBytes int64
-	MaxJobCount      int
-	CpuNanoLimit     int64
+	// MinWorkers is the number of containers (and worker goroutines) kept warm.
+	// Defaults to MaxWorkers, which disables scaling.
+	MinWorkers          int
+	MaxWorkers          int
+	MemoryLimitBytes    int64
+	MaxJobCount         int
+	CpuNanoLimit        int64
+	HealthCheckInterval time.Duration
+	// QueueTimeout is how long ExecuteJob waits for room in the queue before rejecting.
+	QueueTimeout time.Duration
+	// ScaleUpWaitThreshold is the queue wait time above which a container is added.
+	ScaleUpWaitThreshold time.Duration
+	// ScaleDownCooldown is how long the pool must stay idle before a container is removed.
+	ScaleDownCooldown  time.Duration
+	ScaleCheckInterval time.Duration
+	// MaxInFlightPerPlayer caps how many jobs of one player may run at the same time.
+	MaxInFlightPerPlayer int
+	// BuildCacheSize caps how many compiled artifacts each container keeps.
+	BuildCacheSize int
 }
 
 func NewWorkerPool(logger *slog.Logger, queries *store.Queries, opts *WorkerPoolOptions) (*WorkerPool, error) {
-	cm, err := NewDockerContainerManager(opts.MaxWorkers, opts.MemoryLimitBytes, opts.CpuNanoLimit)
+	minWorkers := opts.MinWorkers
+	if minWorkers <= 0 || minWorkers > opts.MaxWorkers {
+		minWorkers = opts.MaxWorkers
+	}
+
+	cm, err := NewDockerContainerManager(minWorkers, opts.MaxWorkers, opts.BuildCacheSize, opts.MemoryLimitBytes, opts.CpuNanoLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -76,27 +147,88 @@ func NewWorkerPool(logger *slog.Logger, queries *store.Queries, opts *WorkerPool
 		return nil, err
 	}
 
+	healthCheckInterval := opts.HealthCheckInterval
+	if healthCheckInterval <= 0 {
+		healthCheckInterval = DefaultHealthCheckInterval
+	}
+
+	queueTimeout := opts.QueueTimeout
+	if queueTimeout <= 0 {
+		queueTimeout = DefaultQueueTimeout
+	}
+
 	w := &WorkerPool{
-		cm:           cm,
-		queries:      queries,
-		logger:       logger,
-		jobs:         make(chan Job, opts.MaxJobCount),
-		shutdownChan: make(chan any),
+		cm:                  cm,
+		queries:             queries,
+		logger:              logger,
+		sched:               newScheduler(opts.MaxJobCount, opts.MaxInFlightPerPlayer),
+		jobs:                make(chan Job),
+		shutdownChan:        make(chan any),
+		healthCheckInterval: healthCheckInterval,
+		queueTimeout:        queueTimeout,
+		retire:              make(chan struct{}, opts.MaxWorkers),
 	}
+	w.scaler = newAutoscaler(w, opts)
+
+	w.wg.Add(1)
+	go w.dispatch()
 
-	for i := range opts.MaxWorkers {
-		w.wg.Add(1)
-		go w.worker(i + 1)
+	for range minWorkers {
+		w.startWorker()
 	}
 
-	w.logger.Info("Initialized worker pool with max workers",
+	w.logger.Info("Initialized worker pool",
+		"min_worker", minWorkers,
 		"max_worker", w.cm.maxWorkers)
 
 	return w, err
 }
 
+// dispatch hands jobs from the scheduler to free workers, one at a time, so that
+// the scheduling decision is made as late as possible.
+func (w *WorkerPool) dispatch() {
+	defer w.wg.Done()
+
+	for {
+		job, ok := w.sched.Pop()
+		if !ok {
+			select {
+			case <-w.sched.Ready():
+				continue
+			case <-w.shutdownChan:
+				return
+			}
+		}
+
+		select {
+		case w.jobs <- job:
+		case <-w.shutdownChan:
+			w.sched.Done(job.Meta.PlayerID)
+			job.Result <- shuttingDownResult()
+			return
+		}
+	}
+}
+
+// startWorker spawns one more worker goroutine
+func (w *WorkerPool) startWorker() {
+	id := int(w.nextWorkerID.Add(1))
+	w.activeWorkers.Add(1)
+	w.wg.Add(1)
+	go w.worker(id)
+}
+
+// retireWorker asks one idle worker goroutine to exit
+func (w *WorkerPool) retireWorker() {
+	select {
+	case w.retire <- struct{}{}:
+	default:
+	}
+}
+
 func (w *WorkerPool) worker(id int) {
 	defer w.wg.Done()
+	defer w.activeWorkers.Add(-1)
 	w.logger.Info("Worker started", "id", id)
 
 	for {
@@ -107,7 +239,14 @@ func (w *WorkerPool) worker(id int) {
 					"worker_id", id)
 				return
 			}
+			w.scaler.observeWait(time.Since(j.enqueuedAt))
 			w.executeJob(id, j)
+			w.sched.Done(j.Meta.PlayerID)
+			w.scaler.markBusy()
+
+		case <-w.retire:
+			w.logger.Info("Worker retired after scale down", "worker_id", id)
+			return
 
 		case <-w.shutdownChan:
 			w.logger.Info("Worker received shutdown signal", "worker_id", id)
@@ -116,21 +255,83 @@ func (w *WorkerPool) worker(id int) {
 	}
 }
 
-// ExecuteJob submits the job for execution
-// input as a pointer so we could either set it or make it null
-func (w *WorkerPool) ExecuteJob(lang store.Language, code string, tcs []store.TestCase) Result {
+// MonitorContainers supervises the container pool until ctx is cancelled:
+// dead, hung and quarantined containers are removed and replaced.
+func (w *WorkerPool) MonitorContainers(ctx context.Context, wg *sync.WaitGroup) {
+	w.cm.MonitorContainers(ctx, wg, w.healthCheckInterval)
+}
+
+// Stats returns a snapshot of the container pool and job queue state
+func (w *WorkerPool) Stats() PoolStats {
+	stats := w.cm.Stats()
+	stats.Workers = int(w.activeWorkers.Load())
+	stats.QueuedJobs = w.sched.Len()
+	stats.BuildCacheHits = w.cacheHits.Load()
+	stats.BuildCacheMisses = w.cacheMisses.Load()
+	return stats
+}
+
+// Shutdown stops all worker goroutines and waits for in-flight jobs to finish.
+// Queued jobs are answered with ErrQueueFull and new jobs are refused.
+func (w *WorkerPool) Shutdown() {
+	w.shutdownOnce.Do(func() {
+		close(w.shutdownChan)
+		for _, job := range w.sched.Close() {
+			job.Result <- shuttingDownResult()
+		}
+	})
+	w.wg.Wait()
+	w.logger.Info("Worker pool stopped")
+}
+
+func shuttingDownResult() Result {
+	return Result{Error: ErrQueueFull, Success: false, Message: "Server is shutting down, please try again later."}
+}
+
+// ExecuteJob submits the job for execution. meta decides the job's priority tier and
+// which room and player it is scheduled fairly against.
+// When the queue is full the pool is asked to scale up and the job waits up to queueTimeout.
+func (w *WorkerPool) ExecuteJob(meta JobMeta, lang store.Language, build BuildResult, tcs []store.TestCase) Result {
 	w.logger.Info("Submitting job...",
-		"language", lang)
+		"language", lang,
+		"player_id", meta.PlayerID,
+		"room_id", meta.RoomID,
+		"priority", meta.Priority)
 
 	result := make(chan Result, 1)
-	select {
-	case w.jobs <- Job{Language: lang, Code: code, TestCases: tcs, Result: result}:
+	job := Job{Meta: meta, Language: lang, Code: build.Code, SourceMap: build.SourceMap, TestCases: tcs, Result: result, enqueuedAt: time.Now()}
+
+	err := w.sched.Push(job)
+	switch {
+	case err == nil:
 		return <-result
-	default:
-		w.logger.Warn("Job queue is full, rejecting job...",
-			"language", lang,
-			"maxJobCount", w.cm.maxWorkers)
-		return Result{}
+	case errors.Is(err, errSchedulerClosed):
+		return shuttingDownResult()
+	}
+
+	w.scaler.requestScaleUp()
+
+	timer := time.NewTimer(w.queueTimeout)
+	defer timer.Stop()
+
+	for {
+		freed := w.sched.Freed()
+		err := w.sched.Push(job)
+		switch {
+		case err == nil:
+			return <-result
+		case errors.Is(err, errSchedulerClosed):
+			return shuttingDownResult()
+		}
+
+		select {
+		case <-freed:
+		case <-timer.C:
+			w.logger.Warn("Job queue is full, rejecting job...",
+				"language", lang,
+				"maxJobCount", w.sched.capacity)
+			return Result{Error: ErrQueueFull, Success: false, Message: "Server is busy, please try again later."}
+		}
 	}
 }
 
@@ -156,6 +357,23 @@ func (w *WorkerPool) executeInContainer(ctx context.Context, containerID, comman
 	}
 }
 
+// runInSandbox runs the user's program and measures its CPU time and peak memory inside the container.
+// When the usage can't be measured it falls back to the wall-clock duration.
+func (w *WorkerPool) runInSandbox(ctx context.Context, containerID, runCmd string, stdin io.Reader, cpuLimit time.Duration) ExecuteCommandResult {
+	marker := newUsageMarker()
+	res := w.executeInContainer(ctx, containerID, wrapWithUsage(runCmd, cpuLimit, marker), stdin)
+	res.TimedOut = errors.Is(ctx.Err(), context.DeadlineExceeded)
+
+	usage, stderr, ok := parseUsage(res.Stderr, marker)
+	if !ok {
+		usage = ResourceUsage{CPUTime: res.Duration}
+	}
+	res.Usage = usage
+	res.Stderr = stderr
+
+	return res
+}
+
 // executeJob handle the execution of a single job
 func (w *WorkerPool) executeJob(workerID int, job Job) error {
 	ctx, cancel := context.WithTimeout(context.Background(), CodeRunTimeOutSecond)
@@ -169,18 +387,14 @@ func (w *WorkerPool) executeJob(workerID int, job Job) error {
 	if err != nil {
 		w.logger.Error("Failed to get available Container",
 			"err", err)
-		return err
-	}
-
-	err = w.cm.SetContainerState(containerID, StateBusy)
-	if err != nil {
+		job.Result <- Result{Error: err, Success: false, Message: "All execution environments are busy, please try again later."}
 		return err
 	}
 
 	defer func() {
-		err = w.cm.SetContainerState(containerID, StateIdle)
+		err := w.cm.ReleaseContainer(containerID)
 		if err != nil {
-			w.logger.Error("failed to set container state to idle",
+			w.logger.Error("failed to release container",
 				"container_id", containerID,
 				"err", err)
 		}
@@ -192,6 +406,7 @@ func (w *WorkerPool) executeJob(workerID int, job Job) error {
 	err = w.cm.copyCodeToContainer(ctx, containerID, job.Language.TempFileDir.String, job.Language.TempFileName.String, []byte(job.Code))
 	if err != nil {
 		w.logger.Error("Failed to copy code to container", "err", err)
+		w.cm.ReportFailure(containerID)
 		job.Result <- Result{Error: err, Success: false, Message: "Failed to set up execution environment."}
 		return err
 	}
@@ -201,28 +416,46 @@ func (w *WorkerPool) executeJob(workerID int, job Job) error {
 		"code", job.Code)
 
 	// Step 2: Run the code
-	// If compiled lang -> compiled first.
+	// If compiled lang -> compiled first, unless the same build is already cached in this container.
+	var metrics JobMetrics
 	if job.Language.CompileCmd != "" { // case Compiled Lang
-		// Create compile command
-		compileCmd := strings.ReplaceAll(job.Language.CompileCmd, tempFileDirHolder, job.Language.TempFileDir.String)
-		w.logger.Info("Compiling code...", "container_id", containerID, "command", compileCmd)
-
-		compileResult := w.executeInContainer(ctx, containerID, compileCmd, nil)
-		if compileResult.Err != nil {
-			w.logger.Warn("Compilation failed",
-				"err", compileResult.Err,
-				"stderr", compileResult.Stderr,
-				"stdout", compileResult.Stdout)
-			job.Result <- Result{
-				Error:   CompileError,
-				Success: false,
-				Stdout:  compileResult.Stdout,
-				Stderr:  compileResult.Stderr,
-				Message: "Compiled failed",
+		cacheKey := buildCacheKey(job.Language, job.Code)
+		metrics.CacheHit = w.restoreCachedBuild(ctx, containerID, cacheKey)
+
+		if !metrics.CacheHit {
+			// Create compile command
+			compileCmd := strings.ReplaceAll(job.Language.CompileCmd, tempFileDirHolder, job.Language.TempFileDir.String)
+			compileCmd = strings.ReplaceAll(compileCmd, tempFileNameHolder, job.Language.TempFileName.String)
+			w.logger.Info("Compiling code...", "container_id", containerID, "command", compileCmd)
+
+			compileResult := w.executeInContainer(ctx, containerID, compileCmd, nil)
+			metrics.CompileTimeMs = compileResult.Duration.Milliseconds()
+			if isInfrastructureError(compileResult) {
+				w.logger.Error("Container failed to run compiler", "container_id", containerID, "err", compileResult.Err)
+				w.cm.ReportFailure(containerID)
+				job.Result <- Result{Error: compileResult.Err, Success: false, Message: "Failed to set up execution environment."}
+				return compileResult.Err
 			}
-			return err
+
+			if compileResult.Err != nil {
+				w.logger.Warn("Compilation failed",
+					"err", compileResult.Err,
+					"stderr", compileResult.Stderr,
+					"stdout", compileResult.Stdout)
+				job.Result <- Result{
+					Error:   CompileError,
+					Success: false,
+					Stdout:  compileResult.Stdout,
+					Stderr:  mapDiagnostics(job.Language, job.SourceMap, cleanCompilerOutput(job.Language, compileResult.Stdout+compileResult.Stderr)),
+					Message: "Compiled failed",
+					Metrics: metrics,
+				}
+				return err
+			}
+			w.logger.Info("Compilation successful", "duration", compileResult.Duration.Milliseconds())
+
+			w.storeCachedBuild(ctx, containerID, cacheKey)
 		}
-		w.logger.Info("Compilation successful", "duration", compileResult.Duration.Milliseconds())
 	}
 
 	// Step 4: Run all test case
@@ -231,62 +464,50 @@ func (w *WorkerPool) executeJob(workerID int, job Job) error {
 
 	w.logger.Info("Preparing to run test cases", "command", finalRunCmd, "count", len(job.TestCases))
 
-	totalExecutionTime := int64(0)
-	for _, tc := range job.TestCases {
-		runCtx, runCancel := context.WithTimeout(ctx, CodeRunTimeOutSecond)
-
-		runResult := w.executeInContainer(runCtx, containerID, finalRunCmd, strings.NewReader(tc.Input))
-		totalExecutionTime += runResult.Duration.Milliseconds()
-
-		if runResult.Err != nil {
-			w.logger.Warn("Runtime error", "test_case_id", tc.ID, "err", runResult.Err, "stderr", runResult.Stderr)
-			job.Result <- Result{
-				Error:   RunTimeError,
-				Success: false,
-				Stdout:  runResult.Stdout,
-				Stderr:  runResult.Stderr,
-				Message: "Runtime error",
-			}
-			runCancel()
-			return nil
-		}
-
-		actualOutput := strings.TrimSpace(runResult.Stdout)
-		w.logger.Info("Test case executed", "actual_output", actualOutput)
-		expectedOutput := strings.TrimSpace(tc.ExpectedOutput)
-		w.logger.Info("Test case executed", "expected_output", expectedOutput)
+	var (
+		usage   runUsage
+		failed  *Result
+		batched bool
+	)
+	if job.Meta.BatchMode {
+		usage, failed, batched, err = w.runTestCasesBatched(ctx, containerID, finalRunCmd, job.Meta, job.TestCases)
+		metrics.Batched = batched
+	}
+	if err == nil && !batched {
+		usage, failed, err = w.runTestCasesSeparately(ctx, containerID, finalRunCmd, job.Meta, job.TestCases)
+	}
 
-		if actualOutput != expectedOutput {
-			w.logger.Warn("Wrong answer",
-				"test_case_id", tc.ID,
-				"actual_output", actualOutput,
-				"expected_output", expectedOutput,
-			)
+	if err != nil {
+		w.logger.Error("Container failed to run code", "container_id", containerID, "err", err)
+		w.cm.ReportFailure(containerID)
+		job.Result <- Result{Error: err, Success: false, Message: "Failed to set up execution environment."}
+		return err
+	}
 
-			message := fmt.Sprintf("Wrong Answer on test case.\nInput:\n%s\n\nExpected Output:\n%s\n\nYour Output:\n%s", tc.Input, expectedOutput, actualOutput)
-			job.Result <- Result{
-				Success: false,
-				Stdout:  runResult.Stdout,
-				Stderr:  runResult.Stderr,
-				Error:   FailTestCase,
-				Message: message,
-			}
-			runCancel()
-			return err
+	if failed != nil {
+		if failed.Error == RunTimeError {
+			failed.Stderr = mapDiagnostics(job.Language, job.SourceMap, cleanCompilerOutput(job.Language, failed.Stderr))
 		}
-
-		runCancel()
+		failed.Metrics = metrics
+		failed.CPUTimeMs = usage.CPUTime.Milliseconds()
+		failed.PeakMemoryKB = usage.PeakMemoryKB
+		job.Result <- *failed
+		return nil
 	}
 
 	duration := time.Since(start)
 	w.logger.Info("full process done", "took", duration)
+	w.cm.ReportSuccess(containerID)
 
 	// Step 4: Send Result
 	w.logger.Info("All test cases passed!", "worker_id", workerID)
 	job.Result <- Result{
 		Success:       true,
 		Message:       "All test cases passed!",
-		ExecutionTime: fmt.Sprintf("%dms", totalExecutionTime),
+		ExecutionTime: fmt.Sprintf("%dms", usage.CPUTime.Milliseconds()),
+		CPUTimeMs:     usage.CPUTime.Milliseconds(),
+		PeakMemoryKB:  usage.PeakMemoryKB,
+		Metrics:       metrics,
 	}
 
 	if err != nil {
@@ -301,12 +522,235 @@ func (w *WorkerPool) executeJob(workerID int, job Job) error {
 			"worker_id", workerID,
 			"container_id", containerID,
 			"duration", duration.Milliseconds(),
-			"lang", job.Language)
+			"lang", job.Language,
+			"cache_hit", metrics.CacheHit,
+			"compile_time_ms", metrics.CompileTimeMs)
 	}
 
 	return nil
 }
 
+// runUsage accumulates the resource usage of a job's test cases
+type runUsage struct {
+	CPUTime      time.Duration
+	PeakMemoryKB int64
+}
+
+func (u *runUsage) add(cpuTime time.Duration, peakMemoryKB int64) {
+	u.CPUTime += cpuTime
+	u.PeakMemoryKB = max(u.PeakMemoryKB, peakMemoryKB)
+}
+
+// runTestCasesSeparately runs every test case in its own process.
+// It returns the failing case's result, or an error when the container itself failed.
+func (w *WorkerPool) runTestCasesSeparately(ctx context.Context, containerID, runCmd string, meta JobMeta, tcs []store.TestCase) (runUsage, *Result, error) {
+	var usage runUsage
+	for _, tc := range tcs {
+		runCtx, runCancel := context.WithTimeout(ctx, CodeRunTimeOutSecond)
+		runResult := w.runInSandbox(runCtx, containerID, runCmd, strings.NewReader(tc.Input), meta.TimeLimit)
+		runCancel()
+		usage.add(runResult.Usage.CPUTime, runResult.Usage.PeakMemoryKB)
+
+		if isInfrastructureError(runResult) {
+			return usage, nil, runResult.Err
+		}
+
+		w.logger.Info("Test case executed",
+			"test_case_id", tc.ID,
+			"cpu_time_ms", runResult.Usage.CPUTime.Milliseconds(),
+			"peak_memory_kb", runResult.Usage.PeakMemoryKB)
+
+		if limitErr := exceedsLimits(meta, runResult.Usage, runResult.TimedOut); limitErr != nil {
+			w.logger.Warn("Limit exceeded", "test_case_id", tc.ID, "err", limitErr)
+			return usage, limitExceededResult(limitErr, runResult), nil
+		}
+
+		if runResult.Err != nil {
+			w.logger.Warn("Runtime error", "test_case_id", tc.ID, "err", runResult.Err, "stderr", runResult.Stderr)
+			return usage, &Result{
+				Error:   RunTimeError,
+				Success: false,
+				Stdout:  runResult.Stdout,
+				Stderr:  runResult.Stderr,
+				Message: "Runtime error",
+			}, nil
+		}
+
+		if failed := w.checkOutput(tc, runResult.Stdout, runResult.Stderr); failed != nil {
+			return usage, failed, nil
+		}
+	}
+
+	return usage, nil, nil
+}
+
+// runTestCasesBatched runs all test cases in a single process using the BatchCaseDelimiter
+// protocol. ok is false when the batch crashed or its output could not be split, in which
+// case the caller should run the cases separately to find the failing one.
+func (w *WorkerPool) runTestCasesBatched(ctx context.Context, containerID, runCmd string, meta JobMeta, tcs []store.TestCase) (usage runUsage, failed *Result, ok bool, err error) {
+	runCtx, runCancel := context.WithTimeout(ctx, CodeRunTimeOutSecond)
+	runResult := w.runInSandbox(runCtx, containerID, runCmd, strings.NewReader(encodeBatchInput(tcs)), meta.TimeLimit*time.Duration(len(tcs)))
+	runCancel()
+
+	if isInfrastructureError(runResult) {
+		return usage, nil, false, runResult.Err
+	}
+
+	outputs := parseBatchOutput(runResult.Stdout, runResult.Usage.CPUTime)
+	if runResult.Err != nil || len(outputs) != len(tcs) {
+		w.logger.Warn("Batched run failed, falling back to one process per test case",
+			"container_id", containerID,
+			"err", runResult.Err,
+			"cases", len(tcs),
+			"outputs", len(outputs))
+		return usage, nil, false, nil
+	}
+
+	for i, tc := range tcs {
+		// memory is only known for the whole process, so every case is charged with its peak
+		caseUsage := ResourceUsage{CPUTime: outputs[i].CPUTime, PeakMemoryKB: runResult.Usage.PeakMemoryKB}
+		usage.add(caseUsage.CPUTime, caseUsage.PeakMemoryKB)
+		w.logger.Info("Test case executed",
+			"test_case_id", tc.ID,
+			"cpu_time_ms", caseUsage.CPUTime.Milliseconds(),
+			"peak_memory_kb", caseUsage.PeakMemoryKB)
+
+		if limitErr := exceedsLimits(meta, caseUsage, false); limitErr != nil {
+			w.logger.Warn("Limit exceeded", "test_case_id", tc.ID, "err", limitErr)
+			return usage, limitExceededResult(limitErr, runResult), true, nil
+		}
+
+		if failed := w.checkOutput(tc, outputs[i].Output, runResult.Stderr); failed != nil {
+			return usage, failed, true, nil
+		}
+	}
+
+	return usage, nil, true, nil
+}
+
+func limitExceededResult(limitErr CodeErr, runResult ExecuteCommandResult) *Result {
+	return &Result{
+		Error:   limitErr,
+		Success: false,
+		Stdout:  runResult.Stdout,
+		Stderr:  runResult.Stderr,
+		Message: limitErr.Error(),
+	}
+}
+
+// checkOutput compares a test case's output with the expected output and
+// returns a wrong answer result when they differ
+func (w *WorkerPool) checkOutput(tc store.TestCase, stdout, stderr string) *Result {
+	actualOutput := strings.TrimSpace(stdout)
+	w.logger.Info("Test case executed", "actual_output", actualOutput)
+	expectedOutput := strings.TrimSpace(tc.ExpectedOutput)
+	w.logger.Info("Test case executed", "expected_output", expectedOutput)
+
+	if actualOutput == expectedOutput {
+		return nil
+	}
+
+	w.logger.Warn("Wrong answer",
+		"test_case_id", tc.ID,
+		"actual_output", actualOutput,
+		"expected_output", expectedOutput,
+	)
+
+	message := fmt.Sprintf("Wrong Answer on test case.\nInput:\n%s\n\nExpected Output:\n%s\n\nYour Output:\n%s", tc.Input, expectedOutput, actualOutput)
+	return &Result{
+		Success: false,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Error:   FailTestCase,
+		Message: message,
+	}
+}
+
+// restoreCachedBuild puts a cached artifact in place and reports whether it was a cache hit
+func (w *WorkerPool) restoreCachedBuild(ctx context.Context, containerID, key string) bool {
+	cache, err := w.cm.BuildCache(containerID)
+	if err != nil {
+		w.cacheMisses.Add(1)
+		return false
+	}
+
+	digest, ok := cache.Lookup(key)
+	if !ok {
+		w.cacheMisses.Add(1)
+		return false
+	}
+
+	res := w.executeInContainer(ctx, containerID, restoreArtifactCmd(key, digest), nil)
+	if res.Err != nil {
+		// artifact is gone (e.g. container was recreated) or was tampered with, build again
+		w.logger.Warn("Failed to restore cached build", "container_id", containerID, "key", key, "stderr", res.Stderr)
+		cache.Remove(key)
+		w.cacheMisses.Add(1)
+		return false
+	}
+
+	w.cacheHits.Add(1)
+	w.logger.Info("Build cache hit", "container_id", containerID, "key", key)
+	return true
+}
+
+// storeCachedBuild saves the artifact of a successful compile in the container's build cache
+func (w *WorkerPool) storeCachedBuild(ctx context.Context, containerID, key string) {
+	cache, err := w.cm.BuildCache(containerID)
+	if err != nil {
+		return
+	}
+
+	res := w.executeInContainer(ctx, containerID, storeArtifactCmd(key), nil)
+	digest, ok := parseArtifactDigest(res.Stdout)
+	if res.Err != nil || !ok {
+		w.logger.Warn("Failed to store build in cache", "container_id", containerID, "key", key, "stderr", res.Stderr)
+		return
+	}
+
+	evicted := cache.Add(key, digest)
+	if len(evicted) == 0 {
+		return
+	}
+	res = w.executeInContainer(ctx, containerID, removeArtifactsCmd(evicted), nil)
+	if res.Err != nil {
+		w.logger.Warn("Failed to remove evicted builds", "container_id", containerID, "stderr", res.Stderr)
+	}
+}
+
+// cleanCompilerOutput makes compiler diagnostics and runtime errors readable for players: sandbox paths are
+// reduced to the file name, terminal colors are removed and very long output is truncated.
+func cleanCompilerOutput(lang store.Language, output string) string {
+	output = ansiEscape.ReplaceAllString(output, "")
+
+	if dir := strings.TrimSuffix(lang.TempFileDir.String, "/"); dir != "" {
+		output = strings.ReplaceAll(output, dir+"/", "")
+	}
+
+	output = strings.TrimSpace(output)
+	if len(output) > maxCompilerOutputLength {
+		output = output[:maxCompilerOutputLength] + "\n... (output truncated)"
+	}
+
+	return output
+}
+
+// isInfrastructureError reports whether a command failed because of docker or the
+// container itself rather than because of the user's program.
+func isInfrastructureError(res ExecuteCommandResult) bool {
+	if res.Err == nil {
+		return false
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(res.Err, &exitErr) {
+		// docker CLI could not be started or was killed by the context
+		return !errors.Is(res.Err, context.DeadlineExceeded) && !errors.Is(res.Err, context.Canceled)
+	}
+
+	return strings.HasPrefix(res.Stderr, "Error response from daemon")
+}
+
 // executeCode run the code in a specific Container
 func (w *WorkerPool) executeCode(lang store.Language, containerID, code string, tcs []store.TestCase) (string, bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), CodeRunTimeOutSecond)
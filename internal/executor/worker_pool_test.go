@@ -0,0 +1,83 @@
+package executor
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanCompilerOutput(t *testing.T) {
+	lang := store.Language{TempFileDir: pgtype.Text{String: "/app/temp/cpp", Valid: true}}
+
+	t.Run("Sandbox paths and colors are removed", func(t *testing.T) {
+		output := "\x1b[1m/app/temp/cpp/code.cpp:3:5:\x1b[0m error: expected ';'\n"
+		assert.Equal(t, "code.cpp:3:5: error: expected ';'", cleanCompilerOutput(lang, output))
+	})
+
+	t.Run("Long output is truncated", func(t *testing.T) {
+		cleaned := cleanCompilerOutput(lang, strings.Repeat("x", maxCompilerOutputLength+100))
+		assert.True(t, strings.HasSuffix(cleaned, "(output truncated)"))
+		assert.Less(t, len(cleaned), maxCompilerOutputLength+100)
+	})
+}
+
+func TestResultJudged(t *testing.T) {
+	judged := []Result{
+		{Success: true},
+		{Error: CompileError},
+		{Error: RunTimeError},
+		{Error: FailTestCase},
+		{Error: TimeLimitExceeded},
+		{Error: MemoryLimitExceeded},
+	}
+	for _, r := range judged {
+		assert.True(t, r.Judged(), r.Error)
+	}
+
+	notJudged := []Result{
+		{Error: ErrQueueFull},
+		{Error: ErrNoContainerAvailable},
+		{Error: errors.New("exit status 125")},
+		{Message: "Failed to set up execution environment."},
+	}
+	for _, r := range notJudged {
+		assert.False(t, r.Judged(), r.Error)
+	}
+}
+
+func TestShutdownAnswersQueuedJobs(t *testing.T) {
+	w := &WorkerPool{
+		logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		sched:        newScheduler(1, 1),
+		shutdownChan: make(chan any),
+		queueTimeout: time.Minute,
+	}
+
+	// no workers run, so the job stays queued until shutdown
+	results := make(chan Result)
+	go func() {
+		results <- w.ExecuteJob(JobMeta{}, store.Language{}, BuildResult{}, nil)
+	}()
+	require.Eventually(t, func() bool { return w.sched.Len() == 1 }, time.Second, time.Millisecond)
+
+	w.Shutdown()
+
+	select {
+	case res := <-results:
+		assert.ErrorIs(t, res.Error, ErrQueueFull)
+		assert.False(t, res.Judged())
+	case <-time.After(time.Second):
+		t.Fatal("queued job was never answered")
+	}
+
+	res := w.ExecuteJob(JobMeta{}, store.Language{}, BuildResult{}, nil)
+	assert.ErrorIs(t, res.Error, ErrQueueFull, "jobs after shutdown are refused")
+}
@@ -1 +1,227 @@
 package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/audit"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/drivergen"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/ratelimit"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/request"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// GetWorkerPoolStatsHandler returns the current state of the code execution container pool.
+func (hr *HandlerRepo) GetWorkerPoolStatsHandler(w http.ResponseWriter, r *http.Request) {
+	err := response.JSON(w, response.JSONResponseParameters{
+		Status:  http.StatusOK,
+		Data:    hr.worker.Stats(),
+		Success: true,
+		Msg:     "Worker pool stats retrieved successfully",
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+	}
+}
+
+// SetEventRateLimitsHandler overrides the submit, run and read limits of an event. Budgets
+// left out of the body use the server defaults, so `{}` clears the overrides.
+func (hr *HandlerRepo) SetEventRateLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		hr.badRequest(w, r, errors.New("invalid event ID in URL"))
+		return
+	}
+
+	var limits ratelimit.Limits
+	if err := request.DecodeJSON(w, r, &limits); err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	if err := limits.Validate(); err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	var data []byte
+	if len(limits) > 0 {
+		data, err = json.Marshal(limits)
+		if err != nil {
+			hr.serverError(w, r, err)
+			return
+		}
+	}
+
+	before, err := hr.queries.GetEventByID(r.Context(), toPgtypeUUID(eventID))
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		hr.notFound(w, r)
+		return
+	case err != nil:
+		hr.serverError(w, r, err)
+		return
+	}
+
+	_, err = hr.queries.UpdateEventRateLimits(r.Context(), store.UpdateEventRateLimitsParams{
+		ID:         toPgtypeUUID(eventID),
+		RateLimits: data,
+	})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		hr.notFound(w, r)
+		return
+	case err != nil:
+		hr.serverError(w, r, err)
+		return
+	}
+	hr.rateLimits.Invalidate(eventID)
+
+	hr.audit.Record(r.Context(), audit.Entry{
+		Action:     audit.ActionEventRateLimitsUpdated,
+		TargetType: audit.TargetEvent,
+		TargetID:   eventID.String(),
+		EventID:    eventID,
+		Before:     json.RawMessage(before.RateLimits),
+		After:      json.RawMessage(data),
+	})
+
+	err = response.JSON(w, response.JSONResponseParameters{
+		Status:  http.StatusOK,
+		Data:    limits,
+		Success: true,
+		Msg:     "Event rate limits updated successfully",
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+	}
+}
+
+type GenerateDriversRequest struct {
+	Signature drivergen.Signature `json:"signature"`
+	// Languages to generate for, every supported language when empty
+	Languages []string `json:"languages"`
+}
+
+// GenerateDriversHandler stores a problem's function signature and generates the stub and
+// driver code of each language from it. Existing language details keep their limits.
+func (hr *HandlerRepo) GenerateDriversHandler(w http.ResponseWriter, r *http.Request) {
+	problemID, err := uuid.Parse(chi.URLParam(r, "problem_id"))
+	if err != nil {
+		hr.badRequest(w, r, ErrInvalidProblem)
+		return
+	}
+
+	var req GenerateDriversRequest
+	if err := request.DecodeJSON(w, r, &req); err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	if err := req.Signature.Validate(); err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	languages := req.Languages
+	if len(languages) == 0 {
+		languages = drivergen.Languages()
+	}
+
+	codes := make([]drivergen.Code, 0, len(languages))
+	for _, name := range languages {
+		spec, found := hr.languages.Resolve(name)
+		if !found {
+			hr.badRequest(w, r, fmt.Errorf("programming language %q is not supported", name))
+			return
+		}
+
+		code, err := drivergen.Generate(spec, req.Signature)
+		if err != nil {
+			hr.badRequest(w, r, err)
+			return
+		}
+		codes = append(codes, code)
+	}
+
+	err = hr.storeGeneratedDrivers(r.Context(), toPgtypeUUID(problemID), req.Signature, codes)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		hr.notFound(w, r)
+		return
+	case err != nil:
+		hr.serverError(w, r, err)
+		return
+	}
+
+	hr.audit.Record(r.Context(), audit.Entry{
+		Action:     audit.ActionProblemDriversGenerated,
+		TargetType: audit.TargetCodeProblem,
+		TargetID:   problemID.String(),
+		After:      map[string]any{"signature": req.Signature, "languages": languages},
+	})
+
+	err = response.JSON(w, response.JSONResponseParameters{
+		Status:  http.StatusOK,
+		Data:    codes,
+		Success: true,
+		Msg:     "Drivers generated successfully",
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+	}
+}
+
+// storeGeneratedDrivers saves the signature and the generated code in one transaction.
+// It returns pgx.ErrNoRows when the problem or one of the languages does not exist.
+func (hr *HandlerRepo) storeGeneratedDrivers(ctx context.Context, problemID pgtype.UUID, sig drivergen.Signature, codes []drivergen.Code) error {
+	signature, err := json.Marshal(sig)
+	if err != nil {
+		return err
+	}
+
+	tx, err := hr.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	qtx := hr.queries.WithTx(tx)
+
+	_, err = qtx.UpdateCodeProblemSignature(ctx, store.UpdateCodeProblemSignatureParams{
+		ID:        problemID,
+		Signature: signature,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update problem signature: %w", err)
+	}
+
+	for _, code := range codes {
+		lang, err := qtx.GetLanguageByName(ctx, code.Language)
+		if err != nil {
+			return fmt.Errorf("failed to get language %s: %w", code.Language, err)
+		}
+
+		_, err = qtx.UpsertCodeProblemLanguageDetail(ctx, store.UpsertCodeProblemLanguageDetailParams{
+			CodeProblemID:   problemID,
+			LanguageID:      lang.ID,
+			SolutionStub:    code.SolutionStub,
+			DriverCode:      code.DriverCode,
+			SolutionMode:    string(executor.SolutionFunction),
+			RequiredSymbols: []string{sig.FunctionName},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to store %s driver: %w", code.Language, err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
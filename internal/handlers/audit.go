@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/response"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const auditPageSize = 50
+
+type AuditLogResponse struct {
+	ID         string          `json:"id"`
+	ActorID    string          `json:"actor_id,omitempty"` // empty for actions done by the system
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	EventID    string          `json:"event_id,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// GetAuditLogsHandler lists audit entries, newest first. It can be filtered with the
+// actor_id, action, target_type, target_id and event_id query params, limited to a time
+// range with since and until (RFC 3339) and paged with page.
+func (hr *HandlerRepo) GetAuditLogsHandler(w http.ResponseWriter, r *http.Request) {
+	params, err := parseAuditFilter(r.URL.Query())
+	if err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	logs, err := hr.queries.ListAuditLogs(r.Context(), params)
+	if err != nil {
+		hr.serverError(w, r, err)
+		return
+	}
+
+	entries := make([]AuditLogResponse, 0, len(logs))
+	for _, l := range logs {
+		entries = append(entries, AuditLogResponse{
+			ID:         uuidString(l.ID),
+			ActorID:    uuidString(l.ActorID),
+			Action:     l.Action,
+			TargetType: l.TargetType,
+			TargetID:   l.TargetID,
+			EventID:    uuidString(l.EventID),
+			Before:     l.Before,
+			After:      l.After,
+			RequestID:  l.RequestID.String,
+			CreatedAt:  l.CreatedAt.Time,
+		})
+	}
+
+	err = response.JSON(w, response.JSONResponseParameters{
+		Status:  http.StatusOK,
+		Data:    entries,
+		Success: true,
+		Msg:     "Audit logs retrieved successfully",
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+	}
+}
+
+func parseAuditFilter(query url.Values) (store.ListAuditLogsParams, error) {
+	params := store.ListAuditLogsParams{
+		Action:     optionalText(query.Get("action")),
+		TargetType: optionalText(query.Get("target_type")),
+		TargetID:   optionalText(query.Get("target_id")),
+		PageLimit:  auditPageSize,
+	}
+
+	var err error
+	if params.ActorID, err = optionalUUID(query.Get("actor_id")); err != nil {
+		return params, errors.New("invalid actor_id")
+	}
+	if params.EventID, err = optionalUUID(query.Get("event_id")); err != nil {
+		return params, errors.New("invalid event_id")
+	}
+	if params.Since, err = optionalTime(query.Get("since")); err != nil {
+		return params, errors.New("invalid since, expected an RFC 3339 time")
+	}
+	if params.Until, err = optionalTime(query.Get("until")); err != nil {
+		return params, errors.New("invalid until, expected an RFC 3339 time")
+	}
+
+	if s := query.Get("page"); s != "" {
+		page, err := strconv.Atoi(s)
+		if err != nil || page < 1 {
+			return params, errors.New("invalid page")
+		}
+		params.PageOffset = int32((page - 1) * auditPageSize)
+	}
+	return params, nil
+}
+
+func optionalText(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
+
+func optionalUUID(s string) (pgtype.UUID, error) {
+	if s == "" {
+		return pgtype.UUID{}, nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+	return toPgtypeUUID(id), nil
+}
+
+func optionalTime(s string) (pgtype.Timestamptz, error) {
+	if s == "" {
+		return pgtype.Timestamptz{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return pgtype.Timestamptz{}, err
+	}
+	return pgtype.Timestamptz{Time: t, Valid: true}, nil
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuditFilter(t *testing.T) {
+	eventID := uuid.New()
+
+	params, err := parseAuditFilter(url.Values{
+		"event_id":    {eventID.String()},
+		"target_type": {"room_player"},
+		"since":       {"2025-10-01T08:00:00Z"},
+		"page":        {"3"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, eventID, uuid.UUID(params.EventID.Bytes))
+	assert.True(t, params.EventID.Valid)
+	assert.Equal(t, "room_player", params.TargetType.String)
+	assert.True(t, params.TargetType.Valid)
+	assert.False(t, params.Action.Valid, "missing filters match everything")
+	assert.False(t, params.ActorID.Valid)
+	assert.Equal(t, time.Date(2025, 10, 1, 8, 0, 0, 0, time.UTC), params.Since.Time.UTC())
+	assert.False(t, params.Until.Valid)
+	assert.Equal(t, int32(2*auditPageSize), params.PageOffset)
+
+	for _, bad := range []url.Values{
+		{"actor_id": {"nope"}},
+		{"event_id": {"nope"}},
+		{"since": {"yesterday"}},
+		{"page": {"0"}},
+	} {
+		_, err := parseAuditFilter(bad)
+		assert.Error(t, err, "%v", bad)
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/auth"
+)
+
+// callerCan reports whether the authenticated caller has perm
+func callerCan(r *http.Request, perm auth.Permission) bool {
+	claims, ok := auth.FromContext(r.Context())
+	return ok && auth.HasPermission(claims, perm)
+}
+
+// RequireRole only lets callers holding one of roles through. It must run after AuthMiddleware.
+func (hr *HandlerRepo) RequireRole(roles ...auth.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := auth.FromContext(r.Context())
+			if !ok {
+				hr.unauthorized(w, r)
+				return
+			}
+
+			if !auth.HasRole(claims, roles...) {
+				hr.logger.Warn("Caller lacks required role", "user_id", claims.ID, "roles", claims.Roles, "required", roles)
+				hr.forbidden(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequirePermission only lets callers whose roles grant perm through. It must run after
+// AuthMiddleware.
+func (hr *HandlerRepo) RequirePermission(perm auth.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := auth.FromContext(r.Context())
+			if !ok {
+				hr.unauthorized(w, r)
+				return
+			}
+
+			if !auth.HasPermission(claims, perm) {
+				hr.logger.Warn("Caller lacks required permission", "user_id", claims.ID, "roles", claims.Roles, "permission", perm)
+				hr.forbidden(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/auth"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireRole(t *testing.T) {
+	hr := &HandlerRepo{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(mw func(http.Handler) http.Handler, claims *jwt.UserClaims) int {
+		req := httptest.NewRequest(http.MethodGet, "/admin/event-requests", nil)
+		if claims != nil {
+			req = req.WithContext(auth.NewContext(req.Context(), claims))
+		}
+		rec := httptest.NewRecorder()
+		mw(ok).ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	admin := &jwt.UserClaims{ID: "a", Roles: []string{"platform_admin"}}
+	leader := &jwt.UserClaims{ID: "g", Roles: []string{"guild_leader"}}
+
+	t.Run("Role", func(t *testing.T) {
+		mw := hr.RequireRole(auth.RolePlatformAdmin)
+		assert.Equal(t, http.StatusOK, serve(mw, admin))
+		assert.Equal(t, http.StatusForbidden, serve(mw, leader))
+		assert.Equal(t, http.StatusUnauthorized, serve(mw, nil))
+	})
+
+	t.Run("Permission", func(t *testing.T) {
+		mw := hr.RequirePermission(auth.PermRequestEvents)
+		assert.Equal(t, http.StatusOK, serve(mw, admin))
+		assert.Equal(t, http.StatusOK, serve(mw, leader))
+		assert.Equal(t, http.StatusForbidden, serve(mw, &jwt.UserClaims{ID: "p"}))
+		assert.Equal(t, http.StatusUnauthorized, serve(mw, nil))
+	})
+}
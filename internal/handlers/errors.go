@@ -4,10 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
 	"runtime/debug"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/response"
 )
 
@@ -70,6 +74,29 @@ func (hr *HandlerRepo) badRequest(w http.ResponseWriter, r *http.Request, err er
 	hr.errorMessage(w, r, http.StatusBadRequest, err.Error(), nil)
 }
 
+// buildError tells the player why their code was rejected before it was run
+func (hr *HandlerRepo) buildError(w http.ResponseWriter, r *http.Request, buildErr *executor.BuildError) {
+	status := http.StatusUnprocessableEntity
+	switch buildErr.Kind {
+	case executor.BuildErrorCodeTooLong:
+		status = http.StatusRequestEntityTooLarge
+	case executor.BuildErrorLanguageUnsupported:
+		status = http.StatusBadRequest
+	}
+
+	err := response.JSON(w, response.JSONResponseParameters{
+		Success: false,
+		Status:  status,
+		Data:    buildErr,
+		Msg:     buildErr.Message,
+		ErrMsg:  buildErr.Message,
+	})
+	if err != nil {
+		hr.reportServerError(r, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
 func (hr *HandlerRepo) basicAuthenticationRequired(w http.ResponseWriter, r *http.Request) {
 	headers := make(http.Header)
 	headers.Set("WWW-Authenticate", `Basic realm="restricted", charset="UTF-8"`)
@@ -82,3 +109,17 @@ func (hr *HandlerRepo) unauthorized(w http.ResponseWriter, r *http.Request) {
 	message := "You must be authenticated to access this resource"
 	hr.errorMessage(w, r, http.StatusUnauthorized, message, nil)
 }
+
+// rateLimited tells the caller how many seconds to wait before trying again
+func (hr *HandlerRepo) rateLimited(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	headers := make(http.Header)
+	headers.Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+
+	message := "Too many requests, please try again later"
+	hr.errorMessage(w, r, http.StatusTooManyRequests, message, headers)
+}
+
+func (hr *HandlerRepo) forbidden(w http.ResponseWriter, r *http.Request) {
+	message := "You do not have permission to access this resource"
+	hr.errorMessage(w, r, http.StatusForbidden, message, nil)
+}
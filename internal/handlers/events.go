@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/audit"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/auth"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/request"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/response"
@@ -43,7 +47,7 @@ func (hr *HandlerRepo) GetEventsHandler(w http.ResponseWriter, r *http.Request)
 }
 
 type EventCreationRequest struct {
-	RequesterGuildID  string               `json:"requester_guild_id"`
+	RequesterGuildID  string               `json:"requester_guild_id,omitempty"` // defaults to the caller's guild
 	EventType         string               `json:"event_type"`
 	Title             string               `json:"title"`
 	Description       string               `json:"description"`
@@ -59,8 +63,17 @@ type EventCreationRequest struct {
 type ParticipationDetails struct {
 	MaxGuilds          int `json:"max_guilds"`
 	MaxPlayersPerGuild int `json:"max_players_per_guild"`
+	// SpectatorVisibility is who may watch the event leaderboard, public when empty
+	SpectatorVisibility string `json:"spectator_visibility,omitempty"`
 }
 
+const (
+	// SpectatorsPublic lets anyone watch the event leaderboard without signing in
+	SpectatorsPublic = "public"
+	// SpectatorsRestricted limits the event leaderboard to participating guilds and admins
+	SpectatorsRestricted = "restricted"
+)
+
 // RoomConfiguration specifies how rooms should be set up for the event.
 type RoomConfiguration struct {
 	NumberOfRooms    int    `json:"number_of_rooms"`
@@ -109,9 +122,20 @@ func (hr *HandlerRepo) CreateEventHandler(w http.ResponseWriter, r *http.Request
 		hr.badRequest(w, r, errors.New("start date must be before end date"))
 		return
 	}
-	requesterGuildID, err := uuid.Parse(req.RequesterGuildID)
+	switch req.Participation.SpectatorVisibility {
+	case "", SpectatorsPublic, SpectatorsRestricted:
+	default:
+		hr.badRequest(w, r, fmt.Errorf("spectator visibility must be %q or %q", SpectatorsPublic, SpectatorsRestricted))
+		return
+	}
+	// guild leaders can only request events for their own guild
+	requesterGuildID, err := callerGuildID(r)
 	if err != nil {
-		hr.badRequest(w, r, errors.New("invalid requester guild ID format"))
+		hr.forbidden(w, r)
+		return
+	}
+	if req.RequesterGuildID != "" && req.RequesterGuildID != requesterGuildID.String() {
+		hr.forbidden(w, r)
 		return
 	}
 
@@ -172,17 +196,9 @@ func (hr *HandlerRepo) CreateEventHandler(w http.ResponseWriter, r *http.Request
 
 // GetMyEventRequestsHandler fetches a list of event requests submitted by a specific guild.
 func (hr *HandlerRepo) GetMyEventRequestsHandler(w http.ResponseWriter, r *http.Request) {
-	// In a real application, this would come from JWT claims or session.
-	// For this example, we'll use a query parameter.
-	guildIDStr := r.URL.Query().Get("guild_id")
-	if guildIDStr == "" {
-		hr.badRequest(w, r, errors.New("guild_id query parameter is required"))
-		return
-	}
-
-	guildID, err := uuid.Parse(guildIDStr)
+	guildID, err := callerGuildID(r)
 	if err != nil {
-		hr.badRequest(w, r, errors.New("invalid guild_id format"))
+		hr.forbidden(w, r)
 		return
 	}
 
@@ -252,13 +268,14 @@ func (hr *HandlerRepo) GetEventRoomsHandler(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// GuildEventRegisterRequest is the optional body of a guild registration
 type GuildEventRegisterRequest struct {
-	GuildID uuid.UUID
-	EventID uuid.UUID
+	// GuildName is shown on the event's guild leaderboard
+	GuildName string `json:"guild_name"`
 }
 
-// RegisterGuildToEventHandler will add the requested Guild to the Event Participant and
-// create a record in guild_leaderboard_entries
+// RegisterGuildToEventHandler will add the requested Guild to the Event Participant. The guild
+// shows up on the guild leaderboard once its players score.
 func (hr *HandlerRepo) RegisterGuildToEventHandler(w http.ResponseWriter, r *http.Request) {
 	guildIDStr := chi.URLParam(r, "guild_id")
 	guildID, err := uuid.Parse(guildIDStr)
@@ -274,10 +291,28 @@ func (hr *HandlerRepo) RegisterGuildToEventHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// guild leaders can only register their own guild, admins can register any
+	if !callerCan(r, auth.PermApproveEventRequests) {
+		callerGuild, err := callerGuildID(r)
+		if err != nil || callerGuild != guildID {
+			hr.forbidden(w, r)
+			return
+		}
+	}
+
+	var req GuildEventRegisterRequest
+	if r.ContentLength != 0 {
+		if err := request.DecodeJSON(w, r, &req); err != nil {
+			hr.badRequest(w, r, err)
+			return
+		}
+	}
+
 	// room_id will be known later
 	_, err = hr.queries.CreateEventGuildParticipant(r.Context(), store.CreateEventGuildParticipantParams{
-		EventID: toPgtypeUUID(eventID),
-		GuildID: toPgtypeUUID(guildID),
+		EventID:   toPgtypeUUID(eventID),
+		GuildID:   toPgtypeUUID(guildID),
+		GuildName: strings.TrimSpace(req.GuildName),
 	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -381,8 +416,11 @@ func (hr *HandlerRepo) ProcessEventRequestHandler(w http.ResponseWriter, r *http
 		return
 	}
 
-	// TODO: Get admin ID from auth context. Using a placeholder for now.
-	adminID := uuid.New()
+	adminID, err := callerID(r)
+	if err != nil {
+		hr.unauthorized(w, r)
+		return
+	}
 
 	// 1. Fetch the original request
 	eventRequest, err := hr.queries.GetEventRequestByID(r.Context(), toPgtypeUUID(requestID))
@@ -400,15 +438,18 @@ func (hr *HandlerRepo) ProcessEventRequestHandler(w http.ResponseWriter, r *http
 		return
 	}
 
+	var processed store.EventRequest
+	action := audit.ActionEventRequestApproved
 	switch payload.Action {
 	case "approve":
-		err = hr.approveEventRequest(r.Context(), eventRequest, adminID)
+		processed, err = hr.approveEventRequest(r.Context(), eventRequest, adminID)
 	case "decline":
 		if payload.RejectionReason == "" {
 			hr.badRequest(w, r, errors.New("rejection reason is required when declining a request"))
 			return
 		}
-		err = hr.declineEventRequest(r.Context(), eventRequest, adminID, payload.RejectionReason)
+		action = audit.ActionEventRequestDeclined
+		processed, err = hr.declineEventRequest(r.Context(), eventRequest, adminID, payload.RejectionReason)
 	default:
 		hr.badRequest(w, r, errors.New("invalid action: must be 'approve' or 'decline'"))
 		return
@@ -419,6 +460,18 @@ func (hr *HandlerRepo) ProcessEventRequestHandler(w http.ResponseWriter, r *http
 		return
 	}
 
+	entry := audit.Entry{
+		Action:     action,
+		TargetType: audit.TargetEventRequest,
+		TargetID:   requestID.String(),
+		Before:     eventRequestAuditState(eventRequest),
+		After:      eventRequestAuditState(processed),
+	}
+	if processed.ApprovedEventID.Valid {
+		entry.EventID = processed.ApprovedEventID.Bytes
+	}
+	hr.audit.Record(r.Context(), entry)
+
 	err = response.JSON(w, response.JSONResponseParameters{
 		Status:  http.StatusOK,
 		Success: true,
@@ -429,40 +482,51 @@ func (hr *HandlerRepo) ProcessEventRequestHandler(w http.ResponseWriter, r *http
 	}
 }
 
-func (hr *HandlerRepo) approveEventRequest(ctx context.Context, req store.EventRequest, adminID uuid.UUID) error {
+// eventRequestAuditState is the part of a request that processing it changes
+func eventRequestAuditState(req store.EventRequest) map[string]any {
+	return map[string]any{
+		"status":                req.Status,
+		"processed_by_admin_id": uuidString(req.ProcessedByAdminID),
+		"approved_event_id":     uuidString(req.ApprovedEventID),
+		"rejection_reason":      req.RejectionReason.String,
+	}
+}
+
+func (hr *HandlerRepo) approveEventRequest(ctx context.Context, req store.EventRequest, adminID uuid.UUID) (store.EventRequest, error) {
 	var roomConfig RoomConfiguration
 	if err := json.Unmarshal(req.RoomConfiguration, &roomConfig); err != nil {
-		return fmt.Errorf("failed to unmarshal room configuration: %w", err)
+		return req, fmt.Errorf("failed to unmarshal room configuration: %w", err)
 	}
 	var participationDetails ParticipationDetails
 	if err := json.Unmarshal(req.ParticipationDetails, &participationDetails); err != nil {
-		return fmt.Errorf("failed to unmarshal participation details: %w", err)
+		return req, fmt.Errorf("failed to unmarshal participation details: %w", err)
 	}
 
 	// Use a transaction to ensure atomicity
 	tx, err := hr.db.Begin(ctx)
 	if err != nil {
-		return err
+		return req, err
 	}
 	defer tx.Rollback(ctx)
 	qtx := hr.queries.WithTx(tx)
 
 	// Create the actual event
 	event, err := qtx.CreateEvent(ctx, store.CreateEventParams{
-		Title:              req.Title,
-		Description:        req.Description,
-		Type:               req.EventType,
-		StartedDate:        req.ProposedStartDate,
-		EndDate:            req.ProposedEndDate,
-		MaxGuilds:          pgtype.Int4{Int32: int32(participationDetails.MaxGuilds), Valid: true},
-		MaxPlayersPerGuild: pgtype.Int4{Int32: int32(participationDetails.MaxPlayersPerGuild), Valid: true},
-		NumberOfRooms:      pgtype.Int4{Int32: int32(roomConfig.NumberOfRooms), Valid: true},
-		GuildsPerRoom:      pgtype.Int4{Int32: int32(roomConfig.GuildsPerRoom), Valid: true},
-		RoomNamingPrefix:   pgtype.Text{String: roomConfig.RoomNamingPrefix, Valid: true},
-		OriginalRequestID:  req.ID,
+		Title:               req.Title,
+		Description:         req.Description,
+		Type:                req.EventType,
+		StartedDate:         req.ProposedStartDate,
+		EndDate:             req.ProposedEndDate,
+		MaxGuilds:           pgtype.Int4{Int32: int32(participationDetails.MaxGuilds), Valid: true},
+		MaxPlayersPerGuild:  pgtype.Int4{Int32: int32(participationDetails.MaxPlayersPerGuild), Valid: true},
+		NumberOfRooms:       pgtype.Int4{Int32: int32(roomConfig.NumberOfRooms), Valid: true},
+		GuildsPerRoom:       pgtype.Int4{Int32: int32(roomConfig.GuildsPerRoom), Valid: true},
+		RoomNamingPrefix:    pgtype.Text{String: roomConfig.RoomNamingPrefix, Valid: true},
+		OriginalRequestID:   req.ID,
+		SpectatorVisibility: cmp.Or(participationDetails.SpectatorVisibility, SpectatorsPublic),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create event: %w", err)
+		return req, fmt.Errorf("failed to create event: %w", err)
 	}
 
 	// Create rooms for the event
@@ -474,7 +538,7 @@ func (hr *HandlerRepo) approveEventRequest(ctx context.Context, req store.EventR
 			Description: fmt.Sprintf("Room %d for event %s", i+1, event.Title),
 		})
 		if err != nil {
-			return fmt.Errorf("failed to create room: %w", err)
+			return req, fmt.Errorf("failed to create room: %w", err)
 		}
 	}
 
@@ -485,11 +549,11 @@ func (hr *HandlerRepo) approveEventRequest(ctx context.Context, req store.EventR
 		RoomID:  pgtype.UUID{Valid: false}, // Room assignment is a separate logic
 	})
 	if err != nil {
-		return fmt.Errorf("failed to add requester guild to event: %w", err)
+		return req, fmt.Errorf("failed to add requester guild to event: %w", err)
 	}
 
 	// Update the request status
-	_, err = qtx.UpdateEventRequestStatus(ctx, store.UpdateEventRequestStatusParams{
+	processed, err := qtx.UpdateEventRequestStatus(ctx, store.UpdateEventRequestStatusParams{
 		ID:                 req.ID,
 		Status:             store.EventRequestStatusApproved,
 		ProcessedByAdminID: toPgtypeUUID(adminID),
@@ -497,21 +561,20 @@ func (hr *HandlerRepo) approveEventRequest(ctx context.Context, req store.EventR
 		RejectionReason:    pgtype.Text{Valid: false},
 	})
 	if err != nil {
-		return fmt.Errorf("failed to update event request status: %w", err)
+		return req, fmt.Errorf("failed to update event request status: %w", err)
 	}
 
-	return tx.Commit(ctx)
+	return processed, tx.Commit(ctx)
 }
 
-func (hr *HandlerRepo) declineEventRequest(ctx context.Context, req store.EventRequest, adminID uuid.UUID, reason string) error {
-	_, err := hr.queries.UpdateEventRequestStatus(ctx, store.UpdateEventRequestStatusParams{
+func (hr *HandlerRepo) declineEventRequest(ctx context.Context, req store.EventRequest, adminID uuid.UUID, reason string) (store.EventRequest, error) {
+	return hr.queries.UpdateEventRequestStatus(ctx, store.UpdateEventRequestStatusParams{
 		ID:                 req.ID,
 		Status:             store.EventRequestStatusRejected,
 		ProcessedByAdminID: toPgtypeUUID(adminID),
 		RejectionReason:    pgtype.Text{String: reason, Valid: true},
 		ApprovedEventID:    pgtype.UUID{Valid: false},
 	})
-	return err
 }
 
 func toEventRequestResponse(req store.EventRequest) (EventRequestResponse, error) {
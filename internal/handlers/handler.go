@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"crypto/rand"
 	"log/slog"
+	"time"
 
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/audit"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/auth"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/hub"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/ratelimit"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/env"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/jwt"
@@ -24,22 +29,43 @@ type HandlerRepo struct {
 	db          *pgxpool.Pool // Add db pool for transactions
 	jwtParser   *jwt.JWTParser
 	codeBuilder executor.CodeBuilder
+	languages   *executor.LanguageRegistry
+	// devAuth lets requests without a token identify themselves through query params
+	devAuth    bool
+	tickets    *auth.TicketIssuer
+	rateLimits *ratelimit.Policy
+	audit      *audit.Recorder
 }
 
 // NewHandlerRepo creates a new HandlerRepo with the provided dependencies.
-func NewHandlerRepo(logger *slog.Logger, db *pgxpool.Pool, queries *store.Queries, codeBuilder executor.CodeBuilder, worker *executor.WorkerPool) *HandlerRepo {
-	secKey := env.GetString("JWT_SECRET_KEY", "")
-	if secKey == "" {
-		panic("JWT_SECRET_KEY env not found")
+func NewHandlerRepo(logger *slog.Logger, db *pgxpool.Pool, queries *store.Queries, codeBuilder executor.CodeBuilder, worker *executor.WorkerPool, languages *executor.LanguageRegistry, jwtParser *jwt.JWTParser, rateLimits *ratelimit.Policy, recorder *audit.Recorder) *HandlerRepo {
+	devAuth := env.GetBool("AUTH_DEV_MODE", false)
+	if devAuth {
+		logger.Warn("AUTH_DEV_MODE is enabled, requests without a token are trusted")
 	}
+
+	ticketSecret := []byte(env.GetString("STREAM_TICKET_SECRET", ""))
+	if len(ticketSecret) == 0 {
+		// tickets then only verify on this instance, which is fine for a single server
+		logger.Warn("STREAM_TICKET_SECRET is not set, using a random key")
+		ticketSecret = make([]byte, 32)
+		rand.Read(ticketSecret)
+	}
+	ticketTTL := time.Duration(env.GetInt("STREAM_TICKET_TTL_SECONDS", 60)) * time.Second
+
 	return &HandlerRepo{
 		worker:      worker,
 		logger:      logger,
 		db:          db,
 		queries:     queries,
-		jwtParser:   jwt.NewJWTParser(secKey, logger),
-		eventHub:    hub.NewEventHub(queries, logger, codeBuilder, worker),
+		jwtParser:   jwtParser,
+		eventHub:    hub.NewEventHub(queries, logger, codeBuilder, worker, languages, recorder),
 		codeBuilder: codeBuilder,
+		languages:   languages,
+		devAuth:     devAuth,
+		tickets:     auth.NewTicketIssuer(ticketSecret, ticketTTL),
+		rateLimits:  rateLimits,
+		audit:       recorder,
 	}
 }
 
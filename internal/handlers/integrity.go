@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/audit"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/events"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/integrity"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/request"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// MaxIntegritySignals is how many signals a client may report at once
+const MaxIntegritySignals = 50
+
+var (
+	ErrNoIntegritySignals       = errors.New("at least one signal is required")
+	ErrTooManyIntegritySignals  = fmt.Errorf("at most %d signals can be reported at once", MaxIntegritySignals)
+	ErrInvalidIntegritySignal   = errors.New("invalid signal")
+	ErrIntegritySignalWrongRoom = errors.New("room does not belong to the event")
+)
+
+type IntegritySignalRequest struct {
+	Kind  integrity.Kind `json:"kind"`
+	Value int            `json:"value"`
+	// OccurredAt is when the client saw it, the time it was received when empty
+	OccurredAt *time.Time `json:"occurred_at,omitempty"`
+}
+
+type ReportIntegrityRequest struct {
+	Signals []IntegritySignalRequest `json:"signals"`
+}
+
+// toSignals validates the reported signals. Times in the future are clamped to now so a
+// client clock cannot reorder its signals past the ones received later.
+func (req ReportIntegrityRequest) toSignals(now time.Time) ([]integrity.Signal, error) {
+	if len(req.Signals) == 0 {
+		return nil, ErrNoIntegritySignals
+	}
+	if len(req.Signals) > MaxIntegritySignals {
+		return nil, ErrTooManyIntegritySignals
+	}
+
+	signals := make([]integrity.Signal, len(req.Signals))
+	for i, s := range req.Signals {
+		if !s.Kind.Valid() {
+			return nil, fmt.Errorf("%w %d: unknown kind %q", ErrInvalidIntegritySignal, i, s.Kind)
+		}
+		if s.Value < 0 || s.Value > math.MaxInt32 {
+			return nil, fmt.Errorf("%w %d: value must be between 0 and %d", ErrInvalidIntegritySignal, i, math.MaxInt32)
+		}
+
+		occurredAt := now
+		if s.OccurredAt != nil && s.OccurredAt.Before(now) {
+			occurredAt = *s.OccurredAt
+		}
+		signals[i] = integrity.Signal{Kind: s.Kind, Value: s.Value, OccurredAt: occurredAt}
+	}
+	return signals, nil
+}
+
+// ReportIntegrityHandler receives the integrity signals of the caller's battle client. They
+// are recorded by the room hub, which flags the player once they break the event's rules.
+func (hr *HandlerRepo) ReportIntegrityHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := callerID(r)
+	if err != nil {
+		hr.unauthorized(w, r)
+		return
+	}
+
+	eventID, roomID, err := getRequestEventIDAndRoomID(r)
+	if err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	var req ReportIntegrityRequest
+	if err := request.DecodeJSON(w, r, &req); err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	signals, err := req.toSignals(time.Now())
+	if err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	roomHub := hr.eventHub.GetRoomById(roomID)
+	if roomHub == nil {
+		hr.notFound(w, r)
+		return
+	}
+	if roomHub.EventID != eventID {
+		hr.badRequest(w, r, ErrIntegritySignalWrongRoom)
+		return
+	}
+
+	select {
+	case roomHub.Events <- events.IntegritySignalsReported{PlayerID: playerID, RoomID: roomID, Signals: signals}:
+	default:
+		hr.logger.Warn("event hub channel is full, integrity signals dropped", "room_id", roomID)
+		hr.errorMessage(w, r, http.StatusServiceUnavailable, "Server is busy, please try again later.", nil)
+		return
+	}
+
+	err = response.JSON(w, response.JSONResponseParameters{
+		Status:  http.StatusAccepted,
+		Success: true,
+		Msg:     "Integrity signals received",
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+	}
+}
+
+type IntegrityKindSummary struct {
+	Count        int32     `json:"count"`
+	MaxValue     int32     `json:"max_value"`
+	TotalValue   int64     `json:"total_value"`
+	LastOccurred time.Time `json:"last_occurred_at"`
+}
+
+// IntegritySummary is what one player of an event reported in one room
+type IntegritySummary struct {
+	RoomID     string                                  `json:"room_id"`
+	UserID     string                                  `json:"user_id"`
+	Username   string                                  `json:"username,omitempty"`
+	Flagged    bool                                    `json:"flagged"`
+	FlagReason string                                  `json:"flag_reason,omitempty"`
+	Signals    map[integrity.Kind]IntegrityKindSummary `json:"signals"`
+}
+
+// GetIntegritySummariesHandler lists the signals of every player of an event, per room
+func (hr *HandlerRepo) GetIntegritySummariesHandler(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		hr.badRequest(w, r, errors.New("invalid event ID in URL"))
+		return
+	}
+
+	rows, err := hr.queries.GetIntegritySummariesByEvent(r.Context(), toPgtypeUUID(eventID))
+	if err != nil {
+		hr.serverError(w, r, err)
+		return
+	}
+
+	// rows are ordered by room and player, so each summary is contiguous
+	summaries := make([]IntegritySummary, 0)
+	for _, row := range rows {
+		roomID, userID := uuidString(row.RoomID), uuidString(row.UserID)
+		if n := len(summaries); n == 0 || summaries[n-1].RoomID != roomID || summaries[n-1].UserID != userID {
+			summaries = append(summaries, IntegritySummary{
+				RoomID:     roomID,
+				UserID:     userID,
+				Username:   row.Username.String,
+				Flagged:    row.Flagged.Bool,
+				FlagReason: row.FlagReason.String,
+				Signals:    make(map[integrity.Kind]IntegrityKindSummary),
+			})
+		}
+
+		summaries[len(summaries)-1].Signals[integrity.Kind(row.Kind)] = IntegrityKindSummary{
+			Count:        row.SignalCount,
+			MaxValue:     row.MaxValue,
+			TotalValue:   row.TotalValue,
+			LastOccurred: row.LastOccurredAt.Time,
+		}
+	}
+
+	err = response.JSON(w, response.JSONResponseParameters{
+		Status:  http.StatusOK,
+		Data:    summaries,
+		Success: true,
+		Msg:     "Integrity summaries retrieved successfully",
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+	}
+}
+
+type IntegrityRulesRequest struct {
+	// Rules replace the defaults, an empty list turns auto-flagging off and leaving them
+	// out restores the defaults
+	Rules integrity.Rules `json:"rules"`
+}
+
+// SetEventIntegrityRulesHandler sets the rules that auto-flag players of an event. Players
+// are checked against them the next time they report a signal or join a room.
+func (hr *HandlerRepo) SetEventIntegrityRulesHandler(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		hr.badRequest(w, r, errors.New("invalid event ID in URL"))
+		return
+	}
+
+	var req IntegrityRulesRequest
+	if err := request.DecodeJSON(w, r, &req); err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	if err := req.Rules.Validate(); err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	rules := integrity.DefaultRules
+	var data []byte
+	if req.Rules != nil {
+		rules = req.Rules
+		data, err = json.Marshal(req.Rules)
+		if err != nil {
+			hr.serverError(w, r, err)
+			return
+		}
+	}
+
+	before, err := hr.queries.GetEventByID(r.Context(), toPgtypeUUID(eventID))
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		hr.notFound(w, r)
+		return
+	case err != nil:
+		hr.serverError(w, r, err)
+		return
+	}
+
+	_, err = hr.queries.UpdateEventIntegrityRules(r.Context(), store.UpdateEventIntegrityRulesParams{
+		ID:             toPgtypeUUID(eventID),
+		IntegrityRules: data,
+	})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		hr.notFound(w, r)
+		return
+	case err != nil:
+		hr.serverError(w, r, err)
+		return
+	}
+
+	hr.audit.Record(r.Context(), audit.Entry{
+		Action:     audit.ActionEventIntegrityRulesUpdated,
+		TargetType: audit.TargetEvent,
+		TargetID:   eventID.String(),
+		EventID:    eventID,
+		Before:     json.RawMessage(before.IntegrityRules),
+		After:      json.RawMessage(data),
+	})
+
+	err = response.JSON(w, response.JSONResponseParameters{
+		Status:  http.StatusOK,
+		Data:    rules,
+		Success: true,
+		Msg:     "Event integrity rules updated successfully",
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/integrity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportIntegrityRequest(t *testing.T) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	earlier, later := now.Add(-time.Minute), now.Add(time.Hour)
+
+	t.Run("Signals are converted", func(t *testing.T) {
+		req := ReportIntegrityRequest{Signals: []IntegritySignalRequest{
+			{Kind: integrity.KindPaste, Value: 300, OccurredAt: &earlier},
+			{Kind: integrity.KindDevTools},
+		}}
+		signals, err := req.toSignals(now)
+		require.NoError(t, err)
+		assert.Equal(t, []integrity.Signal{
+			{Kind: integrity.KindPaste, Value: 300, OccurredAt: earlier},
+			{Kind: integrity.KindDevTools, OccurredAt: now},
+		}, signals)
+	})
+
+	t.Run("Future times are clamped", func(t *testing.T) {
+		req := ReportIntegrityRequest{Signals: []IntegritySignalRequest{{Kind: integrity.KindFocusLost, Value: 5000, OccurredAt: &later}}}
+		signals, err := req.toSignals(now)
+		require.NoError(t, err)
+		assert.Equal(t, now, signals[0].OccurredAt)
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		_, err := ReportIntegrityRequest{}.toSignals(now)
+		assert.ErrorIs(t, err, ErrNoIntegritySignals)
+
+		_, err = ReportIntegrityRequest{Signals: make([]IntegritySignalRequest, MaxIntegritySignals+1)}.toSignals(now)
+		assert.ErrorIs(t, err, ErrTooManyIntegritySignals)
+
+		_, err = ReportIntegrityRequest{Signals: []IntegritySignalRequest{{Kind: "screenshot"}}}.toSignals(now)
+		assert.ErrorIs(t, err, ErrInvalidIntegritySignal)
+
+		_, err = ReportIntegrityRequest{Signals: []IntegritySignalRequest{{Kind: integrity.KindPaste, Value: -1}}}.toSignals(now)
+		assert.ErrorIs(t, err, ErrInvalidIntegritySignal)
+
+		// stored as an integer column, larger values would wrap around
+		_, err = ReportIntegrityRequest{Signals: []IntegritySignalRequest{{Kind: integrity.KindPaste, Value: math.MaxInt32 + 1}}}.toSignals(now)
+		assert.ErrorIs(t, err, ErrInvalidIntegritySignal)
+	})
+}
@@ -1,34 +1,50 @@
 package handlers
 
 import (
-	"context"
+	"errors"
+	"net"
 	"net/http"
 	"strings"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/audit"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/auth"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/ratelimit"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/jwt"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 )
 
-type Key string
+var ErrMissingIdentity = errors.New("request has no authenticated user")
 
-const (
-	UserClaimsKey Key = "user_claims"
-)
+// RequestIDHeader carries the ID of a request, it is echoed back on every response
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID tags each request with the ID sent by the client or a proxy, or a new one, so
+// audit entries can be matched with the request that caused them
+func (hr *HandlerRepo) RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := audit.NormalizeRequestID(r.Header.Get(RequestIDHeader))
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(audit.WithRequestID(r.Context(), id)))
+	})
+}
 
 func (hr *HandlerRepo) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			hr.logger.Warn("Missing Authorization header")
-			hr.unauthorized(w, r)
-			return
-		}
+		tokenStr, ok := bearerToken(r)
+		if !ok {
+			// dev clients identify themselves through query params instead of a token
+			if claims, ok := devClaims(r); ok && hr.devAuth {
+				hr.logger.Debug("Dev auth used", "user_id", claims.ID)
+				next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), claims)))
+				return
+			}
 
-		headerParts := strings.Split(authHeader, " ")
-		if len(headerParts) != 2 || strings.ToLower(headerParts[0]) != "bearer" {
-			hr.logger.Warn("Malformed Authorization header", "header", authHeader)
+			hr.logger.Warn("Missing or malformed Authorization header")
 			hr.unauthorized(w, r)
 			return
 		}
 
-		tokenStr := headerParts[1]
 		// verify token here (with jwt secret key)
 		claims, err := hr.jwtParser.GetUserClaimsFromToken(tokenStr)
 		if err != nil {
@@ -37,9 +53,115 @@ func (hr *HandlerRepo) AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		hr.logger.Info("Auth endpoint hit", "claims", claims)
+		if _, err := uuid.Parse(claims.ID); err != nil {
+			hr.logger.Warn("Token has an invalid user ID", "id", claims.ID)
+			hr.unauthorized(w, r)
+			return
+		}
 
-		ctx := context.WithValue(r.Context(), UserClaimsKey, claims)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), claims)))
 	})
 }
+
+// bearerToken reads the token from the Authorization header. SSE streams, which cannot set
+// headers, authenticate with a stream ticket instead.
+func bearerToken(r *http.Request) (string, bool) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return "", false
+	}
+
+	headerParts := strings.Split(authHeader, " ")
+	if len(headerParts) != 2 || strings.ToLower(headerParts[0]) != "bearer" {
+		return "", false
+	}
+
+	return headerParts[1], true
+}
+
+// devClaims builds claims from the `player_id` (or `connected_player_id`), `guild_id` and
+// comma separated `roles` query params. It is only honoured when AUTH_DEV_MODE is enabled.
+func devClaims(r *http.Request) (*jwt.UserClaims, bool) {
+	query := r.URL.Query()
+	id := query.Get("player_id")
+	if id == "" {
+		id = query.Get("connected_player_id")
+	}
+
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, false
+	}
+
+	claims := &jwt.UserClaims{ID: id, GuildID: query.Get("guild_id")}
+	if roles := query.Get("roles"); roles != "" {
+		claims.Roles = strings.Split(roles, ",")
+	}
+	return claims, true
+}
+
+// RateLimit takes a token from the caller's budget and answers 429 once it is used up.
+// Callers are told apart by user ID when authenticated and by IP address otherwise, so it
+// should run after AuthMiddleware. Routes under an event use that event's limits.
+func (hr *HandlerRepo) RateLimit(budget ratelimit.Budget) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			eventID := hr.rateLimitEvent(r)
+			caller := rateLimitCaller(r)
+			ok, retryAfter := hr.rateLimits.Allow(r.Context(), budget, eventID, caller)
+			if !ok {
+				hr.logger.Warn("Rate limit exceeded", "budget", budget, "caller", caller, "event_id", eventID)
+				hr.rateLimited(w, r, retryAfter)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitEvent is the event of the route's room, or the event the route names itself.
+// The room decides, so a room cannot be reached through a laxer event's URL.
+func (hr *HandlerRepo) rateLimitEvent(r *http.Request) uuid.UUID {
+	if roomID, err := uuid.Parse(chi.URLParam(r, "room_id")); err == nil {
+		if eventID, err := hr.rateLimits.EventForRoom(r.Context(), roomID); err == nil {
+			return eventID
+		}
+	}
+
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		return uuid.Nil
+	}
+	return eventID
+}
+
+// rateLimitCaller is the user ID of an authenticated request, or its remote IP
+func rateLimitCaller(r *http.Request) string {
+	if claims, ok := auth.FromContext(r.Context()); ok {
+		return ratelimit.UserKey(claims.ID)
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return ratelimit.IPKey(host)
+}
+
+// callerID returns the ID of the authenticated user making the request
+func callerID(r *http.Request) (uuid.UUID, error) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		return uuid.UUID{}, ErrMissingIdentity
+	}
+	return uuid.Parse(claims.ID)
+}
+
+// callerGuildID returns the guild of the authenticated user, if the token carries one
+func callerGuildID(r *http.Request) (uuid.UUID, error) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		return uuid.UUID{}, ErrMissingIdentity
+	}
+	return uuid.Parse(claims.GuildID)
+}
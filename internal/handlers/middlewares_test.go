@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/audit"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/auth"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/ratelimit"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/jwt"
+	"github.com/go-chi/chi/v5"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "qwertyuiopasdfghjklzxcvbnm123456"
+
+func signTestToken(t *testing.T, claims *jwt.UserClaims) string {
+	t.Helper()
+	token, err := gojwt.NewWithClaims(gojwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	require.NoError(t, err)
+	return token
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	playerID := "0b8f2a6e-4c53-4d3b-9a43-2f4f1d7e2c11"
+	guildID := "11111111-1111-1111-1111-111111111111"
+
+	newRepo := func(devAuth bool) *HandlerRepo {
+		return &HandlerRepo{logger: logger, jwtParser: jwt.NewJWTParser(testSecret, logger), devAuth: devAuth}
+	}
+
+	// serve runs req through the middleware and returns the status and the caller it saw
+	serve := func(hr *HandlerRepo, req *http.Request) (int, string) {
+		var caller string
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, err := callerID(r)
+			require.NoError(t, err)
+			caller = id.String()
+		})
+		rec := httptest.NewRecorder()
+		hr.AuthMiddleware(next).ServeHTTP(rec, req)
+		return rec.Code, caller
+	}
+
+	validToken := signTestToken(t, &jwt.UserClaims{
+		ID:      playerID,
+		GuildID: guildID,
+		RegisteredClaims: gojwt.RegisteredClaims{
+			ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+
+	t.Run("Bearer token sets the caller", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/submissions?player_id="+guildID, nil)
+		req.Header.Set("Authorization", "Bearer "+validToken)
+
+		code, caller := serve(newRepo(false), req)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, playerID, caller, "the token wins over query params")
+	})
+
+	t.Run("Missing token is rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/submissions", nil)
+
+		code, _ := serve(newRepo(false), req)
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("Query param identity is ignored outside dev mode", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/submissions?player_id="+playerID, nil)
+
+		code, _ := serve(newRepo(false), req)
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("Query param identity is accepted in dev mode", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/events/e/rooms/r/leaderboard?connected_player_id="+playerID, nil)
+
+		code, caller := serve(newRepo(true), req)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, playerID, caller)
+	})
+
+	t.Run("Token with a bad signature is rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/submissions", nil)
+		req.Header.Set("Authorization", "Bearer "+validToken+"x")
+
+		code, _ := serve(newRepo(true), req)
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("Token query param is ignored", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/events/e/rooms/r/leaderboard?token="+validToken, nil)
+		req.Header.Set("Accept", "text/event-stream")
+		code, _ := serve(newRepo(false), req)
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+}
+
+func TestRequestID(t *testing.T) {
+	hr := &HandlerRepo{}
+	var seen string
+	handler := hr.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = audit.RequestID(r.Context())
+	}))
+
+	t.Run("Client ID is kept", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, "trace-42")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, "trace-42", seen)
+		assert.Equal(t, "trace-42", rec.Header().Get(RequestIDHeader))
+	})
+
+	t.Run("Missing ID is generated", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.NotEmpty(t, seen)
+		assert.Equal(t, seen, rec.Header().Get(RequestIDHeader))
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	limits := ratelimit.NewPolicy(ratelimit.Limits{ratelimit.BudgetSubmit: {PerMinute: 1, Burst: 1}}, nil, time.Minute, logger)
+	hr := &HandlerRepo{logger: logger, rateLimits: limits}
+	handler := hr.RateLimit(ratelimit.BudgetSubmit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	asUser := func(req *http.Request, id string) *http.Request {
+		return req.WithContext(auth.NewContext(req.Context(), &jwt.UserClaims{ID: id}))
+	}
+
+	t.Run("Authenticated callers are limited by user", func(t *testing.T) {
+		req := asUser(httptest.NewRequest(http.MethodPost, "/submissions", nil), "0b8f2a6e-4c53-4d3b-9a43-2f4f1d7e2c11")
+		assert.Equal(t, http.StatusOK, serve(req).Code)
+
+		rec := serve(req)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+		other := asUser(httptest.NewRequest(http.MethodPost, "/submissions", nil), "11111111-1111-1111-1111-111111111111")
+		assert.Equal(t, http.StatusOK, serve(other).Code, "the same IP with another user has its own budget")
+	})
+
+	t.Run("Anonymous callers are limited by IP", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/submissions", nil)
+		req.RemoteAddr = "10.0.0.1:5000"
+		assert.Equal(t, http.StatusOK, serve(req).Code)
+
+		req.RemoteAddr = "10.0.0.1:5001"
+		assert.Equal(t, http.StatusTooManyRequests, serve(req).Code, "the port does not matter")
+
+		req.RemoteAddr = "10.0.0.2:5000"
+		assert.Equal(t, http.StatusOK, serve(req).Code)
+	})
+}
+
+// fakeEventStore serves the events and rooms the rate limit policy looks up
+type fakeEventStore struct {
+	events map[uuid.UUID]store.Event
+	rooms  map[uuid.UUID]store.Room
+}
+
+func (f *fakeEventStore) GetEventByID(_ context.Context, id pgtype.UUID) (store.Event, error) {
+	event, ok := f.events[id.Bytes]
+	if !ok {
+		return store.Event{}, pgx.ErrNoRows
+	}
+	return event, nil
+}
+
+func (f *fakeEventStore) GetRoomByID(_ context.Context, id pgtype.UUID) (store.Room, error) {
+	room, ok := f.rooms[id.Bytes]
+	if !ok {
+		return store.Room{}, pgx.ErrNoRows
+	}
+	return room, nil
+}
+
+func TestRateLimitRoomEvent(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	strictEvent, laxEvent, roomID := uuid.New(), uuid.New(), uuid.New()
+	events := &fakeEventStore{
+		events: map[uuid.UUID]store.Event{
+			strictEvent: {ID: pgtype.UUID{Bytes: strictEvent, Valid: true}, RateLimits: []byte(`{"submit": {"per_minute": 1, "burst": 1}}`)},
+			laxEvent:    {ID: pgtype.UUID{Bytes: laxEvent, Valid: true}, RateLimits: []byte(`{"submit": {"per_minute": 100, "burst": 100}}`)},
+		},
+		rooms: map[uuid.UUID]store.Room{
+			roomID: {ID: pgtype.UUID{Bytes: roomID, Valid: true}, EventID: pgtype.UUID{Bytes: strictEvent, Valid: true}},
+		},
+	}
+	limits := ratelimit.NewPolicy(ratelimit.Limits{ratelimit.BudgetSubmit: {PerMinute: 100, Burst: 100}}, events, time.Minute, logger)
+	hr := &HandlerRepo{logger: logger, rateLimits: limits}
+
+	router := chi.NewRouter()
+	router.With(hr.RateLimit(ratelimit.BudgetSubmit)).
+		Post("/events/{event_id}/rooms/{room_id}/submit", func(w http.ResponseWriter, r *http.Request) {})
+
+	submit := func(eventID uuid.UUID) int {
+		req := httptest.NewRequest(http.MethodPost, "/events/"+eventID.String()+"/rooms/"+roomID.String()+"/submit", nil)
+		req = req.WithContext(auth.NewContext(req.Context(), &jwt.UserClaims{ID: "0b8f2a6e-4c53-4d3b-9a43-2f4f1d7e2c11"}))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, submit(strictEvent))
+	assert.Equal(t, http.StatusTooManyRequests, submit(strictEvent))
+	assert.Equal(t, http.StatusTooManyRequests, submit(laxEvent), "the room's event decides, not the URL")
+}
@@ -101,10 +101,13 @@ func (hr *HandlerRepo) GetRoomProblemsHandler(w http.ResponseWriter, r *http.Req
 }
 
 type CodeProblemLanguageDetailResponse struct {
-	SolutionStub      string `json:"solution_stub"`
-	DriverCode        string `json:"driver_code"`
-	TimeConstraintMs  int32  `json:"time_constraint_ms"`
-	SpaceConstraintMb int32  `json:"space_constraint_mb"`
+	SolutionStub      string   `json:"solution_stub"`
+	DriverCode        string   `json:"driver_code"`
+	TimeConstraintMs  int32    `json:"time_constraint_ms"`
+	SpaceConstraintMb int32    `json:"space_constraint_mb"`
+	SolutionMode      string   `json:"solution_mode"`
+	RequiredSymbols   []string `json:"required_symbols"`
+	Sections          []string `json:"sections"`
 }
 
 func (hr *HandlerRepo) GetProblemDetails(w http.ResponseWriter, r *http.Request) {
@@ -115,7 +118,7 @@ func (hr *HandlerRepo) GetProblemDetails(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	normalizedLang, found := executor.NormalizeLanguage(r.URL.Query().Get("lang"))
+	langSpec, found := hr.languages.Resolve(r.URL.Query().Get("lang"))
 	if !found {
 		hr.logger.Warn("lang not found")
 		hr.notFound(w, r)
@@ -124,7 +127,7 @@ func (hr *HandlerRepo) GetProblemDetails(w http.ResponseWriter, r *http.Request)
 
 	detail, err := hr.queries.GetCodeProblemLanguageDetailByLanguageName(r.Context(), store.GetCodeProblemLanguageDetailByLanguageNameParams{
 		CodeProblemID: toPgtypeUUID(problemID),
-		Name:          normalizedLang,
+		Name:          langSpec.Name,
 	})
 
 	if err != nil {
@@ -137,7 +140,7 @@ func (hr *HandlerRepo) GetProblemDetails(w http.ResponseWriter, r *http.Request)
 		Status:  http.StatusOK,
 		Success: true,
 		Msg:     "get code problem language detail successfully",
-		Data:    toProblemDetailResponse(detail),
+		Data:    toProblemDetailResponse(langSpec, detail),
 	})
 	if err != nil {
 		hr.logger.Error("failed to parse json", "err", err)
@@ -153,11 +156,14 @@ func toProblemResponse(problem store.CodeProblem) CodeProblemResponse {
 	}
 }
 
-func toProblemDetailResponse(problem store.CodeProblemLanguageDetail) CodeProblemLanguageDetailResponse {
+func toProblemDetailResponse(lang executor.LanguageSpec, problem store.CodeProblemLanguageDetail) CodeProblemLanguageDetailResponse {
 	return CodeProblemLanguageDetailResponse{
 		SolutionStub:      problem.SolutionStub,
 		DriverCode:        problem.DriverCode,
 		TimeConstraintMs:  problem.TimeConstraintMs,
 		SpaceConstraintMb: problem.SpaceConstraintMb,
+		SolutionMode:      problem.SolutionMode,
+		RequiredSymbols:   problem.RequiredSymbols,
+		Sections:          executor.SectionNames(lang, problem.DriverCode),
 	}
 }
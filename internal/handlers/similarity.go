@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/audit"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/similarity"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/request"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/response"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// DefaultSimilarityThreshold flags pairs sharing at least this share of fingerprints
+	DefaultSimilarityThreshold = 0.8
+	// commonCodeFraction ignores code found in more than this share of a problem's submissions
+	commonCodeFraction = 0.5
+	similarityPageSize = 20
+)
+
+var ErrSimilarityScope = errors.New("event_id or problem_id is required")
+
+type SimilarityScanRequest struct {
+	EventID   string  `json:"event_id,omitempty"`
+	ProblemID string  `json:"problem_id,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"` // DefaultSimilarityThreshold when zero
+}
+
+// SimilarityScan counts what a scan looked at
+type SimilarityScan struct {
+	Problems    int `json:"problems"`
+	Submissions int `json:"submissions"`
+	Compared    int `json:"compared"`
+	Flagged     int `json:"flagged"`
+}
+
+// ScanSimilarityHandler compares the latest submission of every player for a problem, or
+// for every problem of an event, and stores the pairs scoring at least the threshold.
+// Submissions are only compared with others in the same language. In events, players of
+// the same guild are expected to work together and are not compared.
+// The scan runs in the background and replaces the pairs found by earlier scans of the same scope.
+func (hr *HandlerRepo) ScanSimilarityHandler(w http.ResponseWriter, r *http.Request) {
+	var req SimilarityScanRequest
+	if err := request.DecodeJSON(w, r, &req); err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	if req.Threshold < 0 || req.Threshold > 1 {
+		hr.badRequest(w, r, errors.New("threshold must be between 0 and 1"))
+		return
+	}
+	threshold := req.Threshold
+	if threshold == 0 {
+		threshold = DefaultSimilarityThreshold
+	}
+
+	eventID, problemID, err := parseSimilarityScope(req.EventID, req.ProblemID)
+	if err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+	if !eventID.Valid && !problemID.Valid {
+		hr.badRequest(w, r, ErrSimilarityScope)
+		return
+	}
+
+	problems := []pgtype.UUID{problemID}
+	if !problemID.Valid {
+		eventProblems, err := hr.queries.GetEventCodeProblems(r.Context(), eventID)
+		if err != nil {
+			hr.serverError(w, r, err)
+			return
+		}
+
+		problems = problems[:0]
+		for _, p := range eventProblems {
+			problems = append(problems, p.CodeProblemID)
+		}
+	}
+
+	// keep the caller and request ID for the audit trail, but not the request's deadline
+	ctx := context.WithoutCancel(r.Context())
+	go func() {
+		var scan SimilarityScan
+		for _, problem := range problems {
+			if err := hr.scanProblemSimilarity(ctx, eventID, problem, threshold, &scan); err != nil {
+				hr.logger.Error("similarity scan failed", "problem_id", uuidString(problem), "event_id", req.EventID, "error", err)
+			}
+		}
+
+		hr.logger.Info("Similarity scan finished",
+			"event_id", req.EventID,
+			"problem_id", req.ProblemID,
+			"compared", scan.Compared,
+			"flagged", scan.Flagged)
+
+		entry := audit.Entry{
+			Action:     audit.ActionSimilarityScanned,
+			TargetType: audit.TargetCodeProblem,
+			TargetID:   req.ProblemID,
+			After:      map[string]any{"threshold": threshold, "scan": scan},
+		}
+		if eventID.Valid {
+			entry.EventID = eventID.Bytes
+			if !problemID.Valid {
+				entry.TargetType, entry.TargetID = audit.TargetEvent, req.EventID
+			}
+		}
+		hr.audit.Record(ctx, entry)
+	}()
+
+	err = response.JSON(w, response.JSONResponseParameters{
+		Status:  http.StatusAccepted,
+		Data:    map[string]int{"problems": len(problems)},
+		Success: true,
+		Msg:     "Similarity scan started",
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+	}
+}
+
+func parseSimilarityScope(eventIDStr, problemIDStr string) (pgtype.UUID, pgtype.UUID, error) {
+	var eventID, problemID pgtype.UUID
+	if eventIDStr != "" {
+		id, err := uuid.Parse(eventIDStr)
+		if err != nil {
+			return eventID, problemID, errors.New("invalid event_id")
+		}
+		eventID = toPgtypeUUID(id)
+	}
+	if problemIDStr != "" {
+		id, err := uuid.Parse(problemIDStr)
+		if err != nil {
+			return eventID, problemID, ErrInvalidProblem
+		}
+		problemID = toPgtypeUUID(id)
+	}
+	return eventID, problemID, nil
+}
+
+type similarityCandidate struct {
+	submission store.GetLatestSubmissionsForSimilarityRow
+	doc        *similarity.Document
+}
+
+// scanProblemSimilarity compares the submissions of one problem and replaces the stored pairs
+// of the scope in a single transaction, so pairs that no longer match are dropped.
+func (hr *HandlerRepo) scanProblemSimilarity(ctx context.Context, eventID, problemID pgtype.UUID, threshold float64, scan *SimilarityScan) error {
+	submissions, err := hr.queries.GetLatestSubmissionsForSimilarity(ctx, store.GetLatestSubmissionsForSimilarityParams{
+		CodeProblemID: problemID,
+		EventID:       eventID,
+	})
+	if err != nil {
+		return err
+	}
+	scan.Problems++
+	scan.Submissions += len(submissions)
+
+	var flagged []store.UpsertSubmissionSimilarityParams
+
+	// a stable order keeps each pair stored as the same (a, b)
+	slices.SortFunc(submissions, func(a, b store.GetLatestSubmissionsForSimilarityRow) int {
+		return bytes.Compare(a.ID.Bytes[:], b.ID.Bytes[:])
+	})
+
+	byLanguage := make(map[pgtype.UUID][]similarityCandidate)
+	for _, s := range submissions {
+		code, err := submittedCode(s.CodeSubmitted, s.Sections)
+		if err != nil {
+			hr.logger.Warn("skipping submission with invalid sections", "submission_id", s.ID, "err", err)
+			continue
+		}
+		doc, err := similarity.NewDocument(code, s.LanguageName)
+		if err != nil {
+			hr.logger.Warn("skipping submission that cannot be tokenized", "submission_id", s.ID, "err", err)
+			continue
+		}
+		byLanguage[s.LanguageID] = append(byLanguage[s.LanguageID], similarityCandidate{submission: s, doc: doc})
+	}
+
+	for languageID, candidates := range byLanguage {
+		// every player starts from the stub, so it is not evidence of copying
+		detail, err := hr.queries.GetCodeProblemLanguageDetail(ctx, store.GetCodeProblemLanguageDetailParams{
+			CodeProblemID: problemID,
+			LanguageID:    languageID,
+		})
+		if err == nil {
+			stub, err := similarity.NewDocument(detail.SolutionStub, candidates[0].submission.LanguageName)
+			if err == nil {
+				for _, c := range candidates {
+					c.doc.Exclude(stub)
+				}
+			}
+		}
+
+		docs := make([]*similarity.Document, len(candidates))
+		for i, c := range candidates {
+			docs[i] = c.doc
+		}
+		similarity.ExcludeCommon(docs, commonCodeFraction)
+
+		for i, a := range candidates {
+			for _, b := range candidates[i+1:] {
+				if eventID.Valid && a.submission.SubmittedGuildID.Valid && a.submission.SubmittedGuildID == b.submission.SubmittedGuildID {
+					continue
+				}
+
+				scan.Compared++
+				comparison := similarity.Compare(a.doc, b.doc)
+				if comparison.Score < threshold {
+					continue
+				}
+
+				matches, err := json.Marshal(comparison.Matches)
+				if err != nil {
+					return err
+				}
+
+				flagged = append(flagged, store.UpsertSubmissionSimilarityParams{
+					EventID:       eventID,
+					CodeProblemID: problemID,
+					SubmissionAID: a.submission.ID,
+					SubmissionBID: b.submission.ID,
+					Score:         comparison.Score,
+					Matches:       matches,
+				})
+			}
+		}
+	}
+
+	tx, err := hr.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	qtx := hr.queries.WithTx(tx)
+
+	err = qtx.DeleteSubmissionSimilaritiesByScope(ctx, store.DeleteSubmissionSimilaritiesByScopeParams{
+		CodeProblemID: problemID,
+		EventID:       eventID,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, pair := range flagged {
+		if _, err := qtx.UpsertSubmissionSimilarity(ctx, pair); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	scan.Flagged += len(flagged)
+
+	return nil
+}
+
+// SimilarityResponse is a flagged pair shown side by side. Each match highlights the
+// same code in both submissions.
+type SimilarityResponse struct {
+	ID         string             `json:"id"`
+	EventID    string             `json:"event_id,omitempty"`
+	ProblemID  string             `json:"problem_id"`
+	Language   string             `json:"language"`
+	Score      float64            `json:"score"`
+	Matches    []similarity.Match `json:"matches"`
+	A          SimilaritySide     `json:"a"`
+	B          SimilaritySide     `json:"b"`
+	DetectedAt time.Time          `json:"detected_at"`
+}
+
+type SimilaritySide struct {
+	SubmissionID string `json:"submission_id"`
+	UserID       string `json:"user_id"`
+	GuildID      string `json:"guild_id,omitempty"`
+	Code         string `json:"code"`
+}
+
+// GetSimilaritiesHandler lists flagged pairs, most similar first. It can be filtered with
+// the event_id, problem_id and min_score query params and paged with page.
+func (hr *HandlerRepo) GetSimilaritiesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	eventID, problemID, err := parseSimilarityScope(query.Get("event_id"), query.Get("problem_id"))
+	if err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	var minScore float64
+	if s := query.Get("min_score"); s != "" {
+		minScore, err = strconv.ParseFloat(s, 64)
+		if err != nil {
+			hr.badRequest(w, r, errors.New("invalid min_score"))
+			return
+		}
+	}
+
+	page := 1
+	if s := query.Get("page"); s != "" {
+		page, err = strconv.Atoi(s)
+		if err != nil || page < 1 {
+			hr.badRequest(w, r, errors.New("invalid page"))
+			return
+		}
+	}
+
+	rows, err := hr.queries.ListSubmissionSimilarities(r.Context(), store.ListSubmissionSimilaritiesParams{
+		EventID:       eventID,
+		CodeProblemID: problemID,
+		MinScore:      minScore,
+		PageLimit:     similarityPageSize,
+		PageOffset:    int32((page - 1) * similarityPageSize),
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+		return
+	}
+
+	similarities := make([]SimilarityResponse, 0, len(rows))
+	for _, row := range rows {
+		var matches []similarity.Match
+		if err := json.Unmarshal(row.Matches, &matches); err != nil {
+			hr.serverError(w, r, err)
+			return
+		}
+
+		// matches point at lines of the code the scan compared
+		codeA, err := submittedCode(row.CodeA, row.SectionsA)
+		if err != nil {
+			hr.serverError(w, r, err)
+			return
+		}
+		codeB, err := submittedCode(row.CodeB, row.SectionsB)
+		if err != nil {
+			hr.serverError(w, r, err)
+			return
+		}
+
+		similarities = append(similarities, SimilarityResponse{
+			ID:         uuidString(row.ID),
+			EventID:    uuidString(row.EventID),
+			ProblemID:  uuidString(row.CodeProblemID),
+			Language:   row.LanguageName,
+			Score:      row.Score,
+			Matches:    matches,
+			A:          SimilaritySide{SubmissionID: uuidString(row.SubmissionAID), UserID: uuidString(row.UserAID), GuildID: uuidString(row.GuildAID), Code: codeA},
+			B:          SimilaritySide{SubmissionID: uuidString(row.SubmissionBID), UserID: uuidString(row.UserBID), GuildID: uuidString(row.GuildBID), Code: codeB},
+			DetectedAt: row.DetectedAt.Time,
+		})
+	}
+
+	err = response.JSON(w, response.JSONResponseParameters{
+		Status:  http.StatusOK,
+		Data:    similarities,
+		Success: true,
+		Msg:     "Similarities retrieved successfully",
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+	}
+}
+
+// submittedCode is all the code a player wrote for a submission: the main section followed
+// by the named sections in name order
+func submittedCode(code string, storedSections []byte) (string, error) {
+	sections, err := executor.DecodeSections(storedSections)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(code)
+	for _, name := range slices.Sorted(maps.Keys(sections)) {
+		if name == executor.MainSection {
+			continue
+		}
+		b.WriteString("\n")
+		b.WriteString(sections[name])
+	}
+	return b.String(), nil
+}
+
+// uuidString formats id, or returns an empty string when it is NULL
+func uuidString(id pgtype.UUID) string {
+	if !id.Valid {
+		return ""
+	}
+	return uuid.UUID(id.Bytes).String()
+}
@@ -5,10 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/auth"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/events"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/jwt"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/response"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 // SSE Event Handler for room's leaderboard
@@ -25,13 +31,16 @@ func (hr *HandlerRepo) JoinRoomHandler(w http.ResponseWriter, r *http.Request) {
 		"event_id", eventID,
 		"room_id", roomID)
 
-	// get from the passed context in production stage, we will get from query in dev stage
-	connectedPlayerIDStr := r.URL.Query().Get("connected_player_id")
-	connectedPlayerID, err := uuid.Parse(connectedPlayerIDStr)
+	ticket, err := hr.streamTicket(r, eventID, roomID)
 	if err != nil {
-		hr.logger.Error("failed to parse connectedPlayerID",
-			"err", err)
-		hr.badRequest(w, r, err)
+		hr.logger.Warn("room stream refused", "err", err, "room_id", roomID)
+		hr.unauthorized(w, r)
+		return
+	}
+
+	connectedPlayerID, err := uuid.Parse(ticket.UserID)
+	if err != nil {
+		hr.unauthorized(w, r)
 		return
 	}
 
@@ -76,8 +85,9 @@ func (hr *HandlerRepo) JoinRoomHandler(w http.ResponseWriter, r *http.Request) {
 
 	hr.logger.Info("SSE connection established", "connected_player_id", connectedPlayerID, "room_id", roomID)
 
-	// player joined event
-	roomHub.Events <- events.PlayerJoined{PlayerID: connectedPlayerID, RoomID: roomID}
+	// player joined event, players without a guild have uuid.Nil
+	guildID, _ := uuid.Parse(ticket.GuildID)
+	roomHub.Events <- events.PlayerJoined{PlayerID: connectedPlayerID, Username: ticket.Username, RoomID: roomID, GuildID: guildID}
 
 	for {
 		select {
@@ -120,6 +130,24 @@ func (hr *HandlerRepo) SpectateEventHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	event, err := hr.queries.GetEventByID(r.Context(), toPgtypeUUID(eventID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			hr.notFound(w, r)
+			return
+		}
+		hr.serverError(w, r, err)
+		return
+	}
+
+	if event.SpectatorVisibility == SpectatorsRestricted {
+		if _, err := hr.streamTicket(r, eventID, uuid.Nil); err != nil {
+			hr.logger.Warn("spectator stream refused", "err", err, "event_id", eventID)
+			hr.unauthorized(w, r)
+			return
+		}
+	}
+
 	// Set headers for SSE
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -156,7 +184,7 @@ func (hr *HandlerRepo) SpectateEventHandler(w http.ResponseWriter, r *http.Reque
 
 	// --- Send initial leaderboard state ---
 	// So the user sees data immediately upon connecting
-	initialEntries, err := hr.queries.GetGuildLeaderboardByEvent(r.Context(), toPgtypeUUID(eventID))
+	initialEntries, err := hr.queries.GetLatestGuildLeaderboardByEvent(r.Context(), toPgtypeUUID(eventID))
 	if err == nil {
 		initialEvent := events.SseEvent{
 			EventType: events.GUILD_LEADERBOARD_UPDATED,
@@ -205,19 +233,193 @@ func getRequestEventIDAndRoomID(r *http.Request) (eventID, roomID uuid.UUID, err
 	return eventIDUID, roomIDUID, nil
 }
 
-// getRequestPlayerIdAndRoomId extract player_id and room_id from query params
-func getRequestPlayerIDAndEventID(r *http.Request) (playerID, eventID uuid.UUID, err error) {
-	playerIDStr := r.URL.Query().Get("player_id")
-	eventIDStr := r.URL.Query().Get("event_id")
-	playerIDUID, err := uuid.Parse(playerIDStr)
+type StreamTicketResponse struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+var (
+	errTicketScope    = errors.New("stream ticket is for another stream")
+	errTicketRequired = errors.New("stream ticket is required")
+)
+
+// streamTicket verifies the `ticket` query param and checks it was issued for this event and,
+// for room streams, this room. Spectator streams pass uuid.Nil as roomID and accept any
+// ticket for the event. In dev auth mode a missing ticket falls back to the query params.
+func (hr *HandlerRepo) streamTicket(r *http.Request, eventID, roomID uuid.UUID) (auth.StreamTicket, error) {
+	ticketStr := r.URL.Query().Get("ticket")
+	if ticketStr == "" {
+		if claims, ok := devClaims(r); ok && hr.devAuth {
+			return auth.StreamTicket{UserID: claims.ID, EventID: eventID.String(), RoomID: roomID.String(), GuildID: claims.GuildID}, nil
+		}
+		return auth.StreamTicket{}, errTicketRequired
+	}
+
+	ticket, err := hr.tickets.Verify(ticketStr)
 	if err != nil {
-		return uuid.UUID{}, uuid.UUID{}, err
+		return auth.StreamTicket{}, err
 	}
 
-	eventIDUID, err := uuid.Parse(eventIDStr)
+	if ticket.EventID != eventID.String() || (roomID != uuid.Nil && ticket.RoomID != roomID.String()) {
+		return auth.StreamTicket{}, errTicketScope
+	}
+	return ticket, nil
+}
+
+// IssueRoomTicketHandler gives a registered player a short-lived ticket to open the room's
+// leaderboard stream
+func (hr *HandlerRepo) IssueRoomTicketHandler(w http.ResponseWriter, r *http.Request) {
+	eventID, roomID, err := getRequestEventIDAndRoomID(r)
 	if err != nil {
-		return uuid.UUID{}, uuid.UUID{}, err
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		hr.unauthorized(w, r)
+		return
+	}
+
+	room, err := hr.queries.GetRoomByID(r.Context(), toPgtypeUUID(roomID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			hr.notFound(w, r)
+			return
+		}
+		hr.serverError(w, r, err)
+		return
+	}
+	if room.EventID.Bytes != eventID {
+		hr.notFound(w, r)
+		return
+	}
+
+	registered, err := hr.playerRegisteredForRoom(r, claims, eventID, roomID)
+	if err != nil {
+		hr.serverError(w, r, err)
+		return
+	}
+	if !registered {
+		hr.logger.Warn("player is not registered for the room", "user_id", claims.ID, "room_id", roomID)
+		hr.forbidden(w, r)
+		return
 	}
 
-	return playerIDUID, eventIDUID, nil
+	hr.issueStreamTicket(w, r, auth.StreamTicket{
+		UserID:   claims.ID,
+		Username: claims.Username,
+		EventID:  eventID.String(),
+		RoomID:   roomID.String(),
+		GuildID:  claims.GuildID,
+	})
+}
+
+// IssueSpectateTicketHandler gives a ticket for the event's leaderboard stream. Restricted
+// events can only be watched by their participating guilds and platform admins.
+func (hr *HandlerRepo) IssueSpectateTicketHandler(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		hr.badRequest(w, r, errors.New("invalid event ID format"))
+		return
+	}
+
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		hr.unauthorized(w, r)
+		return
+	}
+
+	event, err := hr.queries.GetEventByID(r.Context(), toPgtypeUUID(eventID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			hr.notFound(w, r)
+			return
+		}
+		hr.serverError(w, r, err)
+		return
+	}
+
+	if event.SpectatorVisibility == SpectatorsRestricted && !auth.HasRole(claims, auth.RolePlatformAdmin) {
+		participating, err := hr.guildParticipates(r, claims, eventID)
+		if err != nil {
+			hr.serverError(w, r, err)
+			return
+		}
+		if !participating {
+			hr.forbidden(w, r)
+			return
+		}
+	}
+
+	hr.issueStreamTicket(w, r, auth.StreamTicket{
+		UserID:   claims.ID,
+		Username: claims.Username,
+		EventID:  eventID.String(),
+	})
+}
+
+func (hr *HandlerRepo) issueStreamTicket(w http.ResponseWriter, r *http.Request, ticket auth.StreamTicket) {
+	ticketStr, expiresAt, err := hr.tickets.Issue(ticket)
+	if err != nil {
+		hr.serverError(w, r, err)
+		return
+	}
+
+	err = response.JSON(w, response.JSONResponseParameters{
+		Status:  http.StatusCreated,
+		Success: true,
+		Msg:     "Stream ticket issued",
+		Data:    StreamTicketResponse{Ticket: ticketStr, ExpiresAt: expiresAt},
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+	}
+}
+
+// playerRegisteredForRoom reports whether the player already joined the room or their guild
+// was assigned to it
+func (hr *HandlerRepo) playerRegisteredForRoom(r *http.Request, claims *jwt.UserClaims, eventID, roomID uuid.UUID) (bool, error) {
+	userID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return false, nil
+	}
+
+	_, err = hr.queries.GetRoomPlayer(r.Context(), store.GetRoomPlayerParams{
+		RoomID: toPgtypeUUID(roomID),
+		UserID: toPgtypeUUID(userID),
+	})
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return false, err
+	}
+
+	participant, found, err := hr.guildParticipant(r, claims, eventID)
+	if err != nil || !found {
+		return false, err
+	}
+	return participant.RoomID.Valid && participant.RoomID.Bytes == roomID, nil
+}
+
+func (hr *HandlerRepo) guildParticipates(r *http.Request, claims *jwt.UserClaims, eventID uuid.UUID) (bool, error) {
+	_, found, err := hr.guildParticipant(r, claims, eventID)
+	return found, err
+}
+
+func (hr *HandlerRepo) guildParticipant(r *http.Request, claims *jwt.UserClaims, eventID uuid.UUID) (store.EventGuildParticipant, bool, error) {
+	guildID, err := uuid.Parse(claims.GuildID)
+	if err != nil {
+		return store.EventGuildParticipant{}, false, nil
+	}
+
+	participant, err := hr.queries.GetEventGuildParticipant(r.Context(), store.GetEventGuildParticipantParams{
+		EventID: toPgtypeUUID(eventID),
+		GuildID: toPgtypeUUID(guildID),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return store.EventGuildParticipant{}, false, nil
+	}
+	return participant, err == nil, err
 }
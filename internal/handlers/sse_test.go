@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/auth"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamTicket(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	hr := &HandlerRepo{logger: logger, tickets: auth.NewTicketIssuer([]byte("ticket-secret"), time.Minute)}
+
+	eventID, roomID, otherRoomID := uuid.New(), uuid.New(), uuid.New()
+	playerID := uuid.New().String()
+
+	issue := func(ticket auth.StreamTicket) string {
+		s, _, err := hr.tickets.Issue(ticket)
+		require.NoError(t, err)
+		return s
+	}
+	request := func(query string) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/events/e/rooms/r/leaderboard?"+query, nil)
+	}
+
+	roomTicket := issue(auth.StreamTicket{UserID: playerID, Username: "alice", EventID: eventID.String(), RoomID: roomID.String()})
+
+	t.Run("Room ticket binds the player", func(t *testing.T) {
+		got, err := hr.streamTicket(request("ticket="+roomTicket), eventID, roomID)
+		require.NoError(t, err)
+		assert.Equal(t, playerID, got.UserID)
+		assert.Equal(t, "alice", got.Username)
+	})
+
+	t.Run("Room ticket for another room", func(t *testing.T) {
+		_, err := hr.streamTicket(request("ticket="+roomTicket), eventID, otherRoomID)
+		assert.ErrorIs(t, err, errTicketScope)
+	})
+
+	t.Run("Spectator ticket cannot join a room", func(t *testing.T) {
+		spectate := issue(auth.StreamTicket{UserID: playerID, EventID: eventID.String()})
+		_, err := hr.streamTicket(request("ticket="+spectate), eventID, roomID)
+		assert.ErrorIs(t, err, errTicketScope)
+
+		_, err = hr.streamTicket(request("ticket="+spectate), eventID, uuid.Nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Query param identity needs dev mode", func(t *testing.T) {
+		_, err := hr.streamTicket(request("connected_player_id="+playerID), eventID, roomID)
+		assert.ErrorIs(t, err, errTicketRequired)
+
+		dev := &HandlerRepo{logger: logger, tickets: hr.tickets, devAuth: true}
+		got, err := dev.streamTicket(request("connected_player_id="+playerID), eventID, roomID)
+		require.NoError(t, err)
+		assert.Equal(t, playerID, got.UserID)
+	})
+}
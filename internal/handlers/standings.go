@@ -0,0 +1,461 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/audit"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/request"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// MaxScoreAdjustment bounds the points a single adjustment may add or take away
+	MaxScoreAdjustment = 10000
+	maxStandingsReason = 500
+	// uniqueViolation is the Postgres error code of a duplicate key
+	uniqueViolation = "23505"
+)
+
+var (
+	ErrRejudgeScope            = errors.New("event_id or problem_id is required")
+	ErrAdjustmentPoints        = fmt.Errorf("points must be non-zero and at most %d either way", MaxScoreAdjustment)
+	ErrStandingsReason         = fmt.Errorf("a reason of at most %d characters is required", maxStandingsReason)
+	ErrDisqualificationTarget  = errors.New("exactly one of user_id or guild_id is required")
+	ErrAlreadyDisqualified     = errors.New("already disqualified from this event")
+	ErrStandingsRoomWrongEvent = errors.New("room does not belong to the event")
+)
+
+type RejudgeRequest struct {
+	EventID   string `json:"event_id,omitempty"`
+	ProblemID string `json:"problem_id,omitempty"`
+}
+
+// RejudgeHandler runs submissions again against the current test cases, for one problem, for
+// every problem of an event or for one problem in one event. It answers right away, the
+// rejudge runs in the background and updates the leaderboards of the rooms it changes.
+func (hr *HandlerRepo) RejudgeHandler(w http.ResponseWriter, r *http.Request) {
+	var req RejudgeRequest
+	if err := request.DecodeJSON(w, r, &req); err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	eventID, err := optionalUUID(req.EventID)
+	if err != nil {
+		hr.badRequest(w, r, errors.New("invalid event_id"))
+		return
+	}
+	problemID, err := optionalUUID(req.ProblemID)
+	if err != nil {
+		hr.badRequest(w, r, errors.New("invalid problem_id"))
+		return
+	}
+	if !eventID.Valid && !problemID.Valid {
+		hr.badRequest(w, r, ErrRejudgeScope)
+		return
+	}
+
+	problems := []uuid.UUID{problemID.Bytes}
+	if !problemID.Valid {
+		eventProblems, err := hr.queries.GetEventCodeProblems(r.Context(), eventID)
+		if err != nil {
+			hr.serverError(w, r, err)
+			return
+		}
+
+		problems = problems[:0]
+		for _, p := range eventProblems {
+			problems = append(problems, p.CodeProblemID.Bytes)
+		}
+	}
+
+	// keep the caller and request ID for the audit trail, but not the request's deadline
+	ctx := context.WithoutCancel(r.Context())
+	go func() {
+		for _, problem := range problems {
+			if _, err := hr.eventHub.Rejudge(ctx, problem, eventID.Bytes); err != nil {
+				hr.logger.Error("rejudge failed", "problem_id", problem, "event_id", req.EventID, "error", err)
+			}
+		}
+	}()
+
+	err = response.JSON(w, response.JSONResponseParameters{
+		Status:  http.StatusAccepted,
+		Data:    map[string]int{"problems": len(problems)},
+		Success: true,
+		Msg:     "Rejudge started",
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+	}
+}
+
+type ScoreAdjustmentRequest struct {
+	UserID string `json:"user_id"`
+	// Points are added to the player's score, negative points are a penalty
+	Points int    `json:"points"`
+	Reason string `json:"reason"`
+}
+
+func (req ScoreAdjustmentRequest) validate() (uuid.UUID, string, error) {
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return uuid.Nil, "", errors.New("invalid user_id")
+	}
+	if req.Points == 0 || req.Points > MaxScoreAdjustment || req.Points < -MaxScoreAdjustment {
+		return uuid.Nil, "", ErrAdjustmentPoints
+	}
+	reason, err := standingsReason(req.Reason)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	return userID, reason, nil
+}
+
+type ScoreAdjustmentResponse struct {
+	ID        string    `json:"id"`
+	RoomID    string    `json:"room_id"`
+	UserID    string    `json:"user_id"`
+	Points    int32     `json:"points"`
+	Reason    string    `json:"reason"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func toScoreAdjustmentResponse(a store.ScoreAdjustment) ScoreAdjustmentResponse {
+	return ScoreAdjustmentResponse{
+		ID:        uuidString(a.ID),
+		RoomID:    uuidString(a.RoomID),
+		UserID:    uuidString(a.UserID),
+		Points:    a.Points,
+		Reason:    a.Reason,
+		CreatedBy: uuidString(a.CreatedBy),
+		CreatedAt: a.CreatedAt.Time,
+	}
+}
+
+// AdjustScoreHandler gives a player of a room points, or takes them away, with a reason.
+// Adjustments are kept when scores are recalculated, e.g. by a rejudge.
+func (hr *HandlerRepo) AdjustScoreHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, err := callerID(r)
+	if err != nil {
+		hr.unauthorized(w, r)
+		return
+	}
+
+	eventID, roomID, err := getRequestEventIDAndRoomID(r)
+	if err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	var req ScoreAdjustmentRequest
+	if err := request.DecodeJSON(w, r, &req); err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	userID, reason, err := req.validate()
+	if err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	room, err := hr.queries.GetRoomByID(r.Context(), toPgtypeUUID(roomID))
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		hr.notFound(w, r)
+		return
+	case err != nil:
+		hr.serverError(w, r, err)
+		return
+	}
+	if room.EventID.Bytes != eventID {
+		hr.badRequest(w, r, ErrStandingsRoomWrongEvent)
+		return
+	}
+
+	adjustment, err := hr.queries.CreateScoreAdjustment(r.Context(), store.CreateScoreAdjustmentParams{
+		EventID:   toPgtypeUUID(eventID),
+		RoomID:    toPgtypeUUID(roomID),
+		UserID:    toPgtypeUUID(userID),
+		Points:    int32(req.Points),
+		Reason:    reason,
+		CreatedBy: toPgtypeUUID(adminID),
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+		return
+	}
+
+	hr.audit.Record(r.Context(), audit.Entry{
+		Action:     audit.ActionRoomPlayerScoreAdjusted,
+		TargetType: audit.TargetRoomPlayer,
+		TargetID:   audit.RoomPlayerTarget(roomID, userID),
+		EventID:    eventID,
+		After:      map[string]any{"adjustment_id": uuidString(adjustment.ID), "points": req.Points, "reason": reason},
+	})
+
+	if err := hr.eventHub.RefreshStandings(r.Context(), eventID, roomID); err != nil {
+		hr.serverError(w, r, err)
+		return
+	}
+
+	err = response.JSON(w, response.JSONResponseParameters{
+		Status:  http.StatusCreated,
+		Data:    toScoreAdjustmentResponse(adjustment),
+		Success: true,
+		Msg:     "Score adjusted successfully",
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+	}
+}
+
+// GetScoreAdjustmentsHandler lists the adjustments made in an event, newest first
+func (hr *HandlerRepo) GetScoreAdjustmentsHandler(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		hr.badRequest(w, r, errors.New("invalid event ID in URL"))
+		return
+	}
+
+	adjustments, err := hr.queries.ListScoreAdjustmentsByEvent(r.Context(), toPgtypeUUID(eventID))
+	if err != nil {
+		hr.serverError(w, r, err)
+		return
+	}
+
+	data := make([]ScoreAdjustmentResponse, 0, len(adjustments))
+	for _, a := range adjustments {
+		data = append(data, toScoreAdjustmentResponse(a))
+	}
+
+	err = response.JSON(w, response.JSONResponseParameters{
+		Status:  http.StatusOK,
+		Data:    data,
+		Success: true,
+		Msg:     "Score adjustments retrieved successfully",
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+	}
+}
+
+type DisqualificationRequest struct {
+	// UserID disqualifies one player, GuildID every player of a guild
+	UserID  string `json:"user_id,omitempty"`
+	GuildID string `json:"guild_id,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+func (req DisqualificationRequest) validate() (userID, guildID pgtype.UUID, reason string, err error) {
+	if (req.UserID == "") == (req.GuildID == "") {
+		return userID, guildID, "", ErrDisqualificationTarget
+	}
+	if userID, err = optionalUUID(req.UserID); err != nil {
+		return userID, guildID, "", errors.New("invalid user_id")
+	}
+	if guildID, err = optionalUUID(req.GuildID); err != nil {
+		return userID, guildID, "", errors.New("invalid guild_id")
+	}
+	reason, err = standingsReason(req.Reason)
+	return userID, guildID, reason, err
+}
+
+type DisqualificationResponse struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id,omitempty"`
+	GuildID   string    `json:"guild_id,omitempty"`
+	Reason    string    `json:"reason"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func toDisqualificationResponse(d store.Disqualification) DisqualificationResponse {
+	return DisqualificationResponse{
+		ID:        uuidString(d.ID),
+		UserID:    uuidString(d.UserID),
+		GuildID:   uuidString(d.GuildID),
+		Reason:    d.Reason,
+		CreatedBy: uuidString(d.CreatedBy),
+		CreatedAt: d.CreatedAt.Time,
+	}
+}
+
+// DisqualifyHandler disqualifies a player or a guild from an event. Their players keep their
+// place on the room leaderboards with no score and the guild leaves the guild leaderboard.
+func (hr *HandlerRepo) DisqualifyHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, err := callerID(r)
+	if err != nil {
+		hr.unauthorized(w, r)
+		return
+	}
+
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		hr.badRequest(w, r, errors.New("invalid event ID in URL"))
+		return
+	}
+
+	var req DisqualificationRequest
+	if err := request.DecodeJSON(w, r, &req); err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	userID, guildID, reason, err := req.validate()
+	if err != nil {
+		hr.badRequest(w, r, err)
+		return
+	}
+
+	_, err = hr.queries.GetEventByID(r.Context(), toPgtypeUUID(eventID))
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		hr.notFound(w, r)
+		return
+	case err != nil:
+		hr.serverError(w, r, err)
+		return
+	}
+
+	dq, err := hr.queries.CreateDisqualification(r.Context(), store.CreateDisqualificationParams{
+		EventID:   toPgtypeUUID(eventID),
+		UserID:    userID,
+		GuildID:   guildID,
+		Reason:    reason,
+		CreatedBy: toPgtypeUUID(adminID),
+	})
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == uniqueViolation:
+		hr.errorMessage(w, r, http.StatusConflict, ErrAlreadyDisqualified.Error(), nil)
+		return
+	case err != nil:
+		hr.serverError(w, r, err)
+		return
+	}
+
+	hr.audit.Record(r.Context(), audit.Entry{
+		Action:     audit.ActionDisqualificationCreated,
+		TargetType: audit.TargetDisqualification,
+		TargetID:   uuidString(dq.ID),
+		EventID:    eventID,
+		After:      toDisqualificationResponse(dq),
+	})
+
+	if err := hr.eventHub.RefreshEventStandings(r.Context(), eventID); err != nil {
+		hr.serverError(w, r, err)
+		return
+	}
+
+	err = response.JSON(w, response.JSONResponseParameters{
+		Status:  http.StatusCreated,
+		Data:    toDisqualificationResponse(dq),
+		Success: true,
+		Msg:     "Disqualified successfully",
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+	}
+}
+
+// GetDisqualificationsHandler lists the players and guilds disqualified from an event
+func (hr *HandlerRepo) GetDisqualificationsHandler(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		hr.badRequest(w, r, errors.New("invalid event ID in URL"))
+		return
+	}
+
+	dqs, err := hr.queries.ListDisqualificationsByEvent(r.Context(), toPgtypeUUID(eventID))
+	if err != nil {
+		hr.serverError(w, r, err)
+		return
+	}
+
+	data := make([]DisqualificationResponse, 0, len(dqs))
+	for _, d := range dqs {
+		data = append(data, toDisqualificationResponse(d))
+	}
+
+	err = response.JSON(w, response.JSONResponseParameters{
+		Status:  http.StatusOK,
+		Data:    data,
+		Success: true,
+		Msg:     "Disqualifications retrieved successfully",
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+	}
+}
+
+// ReinstateHandler lifts a disqualification and restores the scores it took away
+func (hr *HandlerRepo) ReinstateHandler(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "event_id"))
+	if err != nil {
+		hr.badRequest(w, r, errors.New("invalid event ID in URL"))
+		return
+	}
+	dqID, err := uuid.Parse(chi.URLParam(r, "disqualification_id"))
+	if err != nil {
+		hr.badRequest(w, r, errors.New("invalid disqualification ID in URL"))
+		return
+	}
+
+	dq, err := hr.queries.DeleteDisqualification(r.Context(), store.DeleteDisqualificationParams{
+		ID:      toPgtypeUUID(dqID),
+		EventID: toPgtypeUUID(eventID),
+	})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		hr.notFound(w, r)
+		return
+	case err != nil:
+		hr.serverError(w, r, err)
+		return
+	}
+
+	hr.audit.Record(r.Context(), audit.Entry{
+		Action:     audit.ActionDisqualificationDeleted,
+		TargetType: audit.TargetDisqualification,
+		TargetID:   dqID.String(),
+		EventID:    eventID,
+		Before:     toDisqualificationResponse(dq),
+	})
+
+	if err := hr.eventHub.RefreshEventStandings(r.Context(), eventID); err != nil {
+		hr.serverError(w, r, err)
+		return
+	}
+
+	err = response.JSON(w, response.JSONResponseParameters{
+		Status:  http.StatusOK,
+		Success: true,
+		Msg:     "Disqualification lifted successfully",
+	})
+	if err != nil {
+		hr.serverError(w, r, err)
+	}
+}
+
+// standingsReason trims the reason given for a standings change and checks it is set
+func standingsReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > maxStandingsReason {
+		return "", ErrStandingsReason
+	}
+	return reason, nil
+}
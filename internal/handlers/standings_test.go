@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreAdjustmentRequest(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("Valid adjustment", func(t *testing.T) {
+		id, reason, err := ScoreAdjustmentRequest{UserID: userID.String(), Points: -20, Reason: "  late penalty "}.validate()
+		require.NoError(t, err)
+		assert.Equal(t, userID, id)
+		assert.Equal(t, "late penalty", reason)
+	})
+
+	t.Run("Invalid adjustments", func(t *testing.T) {
+		_, _, err := ScoreAdjustmentRequest{UserID: "nope", Points: 10, Reason: "x"}.validate()
+		assert.Error(t, err)
+
+		for _, points := range []int{0, MaxScoreAdjustment + 1, -MaxScoreAdjustment - 1} {
+			_, _, err = ScoreAdjustmentRequest{UserID: userID.String(), Points: points, Reason: "x"}.validate()
+			assert.ErrorIs(t, err, ErrAdjustmentPoints, points)
+		}
+
+		for _, reason := range []string{"", "   ", strings.Repeat("a", maxStandingsReason+1)} {
+			_, _, err = ScoreAdjustmentRequest{UserID: userID.String(), Points: 10, Reason: reason}.validate()
+			assert.ErrorIs(t, err, ErrStandingsReason)
+		}
+	})
+}
+
+func TestDisqualificationRequest(t *testing.T) {
+	id := uuid.New()
+
+	t.Run("Player or guild", func(t *testing.T) {
+		userID, guildID, reason, err := DisqualificationRequest{UserID: id.String(), Reason: "cheating"}.validate()
+		require.NoError(t, err)
+		assert.Equal(t, toPgtypeUUID(id), userID)
+		assert.False(t, guildID.Valid)
+		assert.Equal(t, "cheating", reason)
+
+		userID, guildID, _, err = DisqualificationRequest{GuildID: id.String(), Reason: "shared accounts"}.validate()
+		require.NoError(t, err)
+		assert.False(t, userID.Valid)
+		assert.Equal(t, toPgtypeUUID(id), guildID)
+	})
+
+	t.Run("Exactly one target", func(t *testing.T) {
+		_, _, _, err := DisqualificationRequest{Reason: "cheating"}.validate()
+		assert.ErrorIs(t, err, ErrDisqualificationTarget)
+
+		_, _, _, err = DisqualificationRequest{UserID: id.String(), GuildID: id.String(), Reason: "cheating"}.validate()
+		assert.ErrorIs(t, err, ErrDisqualificationTarget)
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		_, _, _, err := DisqualificationRequest{GuildID: "nope", Reason: "cheating"}.validate()
+		assert.Error(t, err)
+
+		_, _, _, err = DisqualificationRequest{UserID: id.String()}.validate()
+		assert.ErrorIs(t, err, ErrStandingsReason)
+	})
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -21,15 +22,16 @@ const (
 )
 
 var (
-	ErrLanguageNotFound error = errors.New("Invalid programming language")
-	ErrInvalidProblem   error = errors.New("Invalid problem")
-	ErrWrongSyntax      error = errors.New("Wrong syntax")
+	ErrInvalidProblem error = errors.New("Invalid problem")
+	ErrRoomNotInEvent error = errors.New("Room does not belong to the event")
 )
 
 type SubmissionRequest struct {
 	ProblemID string `json:"problem_id"`
 	Code      string `json:"code"`
 	Language  string `json:"language"`
+	// Sections fill the problem's named code sections, e.g. helper types
+	Sections map[string]string `json:"sections,omitempty"`
 }
 
 type SubmissionResponse struct {
@@ -39,6 +41,8 @@ type SubmissionResponse struct {
 	Success       bool             `json:"success"`
 	Error         executor.CodeErr `json:"error"`
 	ExecutionTime string           `json:"execution_time_ms"`
+	CPUTimeMs     int64            `json:"cpu_time_ms"`
+	PeakMemoryKB  int64            `json:"peak_memory_kb"`
 }
 
 // SubmitSolutionHandler will compile and run test cases of a solution for a code problem
@@ -50,11 +54,9 @@ func (hr *HandlerRepo) SubmitSolutionHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// get player_id through query param in development stage
-	playerIDStr := r.URL.Query().Get("player_id")
-	playerID, err := uuid.Parse(playerIDStr)
+	playerID, err := callerID(r)
 	if err != nil {
-		hr.badRequest(w, r, errors.New("failed to parse player_id"))
+		hr.unauthorized(w, r)
 		return
 	}
 
@@ -67,18 +69,13 @@ func (hr *HandlerRepo) SubmitSolutionHandler(w http.ResponseWriter, r *http.Requ
 	ctx, cancel := context.WithTimeout(context.Background(), DefaultQueryTimeoutSecond)
 	defer cancel()
 
-	normalizedLang, found := executor.NormalizeLanguage(req.Language)
+	langSpec, found := hr.languages.Resolve(req.Language)
 	if !found {
 		hr.logger.Warn("programming language not found", "lang", req.Language)
-		hr.badRequest(w, r, ErrLanguageNotFound)
-		return
-	}
-
-	lang, err := hr.queries.GetLanguageByName(ctx, normalizedLang)
-	if err != nil {
-		hr.logger.Error("failed to get language", "lang", normalizedLang)
+		hr.buildError(w, r, executor.NewLanguageUnsupportedError(req.Language))
 		return
 	}
+	lang := langSpec.Language()
 
 	problem, err := hr.queries.GetCodeProblemLanguageDetail(ctx, store.GetCodeProblemLanguageDetailParams{
 		CodeProblemID: toPgtypeUUID(problemIDUID),
@@ -98,10 +95,19 @@ func (hr *HandlerRepo) SubmitSolutionHandler(w http.ResponseWriter, r *http.Requ
 	}
 
 	// combine problem's driver code with user's code
-	finalCode, err := hr.codeBuilder.Build(normalizedLang, problem.DriverCode, req.Code)
-	if err == executor.ErrParsed {
-		hr.logger.Warn("Wrong syntax")
-		hr.badRequest(w, r, ErrWrongSyntax)
+	build, err := hr.codeBuilder.Build(executor.BuildRequest{
+		Language:        langSpec,
+		DriverCode:      problem.DriverCode,
+		UserCode:        req.Code,
+		Sections:        req.Sections,
+		Mode:            executor.SolutionMode(problem.SolutionMode),
+		RequiredSymbols: problem.RequiredSymbols,
+		MaxCodeLength:   int(problem.MaxCodeLength.Int32),
+	})
+	var buildErr *executor.BuildError
+	if errors.As(err, &buildErr) {
+		hr.logger.Warn("code rejected", "kind", buildErr.Kind, "message", buildErr.Message)
+		hr.buildError(w, r, buildErr)
 		return
 	}
 
@@ -111,19 +117,37 @@ func (hr *HandlerRepo) SubmitSolutionHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	hr.logger.Info("Code built successfully", "final_code", finalCode)
+	hr.logger.Info("Code built successfully", "final_code", build.Code)
 
 	s, err := hr.queries.CreateSubmission(r.Context(), store.CreateSubmissionParams{
 		UserID:        toPgtypeUUID(playerID),
 		LanguageID:    lang.ID,
 		CodeProblemID: toPgtypeUUID(problemIDUID),
 		CodeSubmitted: req.Code,
+		Sections:      executor.EncodeSections(req.Sections),
 		Status:        store.SubmissionStatusPending,
 	})
 
-	result := hr.worker.ExecuteJob(lang, finalCode, testCases)
+	meta := executor.JobMeta{
+		PlayerID:      playerID,
+		Priority:      executor.PriorityPractice,
+		BatchMode:     problem.BatchMode,
+		TimeLimit:     time.Duration(problem.TimeConstraintMs) * time.Millisecond,
+		MemoryLimitKB: int64(problem.SpaceConstraintMb) * 1024,
+	}
+	result := hr.worker.ExecuteJob(meta, lang, build, testCases)
+	if !result.Judged() {
+		// a server problem is not a verdict, the submission stays pending
+		hr.logger.Warn("submission could not be judged", "submission_id", uuidString(s.ID), "err", result.Error)
+		hr.errorMessage(w, r, http.StatusServiceUnavailable, result.Message, nil)
+		return
+	}
+
+	// the job may have waited in the queue longer than the query timeout above
+	updateCtx, updateCancel := context.WithTimeout(context.Background(), DefaultQueryTimeoutSecond)
+	defer updateCancel()
 
-	err = hr.updateSubmissionStatus(ctx, s.ID, result)
+	err = hr.updateSubmissionStatus(updateCtx, s.ID, result)
 	if err != nil {
 		hr.logger.Error("failed to update submission status", "err", err)
 		hr.serverError(w, r, ErrInternalServer)
@@ -139,6 +163,8 @@ func (hr *HandlerRepo) SubmitSolutionHandler(w http.ResponseWriter, r *http.Requ
 			Success:       result.Success,
 			Error:         result.Error,
 			ExecutionTime: result.ExecutionTime,
+			CPUTimeMs:     result.CPUTimeMs,
+			PeakMemoryKB:  result.PeakMemoryKB,
 		},
 		Success: true,
 		Msg:     "solution submitted successfully.",
@@ -160,16 +186,18 @@ func (hr *HandlerRepo) updateSubmissionStatus(ctx context.Context, submissionID
 			status = store.SubmissionStatusCompilationError
 		case executor.FailTestCase:
 			status = store.SubmissionStatusWrongAnswer
+		case executor.TimeLimitExceeded, executor.MemoryLimitExceeded:
+			status = store.SubmissionStatusLimitExceed
 		default:
-			// 3. Add a default case to catch nil or unknown errors.
-			// This prevents the zero-value bug.
-			status = store.SubmissionStatusRuntimeError // A safe default for unknown failures.
+			return fmt.Errorf("result is not a verdict: %w", result.Error)
 		}
 	}
 
-	_, err := hr.queries.UpdateSubmissionStatus(ctx, store.UpdateSubmissionStatusParams{
-		ID:     submissionID,
-		Status: status,
+	_, err := hr.queries.UpdateSubmissionResult(ctx, store.UpdateSubmissionResultParams{
+		ID:              submissionID,
+		Status:          status,
+		ExecutionTimeMs: pgtype.Int4{Int32: int32(result.CPUTimeMs), Valid: result.CPUTimeMs > 0 || result.Success},
+		MemoryUsedKb:    pgtype.Int4{Int32: int32(result.PeakMemoryKB), Valid: result.PeakMemoryKB > 0},
 	})
 	if err != nil {
 		return err
@@ -179,17 +207,9 @@ func (hr *HandlerRepo) updateSubmissionStatus(ctx context.Context, submissionID
 }
 
 func (hr *HandlerRepo) SubmitSolutionInRoomHandler(w http.ResponseWriter, r *http.Request) {
-	// claims, ok := r.Context().Value("asd").(*jwt.UserClaims)
-	// if !ok {
-	// 	// hr.unauthorizedResponse(w, r)
-	// 	return
-	// }
-
-	// get player_id through query param on dev stage.
-	playerIDStr := r.URL.Query().Get("player_id")
-	playerID, err := uuid.Parse(playerIDStr)
+	playerID, err := callerID(r)
 	if err != nil {
-		hr.badRequest(w, r, errors.New("invalid user ID in token"))
+		hr.unauthorized(w, r)
 		return
 	}
 
@@ -225,6 +245,10 @@ func (hr *HandlerRepo) SubmitSolutionInRoomHandler(w http.ResponseWriter, r *htt
 		hr.notFound(w, r)
 		return
 	}
+	if roomHub.EventID != eventID {
+		hr.badRequest(w, r, ErrRoomNotInEvent)
+		return
+	}
 
 	submissionEvent := events.SolutionSubmitted{
 		PlayerID:      playerID,
@@ -232,6 +256,7 @@ func (hr *HandlerRepo) SubmitSolutionInRoomHandler(w http.ResponseWriter, r *htt
 		RoomID:        roomID,
 		ProblemID:     problemID,
 		Code:          reqPayload.Code,
+		Sections:      reqPayload.Sections,
 		Language:      reqPayload.Language,
 		SubmittedTime: time.Now(),
 	}
@@ -10,8 +10,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/audit"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/events"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/integrity"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -19,6 +21,8 @@ import (
 
 const (
 	DefaultQueryTimeoutSecond = 10 * time.Second
+	// DefaultProblemPoints is what solving a problem scores when the event sets no score for it
+	DefaultProblemPoints = 50
 )
 
 // event-based
@@ -35,6 +39,8 @@ type EventHub struct {
 	Mu            sync.RWMutex
 	leaderboardMu sync.Mutex // Protects leaderboard calculation
 	codeBuilder   executor.CodeBuilder
+	languages     *executor.LanguageRegistry
+	audit         *audit.Recorder
 
 	GuildUpdateChan  chan uuid.UUID
 	EventListeners   map[uuid.UUID]map[uuid.UUID]chan<- events.SseEvent // eventID -> map[listenerID] channel
@@ -47,22 +53,26 @@ type RoomHub struct {
 	Events        chan any                             // Events channel is what happened in the room
 	Listerners    map[uuid.UUID]chan<- events.SseEvent // Players connected to this RoomHub
 	codeBuilder   executor.CodeBuilder
+	languages     *executor.LanguageRegistry
 	worker        *executor.WorkerPool
 	logger        *slog.Logger
 	queries       *store.Queries
 	Mu            sync.RWMutex // Protects Listerners map
 	leaderboardMu sync.Mutex   // Protects leaderboard calculation
+	audit         *audit.Recorder
 
 	guildUpdateChan chan<- uuid.UUID
 }
 
-func NewEventHub(queries *store.Queries, logger *slog.Logger, codeBuilder executor.CodeBuilder, worker *executor.WorkerPool) *EventHub {
+func NewEventHub(queries *store.Queries, logger *slog.Logger, codeBuilder executor.CodeBuilder, worker *executor.WorkerPool, languages *executor.LanguageRegistry, recorder *audit.Recorder) *EventHub {
 	e := EventHub{
 		worker:          worker,
 		logger:          logger,
 		queries:         queries,
 		Rooms:           make(map[uuid.UUID]*RoomHub),
 		codeBuilder:     codeBuilder,
+		languages:       languages,
+		audit:           recorder,
 		GuildUpdateChan: make(chan uuid.UUID, 100), // Buffered channel
 		EventListeners:  make(map[uuid.UUID]map[uuid.UUID]chan<- events.SseEvent),
 	}
@@ -94,7 +104,7 @@ func NewEventHub(queries *store.Queries, logger *slog.Logger, codeBuilder execut
 	return &e
 }
 
-func newRoomHub(eventID, roomId uuid.UUID, queries *store.Queries, worker *executor.WorkerPool, logger *slog.Logger, codeBuilder executor.CodeBuilder, guildUpdateChan chan<- uuid.UUID) *RoomHub {
+func newRoomHub(eventID, roomId uuid.UUID, queries *store.Queries, worker *executor.WorkerPool, logger *slog.Logger, codeBuilder executor.CodeBuilder, languages *executor.LanguageRegistry, recorder *audit.Recorder, guildUpdateChan chan<- uuid.UUID) *RoomHub {
 	return &RoomHub{
 		RoomID:          roomId,
 		EventID:         eventID, // Set the eventID
@@ -106,6 +116,8 @@ func newRoomHub(eventID, roomId uuid.UUID, queries *store.Queries, worker *execu
 		leaderboardMu:   sync.Mutex{},
 		worker:          worker,
 		codeBuilder:     codeBuilder,
+		languages:       languages,
+		audit:           recorder,
 		guildUpdateChan: guildUpdateChan, // Set the notification channel
 	}
 }
@@ -117,7 +129,7 @@ func (h *EventHub) GetRoomById(roomID uuid.UUID) *RoomHub {
 }
 
 func (e *EventHub) CreateRoom(eventID, roomID uuid.UUID, queries *store.Queries) *RoomHub {
-	r := newRoomHub(eventID, roomID, queries, e.worker, e.logger, e.codeBuilder, e.GuildUpdateChan)
+	r := newRoomHub(eventID, roomID, queries, e.worker, e.logger, e.codeBuilder, e.languages, e.audit, e.GuildUpdateChan)
 	e.Mu.Lock()
 	e.Rooms[roomID] = r
 	e.Mu.Unlock()
@@ -131,12 +143,15 @@ func (e *EventHub) Start() {
 
 		ctx, cancel := context.WithTimeout(context.Background(), DefaultQueryTimeoutSecond)
 
-		// TODO: You will need a new SQLc query to recalculate the total score for each guild
-		// based on the sum of their players' scores across all rooms in the event.
-		// For now, we assume you have a way to get the updated leaderboard.
+		// rank the guilds again from their players' scores in every room of the event
+		err := e.queries.SnapshotGuildLeaderboard(ctx, toPgtypeUUID(eventID))
+		if err != nil {
+			e.logger.Error("failed to snapshot guild leaderboard for event", "event_id", eventID, "error", err)
+			cancel()
+			continue
+		}
 
-		// Fetch the latest guild leaderboard data from the database
-		guildEntries, err := e.queries.GetGuildLeaderboardByEvent(ctx, toPgtypeUUID(eventID))
+		guildEntries, err := e.queries.GetLatestGuildLeaderboardByEvent(ctx, toPgtypeUUID(eventID))
 		if err != nil {
 			e.logger.Error("failed to get guild leaderboard for event", "event_id", eventID, "error", err)
 			cancel()
@@ -202,6 +217,14 @@ func (r *RoomHub) Start() {
 			if err := r.processPlayerLeft(e); err != nil {
 				r.logger.Error("failed to process player left event", "error", err)
 			}
+		case events.IntegritySignalsReported:
+			if err := r.processIntegritySignals(e); err != nil {
+				r.logger.Error("failed to process integrity signals event", "error", err)
+			}
+		case events.StandingsChanged:
+			if err := r.processStandingsChanged(e); err != nil {
+				r.logger.Error("failed to process standings changed event", "error", err)
+			}
 		case events.RoomDeleted:
 			if err := r.processRoomDeleted(e); err != nil {
 				r.logger.Error("failed to process room deleted event", "error", err)
@@ -295,38 +318,32 @@ func (r *RoomHub) dispatchEventToPlayer(e events.SseEvent, playerID uuid.UUID) {
 	}(listener, playerID)
 }
 
+// rejectSubmission tells the player why their code was rejected before it was run
+func (r *RoomHub) rejectSubmission(event events.SolutionSubmitted, buildErr *executor.BuildError) {
+	r.dispatchEventToPlayer(events.SseEvent{
+		EventType: events.SUBMISSION_REJECTED,
+		Data: events.SubmissionRejected{
+			ProblemID: event.ProblemID,
+			Language:  event.Language,
+			Kind:      string(buildErr.Kind),
+			Message:   buildErr.Message,
+			Findings:  buildErr.Findings,
+		},
+	}, event.PlayerID)
+}
+
 // TODO: Rewrite processSolutionSubmitted and processSolutionResult
 func (r *RoomHub) processSolutionSubmitted(event events.SolutionSubmitted) error {
 	ctx, cancel := context.WithTimeout(context.Background(), DefaultQueryTimeoutSecond)
 	defer cancel()
 
-	normalizedLang, found := executor.NormalizeLanguage(event.Language)
+	langSpec, found := r.languages.Resolve(event.Language)
 	if !found {
-		r.logger.Warn("lang not found")
-		return errors.New("language not found")
-	}
-
-	lang, err := r.queries.GetLanguageByName(ctx, normalizedLang)
-	if err != nil {
-		r.logger.Error("error", "lang", event.Language)
-		return err
-	}
-
-	submission, err := r.queries.CreateSubmission(ctx, store.CreateSubmissionParams{
-		UserID:        toPgtypeUUID(event.PlayerID),
-		CodeProblemID: toPgtypeUUID(event.ProblemID),
-		LanguageID:    lang.ID,
-		RoomID:        toPgtypeUUID(event.RoomID),
-		CodeSubmitted: event.Code,
-		Status:        store.SubmissionStatusPending,
-	})
-
-	if err != nil {
-		r.logger.Error("failed to create submission", "err", err)
-		return err
+		r.logger.Warn("lang not found", "lang", event.Language)
+		r.rejectSubmission(event, executor.NewLanguageUnsupportedError(event.Language))
+		return nil
 	}
-
-	event.SubmissionID = submission.ID.Bytes
+	lang := langSpec.Language()
 
 	problem, err := r.queries.GetCodeProblemLanguageDetail(ctx, store.GetCodeProblemLanguageDetailParams{
 		CodeProblemID: toPgtypeUUID(event.ProblemID),
@@ -343,18 +360,59 @@ func (r *RoomHub) processSolutionSubmitted(event events.SolutionSubmitted) error
 	}
 
 	// combine problem's driver code with user's code
-	finalCode, err := r.codeBuilder.Build(normalizedLang, problem.DriverCode, event.Code)
+	build, err := r.codeBuilder.Build(executor.BuildRequest{
+		Language:        langSpec,
+		DriverCode:      problem.DriverCode,
+		UserCode:        event.Code,
+		Sections:        event.Sections,
+		Mode:            executor.SolutionMode(problem.SolutionMode),
+		RequiredSymbols: problem.RequiredSymbols,
+		MaxCodeLength:   int(problem.MaxCodeLength.Int32),
+	})
+	var buildErr *executor.BuildError
+	if errors.As(err, &buildErr) {
+		r.logger.Warn("code rejected", "player_id", event.PlayerID, "kind", buildErr.Kind, "message", buildErr.Message)
+		r.rejectSubmission(event, buildErr)
+		return nil
+	}
 	if err != nil {
 		r.logger.Error("failed to build code", "err", err)
 		return err
 	}
 
-	r.logger.Info("Code built successfully", "final_code", finalCode)
+	r.logger.Info("Code built successfully", "final_code", build.Code)
+
+	// rejected code is not stored, like in the practice flow
+	submission, err := r.queries.CreateSubmission(ctx, store.CreateSubmissionParams{
+		UserID:        toPgtypeUUID(event.PlayerID),
+		CodeProblemID: toPgtypeUUID(event.ProblemID),
+		LanguageID:    lang.ID,
+		RoomID:        toPgtypeUUID(event.RoomID),
+		CodeSubmitted: event.Code,
+		Sections:      executor.EncodeSections(event.Sections),
+		Status:        store.SubmissionStatusPending,
+	})
+	if err != nil {
+		r.logger.Error("failed to create submission", "err", err)
+		return err
+	}
 
-	result := r.worker.ExecuteJob(lang, finalCode, testCases)
+	event.SubmissionID = submission.ID.Bytes
 
-	solutionResult := generateSolutionResult(event, result)
-	r.Events <- solutionResult
+	// run the job outside of the room's event loop so that submissions of different
+	// players in the room are scheduled fairly instead of strictly one after another
+	go func() {
+		meta := executor.JobMeta{
+			PlayerID:      event.PlayerID,
+			RoomID:        event.RoomID,
+			Priority:      executor.PriorityEvent,
+			BatchMode:     problem.BatchMode,
+			TimeLimit:     time.Duration(problem.TimeConstraintMs) * time.Millisecond,
+			MemoryLimitKB: int64(problem.SpaceConstraintMb) * 1024,
+		}
+		result := r.worker.ExecuteJob(meta, lang, build, testCases)
+		r.Events <- generateSolutionResult(event, result)
+	}()
 
 	return nil
 }
@@ -362,23 +420,46 @@ func (r *RoomHub) processSolutionSubmitted(event events.SolutionSubmitted) error
 func generateSolutionResult(solutionSubmitted events.SolutionSubmitted, jobResult executor.Result) events.SolutionResult {
 	var solutionResult *events.SolutionResult = &events.SolutionResult{
 		SolutionSubmitted: solutionSubmitted,
-		Score:             50, //change later
+		Score:             DefaultProblemPoints,
 		Status:            events.Accepted,
 		Message:           "Solution is correct!",
+		ExecutionTimeMs:   jobResult.CPUTimeMs,
+		PeakMemoryKB:      jobResult.PeakMemoryKB,
+	}
+
+	if !jobResult.Judged() {
+		solutionResult.Score = 0
+		solutionResult.Status = events.NotJudged
+		solutionResult.Message = jobResult.Message
+		return *solutionResult
 	}
 
 	switch jobResult.Error {
 	case executor.CompileError:
-		solutionResult.Message = fmt.Sprintf("compiled error: %v\n", jobResult.Message)
+		solutionResult.Message = fmt.Sprintf("compiled error: %v\n%s", jobResult.Message, jobResult.Stderr)
 		solutionResult.Status = events.CompilationError
 
 	case executor.RunTimeError:
-		solutionResult.Message = fmt.Sprintf("runtime error: %v\n", jobResult.Message)
+		solutionResult.Message = fmt.Sprintf("runtime error: %v\n%s", jobResult.Message, jobResult.Stderr)
 		solutionResult.Status = events.RuntimeError
 
 	case executor.FailTestCase:
 		solutionResult.Message = fmt.Sprintf("test case failed: %v\n", jobResult.Message)
 		solutionResult.Status = events.WrongAnswer
+
+	case executor.TimeLimitExceeded:
+		solutionResult.Message = fmt.Sprintf("time limit exceeded: %v\n", jobResult.Message)
+		solutionResult.Status = events.TimeLimitExceeded
+
+	case executor.MemoryLimitExceeded:
+		solutionResult.Message = fmt.Sprintf("memory limit exceeded: %v\n", jobResult.Message)
+		solutionResult.Status = events.MemoryLimitExceeded
+
+	default:
+		if !jobResult.Success {
+			solutionResult.Message = jobResult.Message
+			solutionResult.Status = events.RuntimeError
+		}
 	}
 
 	return *solutionResult
@@ -396,6 +477,34 @@ func (r *RoomHub) processSolutionResult(event events.SolutionResult) error {
 
 	r.logger.Info("processing solution result...", "submission_id", event.SolutionSubmitted.SubmissionID)
 
+	// a server problem is not the player's fault, the submission stays pending
+	if event.Status == events.NotJudged {
+		r.logger.Warn("submission could not be judged", "submission_id", event.SolutionSubmitted.SubmissionID, "message", event.Message)
+		r.dispatchEventToPlayer(events.SseEvent{
+			EventType: events.SUBMISSION_REJECTED,
+			Data: events.SubmissionRejected{
+				ProblemID: event.SolutionSubmitted.ProblemID,
+				Language:  event.SolutionSubmitted.Language,
+				Kind:      events.SubmissionNotJudged,
+				Message:   event.Message,
+			},
+		}, event.SolutionSubmitted.PlayerID)
+		return nil
+	}
+
+	// persist the verdict and measured runtime first, the leaderboard tie-break reads them
+	_, err := r.queries.UpdateSubmissionResult(ctx, store.UpdateSubmissionResultParams{
+		ID:              toPgtypeUUID(event.SolutionSubmitted.SubmissionID),
+		Status:          toSubmissionStatus(event.Status),
+		ExecutionTimeMs: pgtype.Int4{Int32: int32(event.ExecutionTimeMs), Valid: event.ExecutionTimeMs > 0 || event.Status == events.Accepted},
+		MemoryUsedKb:    pgtype.Int4{Int32: int32(event.PeakMemoryKB), Valid: event.PeakMemoryKB > 0},
+	})
+	if err != nil {
+		r.logger.Error("failed to update submission result", "error", err,
+			"submission_id", event.SolutionSubmitted.SubmissionID)
+		return err
+	}
+
 	if event.Status != events.Accepted {
 		r.logger.Info("solution failed", "event", event)
 		sseEvent := events.SseEvent{
@@ -403,41 +512,53 @@ func (r *RoomHub) processSolutionResult(event events.SolutionResult) error {
 			Data:      fmt.Sprintf("status:%v,message:%v", event.Status, event.Message),
 		}
 
-		_, err := r.queries.UpdateSubmissionStatus(ctx, store.UpdateSubmissionStatusParams{
-			ID:     toPgtypeUUID(event.SolutionSubmitted.SubmissionID),
-			Status: store.SubmissionStatusWrongAnswer,
-		})
-
-		if err != nil {
-			r.logger.Error("failed to update submission status to wrong", "error", err,
-				"submission_id", event.SolutionSubmitted.SubmissionID)
-			return err
-		}
-
 		go r.dispatchEventToPlayer(sseEvent, event.SolutionSubmitted.PlayerID)
 
 		return nil
 	}
 
-	err := r.queries.AddRoomPlayerScore(ctx, store.AddRoomPlayerScoreParams{
-		PointsToAdd: int32(event.Score),
-		UserID:      toPgtypeUUID(event.SolutionSubmitted.PlayerID),
-		RoomID:      toPgtypeUUID(event.SolutionSubmitted.RoomID),
-	})
-
+	// read the score first so the audit entry shows what the points changed
+	playerParams := store.GetRoomPlayerParams{
+		RoomID: toPgtypeUUID(event.SolutionSubmitted.RoomID),
+		UserID: toPgtypeUUID(event.SolutionSubmitted.PlayerID),
+	}
+	before, err := r.queries.GetRoomPlayer(ctx, playerParams)
 	if err != nil {
+		r.logger.Error("failed to get player before adding score", "err", err)
+		return err
+	}
+
+	// scores are derived from the accepted submissions, so solving a problem again adds nothing
+	if err := r.recalculateScores(ctx); err != nil {
 		r.logger.Error("failed to add score",
 			"err", err)
 		return err
 	}
 
-	select {
-	case r.guildUpdateChan <- r.EventID:
-		r.logger.Info("Sent guild leaderboard update notification", "event_id", r.EventID)
-	default:
-		r.logger.Warn("Guild update channel is full, notification dropped", "event_id", r.EventID)
+	after, err := r.queries.GetRoomPlayer(ctx, playerParams)
+	if err != nil {
+		r.logger.Error("failed to get player after adding score", "err", err)
+		return err
 	}
 
+	if after.Score != before.Score {
+		r.audit.Record(ctx, audit.Entry{
+			Action:     audit.ActionRoomPlayerScoreAdded,
+			TargetType: audit.TargetRoomPlayer,
+			TargetID:   audit.RoomPlayerTarget(event.SolutionSubmitted.RoomID, event.SolutionSubmitted.PlayerID),
+			EventID:    r.EventID,
+			Before:     map[string]any{"score": before.Score},
+			After: map[string]any{
+				"score":         after.Score,
+				"points":        after.Score - before.Score,
+				"submission_id": event.SolutionSubmitted.SubmissionID,
+				"problem_id":    event.SolutionSubmitted.ProblemID,
+			},
+		})
+	}
+
+	r.notifyGuildUpdate()
+
 	// Recalculate leaderboard after score update
 	if err := r.calculateLeaderboard(ctx); err != nil {
 		r.logger.Error("failed to calculate leaderboard after solution result", "error", err)
@@ -464,19 +585,23 @@ func (r *RoomHub) processSolutionResult(event events.SolutionResult) error {
 
 	go r.dispatchEvent(leaderboardUpdated)
 
-	// mark the problem as solved
-	_, err = r.queries.UpdateSubmissionStatus(ctx, store.UpdateSubmissionStatusParams{
-		ID:     toPgtypeUUID(event.SolutionSubmitted.SubmissionID),
-		Status: store.SubmissionStatusAccepted,
-	})
+	return nil
+}
 
-	if err != nil {
-		r.logger.Error("failed to update submission status to accepted", "error", err,
-			"submission_id", event.SolutionSubmitted.SubmissionID)
-		return err
+// toSubmissionStatus maps a judge verdict to the status stored on the submission
+func toSubmissionStatus(status events.JudgeStatus) store.SubmissionStatus {
+	switch status {
+	case events.Accepted:
+		return store.SubmissionStatusAccepted
+	case events.WrongAnswer:
+		return store.SubmissionStatusWrongAnswer
+	case events.CompilationError:
+		return store.SubmissionStatusCompilationError
+	case events.TimeLimitExceeded, events.MemoryLimitExceeded:
+		return store.SubmissionStatusLimitExceed
+	default:
+		return store.SubmissionStatusRuntimeError
 	}
-
-	return nil
 }
 
 // Helper method to check if player is in room
@@ -502,11 +627,12 @@ func (r *RoomHub) playerInRoom(ctx context.Context, roomID, playerID uuid.UUID)
 }
 
 // Helper method to add player to room
-func (r *RoomHub) addPlayerToRoom(ctx context.Context, roomID, playerID uuid.UUID, playerName string) error {
+func (r *RoomHub) addPlayerToRoom(ctx context.Context, roomID, playerID uuid.UUID, playerName string, guildID uuid.UUID) error {
 	createParams := store.CreateRoomPlayerParams{
 		RoomID:   toPgtypeUUID(roomID),
 		UserID:   toPgtypeUUID(playerID),
 		Username: playerName,
+		GuildID:  pgtype.UUID{Bytes: guildID, Valid: guildID != uuid.Nil},
 	}
 
 	_, err := r.queries.CreateRoomPlayer(ctx, createParams)
@@ -525,6 +651,10 @@ type RoomLeaderboardEntry struct {
 	PlayerName string `json:"player_name"`
 	Score      int32  `json:"score"`
 	Place      int32  `json:"place"`
+	Flagged    bool   `json:"flagged"`
+	FlagReason string `json:"flag_reason,omitempty"`
+	// Disqualified players stay on the leaderboard with no score
+	Disqualified bool `json:"disqualified"`
 }
 
 // calculateLeaderboard recalculates and updates player ranks in a single, atomic, and concurrency-safe operation.
@@ -555,9 +685,12 @@ func (r *RoomHub) getRoomLeaderboardEntries(ctx context.Context) ([]RoomLeaderbo
 
 	for _, rp := range roomPlayers {
 		entries = append(entries, RoomLeaderboardEntry{
-			PlayerName: rp.Username,
-			Score:      rp.Score,
-			Place:      rp.Place.Int32,
+			PlayerName:   rp.Username,
+			Score:        rp.Score,
+			Place:        rp.Place.Int32,
+			Flagged:      rp.Flagged,
+			FlagReason:   rp.FlagReason.String,
+			Disqualified: rp.Disqualified,
 		})
 	}
 
@@ -574,13 +707,26 @@ func (r *RoomHub) processPlayerJoined(event events.PlayerJoined) error {
 			"playerID", event.PlayerID,
 			"room", event.RoomID)
 
-		playerName := "grpc_called"
+		playerName := event.Username
+		if playerName == "" {
+			playerName = "player"
+		}
 
-		err := r.addPlayerToRoom(ctx, event.RoomID, event.PlayerID, playerName)
+		err := r.addPlayerToRoom(ctx, event.RoomID, event.PlayerID, playerName, event.GuildID)
 		if err != nil {
 			r.logger.Error("failed to add player to room", "error", err)
 			return err
 		}
+
+		// the player row is recreated on every join, so restore a flag earned earlier
+		if _, err := r.evaluateIntegrity(ctx, event.PlayerID); err != nil {
+			r.logger.Error("failed to evaluate player integrity after join", "error", err)
+		}
+
+		// and the score of problems solved before leaving, or the player's disqualification
+		if err := r.recalculateScores(ctx); err != nil {
+			r.logger.Error("failed to recalculate scores after join", "error", err)
+		}
 	}
 
 	// Recalculate leaderboard after a player joins
@@ -656,6 +802,126 @@ func (r *RoomHub) processPlayerLeft(event events.PlayerLeft) error {
 	return nil
 }
 
+// processIntegritySignals records the signals a player reported and flags them on the
+// room leaderboard once they break one of the event's integrity rules
+func (r *RoomHub) processIntegritySignals(event events.IntegritySignalsReported) error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultQueryTimeoutSecond)
+	defer cancel()
+
+	if !r.playerInRoom(ctx, event.RoomID, event.PlayerID) {
+		return fmt.Errorf("player %s is not in room %s", event.PlayerID, event.RoomID)
+	}
+
+	// one statement, so a report is stored entirely or not at all
+	params := store.CreateIntegritySignalsParams{
+		EventID:     toPgtypeUUID(r.EventID),
+		RoomID:      toPgtypeUUID(event.RoomID),
+		UserID:      toPgtypeUUID(event.PlayerID),
+		Kinds:       make([]string, len(event.Signals)),
+		Values:      make([]int32, len(event.Signals)),
+		OccurredAts: make([]pgtype.Timestamptz, len(event.Signals)),
+	}
+	for i, s := range event.Signals {
+		params.Kinds[i] = string(s.Kind)
+		params.Values[i] = int32(s.Value)
+		params.OccurredAts[i] = pgtype.Timestamptz{Time: s.OccurredAt, Valid: true}
+	}
+	if err := r.queries.CreateIntegritySignals(ctx, params); err != nil {
+		return err
+	}
+
+	changed, err := r.evaluateIntegrity(ctx, event.PlayerID)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+
+	entries, err := r.getRoomLeaderboardEntries(ctx)
+	if err != nil {
+		return err
+	}
+
+	go r.dispatchEvent(events.SseEvent{
+		EventType: events.LEADERBOARD_UPDATED,
+		Data:      entries,
+	})
+
+	return nil
+}
+
+// evaluateIntegrity checks every signal of a player in the room against the event's
+// rules and updates their flag. It reports whether the flag changed.
+func (r *RoomHub) evaluateIntegrity(ctx context.Context, playerID uuid.UUID) (bool, error) {
+	player, err := r.queries.GetRoomPlayer(ctx, store.GetRoomPlayerParams{
+		RoomID: toPgtypeUUID(r.RoomID),
+		UserID: toPgtypeUUID(playerID),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	rules := integrity.DefaultRules
+	event, err := r.queries.GetEventByID(ctx, toPgtypeUUID(r.EventID))
+	if err == nil {
+		rules, err = integrity.ParseRules(event.IntegrityRules)
+		if err != nil {
+			r.logger.Warn("invalid integrity rules, using the defaults", "event_id", r.EventID, "error", err)
+			rules = integrity.DefaultRules
+		}
+	}
+
+	stored, err := r.queries.GetPlayerIntegritySignals(ctx, store.GetPlayerIntegritySignalsParams{
+		RoomID: toPgtypeUUID(r.RoomID),
+		UserID: toPgtypeUUID(playerID),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	signals := make([]integrity.Signal, len(stored))
+	for i, s := range stored {
+		signals[i] = integrity.Signal{Kind: integrity.Kind(s.Kind), Value: int(s.Value), OccurredAt: s.OccurredAt.Time}
+	}
+
+	var reason pgtype.Text
+	rule, flagged := rules.Evaluate(signals)
+	if flagged {
+		reason = pgtype.Text{String: rule.Reason(), Valid: true}
+	}
+	if player.Flagged == flagged && player.FlagReason == reason {
+		return false, nil
+	}
+
+	err = r.queries.UpdateRoomPlayerFlag(ctx, store.UpdateRoomPlayerFlagParams{
+		RoomID:     toPgtypeUUID(r.RoomID),
+		UserID:     toPgtypeUUID(playerID),
+		Flagged:    flagged,
+		FlagReason: reason,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	r.audit.Record(ctx, audit.Entry{
+		Action:     audit.ActionRoomPlayerFlagChanged,
+		TargetType: audit.TargetRoomPlayer,
+		TargetID:   audit.RoomPlayerTarget(r.RoomID, playerID),
+		EventID:    r.EventID,
+		Before:     map[string]any{"flagged": player.Flagged, "flag_reason": player.FlagReason.String},
+		After:      map[string]any{"flagged": flagged, "flag_reason": reason.String},
+	})
+
+	r.logger.Warn("player integrity flag changed",
+		"room_id", r.RoomID,
+		"player_id", playerID,
+		"flagged", flagged,
+		"reason", reason.String)
+
+	return true, nil
+}
+
 // TODO: Complete this
 func (r *RoomHub) processRoomDeleted(event events.RoomDeleted) error {
 	r.Mu.Lock()
@@ -671,9 +937,23 @@ func (r *RoomHub) processRoomDeleted(event events.RoomDeleted) error {
 		Data:      data,
 	}
 
+	// keep what the room was for the audit trail
+	room, roomErr := r.queries.GetRoomByID(ctx, toPgtypeUUID(event.RoomID))
+
 	err := r.queries.DeleteRoom(ctx, toPgtypeUUID(event.RoomID))
 	if err != nil {
 		r.logger.Error("failed to delete room from database", "error", err)
+	} else {
+		entry := audit.Entry{
+			Action:     audit.ActionRoomDeleted,
+			TargetType: audit.TargetRoom,
+			TargetID:   event.RoomID.String(),
+			EventID:    r.EventID,
+		}
+		if roomErr == nil {
+			entry.Before = room
+		}
+		r.audit.Record(ctx, entry)
 	}
 
 	r.logger.Info("room deleted", "roomID", event.RoomID)
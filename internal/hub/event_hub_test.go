@@ -0,0 +1,30 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/events"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateSolutionResult(t *testing.T) {
+	t.Run("Verdicts", func(t *testing.T) {
+		assert.Equal(t, events.Accepted, generateSolutionResult(events.SolutionSubmitted{}, executor.Result{Success: true}).Status)
+		assert.Equal(t, events.WrongAnswer, generateSolutionResult(events.SolutionSubmitted{}, executor.Result{Error: executor.FailTestCase}).Status)
+		assert.Equal(t, events.RuntimeError, generateSolutionResult(events.SolutionSubmitted{}, executor.Result{Error: executor.RunTimeError}).Status)
+	})
+
+	t.Run("Server problems are not verdicts", func(t *testing.T) {
+		for _, jobResult := range []executor.Result{
+			{Error: executor.ErrQueueFull, Message: "Server is busy, please try again later."},
+			{Error: executor.ErrNoContainerAvailable, Message: "All execution environments are busy, please try again later."},
+			{Message: "Failed to set up execution environment."},
+		} {
+			result := generateSolutionResult(events.SolutionSubmitted{}, jobResult)
+			assert.Equal(t, events.NotJudged, result.Status)
+			assert.Equal(t, jobResult.Message, result.Message)
+			assert.Zero(t, result.Score)
+		}
+	})
+}
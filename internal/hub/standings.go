@@ -0,0 +1,280 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/audit"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/events"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// recalculateScores derives the score of every player in the room from their accepted
+// submissions, manual adjustments and disqualifications
+func (r *RoomHub) recalculateScores(ctx context.Context) error {
+	return r.queries.RecalculateRoomScores(ctx, store.RecalculateRoomScoresParams{
+		RoomID:        toPgtypeUUID(r.RoomID),
+		DefaultPoints: DefaultProblemPoints,
+	})
+}
+
+// notifyGuildUpdate asks the event hub to rank the event's guilds again
+func (r *RoomHub) notifyGuildUpdate() {
+	select {
+	case r.guildUpdateChan <- r.EventID:
+		r.logger.Info("Sent guild leaderboard update notification", "event_id", r.EventID)
+	default:
+		r.logger.Warn("Guild update channel is full, notification dropped", "event_id", r.EventID)
+	}
+}
+
+func (r *RoomHub) processStandingsChanged(event events.StandingsChanged) error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultQueryTimeoutSecond)
+	defer cancel()
+
+	if err := r.recalculateScores(ctx); err != nil {
+		return err
+	}
+
+	if err := r.calculateLeaderboard(ctx); err != nil {
+		r.logger.Error("failed to calculate leaderboard after standings changed", "error", err)
+	}
+
+	r.notifyGuildUpdate()
+
+	entries, err := r.getRoomLeaderboardEntries(ctx)
+	if err != nil {
+		return err
+	}
+
+	go r.dispatchEvent(events.SseEvent{
+		EventType: events.LEADERBOARD_UPDATED,
+		Data:      entries,
+	})
+
+	r.logger.Info("standings recalculated", "room_id", event.RoomID)
+	return nil
+}
+
+// RefreshStandings recalculates the scores of a room after its verdicts, adjustments or
+// disqualifications changed. An active room does it on its own loop and broadcasts the new
+// leaderboard, any other room is updated in place.
+func (e *EventHub) RefreshStandings(ctx context.Context, eventID, roomID uuid.UUID) error {
+	if room := e.GetRoomById(roomID); room != nil {
+		select {
+		case room.Events <- events.StandingsChanged{RoomID: roomID}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	err := e.queries.RecalculateRoomScores(ctx, store.RecalculateRoomScoresParams{
+		RoomID:        toPgtypeUUID(roomID),
+		DefaultPoints: DefaultProblemPoints,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := e.queries.CalculateRoomLeaderboard(ctx, toPgtypeUUID(roomID)); err != nil {
+		return err
+	}
+
+	select {
+	case e.GuildUpdateChan <- eventID:
+	default:
+		e.logger.Warn("Guild update channel is full, notification dropped", "event_id", eventID)
+	}
+	return nil
+}
+
+// RefreshEventStandings refreshes the standings of every room of an event
+func (e *EventHub) RefreshEventStandings(ctx context.Context, eventID uuid.UUID) error {
+	rooms, err := e.queries.GetRoomsByEvent(ctx, toPgtypeUUID(eventID))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, room := range rooms {
+		if err := e.RefreshStandings(ctx, eventID, room.ID.Bytes); err != nil {
+			errs = append(errs, fmt.Errorf("room %s: %w", uuid.UUID(room.ID.Bytes), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RejudgeSummary counts what a rejudge did
+type RejudgeSummary struct {
+	Total   int `json:"total"`
+	Changed int `json:"changed"`
+	// Skipped submissions kept their verdict because they could not be built again
+	Skipped int `json:"skipped"`
+	// Failed submissions could not be judged, e.g. no worker was available, and kept their verdict too
+	Failed int `json:"failed"`
+}
+
+// Rejudge runs the judged submissions of a problem against its current test cases and
+// refreshes the standings of every room whose verdicts changed. When eventID is not uuid.Nil
+// only the submissions made in the event's rooms are rejudged.
+//
+// Jobs run one at a time in the practice tier, so live battles keep the workers.
+func (e *EventHub) Rejudge(ctx context.Context, problemID, eventID uuid.UUID) (RejudgeSummary, error) {
+	var summary RejudgeSummary
+
+	submissions, err := e.queries.ListSubmissionsForRejudge(ctx, store.ListSubmissionsForRejudgeParams{
+		CodeProblemID: toPgtypeUUID(problemID),
+		EventID:       pgtype.UUID{Bytes: eventID, Valid: eventID != uuid.Nil},
+	})
+	if err != nil {
+		return summary, err
+	}
+
+	testCases, err := e.queries.GetTestCasesByProblem(ctx, toPgtypeUUID(problemID))
+	if err != nil {
+		return summary, err
+	}
+
+	e.logger.Info("rejudge started", "problem_id", problemID, "event_id", eventID, "submissions", len(submissions))
+
+	// rooms whose standings need a refresh, mapped to their event
+	rooms := make(map[uuid.UUID]uuid.UUID)
+	for _, s := range submissions {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+		summary.Total++
+
+		status, err := e.rejudgeSubmission(ctx, problemID, s, testCases)
+		var buildErr *executor.BuildError
+		switch {
+		case errors.As(err, &buildErr):
+			e.logger.Warn("rejudged submission no longer builds, keeping its verdict",
+				"submission_id", uuid.UUID(s.ID.Bytes), "kind", buildErr.Kind, "message", buildErr.Message)
+			summary.Skipped++
+			continue
+		case err != nil:
+			e.logger.Error("failed to rejudge submission", "submission_id", uuid.UUID(s.ID.Bytes), "error", err)
+			summary.Failed++
+			continue
+		case status == s.Status:
+			continue
+		}
+
+		summary.Changed++
+		e.audit.Record(ctx, audit.Entry{
+			Action:     audit.ActionSubmissionRejudged,
+			TargetType: audit.TargetSubmission,
+			TargetID:   uuid.UUID(s.ID.Bytes).String(),
+			EventID:    s.EventID.Bytes,
+			Before:     map[string]any{"status": s.Status},
+			After:      map[string]any{"status": status},
+		})
+
+		if s.RoomID.Valid && s.EventID.Valid {
+			rooms[s.RoomID.Bytes] = s.EventID.Bytes
+		}
+	}
+
+	for roomID, roomEventID := range rooms {
+		if err := e.RefreshStandings(ctx, roomEventID, roomID); err != nil {
+			e.logger.Error("failed to refresh standings after rejudge", "room_id", roomID, "error", err)
+		}
+	}
+
+	e.audit.Record(ctx, audit.Entry{
+		Action:     audit.ActionProblemRejudged,
+		TargetType: audit.TargetCodeProblem,
+		TargetID:   problemID.String(),
+		EventID:    eventID,
+		After:      summary,
+	})
+
+	e.logger.Info("rejudge finished", "problem_id", problemID, "event_id", eventID,
+		"total", summary.Total, "changed", summary.Changed, "skipped", summary.Skipped, "failed", summary.Failed)
+	return summary, nil
+}
+
+// rejudgeResult turns the result of a rejudge job into a verdict. A job that could not run
+// is an error, so the submission keeps the verdict it had.
+func rejudgeResult(jobResult executor.Result) (events.SolutionResult, error) {
+	if !jobResult.Judged() {
+		if jobResult.Error != nil {
+			return events.SolutionResult{}, fmt.Errorf("job did not run: %w", jobResult.Error)
+		}
+		return events.SolutionResult{}, fmt.Errorf("job did not run: %s", jobResult.Message)
+	}
+	return generateSolutionResult(events.SolutionSubmitted{}, jobResult), nil
+}
+
+// rejudgeSubmission builds and runs one stored submission again and saves the new verdict
+func (e *EventHub) rejudgeSubmission(ctx context.Context, problemID uuid.UUID, s store.ListSubmissionsForRejudgeRow, testCases []store.TestCase) (store.SubmissionStatus, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, DefaultQueryTimeoutSecond)
+	defer cancel()
+
+	langSpec, found := e.languages.Resolve(s.LanguageName)
+	if !found {
+		return "", executor.NewLanguageUnsupportedError(s.LanguageName)
+	}
+	lang := langSpec.Language()
+
+	problem, err := e.queries.GetCodeProblemLanguageDetail(queryCtx, store.GetCodeProblemLanguageDetailParams{
+		CodeProblemID: toPgtypeUUID(problemID),
+		LanguageID:    lang.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sections, err := executor.DecodeSections(s.Sections)
+	if err != nil {
+		return "", err
+	}
+
+	build, err := e.codeBuilder.Build(executor.BuildRequest{
+		Language:        langSpec,
+		DriverCode:      problem.DriverCode,
+		UserCode:        s.CodeSubmitted,
+		Sections:        sections,
+		Mode:            executor.SolutionMode(problem.SolutionMode),
+		RequiredSymbols: problem.RequiredSymbols,
+		MaxCodeLength:   int(problem.MaxCodeLength.Int32),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	meta := executor.JobMeta{
+		PlayerID:      s.UserID.Bytes,
+		RoomID:        s.RoomID.Bytes,
+		Priority:      executor.PriorityPractice,
+		BatchMode:     problem.BatchMode,
+		TimeLimit:     time.Duration(problem.TimeConstraintMs) * time.Millisecond,
+		MemoryLimitKB: int64(problem.SpaceConstraintMb) * 1024,
+	}
+	result, err := rejudgeResult(e.worker.ExecuteJob(meta, lang, build, testCases))
+	if err != nil {
+		return "", err
+	}
+	status := toSubmissionStatus(result.Status)
+
+	queryCtx, cancel = context.WithTimeout(ctx, DefaultQueryTimeoutSecond)
+	defer cancel()
+
+	_, err = e.queries.UpdateSubmissionResult(queryCtx, store.UpdateSubmissionResultParams{
+		ID:              s.ID,
+		Status:          status,
+		ExecutionTimeMs: pgtype.Int4{Int32: int32(result.ExecutionTimeMs), Valid: result.ExecutionTimeMs > 0 || result.Status == events.Accepted},
+		MemoryUsedKb:    pgtype.Int4{Int32: int32(result.PeakMemoryKB), Valid: result.PeakMemoryKB > 0},
+	})
+	if err != nil {
+		return "", err
+	}
+	return status, nil
+}
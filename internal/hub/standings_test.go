@@ -0,0 +1,41 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/events"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRejudgeResult(t *testing.T) {
+	t.Run("Verdicts are kept", func(t *testing.T) {
+		tests := []struct {
+			jobResult executor.Result
+			want      events.JudgeStatus
+		}{
+			{executor.Result{Success: true}, events.Accepted},
+			{executor.Result{Error: executor.FailTestCase}, events.WrongAnswer},
+			{executor.Result{Error: executor.RunTimeError}, events.RuntimeError},
+			{executor.Result{Error: executor.TimeLimitExceeded}, events.TimeLimitExceeded},
+		}
+		for _, tt := range tests {
+			result, err := rejudgeResult(tt.jobResult)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result.Status)
+		}
+	})
+
+	t.Run("Jobs that did not run are errors", func(t *testing.T) {
+		_, err := rejudgeResult(executor.Result{Error: executor.ErrQueueFull, Message: "Server is busy, please try again later."})
+		assert.ErrorIs(t, err, executor.ErrQueueFull)
+
+		_, err = rejudgeResult(executor.Result{Error: executor.ErrNoContainerAvailable})
+		assert.ErrorIs(t, err, executor.ErrNoContainerAvailable)
+
+		// a crashed container is reported without one of the verdict errors
+		_, err = rejudgeResult(executor.Result{Message: "Failed to set up execution environment."})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,120 @@
+// Package integrity evaluates the anti-cheat signals clients report during live battles,
+// like large pastes or leaving the tab, against rules that flag suspicious players.
+package integrity
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Kind is the type of an integrity signal. What Value measures depends on the kind.
+type Kind string
+
+const (
+	// KindPaste is a paste into the editor, Value is the number of characters pasted
+	KindPaste Kind = "paste"
+	// KindFocusLost is the player leaving the battle tab or window, Value is how many
+	// milliseconds they were away
+	KindFocusLost Kind = "focus_lost"
+	// KindDevTools is the browser dev tools being opened, Value is unused
+	KindDevTools Kind = "devtools_opened"
+)
+
+func (k Kind) Valid() bool {
+	switch k {
+	case KindPaste, KindFocusLost, KindDevTools:
+		return true
+	}
+	return false
+}
+
+// Signal is one report of suspicious client behaviour
+type Signal struct {
+	Kind       Kind
+	Value      int
+	OccurredAt time.Time
+}
+
+// Rule flags a player once they reported Count signals of Kind with a value of at least
+// MinValue
+type Rule struct {
+	Kind     Kind `json:"kind"`
+	MinValue int  `json:"min_value,omitempty"`
+	Count    int  `json:"count"`
+}
+
+func (r Rule) matches(s Signal) bool {
+	return s.Kind == r.Kind && s.Value >= r.MinValue
+}
+
+// Reason explains the rule to admins and players looking at the leaderboard
+func (r Rule) Reason() string {
+	switch r.Kind {
+	case KindPaste:
+		return fmt.Sprintf("pasted %d+ characters %d times", r.MinValue, r.Count)
+	case KindFocusLost:
+		return fmt.Sprintf("left the battle for %ds+ %d times", r.MinValue/1000, r.Count)
+	case KindDevTools:
+		return fmt.Sprintf("opened dev tools %d times", r.Count)
+	}
+	return fmt.Sprintf("reported %s %d times", r.Kind, r.Count)
+}
+
+// Rules are checked in order, the first one broken flags the player
+type Rules []Rule
+
+// DefaultRules apply to events without their own rules
+var DefaultRules = Rules{
+	{Kind: KindPaste, MinValue: 200, Count: 2},
+	{Kind: KindFocusLost, MinValue: 30_000, Count: 3},
+	{Kind: KindDevTools, Count: 1},
+}
+
+func (rs Rules) Validate() error {
+	for i, r := range rs {
+		if !r.Kind.Valid() {
+			return fmt.Errorf("rule %d: unknown signal kind %q", i, r.Kind)
+		}
+		if r.Count < 1 {
+			return fmt.Errorf("rule %d: count must be at least 1", i)
+		}
+		if r.MinValue < 0 {
+			return fmt.Errorf("rule %d: min_value must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// Evaluate returns the first rule broken by signals
+func (rs Rules) Evaluate(signals []Signal) (Rule, bool) {
+	for _, r := range rs {
+		count := 0
+		for _, s := range signals {
+			if r.matches(s) {
+				count++
+			}
+		}
+		if count >= r.Count {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// ParseRules decodes the integrity_rules column of an event. Events without rules use
+// DefaultRules, an empty list turns auto-flagging off.
+func ParseRules(data []byte) (Rules, error) {
+	if len(data) == 0 {
+		return DefaultRules, nil
+	}
+
+	var rules Rules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
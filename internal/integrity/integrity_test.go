@@ -0,0 +1,66 @@
+package integrity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	rules := Rules{
+		{Kind: KindPaste, MinValue: 200, Count: 2},
+		{Kind: KindDevTools, Count: 1},
+	}
+
+	t.Run("Small pastes are ignored", func(t *testing.T) {
+		_, flagged := rules.Evaluate([]Signal{
+			{Kind: KindPaste, Value: 20},
+			{Kind: KindPaste, Value: 199},
+			{Kind: KindPaste, Value: 500},
+		})
+		assert.False(t, flagged)
+	})
+
+	t.Run("Rule is broken once the count is reached", func(t *testing.T) {
+		rule, flagged := rules.Evaluate([]Signal{
+			{Kind: KindPaste, Value: 300},
+			{Kind: KindFocusLost, Value: 60_000},
+			{Kind: KindPaste, Value: 200},
+		})
+		require.True(t, flagged)
+		assert.Equal(t, rules[0], rule)
+		assert.Equal(t, "pasted 200+ characters 2 times", rule.Reason())
+	})
+
+	t.Run("First broken rule wins", func(t *testing.T) {
+		rule, flagged := rules.Evaluate([]Signal{{Kind: KindDevTools}})
+		require.True(t, flagged)
+		assert.Equal(t, KindDevTools, rule.Kind)
+	})
+
+	t.Run("No rules never flag", func(t *testing.T) {
+		_, flagged := Rules{}.Evaluate([]Signal{{Kind: KindDevTools}})
+		assert.False(t, flagged)
+	})
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(nil)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRules, rules)
+
+	rules, err = ParseRules([]byte(`[]`))
+	require.NoError(t, err)
+	assert.Empty(t, rules, "an empty list turns auto-flagging off")
+
+	rules, err = ParseRules([]byte(`[{"kind": "focus_lost", "min_value": 10000, "count": 5}]`))
+	require.NoError(t, err)
+	assert.Equal(t, Rules{{Kind: KindFocusLost, MinValue: 10000, Count: 5}}, rules)
+
+	_, err = ParseRules([]byte(`[{"kind": "screenshot", "count": 1}]`))
+	assert.Error(t, err)
+
+	_, err = ParseRules([]byte(`[{"kind": "paste", "count": 0}]`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,410 @@
+// Package lexer splits source code into tokens, so that code can be inspected without
+// being fooled by comments and string literals.
+package lexer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Kind int
+
+const (
+	Ident    Kind = iota // identifiers and keywords
+	Number               // numeric literals
+	String               // string literals, including prefixes and quotes
+	Template             // JavaScript template literals, including backticks
+	Regexp               // JavaScript regular expression literals
+	Operator             // operators and punctuation
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Ident:
+		return "Ident"
+	case Number:
+		return "Number"
+	case String:
+		return "String"
+	case Template:
+		return "Template"
+	case Regexp:
+		return "Regexp"
+	default:
+		return "Operator"
+	}
+}
+
+// Token is a lexical token. Line and Column are 1-based, Column counts runes.
+type Token struct {
+	Kind   Kind
+	Text   string
+	Line   int
+	Column int
+}
+
+// Value returns the content of a string literal without its prefix and quotes.
+// For other tokens it returns Text.
+func (t Token) Value() string {
+	if t.Kind != String && t.Kind != Template {
+		return t.Text
+	}
+
+	text := strings.TrimLeftFunc(t.Text, unicode.IsLetter)
+	for _, quote := range []string{`"""`, `'''`, `"`, `'`, "`"} {
+		if len(text) >= 2*len(quote) && strings.HasPrefix(text, quote) && strings.HasSuffix(text, quote) {
+			return text[len(quote) : len(text)-len(quote)]
+		}
+	}
+	return text
+}
+
+// Syntax describes the lexical rules that differ between languages
+type Syntax struct {
+	LineComment    string
+	BlockComments  bool // /* ... */
+	TripleQuotes   bool // Python ''' and """ strings
+	StringPrefixes bool // Python r"", b"", f"" ...
+	Templates      bool // JavaScript `...${}...`
+	Regexps        bool // JavaScript /.../ literals
+	Lifetimes      bool // Rust 'a lifetimes and labels, scanned as identifiers
+}
+
+var (
+	Python     = Syntax{LineComment: "#", TripleQuotes: true, StringPrefixes: true}
+	JavaScript = Syntax{LineComment: "//", BlockComments: true, Templates: true, Regexps: true}
+	C          = Syntax{LineComment: "//", BlockComments: true} // C, C++ and Java
+	Rust       = Syntax{LineComment: "//", BlockComments: true, Lifetimes: true}
+)
+
+// Error is returned for source that cannot be tokenized, e.g. an unterminated string
+type Error struct {
+	Message string
+	Line    int
+	Column  int
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// operators are matched longest first
+var operators = []string{
+	">>>=", "...", "===", "!==", "**=", "<<=", ">>=", ">>>", "//=", "&&=", "||=", "??=",
+	"==", "!=", "<=", ">=", "&&", "||", "??", "?.", "=>", "++", "--", "**", "//", "<<", ">>",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "->", ":=",
+}
+
+// regexpKeywords are keywords after which a `/` starts a regular expression literal
+var regexpKeywords = map[string]bool{
+	"return": true, "typeof": true, "instanceof": true, "in": true, "of": true, "new": true,
+	"delete": true, "void": true, "throw": true, "case": true, "do": true, "else": true, "yield": true, "await": true,
+}
+
+type scanner struct {
+	syntax Syntax
+	src    string
+	pos    int
+	line   int
+	col    int
+	tokens []Token
+}
+
+// Tokenize splits src into tokens. Comments and whitespace are dropped.
+func Tokenize(src string, syntax Syntax) ([]Token, error) {
+	s := &scanner{syntax: syntax, src: src, line: 1, col: 1}
+
+	for s.pos < len(s.src) {
+		if err := s.next(); err != nil {
+			return s.tokens, err
+		}
+	}
+
+	return s.tokens, nil
+}
+
+func (s *scanner) errorf(line, col int, format string, args ...any) error {
+	return &Error{Message: fmt.Sprintf(format, args...), Line: line, Column: col}
+}
+
+// advance moves n bytes forward, keeping line and column up to date
+func (s *scanner) advance(n int) {
+	for _, r := range s.src[s.pos : s.pos+n] {
+		if r == '\n' {
+			s.line++
+			s.col = 1
+		} else {
+			s.col++
+		}
+	}
+	s.pos += n
+}
+
+func (s *scanner) emit(kind Kind, start, line, col int) {
+	s.tokens = append(s.tokens, Token{Kind: kind, Text: s.src[start:s.pos], Line: line, Column: col})
+}
+
+func (s *scanner) next() error {
+	rest := s.src[s.pos:]
+	r, size := utf8.DecodeRuneInString(rest)
+	start, line, col := s.pos, s.line, s.col
+
+	switch {
+	case unicode.IsSpace(r):
+		s.advance(size)
+		return nil
+
+	case s.syntax.LineComment != "" && strings.HasPrefix(rest, s.syntax.LineComment):
+		end := strings.IndexByte(rest, '\n')
+		if end < 0 {
+			end = len(rest)
+		}
+		s.advance(end)
+		return nil
+
+	case s.syntax.BlockComments && strings.HasPrefix(rest, "/*"):
+		end := strings.Index(rest[2:], "*/")
+		if end < 0 {
+			return s.errorf(line, col, "unterminated comment")
+		}
+		s.advance(end + 4)
+		return nil
+
+	case r == '\'' && s.syntax.Lifetimes && isLifetime(rest):
+		n := 1 + strings.IndexFunc(rest[1:], func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+		})
+		if n == 0 {
+			n = len(rest)
+		}
+		s.advance(n)
+		s.emit(Ident, start, line, col)
+		return nil
+
+	case r == '"' || r == '\'':
+		if err := s.scanString(); err != nil {
+			return err
+		}
+		s.emit(String, start, line, col)
+		return nil
+
+	case r == '`' && s.syntax.Templates:
+		if err := s.scanTemplate(); err != nil {
+			return err
+		}
+		s.emit(Template, start, line, col)
+		return nil
+
+	case r == '/' && s.syntax.Regexps && s.regexpAllowed():
+		if err := s.scanRegexp(); err != nil {
+			return err
+		}
+		s.emit(Regexp, start, line, col)
+		return nil
+
+	case unicode.IsLetter(r) || r == '_' || r == '$':
+		n := strings.IndexFunc(rest, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '$'
+		})
+		if n < 0 {
+			n = len(rest)
+		}
+		s.advance(n)
+
+		if s.syntax.StringPrefixes && isStringPrefix(rest[:n]) && s.pos < len(s.src) && (s.src[s.pos] == '"' || s.src[s.pos] == '\'') {
+			if err := s.scanString(); err != nil {
+				return err
+			}
+			s.emit(String, start, line, col)
+			return nil
+		}
+
+		s.emit(Ident, start, line, col)
+		return nil
+
+	case unicode.IsDigit(r) || (r == '.' && len(rest) > 1 && rest[1] >= '0' && rest[1] <= '9'):
+		n := strings.IndexFunc(rest, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.'
+		})
+		if n < 0 {
+			n = len(rest)
+		}
+		s.advance(n)
+		s.emit(Number, start, line, col)
+		return nil
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			s.advance(len(op))
+			s.emit(Operator, start, line, col)
+			return nil
+		}
+	}
+
+	s.advance(size)
+	s.emit(Operator, start, line, col)
+	return nil
+}
+
+func isStringPrefix(ident string) bool {
+	switch strings.ToLower(ident) {
+	case "r", "u", "b", "f", "br", "rb", "fr", "rf":
+		return true
+	}
+	return false
+}
+
+// isLifetime reports whether rest starts with a Rust lifetime such as 'a or 'outer
+// rather than a character literal such as 'a'
+func isLifetime(rest string) bool {
+	if len(rest) < 2 || !(unicode.IsLetter(rune(rest[1])) || rest[1] == '_') {
+		return false
+	}
+	return len(rest) < 3 || rest[2] != '\''
+}
+
+// scanString scans a quoted string starting at the current position
+func (s *scanner) scanString() error {
+	line, col := s.line, s.col
+	rest := s.src[s.pos:]
+
+	quote := rest[:1]
+	if s.syntax.TripleQuotes && (strings.HasPrefix(rest, `"""`) || strings.HasPrefix(rest, `'''`)) {
+		quote = rest[:3]
+	}
+
+	for i := len(quote); i < len(rest); i++ {
+		switch {
+		case rest[i] == '\\':
+			i++
+		case rest[i] == '\n' && len(quote) == 1:
+			return s.errorf(line, col, "unterminated string")
+		case strings.HasPrefix(rest[i:], quote):
+			s.advance(i + len(quote))
+			return nil
+		}
+	}
+
+	return s.errorf(line, col, "unterminated string")
+}
+
+// scanTemplate scans a template literal, skipping over nested ${...} expressions
+func (s *scanner) scanTemplate() error {
+	line, col := s.line, s.col
+	rest := s.src[s.pos:]
+
+	depth := 0
+	for i := 1; i < len(rest); i++ {
+		switch {
+		case rest[i] == '\\':
+			i++
+		case depth == 0 && strings.HasPrefix(rest[i:], "${"):
+			depth = 1
+			i++
+		case depth > 0 && rest[i] == '{':
+			depth++
+		case depth > 0 && rest[i] == '}':
+			depth--
+		case depth == 0 && rest[i] == '`':
+			s.advance(i + 1)
+			return nil
+		}
+	}
+
+	return s.errorf(line, col, "unterminated template literal")
+}
+
+// regexpAllowed reports whether a `/` at the current position starts a regular expression,
+// which is the case wherever an expression may begin
+func (s *scanner) regexpAllowed() bool {
+	if len(s.tokens) == 0 {
+		return true
+	}
+
+	prev := s.tokens[len(s.tokens)-1]
+	switch prev.Kind {
+	case Ident:
+		return regexpKeywords[prev.Text]
+	case Operator:
+		return prev.Text != ")" && prev.Text != "]" && prev.Text != "}" && prev.Text != "++" && prev.Text != "--"
+	default:
+		return false
+	}
+}
+
+// scanRegexp scans a regular expression literal and its flags
+func (s *scanner) scanRegexp() error {
+	line, col := s.line, s.col
+	rest := s.src[s.pos:]
+
+	inClass := false
+	for i := 1; i < len(rest); i++ {
+		switch {
+		case rest[i] == '\\':
+			i++
+		case rest[i] == '\n':
+			return s.errorf(line, col, "unterminated regular expression")
+		case rest[i] == '[':
+			inClass = true
+		case rest[i] == ']':
+			inClass = false
+		case rest[i] == '/' && !inClass:
+			i++
+			for i < len(rest) && unicode.IsLetter(rune(rest[i])) {
+				i++
+			}
+			s.advance(i)
+			return nil
+		}
+	}
+
+	return s.errorf(line, col, "unterminated regular expression")
+}
+
+// Interpolations returns the source of the expressions embedded in a template literal
+// (`${...}`) or a Python f-string (`{...}`), so they can be tokenized as code.
+func Interpolations(t Token) []string {
+	var open string
+	switch {
+	case t.Kind == Template:
+		open = "${"
+	case t.Kind == String && strings.ContainsAny(t.Text[:strings.IndexAny(t.Text, `"'`)], "fF"):
+		open = "{"
+	default:
+		return nil
+	}
+
+	body := t.Value()
+	var exprs []string
+	for {
+		i := strings.Index(body, open)
+		if i < 0 {
+			return exprs
+		}
+		body = body[i+len(open):]
+
+		// `{{` is an escaped brace in f-strings
+		if open == "{" && strings.HasPrefix(body, "{") {
+			body = body[1:]
+			continue
+		}
+
+		depth, end := 1, len(body)
+		for j := 0; j < len(body); j++ {
+			if body[j] == '{' {
+				depth++
+			} else if body[j] == '}' {
+				depth--
+				if depth == 0 {
+					end = j
+					break
+				}
+			}
+		}
+		exprs = append(exprs, body[:end])
+		body = body[min(end+1, len(body)):]
+	}
+}
@@ -0,0 +1,85 @@
+package lexer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func texts(tokens []Token) []string {
+	out := make([]string, len(tokens))
+	for i, t := range tokens {
+		out[i] = t.Text
+	}
+	return out
+}
+
+func TestTokenizePython(t *testing.T) {
+	t.Run("Comments are dropped and strings kept whole", func(t *testing.T) {
+		src := "x = 'import os'  # eval(x)\ny = r\"\\d+\"\n"
+		tokens, err := Tokenize(src, Python)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"x", "=", "'import os'", "y", "=", `r"\d+"`}, texts(tokens))
+		assert.Equal(t, String, tokens[2].Kind)
+		assert.Equal(t, "import os", tokens[2].Value())
+	})
+
+	t.Run("Positions", func(t *testing.T) {
+		tokens, err := Tokenize("def f():\n    return __import__('os')\n", Python)
+		require.NoError(t, err)
+		assert.Equal(t, Token{Kind: Ident, Text: "__import__", Line: 2, Column: 12}, tokens[6])
+	})
+
+	t.Run("Triple quoted strings span lines", func(t *testing.T) {
+		tokens, err := Tokenize("s = \"\"\"a\n'b'\n\"\"\"\nz", Python)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"s", "=", "\"\"\"a\n'b'\n\"\"\"", "z"}, texts(tokens))
+		assert.Equal(t, 4, tokens[3].Line)
+	})
+
+	t.Run("f-string expressions", func(t *testing.T) {
+		tokens, err := Tokenize(`f"{{x}} {eval('1')} {y:>3}"`, Python)
+		require.NoError(t, err)
+		require.Len(t, tokens, 1)
+		assert.Equal(t, []string{"eval('1')", "y:>3"}, Interpolations(tokens[0]))
+	})
+
+	t.Run("Unterminated string", func(t *testing.T) {
+		_, err := Tokenize("x = 'abc\ny = 1", Python)
+		var lexErr *Error
+		require.ErrorAs(t, err, &lexErr)
+		assert.Equal(t, 1, lexErr.Line)
+		assert.Equal(t, 5, lexErr.Column)
+	})
+}
+
+func TestTokenizeJavaScript(t *testing.T) {
+	t.Run("Comments, operators and regular expressions", func(t *testing.T) {
+		src := "/* require('fs') */ const r = /[/\"]+/g; // eval\nlet x = a / b === c;"
+		tokens, err := Tokenize(src, JavaScript)
+		require.NoError(t, err)
+		assert.Equal(t,
+			[]string{"const", "r", "=", `/[/"]+/g`, ";", "let", "x", "=", "a", "/", "b", "===", "c", ";"},
+			texts(tokens))
+		assert.Equal(t, Regexp, tokens[3].Kind)
+	})
+
+	t.Run("Template literals", func(t *testing.T) {
+		tokens, err := Tokenize("const s = `a ${ {b: 1}.b } ${process.exit()}`;", JavaScript)
+		require.NoError(t, err)
+		require.Len(t, tokens, 5)
+		assert.Equal(t, Template, tokens[3].Kind)
+		assert.Equal(t, []string{" {b: 1}.b ", "process.exit()"}, Interpolations(tokens[3]))
+	})
+}
+
+func TestTokenizeRust(t *testing.T) {
+	tokens, err := Tokenize("fn first<'a>(s: &'a str) -> char { 'x' } // 'y", Rust)
+	require.NoError(t, err)
+	assert.Equal(t,
+		[]string{"fn", "first", "<", "'a", ">", "(", "s", ":", "&", "'a", "str", ")", "->", "char", "{", "'x'", "}"},
+		texts(tokens))
+	assert.Equal(t, Ident, tokens[3].Kind)
+	assert.Equal(t, String, tokens[15].Kind)
+}
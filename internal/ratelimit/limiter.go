@@ -0,0 +1,108 @@
+// Package ratelimit throttles how often players may submit, run and read, using token
+// buckets keyed by user or IP address.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit refills PerMinute tokens a minute into a bucket holding at most Burst tokens.
+// A zero PerMinute means unlimited.
+type Limit struct {
+	PerMinute float64 `json:"per_minute"`
+	Burst     int     `json:"burst"`
+}
+
+func (l Limit) unlimited() bool {
+	return l.PerMinute <= 0
+}
+
+func (l Limit) burst() float64 {
+	return float64(max(l.Burst, 1))
+}
+
+// perSecond is the refill rate
+func (l Limit) perSecond() float64 {
+	return l.PerMinute / 60
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// refill is how long the bucket takes to fill up from empty
+	refill time.Duration
+}
+
+// sweepInterval is how often idle buckets are dropped
+const sweepInterval = time.Minute
+
+// Limiter holds one token bucket per key. The limit is passed on every call so different
+// keys, e.g. different events, can have different limits.
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from key's bucket. When the bucket is empty it returns false and how
+// long until the next token is available.
+func (l *Limiter) Allow(key string, limit Limit) (bool, time.Duration) {
+	if limit.unlimited() {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit.burst(), last: now}
+		l.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(limit.burst(), b.tokens+elapsed*limit.perSecond())
+	b.last = now
+	b.refill = time.Duration(limit.burst() / limit.perSecond() * float64(time.Second))
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := (1 - b.tokens) / limit.perSecond()
+	return false, time.Duration(math.Ceil(wait * float64(time.Second)))
+}
+
+// sweep drops buckets that have been idle long enough to be full again, which is the same
+// as not having a bucket at all
+func (l *Limiter) sweep(now time.Time) {
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= b.refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Len returns the number of tracked buckets
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
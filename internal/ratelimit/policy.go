@@ -0,0 +1,195 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Budget is a group of endpoints sharing one limit
+type Budget string
+
+const (
+	BudgetSubmit Budget = "submit"
+	BudgetRun    Budget = "run"
+	BudgetRead   Budget = "read"
+)
+
+// Limits sets the limit of each budget. Events store their overrides in this shape, e.g.
+// {"submit": {"per_minute": 4, "burst": 2}}.
+type Limits map[Budget]Limit
+
+// DefaultLimits are used for budgets that are not configured otherwise
+var DefaultLimits = Limits{
+	BudgetSubmit: {PerMinute: 6, Burst: 3},
+	BudgetRun:    {PerMinute: 20, Burst: 5},
+	BudgetRead:   {PerMinute: 300, Burst: 60},
+}
+
+// Validate rejects unknown budgets and negative limits
+func (l Limits) Validate() error {
+	for budget, limit := range l {
+		switch budget {
+		case BudgetSubmit, BudgetRun, BudgetRead:
+		default:
+			return fmt.Errorf("unknown rate limit budget %q", budget)
+		}
+		if limit.PerMinute < 0 || limit.Burst < 0 {
+			return fmt.Errorf("rate limit of %q must not be negative", budget)
+		}
+	}
+	return nil
+}
+
+// DefaultEventCacheTTL is how long an event's limits are cached
+const DefaultEventCacheTTL = time.Minute
+
+// EventStore loads the limits configured on an event and the event a room belongs to
+type EventStore interface {
+	GetEventByID(ctx context.Context, id pgtype.UUID) (store.Event, error)
+	GetRoomByID(ctx context.Context, id pgtype.UUID) (store.Room, error)
+}
+
+type cachedLimits struct {
+	limits    Limits
+	expiresAt time.Time
+}
+
+// Policy decides the limit of a budget, applying the event's overrides over the defaults,
+// and takes tokens from the caller's bucket. One Policy is shared by the HTTP and gRPC
+// servers so a player has the same budget on both.
+type Policy struct {
+	limiter  *Limiter
+	defaults Limits
+	events   EventStore
+	cacheTTL time.Duration
+	logger   *slog.Logger
+
+	mu          sync.Mutex
+	eventLimits map[uuid.UUID]cachedLimits
+	// roomEvents never expire, rooms do not move between events
+	roomEvents map[uuid.UUID]uuid.UUID
+}
+
+func NewPolicy(defaults Limits, events EventStore, cacheTTL time.Duration, logger *slog.Logger) *Policy {
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultEventCacheTTL
+	}
+	return &Policy{
+		limiter:     NewLimiter(),
+		defaults:    defaults,
+		events:      events,
+		cacheTTL:    cacheTTL,
+		logger:      logger,
+		eventLimits: make(map[uuid.UUID]cachedLimits),
+		roomEvents:  make(map[uuid.UUID]uuid.UUID),
+	}
+}
+
+// UserKey identifies an authenticated caller
+func UserKey(userID string) string {
+	return "user:" + userID
+}
+
+// IPKey identifies an anonymous caller by address
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Allow takes a token from caller's budget. The budget is scoped to eventID when it names
+// an existing event, otherwise the caller's global budget is used, so made up event IDs do
+// not get a fresh bucket. When the budget is used up it returns false and how long to wait.
+func (p *Policy) Allow(ctx context.Context, budget Budget, eventID uuid.UUID, caller string) (bool, time.Duration) {
+	key := string(budget) + ":" + caller
+	limit := p.defaults[budget]
+	if eventID != uuid.Nil {
+		if overrides, found := p.eventOverrides(ctx, eventID); found {
+			key += ":" + eventID.String()
+			if override, ok := overrides[budget]; ok {
+				limit = override
+			}
+		}
+	}
+	return p.limiter.Allow(key, limit)
+}
+
+// eventOverrides returns the limits configured on eventID and whether the event exists
+func (p *Policy) eventOverrides(ctx context.Context, eventID uuid.UUID) (Limits, bool) {
+	p.mu.Lock()
+	cached, ok := p.eventLimits[eventID]
+	p.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.limits, true
+	}
+
+	event, err := p.events.GetEventByID(ctx, pgtype.UUID{Bytes: eventID, Valid: true})
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			p.logger.Warn("failed to load event rate limits, using defaults", "event_id", eventID, "err", err)
+		}
+		return nil, false
+	}
+
+	limits, err := ParseLimits(event.RateLimits)
+	if err != nil {
+		p.logger.Warn("event has invalid rate limits, using defaults", "event_id", eventID, "err", err)
+	}
+
+	p.mu.Lock()
+	p.eventLimits[eventID] = cachedLimits{limits: limits, expiresAt: time.Now().Add(p.cacheTTL)}
+	p.mu.Unlock()
+	return limits, true
+}
+
+// Invalidate drops the cached limits of eventID after they have been changed
+func (p *Policy) Invalidate(eventID uuid.UUID) {
+	p.mu.Lock()
+	delete(p.eventLimits, eventID)
+	p.mu.Unlock()
+}
+
+// EventForRoom returns the event roomID belongs to
+func (p *Policy) EventForRoom(ctx context.Context, roomID uuid.UUID) (uuid.UUID, error) {
+	p.mu.Lock()
+	eventID, ok := p.roomEvents[roomID]
+	p.mu.Unlock()
+	if ok {
+		return eventID, nil
+	}
+
+	room, err := p.events.GetRoomByID(ctx, pgtype.UUID{Bytes: roomID, Valid: true})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	eventID = uuid.UUID(room.EventID.Bytes)
+	p.mu.Lock()
+	p.roomEvents[roomID] = eventID
+	p.mu.Unlock()
+	return eventID, nil
+}
+
+// ParseLimits decodes the rate_limits column of an event, which may be empty
+func ParseLimits(data []byte) (Limits, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var limits Limits
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return nil, err
+	}
+	if err := limits.Validate(); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
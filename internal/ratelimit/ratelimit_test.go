@@ -0,0 +1,203 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is advanced by hand
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestLimiter(t *testing.T) {
+	newLimiter := func() (*Limiter, *fakeClock) {
+		clock := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+		l := NewLimiter()
+		l.now = clock.now
+		return l, clock
+	}
+	limit := Limit{PerMinute: 6, Burst: 2}
+
+	t.Run("Burst then refill", func(t *testing.T) {
+		l, clock := newLimiter()
+
+		for range 2 {
+			ok, _ := l.Allow("a", limit)
+			require.True(t, ok)
+		}
+
+		ok, retryAfter := l.Allow("a", limit)
+		assert.False(t, ok)
+		assert.Equal(t, 10*time.Second, retryAfter, "6 a minute refills a token every 10s")
+
+		clock.advance(4 * time.Second)
+		ok, retryAfter = l.Allow("a", limit)
+		assert.False(t, ok)
+		assert.Equal(t, 6*time.Second, retryAfter)
+
+		clock.advance(6 * time.Second)
+		ok, _ = l.Allow("a", limit)
+		assert.True(t, ok)
+	})
+
+	t.Run("Keys have their own buckets", func(t *testing.T) {
+		l, _ := newLimiter()
+
+		for range 2 {
+			l.Allow("a", limit)
+		}
+		ok, _ := l.Allow("a", limit)
+		assert.False(t, ok)
+
+		ok, _ = l.Allow("b", limit)
+		assert.True(t, ok)
+	})
+
+	t.Run("Zero rate is unlimited", func(t *testing.T) {
+		l, _ := newLimiter()
+		for range 100 {
+			ok, _ := l.Allow("a", Limit{})
+			require.True(t, ok)
+		}
+		assert.Zero(t, l.Len())
+	})
+
+	t.Run("Idle buckets are swept", func(t *testing.T) {
+		l, clock := newLimiter()
+		l.Allow("a", limit)
+		l.Allow("b", limit)
+		assert.Equal(t, 2, l.Len())
+
+		clock.advance(time.Minute)
+		l.Allow("c", limit)
+		assert.Equal(t, 1, l.Len())
+	})
+}
+
+type fakeEvents struct {
+	events map[uuid.UUID]store.Event
+	rooms  map[uuid.UUID]store.Room
+	loads  int
+}
+
+func (f *fakeEvents) GetEventByID(ctx context.Context, id pgtype.UUID) (store.Event, error) {
+	f.loads++
+	event, ok := f.events[id.Bytes]
+	if !ok {
+		return store.Event{}, pgx.ErrNoRows
+	}
+	return event, nil
+}
+
+func (f *fakeEvents) GetRoomByID(ctx context.Context, id pgtype.UUID) (store.Room, error) {
+	room, ok := f.rooms[id.Bytes]
+	if !ok {
+		return store.Room{}, pgx.ErrNoRows
+	}
+	return room, nil
+}
+
+func TestPolicy(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	strictEvent, plainEvent, roomID := uuid.New(), uuid.New(), uuid.New()
+
+	newPolicy := func() (*Policy, *fakeEvents) {
+		events := &fakeEvents{
+			events: map[uuid.UUID]store.Event{
+				strictEvent: {RateLimits: []byte(`{"submit": {"per_minute": 1, "burst": 1}}`)},
+				plainEvent:  {},
+			},
+			rooms: map[uuid.UUID]store.Room{
+				roomID: {EventID: pgtype.UUID{Bytes: strictEvent, Valid: true}},
+			},
+		}
+		defaults := Limits{
+			BudgetSubmit: {PerMinute: 6, Burst: 2},
+			BudgetRead:   {PerMinute: 60, Burst: 10},
+		}
+		return NewPolicy(defaults, events, time.Minute, logger), events
+	}
+
+	// allowed counts how many of n calls are let through
+	allowed := func(p *Policy, budget Budget, eventID uuid.UUID, caller string, n int) int {
+		count := 0
+		for range n {
+			if ok, _ := p.Allow(ctx, budget, eventID, caller); ok {
+				count++
+			}
+		}
+		return count
+	}
+
+	t.Run("Event overrides the defaults", func(t *testing.T) {
+		p, _ := newPolicy()
+		assert.Equal(t, 1, allowed(p, BudgetSubmit, strictEvent, UserKey("u"), 5))
+		assert.Equal(t, 2, allowed(p, BudgetSubmit, plainEvent, UserKey("u"), 5))
+		assert.Equal(t, 10, allowed(p, BudgetRead, strictEvent, UserKey("u"), 20), "budgets without an override use the default")
+	})
+
+	t.Run("Budgets and callers are separate", func(t *testing.T) {
+		p, _ := newPolicy()
+		assert.Equal(t, 2, allowed(p, BudgetSubmit, uuid.Nil, UserKey("u"), 5))
+		assert.Equal(t, 2, allowed(p, BudgetSubmit, uuid.Nil, IPKey("10.0.0.1"), 5))
+		assert.Equal(t, 10, allowed(p, BudgetRead, uuid.Nil, UserKey("u"), 20))
+	})
+
+	t.Run("Unknown events share the global budget", func(t *testing.T) {
+		p, _ := newPolicy()
+		assert.Equal(t, 2, allowed(p, BudgetSubmit, uuid.New(), UserKey("u"), 2))
+		assert.Equal(t, 0, allowed(p, BudgetSubmit, uuid.New(), UserKey("u"), 2))
+	})
+
+	t.Run("Event limits are cached until invalidated", func(t *testing.T) {
+		p, events := newPolicy()
+		allowed(p, BudgetRead, strictEvent, UserKey("u"), 3)
+		assert.Equal(t, 1, events.loads)
+
+		p.Invalidate(strictEvent)
+		allowed(p, BudgetRead, strictEvent, UserKey("u"), 1)
+		assert.Equal(t, 2, events.loads)
+	})
+
+	t.Run("Room resolves to its event", func(t *testing.T) {
+		p, _ := newPolicy()
+		eventID, err := p.EventForRoom(ctx, roomID)
+		require.NoError(t, err)
+		assert.Equal(t, strictEvent, eventID)
+
+		_, err = p.EventForRoom(ctx, uuid.New())
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+}
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits(nil)
+	require.NoError(t, err)
+	assert.Nil(t, limits)
+
+	limits, err = ParseLimits([]byte(`{"run": {"per_minute": 10, "burst": 3}}`))
+	require.NoError(t, err)
+	assert.Equal(t, Limits{BudgetRun: {PerMinute: 10, Burst: 3}}, limits)
+
+	_, err = ParseLimits([]byte(`{"compile": {"per_minute": 10}}`))
+	assert.Error(t, err)
+
+	_, err = ParseLimits([]byte(`{"run": {"per_minute": -1}}`))
+	assert.Error(t, err)
+}
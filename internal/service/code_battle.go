@@ -4,10 +4,15 @@ import (
 	"context"
 	"log/slog"
 
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/audit"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/auth"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
 	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/store"
 	pb "github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/protos"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -19,12 +24,14 @@ const (
 type CodeBattleServer struct {
 	pb.UnimplementedCodeBattleServiceServer
 	queries *store.Queries
+	audit   *audit.Recorder
 	logger  *slog.Logger
 }
 
-func NewCodeBattleServer(queries *store.Queries, logger *slog.Logger) *CodeBattleServer {
+func NewCodeBattleServer(queries *store.Queries, recorder *audit.Recorder, logger *slog.Logger) *CodeBattleServer {
 	return &CodeBattleServer{
 		queries: queries,
+		audit:   recorder,
 		logger:  logger,
 	}
 }
@@ -65,13 +72,18 @@ func (s *CodeBattleServer) SubmitCodeSolution(ctx context.Context, req *pb.Submi
 		}, err
 	}
 
-	uid, err := uuid.Parse(req.UserId)
+	// the submitter is always the authenticated caller, user_id may only repeat it
+	claims, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing caller identity")
+	}
+	uid, err := uuid.Parse(claims.ID)
 	if err != nil {
-		s.logger.Error("err at parsing user id", "err", err)
-		status := pb.Status{Success: false, Message: "parse user id failed", ErrorMessage: err.Error()}
-		return &pb.SubmitCodeSolutionResponse{
-			Status: &status,
-		}, err
+		return nil, status.Error(codes.Unauthenticated, "invalid caller identity")
+	}
+	if req.UserId != "" && req.UserId != uid.String() {
+		s.logger.Warn("user id does not match the caller", "user_id", req.UserId, "caller_id", uid)
+		return nil, status.Error(codes.PermissionDenied, "user_id does not match the authenticated caller")
 	}
 
 	lid, err := uuid.Parse(req.LanguageId)
@@ -112,6 +124,7 @@ func (s *CodeBattleServer) SubmitCodeSolution(ctx context.Context, req *pb.Submi
 			Valid: true,
 		},
 		CodeSubmitted: req.CodeSubmitted,
+		Sections:      executor.EncodeSections(nil),
 		Status:        store.SubmissionStatusPending,
 	})
 	if err != nil {
@@ -125,7 +138,25 @@ func (s *CodeBattleServer) SubmitCodeSolution(ctx context.Context, req *pb.Submi
 		}, err
 	}
 
-	return nil, nil
+	s.audit.Record(ctx, audit.Entry{
+		Action:     audit.ActionSubmissionCreated,
+		TargetType: audit.TargetSubmission,
+		TargetID:   submission.ID.String(),
+		After: map[string]string{
+			"room_id":         rid.String(),
+			"code_problem_id": cpid.String(),
+			"language_id":     lid.String(),
+			"status":          string(submission.Status),
+		},
+	})
+
+	return &pb.SubmitCodeSolutionResponse{
+		Status: &pb.Status{
+			Success: true,
+			Message: "code solution submitted",
+		},
+		Submission: submissionToPB(&submission),
+	}, nil
 }
 
 func convertStoreEventsToPB(storeEvents []store.Event) []*pb.Event {
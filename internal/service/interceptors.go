@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/audit"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/auth"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/ratelimit"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/jwt"
+	pb "github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/protos"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// MethodPolicy is who may call a gRPC method
+type MethodPolicy struct {
+	// Public methods can be called without a token
+	Public bool
+	// Roles lists the roles allowed to call the method, any authenticated user when empty
+	Roles []auth.Role
+	// Budget rate limits the method, it is not limited when empty
+	Budget ratelimit.Budget
+}
+
+// DefaultMethodPolicies covers every CodeBattleService method. Methods without a policy are
+// refused, so new RPCs have to be added here before they can be called.
+var DefaultMethodPolicies = map[string]MethodPolicy{
+	pb.CodeBattleService_GetEvents_FullMethodName:          {Public: true},
+	pb.CodeBattleService_SubmitCodeSolution_FullMethodName: {Roles: []auth.Role{auth.RolePlayer}, Budget: ratelimit.BudgetSubmit},
+	pb.CodeBattleService_GetUserSubmissions_FullMethodName: {Roles: []auth.Role{auth.RolePlayer}},
+}
+
+// Interceptors authenticate, authorize, rate limit, log and recover gRPC calls
+type Interceptors struct {
+	parser     *jwt.JWTParser
+	policies   map[string]MethodPolicy
+	rateLimits *ratelimit.Policy
+	logger     *slog.Logger
+}
+
+// NewInterceptors creates the interceptors, rateLimits may be nil to not limit any method
+func NewInterceptors(parser *jwt.JWTParser, policies map[string]MethodPolicy, rateLimits *ratelimit.Policy, logger *slog.Logger) *Interceptors {
+	return &Interceptors{
+		parser:     parser,
+		policies:   policies,
+		rateLimits: rateLimits,
+		logger:     logger,
+	}
+}
+
+// ServerOptions chains recovery, logging, auth and rate limiting, in that order, for unary
+// calls. Streams are not rate limited.
+func (i *Interceptors) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(i.UnaryRecovery, i.UnaryLogging, i.UnaryAuth, i.UnaryRateLimit),
+		grpc.ChainStreamInterceptor(i.StreamRecovery, i.StreamLogging, i.StreamAuth),
+	}
+}
+
+// authenticate verifies the bearer token in the `authorization` metadata and checks it
+// against the method's policy. It returns ctx carrying the caller's claims.
+func (i *Interceptors) authenticate(ctx context.Context, method string) (context.Context, error) {
+	policy, ok := i.policies[method]
+	if !ok {
+		i.logger.Warn("gRPC method has no policy", "method", method)
+		return nil, status.Error(codes.PermissionDenied, "method is not allowed")
+	}
+
+	token, found := bearerFromMetadata(ctx)
+	if !found {
+		if policy.Public {
+			return ctx, nil
+		}
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	claims, err := i.parser.GetUserClaimsFromToken(token)
+	if err != nil {
+		i.logger.Warn("gRPC token rejected", "method", method, "err", err)
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	if len(policy.Roles) > 0 && !auth.HasRole(claims, policy.Roles...) {
+		i.logger.Warn("gRPC caller lacks required role", "method", method, "user_id", claims.ID, "roles", claims.Roles)
+		return nil, status.Error(codes.PermissionDenied, "caller lacks the required role")
+	}
+
+	if call, ok := ctx.Value(callInfoKey{}).(*callInfo); ok {
+		call.userID = claims.ID
+	}
+	return auth.NewContext(ctx, claims), nil
+}
+
+func bearerFromMetadata(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", false
+	}
+
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+func (i *Interceptors) UnaryAuth(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := i.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (i *Interceptors) StreamAuth(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := i.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// UnaryRateLimit takes a token from the caller's budget for the method. Requests naming a
+// room or event use that event's limits.
+func (i *Interceptors) UnaryRateLimit(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	budget := i.policies[info.FullMethod].Budget
+	if i.rateLimits == nil || budget == "" {
+		return handler(ctx, req)
+	}
+
+	caller := rateLimitCaller(ctx)
+	ok, retryAfter := i.rateLimits.Allow(ctx, budget, i.requestEvent(ctx, req), caller)
+	if !ok {
+		i.logger.Warn("gRPC rate limit exceeded", "method", info.FullMethod, "caller", caller)
+		return nil, status.Errorf(codes.ResourceExhausted, "too many requests, retry in %s", retryAfter.Round(time.Second))
+	}
+	return handler(ctx, req)
+}
+
+// requestEvent is the event of the request's room, or the event it names itself
+func (i *Interceptors) requestEvent(ctx context.Context, req any) uuid.UUID {
+	if r, ok := req.(interface{ GetRoomId() string }); ok {
+		if roomID, err := uuid.Parse(r.GetRoomId()); err == nil {
+			if eventID, err := i.rateLimits.EventForRoom(ctx, roomID); err == nil {
+				return eventID
+			}
+		}
+	}
+
+	if r, ok := req.(interface{ GetEventId() string }); ok {
+		if eventID, err := uuid.Parse(r.GetEventId()); err == nil {
+			return eventID
+		}
+	}
+	return uuid.Nil
+}
+
+// rateLimitCaller is the authenticated user, or the peer's IP for anonymous calls
+func rateLimitCaller(ctx context.Context) string {
+	if claims, ok := auth.FromContext(ctx); ok {
+		return ratelimit.UserKey(claims.ID)
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ratelimit.IPKey("unknown")
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	return ratelimit.IPKey(host)
+}
+
+// contextStream replaces the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func (i *Interceptors) recovered(method string, p any) error {
+	i.logger.Error("gRPC handler panicked", "method", method, "panic", p, "trace", string(debug.Stack()))
+	return status.Error(codes.Internal, "internal server error")
+}
+
+func (i *Interceptors) UnaryRecovery(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			resp, err = nil, i.recovered(info.FullMethod, p)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func (i *Interceptors) StreamRecovery(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = i.recovered(info.FullMethod, p)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+// callInfo lets the auth interceptor, which runs inside the logging one, report the caller
+type callInfo struct {
+	userID string
+}
+
+type callInfoKey struct{}
+
+// withRequestID tags the call with the `x-request-id` metadata, or a new ID, so its log line
+// and audit entries can be matched
+func withRequestID(ctx context.Context) (context.Context, string) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-request-id"); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	id = audit.NormalizeRequestID(id)
+	return audit.WithRequestID(ctx, id), id
+}
+
+func (i *Interceptors) logCall(call *callInfo, method, requestID string, start time.Time, err error) {
+	attrs := []any{
+		"method", method,
+		"request_id", requestID,
+		"code", status.Code(err).String(),
+		"duration", time.Since(start),
+	}
+	if call.userID != "" {
+		attrs = append(attrs, "user_id", call.userID)
+	}
+
+	if err != nil {
+		i.logger.Warn("gRPC call failed", append(attrs, "err", err)...)
+		return
+	}
+	i.logger.Info("gRPC call", attrs...)
+}
+
+func (i *Interceptors) UnaryLogging(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	call := &callInfo{}
+	ctx, requestID := withRequestID(ctx)
+	resp, err := handler(context.WithValue(ctx, callInfoKey{}, call), req)
+	i.logCall(call, info.FullMethod, requestID, start, err)
+	return resp, err
+}
+
+func (i *Interceptors) StreamLogging(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	call := &callInfo{}
+	ctx, requestID := withRequestID(ss.Context())
+	err := handler(srv, &contextStream{ServerStream: ss, ctx: context.WithValue(ctx, callInfoKey{}, call)})
+	i.logCall(call, info.FullMethod, requestID, start, err)
+	return err
+}
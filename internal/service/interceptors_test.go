@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/auth"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/ratelimit"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/pkg/jwt"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const testSecret = "qwertyuiopasdfghjklzxcvbnm123456"
+
+func bearerContext(t *testing.T, roles ...string) context.Context {
+	t.Helper()
+	claims := &jwt.UserClaims{
+		ID:    "0b8f2a6e-4c53-4d3b-9a43-2f4f1d7e2c11",
+		Roles: roles,
+		RegisteredClaims: gojwt.RegisteredClaims{
+			ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	token, err := gojwt.NewWithClaims(gojwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	require.NoError(t, err)
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeStream) Context() context.Context { return s.ctx }
+
+func TestInterceptors(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	policies := map[string]MethodPolicy{
+		"/test/Public": {Public: true},
+		"/test/Player": {},
+		"/test/Admin":  {Roles: []auth.Role{auth.RolePlatformAdmin}},
+	}
+	i := NewInterceptors(jwt.NewJWTParser(testSecret, logger), policies, nil, logger)
+
+	// call runs method through the unary chain and returns the caller the handler saw
+	call := func(ctx context.Context, method string) (string, error) {
+		var caller string
+		handler := func(ctx context.Context, req any) (any, error) {
+			if claims, ok := auth.FromContext(ctx); ok {
+				caller = claims.ID
+			}
+			return "ok", nil
+		}
+		chain := func(ctx context.Context, req any) (any, error) {
+			return i.UnaryLogging(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req any) (any, error) {
+				return i.UnaryAuth(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+			})
+		}
+		_, err := chain(ctx, nil)
+		return caller, err
+	}
+
+	t.Run("Public method without a token", func(t *testing.T) {
+		caller, err := call(context.Background(), "/test/Public")
+		require.NoError(t, err)
+		assert.Empty(t, caller)
+	})
+
+	t.Run("Protected method without a token", func(t *testing.T) {
+		_, err := call(context.Background(), "/test/Player")
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer nope"))
+		_, err := call(ctx, "/test/Player")
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("Valid token injects the claims", func(t *testing.T) {
+		caller, err := call(bearerContext(t), "/test/Player")
+		require.NoError(t, err)
+		assert.Equal(t, "0b8f2a6e-4c53-4d3b-9a43-2f4f1d7e2c11", caller)
+	})
+
+	t.Run("Missing role", func(t *testing.T) {
+		_, err := call(bearerContext(t, "guild_leader"), "/test/Admin")
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+		_, err = call(bearerContext(t, "platform_admin"), "/test/Admin")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Method without a policy is refused", func(t *testing.T) {
+		_, err := call(bearerContext(t, "platform_admin"), "/test/Unknown")
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Stream auth replaces the stream context", func(t *testing.T) {
+		var caller string
+		err := i.StreamAuth(nil, &fakeStream{ctx: bearerContext(t)}, &grpc.StreamServerInfo{FullMethod: "/test/Player"}, func(srv any, ss grpc.ServerStream) error {
+			claims, ok := auth.FromContext(ss.Context())
+			require.True(t, ok)
+			caller = claims.ID
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "0b8f2a6e-4c53-4d3b-9a43-2f4f1d7e2c11", caller)
+	})
+
+	t.Run("Limited method is refused once the budget is used", func(t *testing.T) {
+		limits := ratelimit.NewPolicy(ratelimit.Limits{ratelimit.BudgetSubmit: {PerMinute: 1, Burst: 2}}, nil, time.Minute, logger)
+		limited := NewInterceptors(nil, map[string]MethodPolicy{"/test/Submit": {Budget: ratelimit.BudgetSubmit}}, limits, logger)
+		info := &grpc.UnaryServerInfo{FullMethod: "/test/Submit"}
+		ok := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+
+		ctx := auth.NewContext(context.Background(), &jwt.UserClaims{ID: "0b8f2a6e-4c53-4d3b-9a43-2f4f1d7e2c11"})
+		for range 2 {
+			_, err := limited.UnaryRateLimit(ctx, nil, info, ok)
+			require.NoError(t, err)
+		}
+		_, err := limited.UnaryRateLimit(ctx, nil, info, ok)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+		other := auth.NewContext(context.Background(), &jwt.UserClaims{ID: "11111111-1111-1111-1111-111111111111"})
+		_, err = limited.UnaryRateLimit(other, nil, info, ok)
+		assert.NoError(t, err, "other callers have their own budget")
+
+		_, err = limited.UnaryRateLimit(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test/Public"}, ok)
+		assert.NoError(t, err, "methods without a budget are not limited")
+	})
+
+	t.Run("Panics become internal errors", func(t *testing.T) {
+		_, err := i.UnaryRecovery(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test/Public"}, func(ctx context.Context, req any) (any, error) {
+			panic("boom")
+		})
+		assert.Equal(t, codes.Internal, status.Code(err))
+
+		err = i.StreamRecovery(nil, &fakeStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/test/Public"}, func(srv any, ss grpc.ServerStream) error {
+			panic("boom")
+		})
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+}
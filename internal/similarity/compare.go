@@ -0,0 +1,117 @@
+package similarity
+
+import (
+	"slices"
+)
+
+// LineRange is an inclusive, 1-based range of lines
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Match is a region of one document that also appears in the other
+type Match struct {
+	A LineRange `json:"a"`
+	B LineRange `json:"b"`
+}
+
+// Comparison is how much two documents have in common
+type Comparison struct {
+	// Score is the share of the smaller document's fingerprints found in the other one,
+	// from 0 to 1, so padding a copy with extra code does not lower it
+	Score   float64 `json:"score"`
+	Matches []Match `json:"matches"`
+}
+
+// matchGap is how far apart, in tokens, two shared fingerprints may be and still belong
+// to the same match
+const matchGap = K + Window
+
+// Compare scores how similar a and b are and finds the regions they share
+func Compare(a, b *Document) Comparison {
+	if a.Len() == 0 || b.Len() == 0 {
+		return Comparison{}
+	}
+
+	shared := 0
+	for hash := range a.hashes {
+		if b.hashes[hash] {
+			shared++
+		}
+	}
+	if shared == 0 {
+		return Comparison{}
+	}
+
+	return Comparison{
+		Score:   float64(shared) / float64(min(a.Len(), b.Len())),
+		Matches: matches(a, b),
+	}
+}
+
+type pair struct {
+	a, b Fingerprint
+}
+
+// matches pairs up the fingerprints the documents share and merges pairs that are close in
+// both documents into one region
+func matches(a, b *Document) []Match {
+	byHash := make(map[uint64][]Fingerprint)
+	for _, fp := range b.Fingerprints {
+		byHash[fp.Hash] = append(byHash[fp.Hash], fp)
+	}
+
+	var pairs []pair
+	for _, fa := range a.Fingerprints {
+		for _, fb := range byHash[fa.Hash] {
+			pairs = append(pairs, pair{fa, fb})
+		}
+	}
+	slices.SortFunc(pairs, func(x, y pair) int {
+		if x.a.Pos != y.a.Pos {
+			return x.a.Pos - y.a.Pos
+		}
+		return x.b.Pos - y.b.Pos
+	})
+
+	type region struct {
+		last  pair
+		match Match
+	}
+	var regions []*region
+	for _, p := range pairs {
+		var joined *region
+		for _, r := range regions {
+			da, db := p.a.Pos-r.last.a.Pos, p.b.Pos-r.last.b.Pos
+			if da >= 0 && da <= matchGap && db > 0 && db <= matchGap {
+				joined = r
+				break
+			}
+		}
+
+		if joined == nil {
+			regions = append(regions, &region{
+				last: p,
+				match: Match{
+					A: LineRange{Start: p.a.StartLine, End: p.a.EndLine},
+					B: LineRange{Start: p.b.StartLine, End: p.b.EndLine},
+				},
+			})
+			continue
+		}
+
+		joined.last = p
+		joined.match.A.End = max(joined.match.A.End, p.a.EndLine)
+		joined.match.B.Start = min(joined.match.B.Start, p.b.StartLine)
+		joined.match.B.End = max(joined.match.B.End, p.b.EndLine)
+	}
+
+	result := make([]Match, 0, len(regions))
+	for _, r := range regions {
+		if !slices.Contains(result, r.match) {
+			result = append(result, r.match)
+		}
+	}
+	return result
+}
@@ -0,0 +1,99 @@
+// Package similarity detects copied solutions. Code is normalized so renaming variables,
+// editing comments or reformatting does not hide a copy, then fingerprinted with winnowing
+// (Schleimer et al., the algorithm behind MOSS) and compared. It runs fully offline.
+package similarity
+
+import (
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/lexer"
+)
+
+// Token is a normalized token and the line it came from
+type Token struct {
+	Text string
+	Line int
+}
+
+// syntaxes are keyed by canonical language name. Go raw strings are scanned as templates.
+var syntaxes = map[string]lexer.Syntax{
+	executor.LanguageGo:         {LineComment: "//", BlockComments: true, Templates: true},
+	executor.LanguagePython:     lexer.Python,
+	executor.LanguageJavascript: lexer.JavaScript,
+	executor.LanguageTypescript: lexer.JavaScript,
+	executor.LanguageCpp:        lexer.C,
+	executor.LanguageJava:       lexer.C,
+	executor.LanguageRust:       lexer.Rust,
+}
+
+// keywords are kept as they are, every other identifier is replaced, so the structure of
+// the code is compared rather than its names
+var keywords = map[string]map[string]bool{
+	executor.LanguageGo: set("break", "case", "chan", "const", "continue", "default", "defer", "else",
+		"fallthrough", "for", "func", "go", "goto", "if", "import", "interface", "map", "package", "range",
+		"return", "select", "struct", "switch", "type", "var", "nil", "true", "false", "make", "len",
+		"cap", "append", "new"),
+	executor.LanguagePython: set("and", "as", "assert", "async", "await", "break", "class", "continue",
+		"def", "del", "elif", "else", "except", "finally", "for", "from", "global", "if", "import", "in",
+		"is", "lambda", "nonlocal", "not", "or", "pass", "raise", "return", "try", "while", "with",
+		"yield", "None", "True", "False", "self", "len", "range"),
+	executor.LanguageJavascript: jsKeywords,
+	executor.LanguageTypescript: jsKeywords,
+	executor.LanguageCpp: set("auto", "bool", "break", "case", "catch", "char", "class", "const",
+		"continue", "default", "delete", "do", "double", "else", "enum", "false", "float", "for", "if",
+		"int", "long", "namespace", "new", "nullptr", "private", "public", "return", "short", "signed",
+		"sizeof", "static", "struct", "switch", "template", "this", "throw", "true", "try", "typename",
+		"unsigned", "using", "void", "while", "vector", "string", "std"),
+	executor.LanguageJava: set("abstract", "boolean", "break", "case", "catch", "char", "class",
+		"continue", "default", "do", "double", "else", "extends", "false", "final", "float", "for", "if",
+		"implements", "import", "int", "interface", "long", "new", "null", "private", "protected",
+		"public", "return", "short", "static", "super", "switch", "this", "throw", "true", "try", "void",
+		"while", "String"),
+	executor.LanguageRust: set("as", "break", "const", "continue", "else", "enum", "false", "fn", "for",
+		"if", "impl", "in", "let", "loop", "match", "mod", "move", "mut", "pub", "ref", "return", "self",
+		"Self", "struct", "trait", "true", "type", "use", "where", "while", "Vec", "Some", "None", "Ok", "Err"),
+}
+
+var jsKeywords = set("async", "await", "break", "case", "catch", "class", "const", "continue",
+	"default", "delete", "do", "else", "extends", "false", "finally", "for", "function", "if", "in",
+	"instanceof", "let", "new", "null", "of", "return", "switch", "this", "throw", "true", "try",
+	"typeof", "undefined", "var", "void", "while", "yield")
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
+
+// Normalize tokenizes code, dropping comments and whitespace, and replaces identifiers,
+// numbers and string literals with placeholders. Unknown languages use C syntax.
+func Normalize(code, language string) ([]Token, error) {
+	syntax, ok := syntaxes[language]
+	if !ok {
+		syntax = lexer.C
+	}
+
+	tokens, err := lexer.Tokenize(code, syntax)
+	if err != nil {
+		return nil, err
+	}
+
+	kw := keywords[language]
+	normalized := make([]Token, len(tokens))
+	for i, t := range tokens {
+		text := t.Text
+		switch t.Kind {
+		case lexer.Ident:
+			if !kw[text] {
+				text = "v"
+			}
+		case lexer.Number:
+			text = "0"
+		case lexer.String, lexer.Template, lexer.Regexp:
+			text = `""`
+		}
+		normalized[i] = Token{Text: text, Line: t.Line}
+	}
+	return normalized, nil
+}
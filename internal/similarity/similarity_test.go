@@ -0,0 +1,129 @@
+package similarity
+
+import (
+	"testing"
+
+	"github.com/FA25SE050-RogueLearn/RogueLearn.CodeBattle/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const original = `func twoSum(nums []int, target int) []int {
+	seen := make(map[int]int)
+	for i, n := range nums {
+		if j, ok := seen[target-n]; ok {
+			return []int{j, i}
+		}
+		seen[n] = i
+	}
+	return nil
+}`
+
+// renamed is original with new names, comments and formatting
+const renamed = `// my own solution!
+func solve(arr []int, goal int) []int {
+	/* index of each value */
+	idx := make(map[int]int)
+	for k, v := range arr {
+		if other, found := idx[goal-v]; found { return []int{other, k} }
+		idx[v] = k
+	}
+	return nil
+}`
+
+const different = `func twoSum(nums []int, target int) []int {
+	for i := 0; i < len(nums); i++ {
+		for j := i + 1; j < len(nums); j++ {
+			if nums[i]+nums[j] == target {
+				return []int{i, j}
+			}
+		}
+	}
+	return nil
+}`
+
+func document(t *testing.T, code, language string) *Document {
+	t.Helper()
+	d, err := NewDocument(code, language)
+	require.NoError(t, err)
+	return d
+}
+
+func TestNormalize(t *testing.T) {
+	tokens, err := Normalize("x := 42 // answer\nprint(\"hi\")", executor.LanguageGo)
+	require.NoError(t, err)
+
+	texts := make([]string, len(tokens))
+	for i, tok := range tokens {
+		texts[i] = tok.Text
+	}
+	assert.Equal(t, []string{"v", ":=", "0", "v", "(", `""`, ")"}, texts)
+	assert.Equal(t, 2, tokens[3].Line)
+
+	tokens, err = Normalize("def f(a):\n    return a  # done", executor.LanguagePython)
+	require.NoError(t, err)
+	assert.Equal(t, "def", tokens[0].Text, "keywords are kept")
+	assert.Equal(t, "v", tokens[1].Text)
+}
+
+func TestCompare(t *testing.T) {
+	t.Run("Renamed copy is identical", func(t *testing.T) {
+		c := Compare(document(t, original, executor.LanguageGo), document(t, renamed, executor.LanguageGo))
+		assert.Equal(t, 1.0, c.Score)
+		require.NotEmpty(t, c.Matches)
+		// the tail of the code is only covered when one of its k-grams wins a window
+		assert.Equal(t, 1, c.Matches[0].A.Start)
+		assert.GreaterOrEqual(t, c.Matches[0].A.End, 7)
+		assert.Equal(t, 2, c.Matches[0].B.Start)
+	})
+
+	t.Run("Different approach scores low", func(t *testing.T) {
+		c := Compare(document(t, original, executor.LanguageGo), document(t, different, executor.LanguageGo))
+		assert.Less(t, c.Score, 0.5)
+	})
+
+	t.Run("Padding does not hide a copy", func(t *testing.T) {
+		padded := different + "\n\n" + renamed
+		c := Compare(document(t, original, executor.LanguageGo), document(t, padded, executor.LanguageGo))
+		assert.Equal(t, 1.0, c.Score)
+	})
+
+	t.Run("Empty documents", func(t *testing.T) {
+		c := Compare(document(t, "", executor.LanguageGo), document(t, original, executor.LanguageGo))
+		assert.Zero(t, c.Score)
+		assert.Empty(t, c.Matches)
+	})
+}
+
+func TestExclude(t *testing.T) {
+	stub := "func twoSum(nums []int, target int) []int {\n}"
+
+	t.Run("Solution stub is ignored", func(t *testing.T) {
+		a, b := document(t, original, executor.LanguageGo), document(t, different, executor.LanguageGo)
+		before := Compare(a, b).Score
+
+		base := document(t, stub, executor.LanguageGo)
+		a.Exclude(base)
+		b.Exclude(base)
+		assert.Less(t, Compare(a, b).Score, before)
+	})
+
+	t.Run("Common code is ignored", func(t *testing.T) {
+		docs := []*Document{
+			document(t, original, executor.LanguageGo),
+			document(t, renamed, executor.LanguageGo),
+			document(t, original, executor.LanguageGo),
+			document(t, renamed, executor.LanguageGo),
+		}
+		ExcludeCommon(docs, 0.5)
+		for _, d := range docs {
+			assert.Zero(t, d.Len())
+		}
+	})
+
+	t.Run("Small sets are left alone", func(t *testing.T) {
+		docs := []*Document{document(t, original, executor.LanguageGo), document(t, renamed, executor.LanguageGo)}
+		ExcludeCommon(docs, 0.5)
+		assert.NotZero(t, docs[0].Len())
+	})
+}
@@ -0,0 +1,122 @@
+package similarity
+
+import (
+	"hash/fnv"
+)
+
+const (
+	// K is the number of normalized tokens hashed together, shorter matches are noise
+	K = 5
+	// Window is the winnowing window. Every match of at least K+Window-1 tokens is detected.
+	Window = 4
+)
+
+// Fingerprint is a selected k-gram hash and where in the code it came from
+type Fingerprint struct {
+	Hash uint64
+	// Pos is the index of the k-gram's first token
+	Pos       int
+	StartLine int
+	EndLine   int
+}
+
+// Document is the fingerprint of one piece of code
+type Document struct {
+	Fingerprints []Fingerprint
+	hashes       map[uint64]bool
+}
+
+// NewDocument normalizes code and selects its fingerprints with winnowing
+func NewDocument(code, language string) (*Document, error) {
+	tokens, err := Normalize(code, language)
+	if err != nil {
+		return nil, err
+	}
+	return newDocument(winnow(tokens, K, Window)), nil
+}
+
+func newDocument(fps []Fingerprint) *Document {
+	d := &Document{Fingerprints: fps, hashes: make(map[uint64]bool, len(fps))}
+	for _, fp := range fps {
+		d.hashes[fp.Hash] = true
+	}
+	return d
+}
+
+// Len is the number of distinct fingerprints
+func (d *Document) Len() int {
+	return len(d.hashes)
+}
+
+// Exclude drops the fingerprints found in base, e.g. the problem's solution stub that
+// every player starts from
+func (d *Document) Exclude(base *Document) {
+	d.filter(func(hash uint64) bool { return base.hashes[hash] })
+}
+
+func (d *Document) filter(drop func(hash uint64) bool) {
+	kept := d.Fingerprints[:0]
+	for _, fp := range d.Fingerprints {
+		if !drop(fp.Hash) {
+			kept = append(kept, fp)
+		}
+	}
+	*d = *newDocument(kept)
+}
+
+// ExcludeCommon drops fingerprints found in more than maxFraction of docs. Code that most
+// players write, like reading input, says nothing about copying. It needs a few documents
+// to tell common code from a copy, so smaller sets are left alone.
+func ExcludeCommon(docs []*Document, maxFraction float64) {
+	const minDocs = 4
+	if len(docs) < minDocs {
+		return
+	}
+
+	counts := make(map[uint64]int)
+	for _, d := range docs {
+		for hash := range d.hashes {
+			counts[hash]++
+		}
+	}
+
+	limit := int(maxFraction * float64(len(docs)))
+	for _, d := range docs {
+		d.filter(func(hash uint64) bool { return counts[hash] > limit })
+	}
+}
+
+// winnow hashes every k-gram of tokens and keeps the rightmost minimum of each window of
+// w hashes
+func winnow(tokens []Token, k, w int) []Fingerprint {
+	if len(tokens) < k {
+		return nil
+	}
+
+	grams := make([]Fingerprint, len(tokens)-k+1)
+	for i := range grams {
+		h := fnv.New64a()
+		for _, t := range tokens[i : i+k] {
+			h.Write([]byte(t.Text))
+			h.Write([]byte{0})
+		}
+		grams[i] = Fingerprint{Hash: h.Sum64(), Pos: i, StartLine: tokens[i].Line, EndLine: tokens[i+k-1].Line}
+	}
+
+	w = min(w, len(grams))
+	var fps []Fingerprint
+	selected := -1
+	for start := 0; start+w <= len(grams); start++ {
+		minIdx := start
+		for i := start + 1; i < start+w; i++ {
+			if grams[i].Hash <= grams[minIdx].Hash {
+				minIdx = i
+			}
+		}
+		if minIdx != selected {
+			fps = append(fps, grams[minIdx])
+			selected = minIdx
+		}
+	}
+	return fps
+}
@@ -189,12 +189,26 @@ func (ns NullSubmissionStatus) Value() (driver.Value, error) {
 	return string(ns.SubmissionStatus), nil
 }
 
+type AuditLog struct {
+	ID         pgtype.UUID
+	ActorID    pgtype.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	EventID    pgtype.UUID
+	Before     []byte
+	After      []byte
+	RequestID  pgtype.Text
+	CreatedAt  pgtype.Timestamptz
+}
+
 type CodeProblem struct {
 	ID               pgtype.UUID
 	Title            string
 	ProblemStatement string
 	Difficulty       int32
 	CreatedAt        pgtype.Timestamptz
+	Signature        []byte
 }
 
 type CodeProblemLanguageDetail struct {
@@ -204,6 +218,10 @@ type CodeProblemLanguageDetail struct {
 	DriverCode        string
 	TimeConstraintMs  int32
 	SpaceConstraintMb int32
+	BatchMode         bool
+	MaxCodeLength     pgtype.Int4
+	SolutionMode      string
+	RequiredSymbols   []string
 }
 
 type CodeProblemTag struct {
@@ -211,19 +229,32 @@ type CodeProblemTag struct {
 	TagID         pgtype.UUID
 }
 
+type Disqualification struct {
+	ID        pgtype.UUID
+	EventID   pgtype.UUID
+	UserID    pgtype.UUID
+	GuildID   pgtype.UUID
+	Reason    string
+	CreatedBy pgtype.UUID
+	CreatedAt pgtype.Timestamptz
+}
+
 type Event struct {
-	ID                 pgtype.UUID
-	Title              string
-	Description        string
-	Type               EventType
-	StartedDate        pgtype.Timestamptz
-	EndDate            pgtype.Timestamptz
-	MaxGuilds          pgtype.Int4
-	MaxPlayersPerGuild pgtype.Int4
-	NumberOfRooms      pgtype.Int4
-	GuildsPerRoom      pgtype.Int4
-	RoomNamingPrefix   pgtype.Text
-	OriginalRequestID  pgtype.UUID
+	ID                  pgtype.UUID
+	Title               string
+	Description         string
+	Type                EventType
+	StartedDate         pgtype.Timestamptz
+	EndDate             pgtype.Timestamptz
+	MaxGuilds           pgtype.Int4
+	MaxPlayersPerGuild  pgtype.Int4
+	NumberOfRooms       pgtype.Int4
+	GuildsPerRoom       pgtype.Int4
+	RoomNamingPrefix    pgtype.Text
+	OriginalRequestID   pgtype.UUID
+	SpectatorVisibility string
+	RateLimits          []byte
+	IntegrityRules      []byte
 }
 
 type EventCodeProblem struct {
@@ -233,10 +264,11 @@ type EventCodeProblem struct {
 }
 
 type EventGuildParticipant struct {
-	EventID  pgtype.UUID
-	GuildID  pgtype.UUID
-	JoinedAt pgtype.Timestamptz
-	RoomID   pgtype.UUID
+	EventID   pgtype.UUID
+	GuildID   pgtype.UUID
+	JoinedAt  pgtype.Timestamptz
+	RoomID    pgtype.UUID
+	GuildName string
 }
 
 type EventRequest struct {
@@ -269,13 +301,27 @@ type GuildLeaderboardEntry struct {
 	SnapshotDate pgtype.Timestamptz
 }
 
+type IntegritySignal struct {
+	ID         pgtype.UUID
+	EventID    pgtype.UUID
+	RoomID     pgtype.UUID
+	UserID     pgtype.UUID
+	Kind       string
+	Value      int32
+	OccurredAt pgtype.Timestamptz
+	ReceivedAt pgtype.Timestamptz
+}
+
 type Language struct {
-	ID           pgtype.UUID
-	Name         string
-	CompileCmd   string
-	RunCmd       string
-	TempFileDir  pgtype.Text
-	TempFileName pgtype.Text
+	ID                pgtype.UUID
+	Name              string
+	CompileCmd        string
+	RunCmd            string
+	TempFileDir       pgtype.Text
+	TempFileName      pgtype.Text
+	Aliases           []string
+	CodePlaceholder   string
+	ImportPlaceholder string
 }
 
 type LeaderboardEntry struct {
@@ -305,6 +351,21 @@ type RoomPlayer struct {
 	State          RoomPlayerState
 	DisconnectedAt pgtype.Timestamptz
 	JoinedAt       pgtype.Timestamptz
+	Flagged        bool
+	FlagReason     pgtype.Text
+	GuildID        pgtype.UUID
+	Disqualified   bool
+}
+
+type ScoreAdjustment struct {
+	ID        pgtype.UUID
+	EventID   pgtype.UUID
+	RoomID    pgtype.UUID
+	UserID    pgtype.UUID
+	Points    int32
+	Reason    string
+	CreatedBy pgtype.UUID
+	CreatedAt pgtype.Timestamptz
 }
 
 type Submission struct {
@@ -318,6 +379,19 @@ type Submission struct {
 	ExecutionTimeMs  pgtype.Int4
 	SubmittedAt      pgtype.Timestamptz
 	SubmittedGuildID pgtype.UUID
+	MemoryUsedKb     pgtype.Int4
+	Sections         []byte
+}
+
+type SubmissionSimilarity struct {
+	ID            pgtype.UUID
+	EventID       pgtype.UUID
+	CodeProblemID pgtype.UUID
+	SubmissionAID pgtype.UUID
+	SubmissionBID pgtype.UUID
+	Score         float64
+	Matches       []byte
+	DetectedAt    pgtype.Timestamptz
 }
 
 type Tag struct {
@@ -53,12 +53,24 @@ func (q *Queries) CalculateGuildLeaderboard(ctx context.Context, eventID pgtype.
 }
 
 const calculateRoomLeaderboard = `-- name: CalculateRoomLeaderboard :exec
-WITH ranked_players AS (
+WITH best_runtimes AS (
+  SELECT user_id, code_problem_id, MIN(execution_time_ms) AS best_ms
+  FROM submissions
+  WHERE room_id = $1 AND status = 'accepted'
+  GROUP BY user_id, code_problem_id
+),
+player_runtimes AS (
+  SELECT user_id, SUM(best_ms) AS total_ms
+  FROM best_runtimes
+  GROUP BY user_id
+),
+ranked_players AS (
   SELECT
-    user_id,
-    RANK() OVER (ORDER BY score DESC, joined_at ASC) as new_place
-  FROM room_players
-  WHERE room_id = $1
+    rp.user_id,
+    RANK() OVER (ORDER BY rp.score DESC, COALESCE(pr.total_ms, 0) ASC, rp.joined_at ASC) as new_place
+  FROM room_players rp
+  LEFT JOIN player_runtimes pr ON pr.user_id = rp.user_id
+  WHERE rp.room_id = $1
 )
 UPDATE room_players rp
 SET place = rp_ranked.new_place
@@ -74,7 +86,7 @@ func (q *Queries) CalculateRoomLeaderboard(ctx context.Context, roomID pgtype.UU
 const createCodeProblem = `-- name: CreateCodeProblem :one
 INSERT INTO code_problems (title, problem_statement, difficulty)
 VALUES ($1, $2, $3)
-RETURNING id, title, problem_statement, difficulty, created_at
+RETURNING id, title, problem_statement, difficulty, created_at, signature
 `
 
 type CreateCodeProblemParams struct {
@@ -93,14 +105,15 @@ func (q *Queries) CreateCodeProblem(ctx context.Context, arg CreateCodeProblemPa
 		&i.ProblemStatement,
 		&i.Difficulty,
 		&i.CreatedAt,
+		&i.Signature,
 	)
 	return i, err
 }
 
 const createCodeProblemLanguageDetail = `-- name: CreateCodeProblemLanguageDetail :one
-INSERT INTO code_problem_language_details (code_problem_id, language_id, solution_stub, driver_code, time_constraint_ms, space_constraint_mb)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING code_problem_id, language_id, solution_stub, driver_code, time_constraint_ms, space_constraint_mb
+INSERT INTO code_problem_language_details (code_problem_id, language_id, solution_stub, driver_code, time_constraint_ms, space_constraint_mb, batch_mode, max_code_length, solution_mode, required_symbols)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING code_problem_id, language_id, solution_stub, driver_code, time_constraint_ms, space_constraint_mb, batch_mode, max_code_length, solution_mode, required_symbols
 `
 
 type CreateCodeProblemLanguageDetailParams struct {
@@ -110,6 +123,10 @@ type CreateCodeProblemLanguageDetailParams struct {
 	DriverCode        string
 	TimeConstraintMs  int32
 	SpaceConstraintMb int32
+	BatchMode         bool
+	MaxCodeLength     pgtype.Int4
+	SolutionMode      string
+	RequiredSymbols   []string
 }
 
 // Code Problem Language Details
@@ -121,6 +138,10 @@ func (q *Queries) CreateCodeProblemLanguageDetail(ctx context.Context, arg Creat
 		arg.DriverCode,
 		arg.TimeConstraintMs,
 		arg.SpaceConstraintMb,
+		arg.BatchMode,
+		arg.MaxCodeLength,
+		arg.SolutionMode,
+		arg.RequiredSymbols,
 	)
 	var i CodeProblemLanguageDetail
 	err := row.Scan(
@@ -130,6 +151,10 @@ func (q *Queries) CreateCodeProblemLanguageDetail(ctx context.Context, arg Creat
 		&i.DriverCode,
 		&i.TimeConstraintMs,
 		&i.SpaceConstraintMb,
+		&i.BatchMode,
+		&i.MaxCodeLength,
+		&i.SolutionMode,
+		&i.RequiredSymbols,
 	)
 	return i, err
 }
@@ -162,24 +187,26 @@ INSERT INTO events (
   number_of_rooms,
   guilds_per_room,
   room_naming_prefix,
-  original_request_id
+  original_request_id,
+  spectator_visibility
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-RETURNING id, title, description, type, started_date, end_date, max_guilds, max_players_per_guild, number_of_rooms, guilds_per_room, room_naming_prefix, original_request_id
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING id, title, description, type, started_date, end_date, max_guilds, max_players_per_guild, number_of_rooms, guilds_per_room, room_naming_prefix, original_request_id, spectator_visibility, rate_limits, integrity_rules
 `
 
 type CreateEventParams struct {
-	Title              string
-	Description        string
-	Type               EventType
-	StartedDate        pgtype.Timestamptz
-	EndDate            pgtype.Timestamptz
-	MaxGuilds          pgtype.Int4
-	MaxPlayersPerGuild pgtype.Int4
-	NumberOfRooms      pgtype.Int4
-	GuildsPerRoom      pgtype.Int4
-	RoomNamingPrefix   pgtype.Text
-	OriginalRequestID  pgtype.UUID
+	Title               string
+	Description         string
+	Type                EventType
+	StartedDate         pgtype.Timestamptz
+	EndDate             pgtype.Timestamptz
+	MaxGuilds           pgtype.Int4
+	MaxPlayersPerGuild  pgtype.Int4
+	NumberOfRooms       pgtype.Int4
+	GuildsPerRoom       pgtype.Int4
+	RoomNamingPrefix    pgtype.Text
+	OriginalRequestID   pgtype.UUID
+	SpectatorVisibility string
 }
 
 // Events
@@ -196,6 +223,7 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event
 		arg.GuildsPerRoom,
 		arg.RoomNamingPrefix,
 		arg.OriginalRequestID,
+		arg.SpectatorVisibility,
 	)
 	var i Event
 	err := row.Scan(
@@ -211,6 +239,9 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event
 		&i.GuildsPerRoom,
 		&i.RoomNamingPrefix,
 		&i.OriginalRequestID,
+		&i.SpectatorVisibility,
+		&i.RateLimits,
+		&i.IntegrityRules,
 	)
 	return i, err
 }
@@ -233,26 +264,33 @@ func (q *Queries) CreateEventCodeProblem(ctx context.Context, arg CreateEventCod
 }
 
 const createEventGuildParticipant = `-- name: CreateEventGuildParticipant :one
-INSERT INTO event_guild_participants (event_id, guild_id, room_id)
-VALUES ($1, $2, $3)
-RETURNING event_id, guild_id, joined_at, room_id
+INSERT INTO event_guild_participants (event_id, guild_id, room_id, guild_name)
+VALUES ($1, $2, $3, $4)
+RETURNING event_id, guild_id, joined_at, room_id, guild_name
 `
 
 type CreateEventGuildParticipantParams struct {
-	EventID pgtype.UUID
-	GuildID pgtype.UUID
-	RoomID  pgtype.UUID
+	EventID   pgtype.UUID
+	GuildID   pgtype.UUID
+	RoomID    pgtype.UUID
+	GuildName string
 }
 
 // Event Guild Participants
 func (q *Queries) CreateEventGuildParticipant(ctx context.Context, arg CreateEventGuildParticipantParams) (EventGuildParticipant, error) {
-	row := q.db.QueryRow(ctx, createEventGuildParticipant, arg.EventID, arg.GuildID, arg.RoomID)
+	row := q.db.QueryRow(ctx, createEventGuildParticipant,
+		arg.EventID,
+		arg.GuildID,
+		arg.RoomID,
+		arg.GuildName,
+	)
 	var i EventGuildParticipant
 	err := row.Scan(
 		&i.EventID,
 		&i.GuildID,
 		&i.JoinedAt,
 		&i.RoomID,
+		&i.GuildName,
 	)
 	return i, err
 }
@@ -354,17 +392,20 @@ func (q *Queries) CreateGuildLeaderboardEntry(ctx context.Context, arg CreateGui
 }
 
 const createLanguage = `-- name: CreateLanguage :one
-INSERT INTO languages (name, compile_cmd, run_cmd, temp_file_dir, temp_file_name)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, name, compile_cmd, run_cmd, temp_file_dir, temp_file_name
+INSERT INTO languages (name, compile_cmd, run_cmd, temp_file_dir, temp_file_name, aliases, code_placeholder, import_placeholder)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, name, compile_cmd, run_cmd, temp_file_dir, temp_file_name, aliases, code_placeholder, import_placeholder
 `
 
 type CreateLanguageParams struct {
-	Name         string
-	CompileCmd   string
-	RunCmd       string
-	TempFileDir  pgtype.Text
-	TempFileName pgtype.Text
+	Name              string
+	CompileCmd        string
+	RunCmd            string
+	TempFileDir       pgtype.Text
+	TempFileName      pgtype.Text
+	Aliases           []string
+	CodePlaceholder   string
+	ImportPlaceholder string
 }
 
 // Languages
@@ -375,6 +416,9 @@ func (q *Queries) CreateLanguage(ctx context.Context, arg CreateLanguageParams)
 		arg.RunCmd,
 		arg.TempFileDir,
 		arg.TempFileName,
+		arg.Aliases,
+		arg.CodePlaceholder,
+		arg.ImportPlaceholder,
 	)
 	var i Language
 	err := row.Scan(
@@ -384,6 +428,9 @@ func (q *Queries) CreateLanguage(ctx context.Context, arg CreateLanguageParams)
 		&i.RunCmd,
 		&i.TempFileDir,
 		&i.TempFileName,
+		&i.Aliases,
+		&i.CodePlaceholder,
+		&i.ImportPlaceholder,
 	)
 	return i, err
 }
@@ -451,9 +498,9 @@ func (q *Queries) CreateRoom(ctx context.Context, arg CreateRoomParams) (Room, e
 }
 
 const createRoomPlayer = `-- name: CreateRoomPlayer :one
-INSERT INTO room_players (room_id, user_id, username, score, place, state)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING room_id, user_id, username, score, place, state, disconnected_at, joined_at
+INSERT INTO room_players (room_id, user_id, username, score, place, state, guild_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING room_id, user_id, username, score, place, state, disconnected_at, joined_at, flagged, flag_reason, guild_id, disqualified
 `
 
 type CreateRoomPlayerParams struct {
@@ -463,6 +510,7 @@ type CreateRoomPlayerParams struct {
 	Score    int32
 	Place    pgtype.Int4
 	State    RoomPlayerState
+	GuildID  pgtype.UUID
 }
 
 // Room Players
@@ -474,6 +522,7 @@ func (q *Queries) CreateRoomPlayer(ctx context.Context, arg CreateRoomPlayerPara
 		arg.Score,
 		arg.Place,
 		arg.State,
+		arg.GuildID,
 	)
 	var i RoomPlayer
 	err := row.Scan(
@@ -485,14 +534,18 @@ func (q *Queries) CreateRoomPlayer(ctx context.Context, arg CreateRoomPlayerPara
 		&i.State,
 		&i.DisconnectedAt,
 		&i.JoinedAt,
+		&i.Flagged,
+		&i.FlagReason,
+		&i.GuildID,
+		&i.Disqualified,
 	)
 	return i, err
 }
 
 const createSubmission = `-- name: CreateSubmission :one
-INSERT INTO submissions (user_id, code_problem_id, language_id, room_id, code_submitted, status, execution_time_ms, submitted_guild_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, user_id, code_problem_id, language_id, room_id, code_submitted, status, execution_time_ms, submitted_at, submitted_guild_id
+INSERT INTO submissions (user_id, code_problem_id, language_id, room_id, code_submitted, status, execution_time_ms, submitted_guild_id, sections)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, user_id, code_problem_id, language_id, room_id, code_submitted, status, execution_time_ms, submitted_at, submitted_guild_id, memory_used_kb, sections
 `
 
 type CreateSubmissionParams struct {
@@ -504,6 +557,7 @@ type CreateSubmissionParams struct {
 	Status           SubmissionStatus
 	ExecutionTimeMs  pgtype.Int4
 	SubmittedGuildID pgtype.UUID
+	Sections         []byte
 }
 
 // Submissions
@@ -517,6 +571,7 @@ func (q *Queries) CreateSubmission(ctx context.Context, arg CreateSubmissionPara
 		arg.Status,
 		arg.ExecutionTimeMs,
 		arg.SubmittedGuildID,
+		arg.Sections,
 	)
 	var i Submission
 	err := row.Scan(
@@ -530,6 +585,8 @@ func (q *Queries) CreateSubmission(ctx context.Context, arg CreateSubmissionPara
 		&i.ExecutionTimeMs,
 		&i.SubmittedAt,
 		&i.SubmittedGuildID,
+		&i.MemoryUsedKb,
+		&i.Sections,
 	)
 	return i, err
 }
@@ -742,7 +799,7 @@ const disconnectRoomPlayer = `-- name: DisconnectRoomPlayer :one
 UPDATE room_players
 SET disconnected_at = NOW()
 WHERE room_id = $1 AND user_id = $2
-RETURNING room_id, user_id, username, score, place, state, disconnected_at, joined_at
+RETURNING room_id, user_id, username, score, place, state, disconnected_at, joined_at, flagged, flag_reason, guild_id, disqualified
 `
 
 type DisconnectRoomPlayerParams struct {
@@ -762,12 +819,16 @@ func (q *Queries) DisconnectRoomPlayer(ctx context.Context, arg DisconnectRoomPl
 		&i.State,
 		&i.DisconnectedAt,
 		&i.JoinedAt,
+		&i.Flagged,
+		&i.FlagReason,
+		&i.GuildID,
+		&i.Disqualified,
 	)
 	return i, err
 }
 
 const getActiveEvents = `-- name: GetActiveEvents :many
-SELECT id, title, description, type, started_date, end_date, max_guilds, max_players_per_guild, number_of_rooms, guilds_per_room, room_naming_prefix, original_request_id FROM events
+SELECT id, title, description, type, started_date, end_date, max_guilds, max_players_per_guild, number_of_rooms, guilds_per_room, room_naming_prefix, original_request_id, spectator_visibility, rate_limits, integrity_rules FROM events
 WHERE started_date <= NOW() AND end_date >= NOW()
 ORDER BY started_date ASC
 `
@@ -794,6 +855,44 @@ func (q *Queries) GetActiveEvents(ctx context.Context) ([]Event, error) {
 			&i.GuildsPerRoom,
 			&i.RoomNamingPrefix,
 			&i.OriginalRequestID,
+			&i.SpectatorVisibility,
+			&i.RateLimits,
+			&i.IntegrityRules,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllLanguages = `-- name: GetAllLanguages :many
+SELECT id, name, compile_cmd, run_cmd, temp_file_dir, temp_file_name, aliases, code_placeholder, import_placeholder FROM languages
+ORDER BY name
+`
+
+func (q *Queries) GetAllLanguages(ctx context.Context) ([]Language, error) {
+	rows, err := q.db.Query(ctx, getAllLanguages)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Language
+	for rows.Next() {
+		var i Language
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CompileCmd,
+			&i.RunCmd,
+			&i.TempFileDir,
+			&i.TempFileName,
+			&i.Aliases,
+			&i.CodePlaceholder,
+			&i.ImportPlaceholder,
 		); err != nil {
 			return nil, err
 		}
@@ -806,7 +905,7 @@ func (q *Queries) GetActiveEvents(ctx context.Context) ([]Event, error) {
 }
 
 const getCodeProblemByID = `-- name: GetCodeProblemByID :one
-SELECT id, title, problem_statement, difficulty, created_at FROM code_problems WHERE id = $1
+SELECT id, title, problem_statement, difficulty, created_at, signature FROM code_problems WHERE id = $1
 `
 
 func (q *Queries) GetCodeProblemByID(ctx context.Context, id pgtype.UUID) (CodeProblem, error) {
@@ -818,12 +917,13 @@ func (q *Queries) GetCodeProblemByID(ctx context.Context, id pgtype.UUID) (CodeP
 		&i.ProblemStatement,
 		&i.Difficulty,
 		&i.CreatedAt,
+		&i.Signature,
 	)
 	return i, err
 }
 
 const getCodeProblemLanguage = `-- name: GetCodeProblemLanguage :one
-SELECT code_problem_id, language_id, solution_stub, driver_code, time_constraint_ms, space_constraint_mb FROM code_problem_language_details
+SELECT code_problem_id, language_id, solution_stub, driver_code, time_constraint_ms, space_constraint_mb, batch_mode, max_code_length, solution_mode, required_symbols FROM code_problem_language_details
 WHERE code_problem_id = $1 AND language_id = $2
 `
 
@@ -842,12 +942,16 @@ func (q *Queries) GetCodeProblemLanguage(ctx context.Context, arg GetCodeProblem
 		&i.DriverCode,
 		&i.TimeConstraintMs,
 		&i.SpaceConstraintMb,
+		&i.BatchMode,
+		&i.MaxCodeLength,
+		&i.SolutionMode,
+		&i.RequiredSymbols,
 	)
 	return i, err
 }
 
 const getCodeProblemLanguageDetail = `-- name: GetCodeProblemLanguageDetail :one
-SELECT code_problem_id, language_id, solution_stub, driver_code, time_constraint_ms, space_constraint_mb FROM code_problem_language_details
+SELECT code_problem_id, language_id, solution_stub, driver_code, time_constraint_ms, space_constraint_mb, batch_mode, max_code_length, solution_mode, required_symbols FROM code_problem_language_details
 WHERE code_problem_id = $1 AND language_id = $2
 `
 
@@ -866,12 +970,16 @@ func (q *Queries) GetCodeProblemLanguageDetail(ctx context.Context, arg GetCodeP
 		&i.DriverCode,
 		&i.TimeConstraintMs,
 		&i.SpaceConstraintMb,
+		&i.BatchMode,
+		&i.MaxCodeLength,
+		&i.SolutionMode,
+		&i.RequiredSymbols,
 	)
 	return i, err
 }
 
 const getCodeProblemLanguageDetailByLanguageName = `-- name: GetCodeProblemLanguageDetailByLanguageName :one
-SELECT cpld.code_problem_id, cpld.language_id, cpld.solution_stub, cpld.driver_code, cpld.time_constraint_ms, cpld.space_constraint_mb
+SELECT cpld.code_problem_id, cpld.language_id, cpld.solution_stub, cpld.driver_code, cpld.time_constraint_ms, cpld.space_constraint_mb, cpld.batch_mode, cpld.max_code_length, cpld.solution_mode, cpld.required_symbols
 FROM code_problem_language_details cpld
 JOIN languages l ON cpld.language_id = l.id
 WHERE cpld.code_problem_id = $1 AND l.name = $2
@@ -892,12 +1000,16 @@ func (q *Queries) GetCodeProblemLanguageDetailByLanguageName(ctx context.Context
 		&i.DriverCode,
 		&i.TimeConstraintMs,
 		&i.SpaceConstraintMb,
+		&i.BatchMode,
+		&i.MaxCodeLength,
+		&i.SolutionMode,
+		&i.RequiredSymbols,
 	)
 	return i, err
 }
 
 const getCodeProblemLanguageDetails = `-- name: GetCodeProblemLanguageDetails :many
-SELECT code_problem_id, language_id, solution_stub, driver_code, time_constraint_ms, space_constraint_mb FROM code_problem_language_details
+SELECT code_problem_id, language_id, solution_stub, driver_code, time_constraint_ms, space_constraint_mb, batch_mode, max_code_length, solution_mode, required_symbols FROM code_problem_language_details
 WHERE code_problem_id = $1
 LIMIT $2
 OFFSET $3
@@ -925,6 +1037,10 @@ func (q *Queries) GetCodeProblemLanguageDetails(ctx context.Context, arg GetCode
 			&i.DriverCode,
 			&i.TimeConstraintMs,
 			&i.SpaceConstraintMb,
+			&i.BatchMode,
+			&i.MaxCodeLength,
+			&i.SolutionMode,
+			&i.RequiredSymbols,
 		); err != nil {
 			return nil, err
 		}
@@ -970,7 +1086,7 @@ func (q *Queries) GetCodeProblemTags(ctx context.Context, codeProblemID pgtype.U
 }
 
 const getCodeProblems = `-- name: GetCodeProblems :many
-SELECT id, title, problem_statement, difficulty, created_at FROM code_problems
+SELECT id, title, problem_statement, difficulty, created_at, signature FROM code_problems
 ORDER BY created_at DESC
 LIMIT $1
 OFFSET $2
@@ -996,6 +1112,7 @@ func (q *Queries) GetCodeProblems(ctx context.Context, arg GetCodeProblemsParams
 			&i.ProblemStatement,
 			&i.Difficulty,
 			&i.CreatedAt,
+			&i.Signature,
 		); err != nil {
 			return nil, err
 		}
@@ -1008,7 +1125,7 @@ func (q *Queries) GetCodeProblems(ctx context.Context, arg GetCodeProblemsParams
 }
 
 const getCodeProblemsByDifficulty = `-- name: GetCodeProblemsByDifficulty :many
-SELECT id, title, problem_statement, difficulty, created_at FROM code_problems
+SELECT id, title, problem_statement, difficulty, created_at, signature FROM code_problems
 WHERE difficulty = $1
 ORDER BY created_at DESC
 LIMIT $2
@@ -1036,6 +1153,7 @@ func (q *Queries) GetCodeProblemsByDifficulty(ctx context.Context, arg GetCodePr
 			&i.ProblemStatement,
 			&i.Difficulty,
 			&i.CreatedAt,
+			&i.Signature,
 		); err != nil {
 			return nil, err
 		}
@@ -1048,7 +1166,7 @@ func (q *Queries) GetCodeProblemsByDifficulty(ctx context.Context, arg GetCodePr
 }
 
 const getCodeProblemsByTag = `-- name: GetCodeProblemsByTag :many
-SELECT cp.id, cp.title, cp.problem_statement, cp.difficulty, cp.created_at, t.name as tag_name
+SELECT cp.id, cp.title, cp.problem_statement, cp.difficulty, cp.created_at, cp.signature, t.name as tag_name
 FROM code_problems cp
 JOIN code_problem_tags cpt ON cp.id = cpt.code_problem_id
 JOIN tags t ON cpt.tag_id = t.id
@@ -1062,6 +1180,7 @@ type GetCodeProblemsByTagRow struct {
 	ProblemStatement string
 	Difficulty       int32
 	CreatedAt        pgtype.Timestamptz
+	Signature        []byte
 	TagName          string
 }
 
@@ -1080,6 +1199,7 @@ func (q *Queries) GetCodeProblemsByTag(ctx context.Context, id pgtype.UUID) ([]G
 			&i.ProblemStatement,
 			&i.Difficulty,
 			&i.CreatedAt,
+			&i.Signature,
 			&i.TagName,
 		); err != nil {
 			return nil, err
@@ -1093,7 +1213,7 @@ func (q *Queries) GetCodeProblemsByTag(ctx context.Context, id pgtype.UUID) ([]G
 }
 
 const getEventByID = `-- name: GetEventByID :one
-SELECT id, title, description, type, started_date, end_date, max_guilds, max_players_per_guild, number_of_rooms, guilds_per_room, room_naming_prefix, original_request_id FROM events WHERE id = $1
+SELECT id, title, description, type, started_date, end_date, max_guilds, max_players_per_guild, number_of_rooms, guilds_per_room, room_naming_prefix, original_request_id, spectator_visibility, rate_limits, integrity_rules FROM events WHERE id = $1
 `
 
 func (q *Queries) GetEventByID(ctx context.Context, id pgtype.UUID) (Event, error) {
@@ -1112,6 +1232,9 @@ func (q *Queries) GetEventByID(ctx context.Context, id pgtype.UUID) (Event, erro
 		&i.GuildsPerRoom,
 		&i.RoomNamingPrefix,
 		&i.OriginalRequestID,
+		&i.SpectatorVisibility,
+		&i.RateLimits,
+		&i.IntegrityRules,
 	)
 	return i, err
 }
@@ -1192,7 +1315,7 @@ func (q *Queries) GetEventCodeProblems(ctx context.Context, eventID pgtype.UUID)
 }
 
 const getEventGuildParticipant = `-- name: GetEventGuildParticipant :one
-SELECT event_id, guild_id, joined_at, room_id FROM event_guild_participants
+SELECT event_id, guild_id, joined_at, room_id, guild_name FROM event_guild_participants
 WHERE event_id = $1 AND guild_id = $2
 `
 
@@ -1209,12 +1332,13 @@ func (q *Queries) GetEventGuildParticipant(ctx context.Context, arg GetEventGuil
 		&i.GuildID,
 		&i.JoinedAt,
 		&i.RoomID,
+		&i.GuildName,
 	)
 	return i, err
 }
 
 const getEventGuildParticipants = `-- name: GetEventGuildParticipants :many
-SELECT event_id, guild_id, joined_at, room_id FROM event_guild_participants
+SELECT event_id, guild_id, joined_at, room_id, guild_name FROM event_guild_participants
 WHERE event_id = $1
 ORDER BY joined_at ASC
 `
@@ -1233,6 +1357,7 @@ func (q *Queries) GetEventGuildParticipants(ctx context.Context, eventID pgtype.
 			&i.GuildID,
 			&i.JoinedAt,
 			&i.RoomID,
+			&i.GuildName,
 		); err != nil {
 			return nil, err
 		}
@@ -1275,7 +1400,7 @@ func (q *Queries) GetEventRequestByID(ctx context.Context, id pgtype.UUID) (Even
 
 const getEventWithProblemsAndLanguages = `-- name: GetEventWithProblemsAndLanguages :many
 SELECT
-    e.id, e.title, e.description, e.type, e.started_date, e.end_date, e.max_guilds, e.max_players_per_guild, e.number_of_rooms, e.guilds_per_room, e.room_naming_prefix, e.original_request_id,
+    e.id, e.title, e.description, e.type, e.started_date, e.end_date, e.max_guilds, e.max_players_per_guild, e.number_of_rooms, e.guilds_per_room, e.room_naming_prefix, e.original_request_id, e.spectator_visibility, e.rate_limits, e.integrity_rules,
     cp.id as problem_id,
     cp.title as problem_title,
     cp.difficulty as problem_difficulty,
@@ -1288,22 +1413,25 @@ ORDER BY ecp.score DESC
 `
 
 type GetEventWithProblemsAndLanguagesRow struct {
-	ID                 pgtype.UUID
-	Title              string
-	Description        string
-	Type               EventType
-	StartedDate        pgtype.Timestamptz
-	EndDate            pgtype.Timestamptz
-	MaxGuilds          pgtype.Int4
-	MaxPlayersPerGuild pgtype.Int4
-	NumberOfRooms      pgtype.Int4
-	GuildsPerRoom      pgtype.Int4
-	RoomNamingPrefix   pgtype.Text
-	OriginalRequestID  pgtype.UUID
-	ProblemID          pgtype.UUID
-	ProblemTitle       pgtype.Text
-	ProblemDifficulty  pgtype.Int4
-	ProblemScore       pgtype.Int4
+	ID                  pgtype.UUID
+	Title               string
+	Description         string
+	Type                EventType
+	StartedDate         pgtype.Timestamptz
+	EndDate             pgtype.Timestamptz
+	MaxGuilds           pgtype.Int4
+	MaxPlayersPerGuild  pgtype.Int4
+	NumberOfRooms       pgtype.Int4
+	GuildsPerRoom       pgtype.Int4
+	RoomNamingPrefix    pgtype.Text
+	OriginalRequestID   pgtype.UUID
+	SpectatorVisibility string
+	RateLimits          []byte
+	IntegrityRules      []byte
+	ProblemID           pgtype.UUID
+	ProblemTitle        pgtype.Text
+	ProblemDifficulty   pgtype.Int4
+	ProblemScore        pgtype.Int4
 }
 
 // Complex Queries
@@ -1329,6 +1457,9 @@ func (q *Queries) GetEventWithProblemsAndLanguages(ctx context.Context, id pgtyp
 			&i.GuildsPerRoom,
 			&i.RoomNamingPrefix,
 			&i.OriginalRequestID,
+			&i.SpectatorVisibility,
+			&i.RateLimits,
+			&i.IntegrityRules,
 			&i.ProblemID,
 			&i.ProblemTitle,
 			&i.ProblemDifficulty,
@@ -1345,7 +1476,7 @@ func (q *Queries) GetEventWithProblemsAndLanguages(ctx context.Context, id pgtyp
 }
 
 const getEvents = `-- name: GetEvents :many
-SELECT id, title, description, type, started_date, end_date, max_guilds, max_players_per_guild, number_of_rooms, guilds_per_room, room_naming_prefix, original_request_id FROM events
+SELECT id, title, description, type, started_date, end_date, max_guilds, max_players_per_guild, number_of_rooms, guilds_per_room, room_naming_prefix, original_request_id, spectator_visibility, rate_limits, integrity_rules FROM events
 ORDER BY started_date ASC
 LIMIT $1
 OFFSET $2
@@ -1378,6 +1509,9 @@ func (q *Queries) GetEvents(ctx context.Context, arg GetEventsParams) ([]Event,
 			&i.GuildsPerRoom,
 			&i.RoomNamingPrefix,
 			&i.OriginalRequestID,
+			&i.SpectatorVisibility,
+			&i.RateLimits,
+			&i.IntegrityRules,
 		); err != nil {
 			return nil, err
 		}
@@ -1390,7 +1524,7 @@ func (q *Queries) GetEvents(ctx context.Context, arg GetEventsParams) ([]Event,
 }
 
 const getEventsByType = `-- name: GetEventsByType :many
-SELECT id, title, description, type, started_date, end_date, max_guilds, max_players_per_guild, number_of_rooms, guilds_per_room, room_naming_prefix, original_request_id FROM events
+SELECT id, title, description, type, started_date, end_date, max_guilds, max_players_per_guild, number_of_rooms, guilds_per_room, room_naming_prefix, original_request_id, spectator_visibility, rate_limits, integrity_rules FROM events
 WHERE type = $1
 ORDER BY started_date ASC
 `
@@ -1417,6 +1551,9 @@ func (q *Queries) GetEventsByType(ctx context.Context, type_ EventType) ([]Event
 			&i.GuildsPerRoom,
 			&i.RoomNamingPrefix,
 			&i.OriginalRequestID,
+			&i.SpectatorVisibility,
+			&i.RateLimits,
+			&i.IntegrityRules,
 		); err != nil {
 			return nil, err
 		}
@@ -1511,7 +1648,7 @@ func (q *Queries) GetGuildLeaderboardByGuild(ctx context.Context, guildID pgtype
 }
 
 const getGuildParticipantsByGuild = `-- name: GetGuildParticipantsByGuild :many
-SELECT event_id, guild_id, joined_at, room_id FROM event_guild_participants
+SELECT event_id, guild_id, joined_at, room_id, guild_name FROM event_guild_participants
 WHERE guild_id = $1
 ORDER BY joined_at DESC
 `
@@ -1530,6 +1667,7 @@ func (q *Queries) GetGuildParticipantsByGuild(ctx context.Context, guildID pgtyp
 			&i.GuildID,
 			&i.JoinedAt,
 			&i.RoomID,
+			&i.GuildName,
 		); err != nil {
 			return nil, err
 		}
@@ -1542,7 +1680,7 @@ func (q *Queries) GetGuildParticipantsByGuild(ctx context.Context, guildID pgtyp
 }
 
 const getLanguageByID = `-- name: GetLanguageByID :one
-SELECT id, name, compile_cmd, run_cmd, temp_file_dir, temp_file_name FROM languages WHERE id = $1
+SELECT id, name, compile_cmd, run_cmd, temp_file_dir, temp_file_name, aliases, code_placeholder, import_placeholder FROM languages WHERE id = $1
 `
 
 func (q *Queries) GetLanguageByID(ctx context.Context, id pgtype.UUID) (Language, error) {
@@ -1555,12 +1693,15 @@ func (q *Queries) GetLanguageByID(ctx context.Context, id pgtype.UUID) (Language
 		&i.RunCmd,
 		&i.TempFileDir,
 		&i.TempFileName,
+		&i.Aliases,
+		&i.CodePlaceholder,
+		&i.ImportPlaceholder,
 	)
 	return i, err
 }
 
 const getLanguageByName = `-- name: GetLanguageByName :one
-SELECT id, name, compile_cmd, run_cmd, temp_file_dir, temp_file_name FROM languages WHERE name = $1
+SELECT id, name, compile_cmd, run_cmd, temp_file_dir, temp_file_name, aliases, code_placeholder, import_placeholder FROM languages WHERE name = $1
 `
 
 func (q *Queries) GetLanguageByName(ctx context.Context, name string) (Language, error) {
@@ -1573,12 +1714,15 @@ func (q *Queries) GetLanguageByName(ctx context.Context, name string) (Language,
 		&i.RunCmd,
 		&i.TempFileDir,
 		&i.TempFileName,
+		&i.Aliases,
+		&i.CodePlaceholder,
+		&i.ImportPlaceholder,
 	)
 	return i, err
 }
 
 const getLanguageDetailsForProblem = `-- name: GetLanguageDetailsForProblem :many
-SELECT cpld.code_problem_id, cpld.language_id, cpld.solution_stub, cpld.driver_code, cpld.time_constraint_ms, cpld.space_constraint_mb, l.name as language_name
+SELECT cpld.code_problem_id, cpld.language_id, cpld.solution_stub, cpld.driver_code, cpld.time_constraint_ms, cpld.space_constraint_mb, cpld.batch_mode, cpld.max_code_length, cpld.solution_mode, cpld.required_symbols, l.name as language_name
 FROM code_problem_language_details cpld
 JOIN languages l ON cpld.language_id = l.id
 WHERE cpld.code_problem_id = $1
@@ -1591,6 +1735,10 @@ type GetLanguageDetailsForProblemRow struct {
 	DriverCode        string
 	TimeConstraintMs  int32
 	SpaceConstraintMb int32
+	BatchMode         bool
+	MaxCodeLength     pgtype.Int4
+	SolutionMode      string
+	RequiredSymbols   []string
 	LanguageName      string
 }
 
@@ -1610,6 +1758,10 @@ func (q *Queries) GetLanguageDetailsForProblem(ctx context.Context, codeProblemI
 			&i.DriverCode,
 			&i.TimeConstraintMs,
 			&i.SpaceConstraintMb,
+			&i.BatchMode,
+			&i.MaxCodeLength,
+			&i.SolutionMode,
+			&i.RequiredSymbols,
 			&i.LanguageName,
 		); err != nil {
 			return nil, err
@@ -1623,7 +1775,7 @@ func (q *Queries) GetLanguageDetailsForProblem(ctx context.Context, codeProblemI
 }
 
 const getLanguages = `-- name: GetLanguages :many
-SELECT id, name, compile_cmd, run_cmd, temp_file_dir, temp_file_name
+SELECT id, name, compile_cmd, run_cmd, temp_file_dir, temp_file_name, aliases, code_placeholder, import_placeholder
 FROM languages
 ORDER BY name
 LIMIT $1
@@ -1651,6 +1803,9 @@ func (q *Queries) GetLanguages(ctx context.Context, arg GetLanguagesParams) ([]L
 			&i.RunCmd,
 			&i.TempFileDir,
 			&i.TempFileName,
+			&i.Aliases,
+			&i.CodePlaceholder,
+			&i.ImportPlaceholder,
 		); err != nil {
 			return nil, err
 		}
@@ -1823,7 +1978,7 @@ func (q *Queries) GetLeaderboardByUser(ctx context.Context, userID pgtype.UUID)
 }
 
 const getPlayersByUserID = `-- name: GetPlayersByUserID :many
-SELECT room_id, user_id, username, score, place, state, disconnected_at, joined_at FROM room_players
+SELECT room_id, user_id, username, score, place, state, disconnected_at, joined_at, flagged, flag_reason, guild_id, disqualified FROM room_players
 WHERE user_id = $1
 ORDER BY score DESC
 `
@@ -1846,6 +2001,10 @@ func (q *Queries) GetPlayersByUserID(ctx context.Context, userID pgtype.UUID) ([
 			&i.State,
 			&i.DisconnectedAt,
 			&i.JoinedAt,
+			&i.Flagged,
+			&i.FlagReason,
+			&i.GuildID,
+			&i.Disqualified,
 		); err != nil {
 			return nil, err
 		}
@@ -1937,7 +2096,7 @@ func (q *Queries) GetRoomByID(ctx context.Context, id pgtype.UUID) (Room, error)
 
 const getRoomLeaderboard = `-- name: GetRoomLeaderboard :many
 SELECT
-    rp.room_id, rp.user_id, rp.username, rp.score, rp.place, rp.state, rp.disconnected_at, rp.joined_at,
+    rp.room_id, rp.user_id, rp.username, rp.score, rp.place, rp.state, rp.disconnected_at, rp.joined_at, rp.flagged, rp.flag_reason, rp.guild_id, rp.disqualified,
     COUNT(s.id) as submission_count,
     MAX(s.submitted_at) as last_submission
 FROM room_players rp
@@ -1956,6 +2115,10 @@ type GetRoomLeaderboardRow struct {
 	State           RoomPlayerState
 	DisconnectedAt  pgtype.Timestamptz
 	JoinedAt        pgtype.Timestamptz
+	Flagged         bool
+	FlagReason      pgtype.Text
+	GuildID         pgtype.UUID
+	Disqualified    bool
 	SubmissionCount int64
 	LastSubmission  interface{}
 }
@@ -1978,6 +2141,10 @@ func (q *Queries) GetRoomLeaderboard(ctx context.Context, roomID pgtype.UUID) ([
 			&i.State,
 			&i.DisconnectedAt,
 			&i.JoinedAt,
+			&i.Flagged,
+			&i.FlagReason,
+			&i.GuildID,
+			&i.Disqualified,
 			&i.SubmissionCount,
 			&i.LastSubmission,
 		); err != nil {
@@ -1992,7 +2159,7 @@ func (q *Queries) GetRoomLeaderboard(ctx context.Context, roomID pgtype.UUID) ([
 }
 
 const getRoomPlayer = `-- name: GetRoomPlayer :one
-SELECT room_id, user_id, username, score, place, state, disconnected_at, joined_at FROM room_players
+SELECT room_id, user_id, username, score, place, state, disconnected_at, joined_at, flagged, flag_reason, guild_id, disqualified FROM room_players
 WHERE room_id = $1 AND user_id = $2
 `
 
@@ -2013,12 +2180,16 @@ func (q *Queries) GetRoomPlayer(ctx context.Context, arg GetRoomPlayerParams) (R
 		&i.State,
 		&i.DisconnectedAt,
 		&i.JoinedAt,
+		&i.Flagged,
+		&i.FlagReason,
+		&i.GuildID,
+		&i.Disqualified,
 	)
 	return i, err
 }
 
 const getRoomPlayers = `-- name: GetRoomPlayers :many
-SELECT room_id, user_id, username, score, place, state, disconnected_at, joined_at FROM room_players
+SELECT room_id, user_id, username, score, place, state, disconnected_at, joined_at, flagged, flag_reason, guild_id, disqualified FROM room_players
 WHERE room_id = $1
 ORDER BY score DESC, place ASC
 `
@@ -2041,6 +2212,10 @@ func (q *Queries) GetRoomPlayers(ctx context.Context, roomID pgtype.UUID) ([]Roo
 			&i.State,
 			&i.DisconnectedAt,
 			&i.JoinedAt,
+			&i.Flagged,
+			&i.FlagReason,
+			&i.GuildID,
+			&i.Disqualified,
 		); err != nil {
 			return nil, err
 		}
@@ -2131,7 +2306,7 @@ func (q *Queries) GetRoomsWithEventDetails(ctx context.Context, eventID pgtype.U
 }
 
 const getSubmissionByID = `-- name: GetSubmissionByID :one
-SELECT id, user_id, code_problem_id, language_id, room_id, code_submitted, status, execution_time_ms, submitted_at, submitted_guild_id FROM submissions WHERE id = $1
+SELECT id, user_id, code_problem_id, language_id, room_id, code_submitted, status, execution_time_ms, submitted_at, submitted_guild_id, memory_used_kb, sections FROM submissions WHERE id = $1
 `
 
 func (q *Queries) GetSubmissionByID(ctx context.Context, id pgtype.UUID) (Submission, error) {
@@ -2148,12 +2323,14 @@ func (q *Queries) GetSubmissionByID(ctx context.Context, id pgtype.UUID) (Submis
 		&i.ExecutionTimeMs,
 		&i.SubmittedAt,
 		&i.SubmittedGuildID,
+		&i.MemoryUsedKb,
+		&i.Sections,
 	)
 	return i, err
 }
 
 const getSubmissionsByGuild = `-- name: GetSubmissionsByGuild :many
-SELECT s.id, s.user_id, s.code_problem_id, s.language_id, s.room_id, s.code_submitted, s.status, s.execution_time_ms, s.submitted_at, s.submitted_guild_id, cp.title as problem_title, l.name as language_name
+SELECT s.id, s.user_id, s.code_problem_id, s.language_id, s.room_id, s.code_submitted, s.status, s.execution_time_ms, s.submitted_at, s.submitted_guild_id, s.memory_used_kb, s.sections, cp.title as problem_title, l.name as language_name
 FROM submissions s
 JOIN code_problems cp ON s.code_problem_id = cp.id
 JOIN languages l ON s.language_id = l.id
@@ -2172,6 +2349,8 @@ type GetSubmissionsByGuildRow struct {
 	ExecutionTimeMs  pgtype.Int4
 	SubmittedAt      pgtype.Timestamptz
 	SubmittedGuildID pgtype.UUID
+	MemoryUsedKb     pgtype.Int4
+	Sections         []byte
 	ProblemTitle     string
 	LanguageName     string
 }
@@ -2196,6 +2375,8 @@ func (q *Queries) GetSubmissionsByGuild(ctx context.Context, submittedGuildID pg
 			&i.ExecutionTimeMs,
 			&i.SubmittedAt,
 			&i.SubmittedGuildID,
+			&i.MemoryUsedKb,
+			&i.Sections,
 			&i.ProblemTitle,
 			&i.LanguageName,
 		); err != nil {
@@ -2210,7 +2391,7 @@ func (q *Queries) GetSubmissionsByGuild(ctx context.Context, submittedGuildID pg
 }
 
 const getSubmissionsByProblem = `-- name: GetSubmissionsByProblem :many
-SELECT s.id, s.user_id, s.code_problem_id, s.language_id, s.room_id, s.code_submitted, s.status, s.execution_time_ms, s.submitted_at, s.submitted_guild_id, l.name as language_name
+SELECT s.id, s.user_id, s.code_problem_id, s.language_id, s.room_id, s.code_submitted, s.status, s.execution_time_ms, s.submitted_at, s.submitted_guild_id, s.memory_used_kb, s.sections, l.name as language_name
 FROM submissions s
 JOIN languages l ON s.language_id = l.id
 WHERE s.code_problem_id = $1
@@ -2228,6 +2409,8 @@ type GetSubmissionsByProblemRow struct {
 	ExecutionTimeMs  pgtype.Int4
 	SubmittedAt      pgtype.Timestamptz
 	SubmittedGuildID pgtype.UUID
+	MemoryUsedKb     pgtype.Int4
+	Sections         []byte
 	LanguageName     string
 }
 
@@ -2251,6 +2434,8 @@ func (q *Queries) GetSubmissionsByProblem(ctx context.Context, codeProblemID pgt
 			&i.ExecutionTimeMs,
 			&i.SubmittedAt,
 			&i.SubmittedGuildID,
+			&i.MemoryUsedKb,
+			&i.Sections,
 			&i.LanguageName,
 		); err != nil {
 			return nil, err
@@ -2264,7 +2449,7 @@ func (q *Queries) GetSubmissionsByProblem(ctx context.Context, codeProblemID pgt
 }
 
 const getSubmissionsByRoom = `-- name: GetSubmissionsByRoom :many
-SELECT s.id, s.user_id, s.code_problem_id, s.language_id, s.room_id, s.code_submitted, s.status, s.execution_time_ms, s.submitted_at, s.submitted_guild_id, cp.title as problem_title, l.name as language_name
+SELECT s.id, s.user_id, s.code_problem_id, s.language_id, s.room_id, s.code_submitted, s.status, s.execution_time_ms, s.submitted_at, s.submitted_guild_id, s.memory_used_kb, s.sections, cp.title as problem_title, l.name as language_name
 FROM submissions s
 JOIN code_problems cp ON s.code_problem_id = cp.id
 JOIN languages l ON s.language_id = l.id
@@ -2283,6 +2468,8 @@ type GetSubmissionsByRoomRow struct {
 	ExecutionTimeMs  pgtype.Int4
 	SubmittedAt      pgtype.Timestamptz
 	SubmittedGuildID pgtype.UUID
+	MemoryUsedKb     pgtype.Int4
+	Sections         []byte
 	ProblemTitle     string
 	LanguageName     string
 }
@@ -2307,6 +2494,8 @@ func (q *Queries) GetSubmissionsByRoom(ctx context.Context, roomID pgtype.UUID)
 			&i.ExecutionTimeMs,
 			&i.SubmittedAt,
 			&i.SubmittedGuildID,
+			&i.MemoryUsedKb,
+			&i.Sections,
 			&i.ProblemTitle,
 			&i.LanguageName,
 		); err != nil {
@@ -2321,7 +2510,7 @@ func (q *Queries) GetSubmissionsByRoom(ctx context.Context, roomID pgtype.UUID)
 }
 
 const getSubmissionsByStatus = `-- name: GetSubmissionsByStatus :many
-SELECT s.id, s.user_id, s.code_problem_id, s.language_id, s.room_id, s.code_submitted, s.status, s.execution_time_ms, s.submitted_at, s.submitted_guild_id, cp.title as problem_title, l.name as language_name
+SELECT s.id, s.user_id, s.code_problem_id, s.language_id, s.room_id, s.code_submitted, s.status, s.execution_time_ms, s.submitted_at, s.submitted_guild_id, s.memory_used_kb, s.sections, cp.title as problem_title, l.name as language_name
 FROM submissions s
 JOIN code_problems cp ON s.code_problem_id = cp.id
 JOIN languages l ON s.language_id = l.id
@@ -2340,6 +2529,8 @@ type GetSubmissionsByStatusRow struct {
 	ExecutionTimeMs  pgtype.Int4
 	SubmittedAt      pgtype.Timestamptz
 	SubmittedGuildID pgtype.UUID
+	MemoryUsedKb     pgtype.Int4
+	Sections         []byte
 	ProblemTitle     string
 	LanguageName     string
 }
@@ -2364,6 +2555,8 @@ func (q *Queries) GetSubmissionsByStatus(ctx context.Context, status SubmissionS
 			&i.ExecutionTimeMs,
 			&i.SubmittedAt,
 			&i.SubmittedGuildID,
+			&i.MemoryUsedKb,
+			&i.Sections,
 			&i.ProblemTitle,
 			&i.LanguageName,
 		); err != nil {
@@ -2378,7 +2571,7 @@ func (q *Queries) GetSubmissionsByStatus(ctx context.Context, status SubmissionS
 }
 
 const getSubmissionsByUser = `-- name: GetSubmissionsByUser :many
-SELECT s.id, s.user_id, s.code_problem_id, s.language_id, s.room_id, s.code_submitted, s.status, s.execution_time_ms, s.submitted_at, s.submitted_guild_id, cp.title as problem_title, l.name as language_name
+SELECT s.id, s.user_id, s.code_problem_id, s.language_id, s.room_id, s.code_submitted, s.status, s.execution_time_ms, s.submitted_at, s.submitted_guild_id, s.memory_used_kb, s.sections, cp.title as problem_title, l.name as language_name
 FROM submissions s
 JOIN code_problems cp ON s.code_problem_id = cp.id
 JOIN languages l ON s.language_id = l.id
@@ -2397,6 +2590,8 @@ type GetSubmissionsByUserRow struct {
 	ExecutionTimeMs  pgtype.Int4
 	SubmittedAt      pgtype.Timestamptz
 	SubmittedGuildID pgtype.UUID
+	MemoryUsedKb     pgtype.Int4
+	Sections         []byte
 	ProblemTitle     string
 	LanguageName     string
 }
@@ -2421,6 +2616,8 @@ func (q *Queries) GetSubmissionsByUser(ctx context.Context, userID pgtype.UUID)
 			&i.ExecutionTimeMs,
 			&i.SubmittedAt,
 			&i.SubmittedGuildID,
+			&i.MemoryUsedKb,
+			&i.Sections,
 			&i.ProblemTitle,
 			&i.LanguageName,
 		); err != nil {
@@ -2724,7 +2921,7 @@ const updateCodeProblem = `-- name: UpdateCodeProblem :one
 UPDATE code_problems
 SET title = $2, problem_statement = $3, difficulty = $4
 WHERE id = $1
-RETURNING id, title, problem_statement, difficulty, created_at
+RETURNING id, title, problem_statement, difficulty, created_at, signature
 `
 
 type UpdateCodeProblemParams struct {
@@ -2748,15 +2945,16 @@ func (q *Queries) UpdateCodeProblem(ctx context.Context, arg UpdateCodeProblemPa
 		&i.ProblemStatement,
 		&i.Difficulty,
 		&i.CreatedAt,
+		&i.Signature,
 	)
 	return i, err
 }
 
 const updateCodeProblemLanguageDetail = `-- name: UpdateCodeProblemLanguageDetail :one
 UPDATE code_problem_language_details
-SET solution_stub = $3, driver_code = $4, time_constraint_ms = $5, space_constraint_mb = $6
+SET solution_stub = $3, driver_code = $4, time_constraint_ms = $5, space_constraint_mb = $6, batch_mode = $7, max_code_length = $8, solution_mode = $9, required_symbols = $10
 WHERE code_problem_id = $1 AND language_id = $2
-RETURNING code_problem_id, language_id, solution_stub, driver_code, time_constraint_ms, space_constraint_mb
+RETURNING code_problem_id, language_id, solution_stub, driver_code, time_constraint_ms, space_constraint_mb, batch_mode, max_code_length, solution_mode, required_symbols
 `
 
 type UpdateCodeProblemLanguageDetailParams struct {
@@ -2766,6 +2964,10 @@ type UpdateCodeProblemLanguageDetailParams struct {
 	DriverCode        string
 	TimeConstraintMs  int32
 	SpaceConstraintMb int32
+	BatchMode         bool
+	MaxCodeLength     pgtype.Int4
+	SolutionMode      string
+	RequiredSymbols   []string
 }
 
 func (q *Queries) UpdateCodeProblemLanguageDetail(ctx context.Context, arg UpdateCodeProblemLanguageDetailParams) (CodeProblemLanguageDetail, error) {
@@ -2776,6 +2978,10 @@ func (q *Queries) UpdateCodeProblemLanguageDetail(ctx context.Context, arg Updat
 		arg.DriverCode,
 		arg.TimeConstraintMs,
 		arg.SpaceConstraintMb,
+		arg.BatchMode,
+		arg.MaxCodeLength,
+		arg.SolutionMode,
+		arg.RequiredSymbols,
 	)
 	var i CodeProblemLanguageDetail
 	err := row.Scan(
@@ -2785,6 +2991,36 @@ func (q *Queries) UpdateCodeProblemLanguageDetail(ctx context.Context, arg Updat
 		&i.DriverCode,
 		&i.TimeConstraintMs,
 		&i.SpaceConstraintMb,
+		&i.BatchMode,
+		&i.MaxCodeLength,
+		&i.SolutionMode,
+		&i.RequiredSymbols,
+	)
+	return i, err
+}
+
+const updateCodeProblemSignature = `-- name: UpdateCodeProblemSignature :one
+UPDATE code_problems
+SET signature = $2
+WHERE id = $1
+RETURNING id, title, problem_statement, difficulty, created_at, signature
+`
+
+type UpdateCodeProblemSignatureParams struct {
+	ID        pgtype.UUID
+	Signature []byte
+}
+
+func (q *Queries) UpdateCodeProblemSignature(ctx context.Context, arg UpdateCodeProblemSignatureParams) (CodeProblem, error) {
+	row := q.db.QueryRow(ctx, updateCodeProblemSignature, arg.ID, arg.Signature)
+	var i CodeProblem
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.ProblemStatement,
+		&i.Difficulty,
+		&i.CreatedAt,
+		&i.Signature,
 	)
 	return i, err
 }
@@ -2803,7 +3039,7 @@ SET
   guilds_per_room = $10,
   room_naming_prefix = $11
 WHERE id = $1
-RETURNING id, title, description, type, started_date, end_date, max_guilds, max_players_per_guild, number_of_rooms, guilds_per_room, room_naming_prefix, original_request_id
+RETURNING id, title, description, type, started_date, end_date, max_guilds, max_players_per_guild, number_of_rooms, guilds_per_room, room_naming_prefix, original_request_id, spectator_visibility, rate_limits, integrity_rules
 `
 
 type UpdateEventParams struct {
@@ -2848,6 +3084,9 @@ func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Event
 		&i.GuildsPerRoom,
 		&i.RoomNamingPrefix,
 		&i.OriginalRequestID,
+		&i.SpectatorVisibility,
+		&i.RateLimits,
+		&i.IntegrityRules,
 	)
 	return i, err
 }
@@ -2876,7 +3115,7 @@ const updateEventGuildParticipantRoom = `-- name: UpdateEventGuildParticipantRoo
 UPDATE event_guild_participants
 SET room_id = $3
 WHERE event_id = $1 AND guild_id = $2
-RETURNING event_id, guild_id, joined_at, room_id
+RETURNING event_id, guild_id, joined_at, room_id, guild_name
 `
 
 type UpdateEventGuildParticipantRoomParams struct {
@@ -2893,6 +3132,7 @@ func (q *Queries) UpdateEventGuildParticipantRoom(ctx context.Context, arg Updat
 		&i.GuildID,
 		&i.JoinedAt,
 		&i.RoomID,
+		&i.GuildName,
 	)
 	return i, err
 }
@@ -2978,18 +3218,21 @@ func (q *Queries) UpdateGuildLeaderboardEntry(ctx context.Context, arg UpdateGui
 
 const updateLanguage = `-- name: UpdateLanguage :one
 UPDATE languages
-SET name = $2, compile_cmd = $3, run_cmd = $4, temp_file_dir = $5, temp_file_name = $6
+SET name = $2, compile_cmd = $3, run_cmd = $4, temp_file_dir = $5, temp_file_name = $6, aliases = $7, code_placeholder = $8, import_placeholder = $9
 WHERE id = $1
-RETURNING id, name, compile_cmd, run_cmd, temp_file_dir, temp_file_name
+RETURNING id, name, compile_cmd, run_cmd, temp_file_dir, temp_file_name, aliases, code_placeholder, import_placeholder
 `
 
 type UpdateLanguageParams struct {
-	ID           pgtype.UUID
-	Name         string
-	CompileCmd   string
-	RunCmd       string
-	TempFileDir  pgtype.Text
-	TempFileName pgtype.Text
+	ID                pgtype.UUID
+	Name              string
+	CompileCmd        string
+	RunCmd            string
+	TempFileDir       pgtype.Text
+	TempFileName      pgtype.Text
+	Aliases           []string
+	CodePlaceholder   string
+	ImportPlaceholder string
 }
 
 func (q *Queries) UpdateLanguage(ctx context.Context, arg UpdateLanguageParams) (Language, error) {
@@ -3000,6 +3243,9 @@ func (q *Queries) UpdateLanguage(ctx context.Context, arg UpdateLanguageParams)
 		arg.RunCmd,
 		arg.TempFileDir,
 		arg.TempFileName,
+		arg.Aliases,
+		arg.CodePlaceholder,
+		arg.ImportPlaceholder,
 	)
 	var i Language
 	err := row.Scan(
@@ -3009,6 +3255,9 @@ func (q *Queries) UpdateLanguage(ctx context.Context, arg UpdateLanguageParams)
 		&i.RunCmd,
 		&i.TempFileDir,
 		&i.TempFileName,
+		&i.Aliases,
+		&i.CodePlaceholder,
+		&i.ImportPlaceholder,
 	)
 	return i, err
 }
@@ -3071,7 +3320,7 @@ const updateRoomPlayerScore = `-- name: UpdateRoomPlayerScore :one
 UPDATE room_players
 SET score = $3, place = $4
 WHERE room_id = $1 AND user_id = $2
-RETURNING room_id, user_id, username, score, place, state, disconnected_at, joined_at
+RETURNING room_id, user_id, username, score, place, state, disconnected_at, joined_at, flagged, flag_reason, guild_id, disqualified
 `
 
 type UpdateRoomPlayerScoreParams struct {
@@ -3098,6 +3347,10 @@ func (q *Queries) UpdateRoomPlayerScore(ctx context.Context, arg UpdateRoomPlaye
 		&i.State,
 		&i.DisconnectedAt,
 		&i.JoinedAt,
+		&i.Flagged,
+		&i.FlagReason,
+		&i.GuildID,
+		&i.Disqualified,
 	)
 	return i, err
 }
@@ -3106,7 +3359,7 @@ const updateRoomPlayerState = `-- name: UpdateRoomPlayerState :one
 UPDATE room_players
 SET state = $3
 WHERE room_id = $1 AND user_id = $2
-RETURNING room_id, user_id, username, score, place, state, disconnected_at, joined_at
+RETURNING room_id, user_id, username, score, place, state, disconnected_at, joined_at, flagged, flag_reason, guild_id, disqualified
 `
 
 type UpdateRoomPlayerStateParams struct {
@@ -3127,6 +3380,49 @@ func (q *Queries) UpdateRoomPlayerState(ctx context.Context, arg UpdateRoomPlaye
 		&i.State,
 		&i.DisconnectedAt,
 		&i.JoinedAt,
+		&i.Flagged,
+		&i.FlagReason,
+		&i.GuildID,
+		&i.Disqualified,
+	)
+	return i, err
+}
+
+const updateSubmissionResult = `-- name: UpdateSubmissionResult :one
+UPDATE submissions
+SET status = $2, execution_time_ms = $3, memory_used_kb = $4
+WHERE id = $1
+RETURNING id, user_id, code_problem_id, language_id, room_id, code_submitted, status, execution_time_ms, submitted_at, submitted_guild_id, memory_used_kb, sections
+`
+
+type UpdateSubmissionResultParams struct {
+	ID              pgtype.UUID
+	Status          SubmissionStatus
+	ExecutionTimeMs pgtype.Int4
+	MemoryUsedKb    pgtype.Int4
+}
+
+func (q *Queries) UpdateSubmissionResult(ctx context.Context, arg UpdateSubmissionResultParams) (Submission, error) {
+	row := q.db.QueryRow(ctx, updateSubmissionResult,
+		arg.ID,
+		arg.Status,
+		arg.ExecutionTimeMs,
+		arg.MemoryUsedKb,
+	)
+	var i Submission
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CodeProblemID,
+		&i.LanguageID,
+		&i.RoomID,
+		&i.CodeSubmitted,
+		&i.Status,
+		&i.ExecutionTimeMs,
+		&i.SubmittedAt,
+		&i.SubmittedGuildID,
+		&i.MemoryUsedKb,
+		&i.Sections,
 	)
 	return i, err
 }
@@ -3135,7 +3431,7 @@ const updateSubmissionStatus = `-- name: UpdateSubmissionStatus :one
 UPDATE submissions
 SET status = $2
 WHERE id = $1
-RETURNING id, user_id, code_problem_id, language_id, room_id, code_submitted, status, execution_time_ms, submitted_at, submitted_guild_id
+RETURNING id, user_id, code_problem_id, language_id, room_id, code_submitted, status, execution_time_ms, submitted_at, submitted_guild_id, memory_used_kb, sections
 `
 
 type UpdateSubmissionStatusParams struct {
@@ -3157,6 +3453,8 @@ func (q *Queries) UpdateSubmissionStatus(ctx context.Context, arg UpdateSubmissi
 		&i.ExecutionTimeMs,
 		&i.SubmittedAt,
 		&i.SubmittedGuildID,
+		&i.MemoryUsedKb,
+		&i.Sections,
 	)
 	return i, err
 }
@@ -3211,3 +3509,889 @@ func (q *Queries) UpdateTestCase(ctx context.Context, arg UpdateTestCaseParams)
 	)
 	return i, err
 }
+
+const upsertCodeProblemLanguageDetail = `-- name: UpsertCodeProblemLanguageDetail :one
+INSERT INTO code_problem_language_details (code_problem_id, language_id, solution_stub, driver_code, solution_mode, required_symbols)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (language_id, code_problem_id) DO UPDATE
+SET solution_stub = EXCLUDED.solution_stub, driver_code = EXCLUDED.driver_code, solution_mode = EXCLUDED.solution_mode, required_symbols = EXCLUDED.required_symbols,
+    batch_mode = false
+RETURNING code_problem_id, language_id, solution_stub, driver_code, time_constraint_ms, space_constraint_mb, batch_mode, max_code_length, solution_mode, required_symbols
+`
+
+type UpsertCodeProblemLanguageDetailParams struct {
+	CodeProblemID   pgtype.UUID
+	LanguageID      pgtype.UUID
+	SolutionStub    string
+	DriverCode      string
+	SolutionMode    string
+	RequiredSymbols []string
+}
+
+// Generated drivers read a single test case, so batch mode is turned off.
+func (q *Queries) UpsertCodeProblemLanguageDetail(ctx context.Context, arg UpsertCodeProblemLanguageDetailParams) (CodeProblemLanguageDetail, error) {
+	row := q.db.QueryRow(ctx, upsertCodeProblemLanguageDetail,
+		arg.CodeProblemID,
+		arg.LanguageID,
+		arg.SolutionStub,
+		arg.DriverCode,
+		arg.SolutionMode,
+		arg.RequiredSymbols,
+	)
+	var i CodeProblemLanguageDetail
+	err := row.Scan(
+		&i.CodeProblemID,
+		&i.LanguageID,
+		&i.SolutionStub,
+		&i.DriverCode,
+		&i.TimeConstraintMs,
+		&i.SpaceConstraintMb,
+		&i.BatchMode,
+		&i.MaxCodeLength,
+		&i.SolutionMode,
+		&i.RequiredSymbols,
+	)
+	return i, err
+}
+
+const updateEventRateLimits = `-- name: UpdateEventRateLimits :one
+UPDATE events
+SET rate_limits = $2
+WHERE id = $1
+RETURNING id, title, description, type, started_date, end_date, max_guilds, max_players_per_guild, number_of_rooms, guilds_per_room, room_naming_prefix, original_request_id, spectator_visibility, rate_limits, integrity_rules
+`
+
+type UpdateEventRateLimitsParams struct {
+	ID         pgtype.UUID
+	RateLimits []byte
+}
+
+func (q *Queries) UpdateEventRateLimits(ctx context.Context, arg UpdateEventRateLimitsParams) (Event, error) {
+	row := q.db.QueryRow(ctx, updateEventRateLimits, arg.ID, arg.RateLimits)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.Type,
+		&i.StartedDate,
+		&i.EndDate,
+		&i.MaxGuilds,
+		&i.MaxPlayersPerGuild,
+		&i.NumberOfRooms,
+		&i.GuildsPerRoom,
+		&i.RoomNamingPrefix,
+		&i.OriginalRequestID,
+		&i.SpectatorVisibility,
+		&i.RateLimits,
+		&i.IntegrityRules,
+	)
+	return i, err
+}
+
+const getLatestSubmissionsForSimilarity = `-- name: GetLatestSubmissionsForSimilarity :many
+SELECT DISTINCT ON (s.user_id, s.language_id)
+  s.id, s.user_id, s.language_id, s.code_submitted, s.sections, s.submitted_guild_id, l.name as language_name
+FROM submissions s
+JOIN languages l ON s.language_id = l.id
+LEFT JOIN rooms r ON s.room_id = r.id
+WHERE s.code_problem_id = $1
+  AND ($2::uuid IS NULL OR r.event_id = $2)
+ORDER BY s.user_id, s.language_id, s.submitted_at DESC
+`
+
+type GetLatestSubmissionsForSimilarityParams struct {
+	CodeProblemID pgtype.UUID
+	EventID       pgtype.UUID
+}
+
+type GetLatestSubmissionsForSimilarityRow struct {
+	ID               pgtype.UUID
+	UserID           pgtype.UUID
+	LanguageID       pgtype.UUID
+	CodeSubmitted    string
+	Sections         []byte
+	SubmittedGuildID pgtype.UUID
+	LanguageName     string
+}
+
+func (q *Queries) GetLatestSubmissionsForSimilarity(ctx context.Context, arg GetLatestSubmissionsForSimilarityParams) ([]GetLatestSubmissionsForSimilarityRow, error) {
+	rows, err := q.db.Query(ctx, getLatestSubmissionsForSimilarity, arg.CodeProblemID, arg.EventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLatestSubmissionsForSimilarityRow
+	for rows.Next() {
+		var i GetLatestSubmissionsForSimilarityRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.LanguageID,
+			&i.CodeSubmitted,
+			&i.Sections,
+			&i.SubmittedGuildID,
+			&i.LanguageName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteSubmissionSimilaritiesByScope = `-- name: DeleteSubmissionSimilaritiesByScope :exec
+DELETE FROM submission_similarities
+WHERE code_problem_id = $1
+  AND event_id IS NOT DISTINCT FROM $2
+`
+
+type DeleteSubmissionSimilaritiesByScopeParams struct {
+	CodeProblemID pgtype.UUID
+	EventID       pgtype.UUID
+}
+
+func (q *Queries) DeleteSubmissionSimilaritiesByScope(ctx context.Context, arg DeleteSubmissionSimilaritiesByScopeParams) error {
+	_, err := q.db.Exec(ctx, deleteSubmissionSimilaritiesByScope, arg.CodeProblemID, arg.EventID)
+	return err
+}
+
+const upsertSubmissionSimilarity = `-- name: UpsertSubmissionSimilarity :one
+INSERT INTO submission_similarities (event_id, code_problem_id, submission_a_id, submission_b_id, score, matches)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (submission_a_id, submission_b_id)
+DO UPDATE SET score = EXCLUDED.score, matches = EXCLUDED.matches, detected_at = now()
+RETURNING id, event_id, code_problem_id, submission_a_id, submission_b_id, score, matches, detected_at
+`
+
+type UpsertSubmissionSimilarityParams struct {
+	EventID       pgtype.UUID
+	CodeProblemID pgtype.UUID
+	SubmissionAID pgtype.UUID
+	SubmissionBID pgtype.UUID
+	Score         float64
+	Matches       []byte
+}
+
+func (q *Queries) UpsertSubmissionSimilarity(ctx context.Context, arg UpsertSubmissionSimilarityParams) (SubmissionSimilarity, error) {
+	row := q.db.QueryRow(ctx, upsertSubmissionSimilarity,
+		arg.EventID,
+		arg.CodeProblemID,
+		arg.SubmissionAID,
+		arg.SubmissionBID,
+		arg.Score,
+		arg.Matches,
+	)
+	var i SubmissionSimilarity
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.CodeProblemID,
+		&i.SubmissionAID,
+		&i.SubmissionBID,
+		&i.Score,
+		&i.Matches,
+		&i.DetectedAt,
+	)
+	return i, err
+}
+
+const listSubmissionSimilarities = `-- name: ListSubmissionSimilarities :many
+SELECT ss.id, ss.event_id, ss.code_problem_id, ss.submission_a_id, ss.submission_b_id, ss.score, ss.matches, ss.detected_at,
+  sa.user_id as user_a_id, sa.code_submitted as code_a, sa.sections as sections_a, sa.submitted_guild_id as guild_a_id,
+  sb.user_id as user_b_id, sb.code_submitted as code_b, sb.sections as sections_b, sb.submitted_guild_id as guild_b_id,
+  l.name as language_name
+FROM submission_similarities ss
+JOIN submissions sa ON ss.submission_a_id = sa.id
+JOIN submissions sb ON ss.submission_b_id = sb.id
+JOIN languages l ON sa.language_id = l.id
+WHERE ($1::uuid IS NULL OR ss.event_id = $1)
+  AND ($2::uuid IS NULL OR ss.code_problem_id = $2)
+  AND ss.score >= $3::double precision
+ORDER BY ss.score DESC, ss.detected_at DESC
+LIMIT $4 OFFSET $5
+`
+
+type ListSubmissionSimilaritiesParams struct {
+	EventID       pgtype.UUID
+	CodeProblemID pgtype.UUID
+	MinScore      float64
+	PageLimit     int32
+	PageOffset    int32
+}
+
+type ListSubmissionSimilaritiesRow struct {
+	ID            pgtype.UUID
+	EventID       pgtype.UUID
+	CodeProblemID pgtype.UUID
+	SubmissionAID pgtype.UUID
+	SubmissionBID pgtype.UUID
+	Score         float64
+	Matches       []byte
+	DetectedAt    pgtype.Timestamptz
+	UserAID       pgtype.UUID
+	CodeA         string
+	SectionsA     []byte
+	GuildAID      pgtype.UUID
+	UserBID       pgtype.UUID
+	CodeB         string
+	SectionsB     []byte
+	GuildBID      pgtype.UUID
+	LanguageName  string
+}
+
+func (q *Queries) ListSubmissionSimilarities(ctx context.Context, arg ListSubmissionSimilaritiesParams) ([]ListSubmissionSimilaritiesRow, error) {
+	rows, err := q.db.Query(ctx, listSubmissionSimilarities,
+		arg.EventID,
+		arg.CodeProblemID,
+		arg.MinScore,
+		arg.PageLimit,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSubmissionSimilaritiesRow
+	for rows.Next() {
+		var i ListSubmissionSimilaritiesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.CodeProblemID,
+			&i.SubmissionAID,
+			&i.SubmissionBID,
+			&i.Score,
+			&i.Matches,
+			&i.DetectedAt,
+			&i.UserAID,
+			&i.CodeA,
+			&i.SectionsA,
+			&i.GuildAID,
+			&i.UserBID,
+			&i.CodeB,
+			&i.SectionsB,
+			&i.GuildBID,
+			&i.LanguageName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateEventIntegrityRules = `-- name: UpdateEventIntegrityRules :one
+UPDATE events
+SET integrity_rules = $2
+WHERE id = $1
+RETURNING id, title, description, type, started_date, end_date, max_guilds, max_players_per_guild, number_of_rooms, guilds_per_room, room_naming_prefix, original_request_id, spectator_visibility, rate_limits, integrity_rules
+`
+
+type UpdateEventIntegrityRulesParams struct {
+	ID             pgtype.UUID
+	IntegrityRules []byte
+}
+
+func (q *Queries) UpdateEventIntegrityRules(ctx context.Context, arg UpdateEventIntegrityRulesParams) (Event, error) {
+	row := q.db.QueryRow(ctx, updateEventIntegrityRules, arg.ID, arg.IntegrityRules)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.Type,
+		&i.StartedDate,
+		&i.EndDate,
+		&i.MaxGuilds,
+		&i.MaxPlayersPerGuild,
+		&i.NumberOfRooms,
+		&i.GuildsPerRoom,
+		&i.RoomNamingPrefix,
+		&i.OriginalRequestID,
+		&i.SpectatorVisibility,
+		&i.RateLimits,
+		&i.IntegrityRules,
+	)
+	return i, err
+}
+
+const updateRoomPlayerFlag = `-- name: UpdateRoomPlayerFlag :exec
+UPDATE room_players
+SET flagged = $3, flag_reason = $4
+WHERE room_id = $1 AND user_id = $2
+`
+
+type UpdateRoomPlayerFlagParams struct {
+	RoomID     pgtype.UUID
+	UserID     pgtype.UUID
+	Flagged    bool
+	FlagReason pgtype.Text
+}
+
+func (q *Queries) UpdateRoomPlayerFlag(ctx context.Context, arg UpdateRoomPlayerFlagParams) error {
+	_, err := q.db.Exec(ctx, updateRoomPlayerFlag,
+		arg.RoomID,
+		arg.UserID,
+		arg.Flagged,
+		arg.FlagReason,
+	)
+	return err
+}
+
+const createIntegritySignals = `-- name: CreateIntegritySignals :exec
+INSERT INTO integrity_signals (event_id, room_id, user_id, kind, value, occurred_at)
+SELECT $1, $2, $3, s.kind, s.value, s.occurred_at
+FROM unnest($4::text[], $5::integer[], $6::timestamptz[]) AS s(kind, value, occurred_at)
+`
+
+type CreateIntegritySignalsParams struct {
+	EventID     pgtype.UUID
+	RoomID      pgtype.UUID
+	UserID      pgtype.UUID
+	Kinds       []string
+	Values      []int32
+	OccurredAts []pgtype.Timestamptz
+}
+
+func (q *Queries) CreateIntegritySignals(ctx context.Context, arg CreateIntegritySignalsParams) error {
+	_, err := q.db.Exec(ctx, createIntegritySignals,
+		arg.EventID,
+		arg.RoomID,
+		arg.UserID,
+		arg.Kinds,
+		arg.Values,
+		arg.OccurredAts,
+	)
+	return err
+}
+
+const getPlayerIntegritySignals = `-- name: GetPlayerIntegritySignals :many
+SELECT id, event_id, room_id, user_id, kind, value, occurred_at, received_at FROM integrity_signals
+WHERE room_id = $1 AND user_id = $2
+ORDER BY occurred_at
+`
+
+type GetPlayerIntegritySignalsParams struct {
+	RoomID pgtype.UUID
+	UserID pgtype.UUID
+}
+
+func (q *Queries) GetPlayerIntegritySignals(ctx context.Context, arg GetPlayerIntegritySignalsParams) ([]IntegritySignal, error) {
+	rows, err := q.db.Query(ctx, getPlayerIntegritySignals, arg.RoomID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []IntegritySignal
+	for rows.Next() {
+		var i IntegritySignal
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.RoomID,
+			&i.UserID,
+			&i.Kind,
+			&i.Value,
+			&i.OccurredAt,
+			&i.ReceivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIntegritySummariesByEvent = `-- name: GetIntegritySummariesByEvent :many
+SELECT s.room_id, s.user_id, s.kind,
+  count(*)::integer as signal_count,
+  max(s.value)::integer as max_value,
+  sum(s.value)::bigint as total_value,
+  max(s.occurred_at)::timestamptz as last_occurred_at,
+  rp.username, rp.flagged, rp.flag_reason
+FROM integrity_signals s
+LEFT JOIN room_players rp ON rp.room_id = s.room_id AND rp.user_id = s.user_id
+WHERE s.event_id = $1
+GROUP BY s.room_id, s.user_id, s.kind, rp.username, rp.flagged, rp.flag_reason
+ORDER BY s.room_id, s.user_id, s.kind
+`
+
+type GetIntegritySummariesByEventRow struct {
+	RoomID         pgtype.UUID
+	UserID         pgtype.UUID
+	Kind           string
+	SignalCount    int32
+	MaxValue       int32
+	TotalValue     int64
+	LastOccurredAt pgtype.Timestamptz
+	Username       pgtype.Text
+	Flagged        pgtype.Bool
+	FlagReason     pgtype.Text
+}
+
+func (q *Queries) GetIntegritySummariesByEvent(ctx context.Context, eventID pgtype.UUID) ([]GetIntegritySummariesByEventRow, error) {
+	rows, err := q.db.Query(ctx, getIntegritySummariesByEvent, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetIntegritySummariesByEventRow
+	for rows.Next() {
+		var i GetIntegritySummariesByEventRow
+		if err := rows.Scan(
+			&i.RoomID,
+			&i.UserID,
+			&i.Kind,
+			&i.SignalCount,
+			&i.MaxValue,
+			&i.TotalValue,
+			&i.LastOccurredAt,
+			&i.Username,
+			&i.Flagged,
+			&i.FlagReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createAuditLog = `-- name: CreateAuditLog :one
+INSERT INTO audit_logs (actor_id, action, target_type, target_id, event_id, before, after, request_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, actor_id, action, target_type, target_id, event_id, before, after, request_id, created_at
+`
+
+type CreateAuditLogParams struct {
+	ActorID    pgtype.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	EventID    pgtype.UUID
+	Before     []byte
+	After      []byte
+	RequestID  pgtype.Text
+}
+
+func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error) {
+	row := q.db.QueryRow(ctx, createAuditLog,
+		arg.ActorID,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.EventID,
+		arg.Before,
+		arg.After,
+		arg.RequestID,
+	)
+	var i AuditLog
+	err := row.Scan(
+		&i.ID,
+		&i.ActorID,
+		&i.Action,
+		&i.TargetType,
+		&i.TargetID,
+		&i.EventID,
+		&i.Before,
+		&i.After,
+		&i.RequestID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAuditLogs = `-- name: ListAuditLogs :many
+SELECT id, actor_id, action, target_type, target_id, event_id, before, after, request_id, created_at FROM audit_logs
+WHERE ($1::uuid IS NULL OR actor_id = $1)
+  AND ($2::text IS NULL OR action = $2)
+  AND ($3::text IS NULL OR target_type = $3)
+  AND ($4::text IS NULL OR target_id = $4)
+  AND ($5::uuid IS NULL OR event_id = $5)
+  AND ($6::timestamptz IS NULL OR created_at >= $6)
+  AND ($7::timestamptz IS NULL OR created_at < $7)
+ORDER BY created_at DESC
+LIMIT $8 OFFSET $9
+`
+
+type ListAuditLogsParams struct {
+	ActorID    pgtype.UUID
+	Action     pgtype.Text
+	TargetType pgtype.Text
+	TargetID   pgtype.Text
+	EventID    pgtype.UUID
+	Since      pgtype.Timestamptz
+	Until      pgtype.Timestamptz
+	PageLimit  int32
+	PageOffset int32
+}
+
+func (q *Queries) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error) {
+	rows, err := q.db.Query(ctx, listAuditLogs,
+		arg.ActorID,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.EventID,
+		arg.Since,
+		arg.Until,
+		arg.PageLimit,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActorID,
+			&i.Action,
+			&i.TargetType,
+			&i.TargetID,
+			&i.EventID,
+			&i.Before,
+			&i.After,
+			&i.RequestID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createScoreAdjustment = `-- name: CreateScoreAdjustment :one
+INSERT INTO score_adjustments (event_id, room_id, user_id, points, reason, created_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, event_id, room_id, user_id, points, reason, created_by, created_at
+`
+
+type CreateScoreAdjustmentParams struct {
+	EventID   pgtype.UUID
+	RoomID    pgtype.UUID
+	UserID    pgtype.UUID
+	Points    int32
+	Reason    string
+	CreatedBy pgtype.UUID
+}
+
+// Standings
+func (q *Queries) CreateScoreAdjustment(ctx context.Context, arg CreateScoreAdjustmentParams) (ScoreAdjustment, error) {
+	row := q.db.QueryRow(ctx, createScoreAdjustment,
+		arg.EventID,
+		arg.RoomID,
+		arg.UserID,
+		arg.Points,
+		arg.Reason,
+		arg.CreatedBy,
+	)
+	var i ScoreAdjustment
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.RoomID,
+		&i.UserID,
+		&i.Points,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listScoreAdjustmentsByEvent = `-- name: ListScoreAdjustmentsByEvent :many
+SELECT id, event_id, room_id, user_id, points, reason, created_by, created_at FROM score_adjustments
+WHERE event_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListScoreAdjustmentsByEvent(ctx context.Context, eventID pgtype.UUID) ([]ScoreAdjustment, error) {
+	rows, err := q.db.Query(ctx, listScoreAdjustmentsByEvent, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ScoreAdjustment
+	for rows.Next() {
+		var i ScoreAdjustment
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.RoomID,
+			&i.UserID,
+			&i.Points,
+			&i.Reason,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createDisqualification = `-- name: CreateDisqualification :one
+INSERT INTO disqualifications (event_id, user_id, guild_id, reason, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, event_id, user_id, guild_id, reason, created_by, created_at
+`
+
+type CreateDisqualificationParams struct {
+	EventID   pgtype.UUID
+	UserID    pgtype.UUID
+	GuildID   pgtype.UUID
+	Reason    string
+	CreatedBy pgtype.UUID
+}
+
+func (q *Queries) CreateDisqualification(ctx context.Context, arg CreateDisqualificationParams) (Disqualification, error) {
+	row := q.db.QueryRow(ctx, createDisqualification,
+		arg.EventID,
+		arg.UserID,
+		arg.GuildID,
+		arg.Reason,
+		arg.CreatedBy,
+	)
+	var i Disqualification
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.UserID,
+		&i.GuildID,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listDisqualificationsByEvent = `-- name: ListDisqualificationsByEvent :many
+SELECT id, event_id, user_id, guild_id, reason, created_by, created_at FROM disqualifications
+WHERE event_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListDisqualificationsByEvent(ctx context.Context, eventID pgtype.UUID) ([]Disqualification, error) {
+	rows, err := q.db.Query(ctx, listDisqualificationsByEvent, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Disqualification
+	for rows.Next() {
+		var i Disqualification
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.UserID,
+			&i.GuildID,
+			&i.Reason,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteDisqualification = `-- name: DeleteDisqualification :one
+DELETE FROM disqualifications
+WHERE id = $1 AND event_id = $2
+RETURNING id, event_id, user_id, guild_id, reason, created_by, created_at
+`
+
+type DeleteDisqualificationParams struct {
+	ID      pgtype.UUID
+	EventID pgtype.UUID
+}
+
+func (q *Queries) DeleteDisqualification(ctx context.Context, arg DeleteDisqualificationParams) (Disqualification, error) {
+	row := q.db.QueryRow(ctx, deleteDisqualification, arg.ID, arg.EventID)
+	var i Disqualification
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.UserID,
+		&i.GuildID,
+		&i.Reason,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const recalculateRoomScores = `-- name: RecalculateRoomScores :exec
+WITH room AS (
+  SELECT id, event_id FROM rooms WHERE id = $1
+),
+solved AS (
+  SELECT DISTINCT s.user_id, s.code_problem_id
+  FROM submissions s
+  WHERE s.room_id = $1 AND s.status = 'accepted'
+),
+problem_points AS (
+  SELECT solved.user_id, SUM(COALESCE(NULLIF(ecp.score, 0), $2::integer)) AS points
+  FROM solved
+  CROSS JOIN room
+  LEFT JOIN event_code_problems ecp ON ecp.event_id = room.event_id AND ecp.code_problem_id = solved.code_problem_id
+  GROUP BY solved.user_id
+),
+adjusted_points AS (
+  SELECT user_id, SUM(points) AS points
+  FROM score_adjustments
+  WHERE room_id = $1
+  GROUP BY user_id
+),
+disqualified AS (
+  SELECT rp.user_id
+  FROM room_players rp
+  CROSS JOIN room
+  JOIN disqualifications d ON d.event_id = room.event_id
+    AND (d.user_id = rp.user_id OR d.guild_id = rp.guild_id)
+  WHERE rp.room_id = $1
+)
+UPDATE room_players rp
+SET
+  disqualified = rp.user_id IN (SELECT user_id FROM disqualified),
+  score = CASE
+    WHEN rp.user_id IN (SELECT user_id FROM disqualified) THEN 0
+    ELSE (
+      COALESCE((SELECT pp.points FROM problem_points pp WHERE pp.user_id = rp.user_id), 0) +
+      COALESCE((SELECT ap.points FROM adjusted_points ap WHERE ap.user_id = rp.user_id), 0)
+    )::integer
+  END
+WHERE rp.room_id = $1
+`
+
+type RecalculateRoomScoresParams struct {
+	RoomID        pgtype.UUID
+	DefaultPoints int32
+}
+
+// A player scores the points of each problem they solved once, plus their adjustments.
+// Disqualified players, alone or through their guild, score nothing.
+func (q *Queries) RecalculateRoomScores(ctx context.Context, arg RecalculateRoomScoresParams) error {
+	_, err := q.db.Exec(ctx, recalculateRoomScores, arg.RoomID, arg.DefaultPoints)
+	return err
+}
+
+const snapshotGuildLeaderboard = `-- name: SnapshotGuildLeaderboard :exec
+WITH guild_scores AS (
+  SELECT rp.guild_id, SUM(rp.score)::integer AS total_score
+  FROM room_players rp
+  JOIN rooms r ON rp.room_id = r.id
+  WHERE r.event_id = $1 AND rp.guild_id IS NOT NULL
+    AND NOT EXISTS (
+      SELECT 1 FROM disqualifications d
+      WHERE d.event_id = $1 AND d.guild_id = rp.guild_id
+    )
+  GROUP BY rp.guild_id
+),
+dropped AS (
+  DELETE FROM guild_leaderboard_entries gle
+  WHERE gle.event_id = $1 AND gle.guild_id NOT IN (SELECT guild_id FROM guild_scores)
+)
+INSERT INTO guild_leaderboard_entries (guild_id, guild_name, event_id, rank, total_score)
+SELECT gs.guild_id, COALESCE(egp.guild_name, ''), $1,
+  RANK() OVER (ORDER BY gs.total_score DESC), gs.total_score
+FROM guild_scores gs
+LEFT JOIN event_guild_participants egp ON egp.event_id = $1 AND egp.guild_id = gs.guild_id
+ON CONFLICT (event_id, guild_id) DO UPDATE
+SET guild_name = COALESCE(NULLIF(EXCLUDED.guild_name, ''), guild_leaderboard_entries.guild_name),
+  rank = EXCLUDED.rank,
+  total_score = EXCLUDED.total_score,
+  snapshot_date = EXCLUDED.snapshot_date
+`
+
+// Ranks the guilds of an event by the scores of their players in every room. Each guild has
+// one entry per event that is updated in place, guilds without players or disqualified ones
+// are dropped. Names come from the guild's registration.
+func (q *Queries) SnapshotGuildLeaderboard(ctx context.Context, eventID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, snapshotGuildLeaderboard, eventID)
+	return err
+}
+
+const listSubmissionsForRejudge = `-- name: ListSubmissionsForRejudge :many
+SELECT s.id, s.user_id, s.room_id, s.code_submitted, s.sections, s.status, r.event_id, l.name as language_name
+FROM submissions s
+JOIN languages l ON s.language_id = l.id
+LEFT JOIN rooms r ON s.room_id = r.id
+WHERE s.code_problem_id = $1
+  AND ($2::uuid IS NULL OR r.event_id = $2)
+  AND s.status <> 'pending'
+ORDER BY s.submitted_at
+`
+
+type ListSubmissionsForRejudgeParams struct {
+	CodeProblemID pgtype.UUID
+	EventID       pgtype.UUID
+}
+
+type ListSubmissionsForRejudgeRow struct {
+	ID            pgtype.UUID
+	UserID        pgtype.UUID
+	RoomID        pgtype.UUID
+	CodeSubmitted string
+	Sections      []byte
+	Status        SubmissionStatus
+	EventID       pgtype.UUID
+	LanguageName  string
+}
+
+func (q *Queries) ListSubmissionsForRejudge(ctx context.Context, arg ListSubmissionsForRejudgeParams) ([]ListSubmissionsForRejudgeRow, error) {
+	rows, err := q.db.Query(ctx, listSubmissionsForRejudge, arg.CodeProblemID, arg.EventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSubmissionsForRejudgeRow
+	for rows.Next() {
+		var i ListSubmissionsForRejudgeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.RoomID,
+			&i.CodeSubmitted,
+			&i.Sections,
+			&i.Status,
+			&i.EventID,
+			&i.LanguageName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
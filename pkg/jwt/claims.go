@@ -9,5 +9,6 @@ type UserClaims struct {
 	Username string   `json:"username"`
 	Email    string   `json:"email"`
 	Roles    []string `json:"roles"`
+	GuildID  string   `json:"guild_id,omitempty"`
 	jwt.RegisteredClaims
 }
@@ -0,0 +1,301 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrKeyNotFound = errors.New("signing key not found")
+
+// KeySource resolves the public key a token was signed with from its `kid` header
+type KeySource interface {
+	Key(kid string) (crypto.PublicKey, error)
+}
+
+const (
+	DefaultJWKSCacheTTL = 5 * time.Minute
+	// minJWKSRefresh limits how often an unknown kid can force a refetch
+	minJWKSRefresh = 30 * time.Second
+)
+
+// JWKS is a KeySource backed by a JSON Web Key Set. Keys are cached for the TTL and the set
+// is refetched early when a token names a kid it does not know, so rotated keys are picked
+// up without a restart.
+//
+// Fetches run without holding the lock and at most one at a time. Once a set is cached,
+// expired keys keep being served while the new set is fetched in the background.
+type JWKS struct {
+	logger *slog.Logger
+	fetch  func() ([]byte, error)
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time // start of the last fetch, successful or not
+	inflight  *jwksRefresh
+}
+
+// jwksRefresh is a fetch of the key set in progress. err is set before done is closed.
+type jwksRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+// NewRemoteJWKS fetches the key set from url, usually the identity provider's jwks_uri
+func NewRemoteJWKS(url string, client *http.Client, ttl time.Duration, logger *slog.Logger) *JWKS {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return newJWKS(func() ([]byte, error) {
+		resp, err := client.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("jwks endpoint returned %s", resp.Status)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	}, ttl, logger)
+}
+
+// NewFileJWKS reads the key set from a local file, which is re-read when the cache expires
+func NewFileJWKS(path string, ttl time.Duration, logger *slog.Logger) *JWKS {
+	return newJWKS(func() ([]byte, error) {
+		return os.ReadFile(path)
+	}, ttl, logger)
+}
+
+func newJWKS(fetch func() ([]byte, error), ttl time.Duration, logger *slog.Logger) *JWKS {
+	if ttl <= 0 {
+		ttl = DefaultJWKSCacheTTL
+	}
+	return &JWKS{
+		logger: logger,
+		fetch:  fetch,
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+func (s *JWKS) Key(kid string) (crypto.PublicKey, error) {
+	expired := func() bool { return s.keys == nil || s.now().Sub(s.fetchedAt) >= s.ttl }
+	if call := s.beginRefresh(expired); call != nil && !s.loaded() {
+		// nothing to serve until the first fetch is done
+		<-call.done
+		if call.err != nil && !s.loaded() {
+			return nil, call.err
+		}
+	}
+
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+
+	// the key may have been rotated since the last fetch
+	rotationDue := func() bool { return s.now().Sub(s.fetchedAt) >= minJWKSRefresh }
+	if call := s.beginRefresh(rotationDue); call != nil {
+		<-call.done
+		if key, ok := s.lookup(kid); ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+}
+
+// beginRefresh returns the fetch in progress, or starts one when due reports that the cached
+// set needs it. due is called with the lock held. It returns nil when no fetch is needed.
+func (s *JWKS) beginRefresh(due func() bool) *jwksRefresh {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.inflight != nil {
+		return s.inflight
+	}
+	if !due() {
+		return nil
+	}
+
+	// record the attempt even if it fails so a broken endpoint is not hammered
+	s.fetchedAt = s.now()
+	call := &jwksRefresh{done: make(chan struct{})}
+	s.inflight = call
+	go s.refresh(call)
+	return call
+}
+
+// loaded reports whether a key set has been fetched
+func (s *JWKS) loaded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys != nil
+}
+
+// lookup finds kid in the cached set. Tokens without a kid are accepted when the set holds
+// a single key.
+func (s *JWKS) lookup(kid string) (crypto.PublicKey, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// refresh fetches the key set for call. A failed fetch keeps the cached keys, serving a stale
+// set is better than rejecting every token.
+func (s *JWKS) refresh(call *jwksRefresh) {
+	keys, err := s.load()
+
+	s.mu.Lock()
+	if err == nil {
+		s.keys = keys
+	}
+	s.inflight = nil
+	s.mu.Unlock()
+
+	if err != nil {
+		s.logger.Error("Failed to refresh JWKS", "err", err)
+	} else {
+		s.logger.Info("JWKS refreshed", "keys", len(keys))
+	}
+
+	call.err = err
+	close(call.done)
+}
+
+func (s *JWKS) load() (map[string]crypto.PublicKey, error) {
+	data, err := s.fetch()
+	if err != nil {
+		return nil, fmt.Errorf("fetching jwks: %w", err)
+	}
+	return ParseJWKS(data)
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS returns the RSA and EC signing keys of a key set by kid. Keys meant for
+// encryption or of unsupported types are skipped.
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parsing jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		var (
+			key crypto.PublicKey
+			err error
+		)
+		switch jwk.Kty {
+		case "RSA":
+			key, err = jwk.rsaKey()
+		case "EC":
+			key, err = jwk.ecKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jwk %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (k jsonWebKey) rsaKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid rsa key")
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+func (k jsonWebKey) ecKey() (*ecdsa.PublicKey, error) {
+	var (
+		curve elliptic.Curve
+		check ecdh.Curve
+	)
+	switch k.Crv {
+	case "P-256":
+		curve, check = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, check = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, check = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x coordinate: %w", err)
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid y coordinate: %w", err)
+	}
+
+	// ecdh rejects points that are not on the curve
+	size := (curve.Params().BitSize + 7) / 8
+	if len(x) != size || len(y) != size {
+		return nil, errors.New("invalid ec key")
+	}
+	point := append(append([]byte{4}, x...), y...)
+	if _, err := check.NewPublicKey(point); err != nil {
+		return nil, fmt.Errorf("invalid ec key: %w", err)
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
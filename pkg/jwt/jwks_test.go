@@ -0,0 +1,232 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// jwkFor encodes the public half of key as a JWK
+func jwkFor(t *testing.T, kid string, key crypto.PrivateKey) map[string]string {
+	t.Helper()
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return map[string]string{"kty": "RSA", "kid": kid, "use": "sig", "n": b64(k.N.Bytes()), "e": b64(big.NewInt(int64(k.E)).Bytes())}
+	case *ecdsa.PrivateKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return map[string]string{"kty": "EC", "kid": kid, "crv": k.Curve.Params().Name, "x": b64(k.X.FillBytes(make([]byte, size))), "y": b64(k.Y.FillBytes(make([]byte, size)))}
+	}
+	t.Fatalf("unsupported key %T", key)
+	return nil
+}
+
+func jwksJSON(t *testing.T, keys ...map[string]string) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	require.NoError(t, err)
+	return data
+}
+
+func signWith(t *testing.T, method jwt.SigningMethod, kid string, key crypto.PrivateKey, claims *UserClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+func validClaims(id string) *UserClaims {
+	return &UserClaims{
+		ID: id,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+}
+
+func TestJWKSVerification(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwksJSON(t, jwkFor(t, "rsa-1", rsaKey), jwkFor(t, "ec-1", ecKey)), 0o600))
+
+	parser := NewJWTParser("", logger, WithKeySource(NewFileJWKS(path, 0, logger)))
+
+	t.Run("RS256 token from a local JWKS file", func(t *testing.T) {
+		claims, err := parser.GetUserClaimsFromToken(signWith(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims("rsa-user")))
+		require.NoError(t, err)
+		assert.Equal(t, "rsa-user", claims.ID)
+	})
+
+	t.Run("ES256 token from a local JWKS file", func(t *testing.T) {
+		claims, err := parser.GetUserClaimsFromToken(signWith(t, jwt.SigningMethodES256, "ec-1", ecKey, validClaims("ec-user")))
+		require.NoError(t, err)
+		assert.Equal(t, "ec-user", claims.ID)
+	})
+
+	t.Run("Unknown kid is rejected", func(t *testing.T) {
+		_, err := parser.GetUserClaimsFromToken(signWith(t, jwt.SigningMethodRS256, "rsa-2", rsaKey, validClaims("u")))
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("Key of the wrong type is rejected", func(t *testing.T) {
+		_, err := parser.GetUserClaimsFromToken(signWith(t, jwt.SigningMethodES256, "rsa-1", ecKey, validClaims("u")))
+		assert.Error(t, err)
+	})
+
+	t.Run("HMAC tokens are refused without a secret", func(t *testing.T) {
+		_, err := parser.GetUserClaimsFromToken(signWith(t, jwt.SigningMethodHS256, "rsa-1", []byte("secret"), validClaims("u")))
+		assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+	})
+
+	t.Run("Expired asymmetric token is rejected", func(t *testing.T) {
+		claims := validClaims("u")
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+		_, err := parser.GetUserClaimsFromToken(signWith(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims))
+		assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+	})
+}
+
+func TestRemoteJWKS(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var (
+		body     atomic.Value
+		requests atomic.Int32
+	)
+	body.Store(jwksJSON(t, jwkFor(t, "old", oldKey)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write(body.Load().([]byte))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	jwks := NewRemoteJWKS(server.URL, server.Client(), time.Hour, logger)
+	jwks.now = func() time.Time { return now }
+	parser := NewJWTParser("", logger, WithKeySource(jwks))
+
+	t.Run("Keys are cached", func(t *testing.T) {
+		for range 3 {
+			_, err := parser.GetUserClaimsFromToken(signWith(t, jwt.SigningMethodRS256, "old", oldKey, validClaims("u")))
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("Rotated key is fetched on an unknown kid", func(t *testing.T) {
+		body.Store(jwksJSON(t, jwkFor(t, "new", newKey)))
+		token := signWith(t, jwt.SigningMethodRS256, "new", newKey, validClaims("u"))
+
+		// too soon after the last fetch
+		_, err := parser.GetUserClaimsFromToken(token)
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+
+		now = now.Add(minJWKSRefresh)
+		_, err = parser.GetUserClaimsFromToken(token)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("Stale keys are served when the endpoint fails", func(t *testing.T) {
+		body.Store([]byte("not json"))
+		now = now.Add(2 * time.Hour)
+
+		_, err := parser.GetUserClaimsFromToken(signWith(t, jwt.SigningMethodRS256, "new", newKey, validClaims("u")))
+		assert.NoError(t, err)
+	})
+}
+
+func TestJWKSSlowProvider(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	set := jwksJSON(t, jwkFor(t, "k", key))
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	jwks := newJWKS(func() ([]byte, error) {
+		if fetches.Add(1) > 1 {
+			<-release // the provider hangs after the first fetch
+		}
+		return set, nil
+	}, time.Minute, logger)
+	now := time.Now()
+	jwks.now = func() time.Time { return now }
+
+	_, err = jwks.Key("k")
+	require.NoError(t, err)
+
+	now = now.Add(time.Hour)
+	start := time.Now()
+	for range 5 {
+		got, err := jwks.Key("k")
+		require.NoError(t, err)
+		assert.NotNil(t, got)
+	}
+	assert.Less(t, time.Since(start), time.Second, "expired keys are served while the provider is slow")
+
+	// unknown kids wait for the fetch in progress instead of starting their own
+	errs := make(chan error, 3)
+	for range 3 {
+		go func() {
+			_, err := jwks.Key("unknown")
+			errs <- err
+		}()
+	}
+	close(release)
+	for range 3 {
+		assert.ErrorIs(t, <-errs, ErrKeyNotFound)
+	}
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestParseJWKS(t *testing.T) {
+	t.Run("Encryption and unknown keys are skipped", func(t *testing.T) {
+		keys, err := ParseJWKS([]byte(`{"keys":[{"kty":"RSA","kid":"enc","use":"enc","n":"AQAB","e":"AQAB"},{"kty":"OKP","kid":"ed","crv":"Ed25519","x":"AA"}]}`))
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+
+	t.Run("Point off the curve is rejected", func(t *testing.T) {
+		coord := b64(make([]byte, 32))
+		_, err := ParseJWKS([]byte(`{"keys":[{"kty":"EC","kid":"bad","crv":"P-256","x":"` + coord + `","y":"` + coord + `"}]}`))
+		assert.Error(t, err)
+	})
+}
@@ -4,31 +4,106 @@ package jwt
 import (
 	"errors"
 	"log/slog"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+var ErrNoVerificationKey = errors.New("no key configured to verify this token")
+
 type JWTParser struct {
 	logger    *slog.Logger
 	secretKey string
+	keys      KeySource
+	issuer    string
+	audience  string
+	leeway    time.Duration
 }
 
-func NewJWTParser(secretKey string, logger *slog.Logger) *JWTParser {
-	return &JWTParser{
+type Option func(*JWTParser)
+
+// WithKeySource verifies RS*, PS* and ES* tokens with keys resolved from ks
+func WithKeySource(ks KeySource) Option {
+	return func(p *JWTParser) { p.keys = ks }
+}
+
+// WithIssuer rejects tokens whose `iss` claim is not iss
+func WithIssuer(iss string) Option {
+	return func(p *JWTParser) { p.issuer = iss }
+}
+
+// WithAudience rejects tokens whose `aud` claim does not contain aud
+func WithAudience(aud string) Option {
+	return func(p *JWTParser) { p.audience = aud }
+}
+
+// WithLeeway tolerates clock skew when checking exp, nbf and iat
+func WithLeeway(d time.Duration) Option {
+	return func(p *JWTParser) { p.leeway = d }
+}
+
+// NewJWTParser verifies HMAC tokens with secretKey. Pass WithKeySource for asymmetric tokens,
+// secretKey may then be empty to refuse HMAC tokens entirely.
+func NewJWTParser(secretKey string, logger *slog.Logger, opts ...Option) *JWTParser {
+	p := &JWTParser{
 		logger:    logger,
 		secretKey: secretKey,
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
-func (p *JWTParser) VerifyToken(tokenStr string) (*UserClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &UserClaims{}, func(token *jwt.Token) (any, error) {
-		_, ok := token.Method.(*jwt.SigningMethodHMAC)
+// validMethods only allows algorithms we have a key for, so an HMAC token can never be
+// verified with a public key and vice versa
+func (p *JWTParser) validMethods() []string {
+	var methods []string
+	if p.secretKey != "" {
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+	if p.keys != nil {
+		methods = append(methods, "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512")
+	}
+	return methods
+}
 
-		if !ok {
-			return nil, errors.New("error parsing token")
+func (p *JWTParser) keyFunc(token *jwt.Token) (any, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if p.secretKey == "" {
+			return nil, ErrNoVerificationKey
 		}
 		return []byte(p.secretKey), nil
-	})
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA:
+		if p.keys == nil {
+			return nil, ErrNoVerificationKey
+		}
+		kid, _ := token.Header["kid"].(string)
+		return p.keys.Key(kid)
+	default:
+		p.logger.Error("Unexpected signing method", "method", token.Method)
+		return nil, errors.New("unexpected signing method")
+	}
+}
+
+// VerifyToken checks the signature and the exp, nbf, iat, iss and aud claims. Tokens
+// without an expiry are rejected.
+func (p *JWTParser) VerifyToken(tokenStr string) (*UserClaims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(p.validMethods()),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(p.leeway),
+	}
+	if p.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(p.issuer))
+	}
+	if p.audience != "" {
+		opts = append(opts, jwt.WithAudience(p.audience))
+	}
+
+	token, err := jwt.ParseWithClaims(tokenStr, &UserClaims{}, p.keyFunc, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -40,25 +115,12 @@ func (p *JWTParser) VerifyToken(tokenStr string) (*UserClaims, error) {
 	return nil, errors.New("invalid token claims")
 }
 
+// GetUserClaimsFromToken returns the claims of a valid token, see VerifyToken
 func (p *JWTParser) GetUserClaimsFromToken(tokenStr string) (*UserClaims, error) {
-	// Parse the token and extract claims
-	token, err := jwt.ParseWithClaims(tokenStr, &UserClaims{}, func(token *jwt.Token) (any, error) {
-		_, ok := token.Method.(*jwt.SigningMethodHMAC)
-		if !ok {
-			p.logger.Error("Unexpected signing method", "method", token.Method)
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(p.secretKey), nil
-	})
-
-	if err != nil && !errors.Is(err, jwt.ErrTokenExpired) {
-		p.logger.Error("Failed to parse jwt at 54", "err", err)
+	claims, err := p.VerifyToken(tokenStr)
+	if err != nil {
+		p.logger.Debug("Failed to verify jwt", "err", err)
 		return nil, err
 	}
-
-	if claims := token.Claims.(*UserClaims); claims != nil {
-		return claims, nil
-	}
-
-	return nil, errors.New("invalid token claims")
+	return claims, nil
 }
@@ -80,9 +80,7 @@ func TestGetUserClaimsFromToken(t *testing.T) {
 		assert.ErrorIs(t, err, jwt.ErrSignatureInvalid, "Error should be of type ErrSignatureInvalid")
 	})
 
-	t.Run("Successful Parse of Expired Token", func(t *testing.T) {
-		// Your GetUserClaimsFromToken function is specifically designed to parse claims
-		// even if the token is expired. This test verifies that behavior.
+	t.Run("Fail on Expired Token", func(t *testing.T) {
 		claims := &UserClaims{
 			ID: "expired-user-id",
 			RegisteredClaims: jwt.RegisteredClaims{
@@ -93,19 +91,68 @@ func TestGetUserClaimsFromToken(t *testing.T) {
 		tokenString, err := generateTestToken(claims, secretKey)
 		require.NoError(t, err)
 
-		gotClaims, err := parser.GetUserClaimsFromToken(tokenString)
-
-		// We expect NO error from GetUserClaimsFromToken because it ignores expiration
-		assert.NoError(t, err, "GetUserClaimsFromToken should not return an error for an expired token")
-		require.NotNil(t, gotClaims)
-		assert.Equal(t, "expired-user-id", gotClaims.ID)
+		_, err = parser.GetUserClaimsFromToken(tokenString)
+		require.Error(t, err, "GetUserClaimsFromToken should fail for an expired token")
+		assert.ErrorIs(t, err, jwt.ErrTokenExpired)
 
-		// In contrast, VerifyToken should fail for the same token
 		_, err = parser.VerifyToken(tokenString)
 		require.Error(t, err, "VerifyToken should fail for an expired token")
 		assert.ErrorIs(t, err, jwt.ErrTokenExpired)
 	})
 
+	t.Run("Fail on Token Without Expiry", func(t *testing.T) {
+		tokenString, err := generateTestToken(&UserClaims{ID: "user-id"}, secretKey)
+		require.NoError(t, err)
+
+		_, err = parser.GetUserClaimsFromToken(tokenString)
+		assert.ErrorIs(t, err, jwt.ErrTokenRequiredClaimMissing)
+	})
+
+	t.Run("Fail on Token Not Yet Valid", func(t *testing.T) {
+		claims := &UserClaims{
+			ID: "user-id",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(2 * time.Hour)),
+				NotBefore: jwt.NewNumericDate(time.Now().Add(1 * time.Hour)),
+			},
+		}
+
+		tokenString, err := generateTestToken(claims, secretKey)
+		require.NoError(t, err)
+
+		_, err = parser.GetUserClaimsFromToken(tokenString)
+		assert.ErrorIs(t, err, jwt.ErrTokenNotValidYet)
+	})
+
+	t.Run("Issuer and Audience Are Checked When Configured", func(t *testing.T) {
+		strict := NewJWTParser(secretKey, logger, WithIssuer("rogue-learn"), WithAudience("code-battle"))
+		claims := func(iss, aud string) *UserClaims {
+			return &UserClaims{
+				ID: "user-id",
+				RegisteredClaims: jwt.RegisteredClaims{
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(1 * time.Hour)),
+					Issuer:    iss,
+					Audience:  jwt.ClaimStrings{aud},
+				},
+			}
+		}
+
+		tokenString, err := generateTestToken(claims("rogue-learn", "code-battle"), secretKey)
+		require.NoError(t, err)
+		_, err = strict.GetUserClaimsFromToken(tokenString)
+		assert.NoError(t, err)
+
+		tokenString, err = generateTestToken(claims("someone-else", "code-battle"), secretKey)
+		require.NoError(t, err)
+		_, err = strict.GetUserClaimsFromToken(tokenString)
+		assert.ErrorIs(t, err, jwt.ErrTokenInvalidIssuer)
+
+		tokenString, err = generateTestToken(claims("rogue-learn", "another-service"), secretKey)
+		require.NoError(t, err)
+		_, err = strict.GetUserClaimsFromToken(tokenString)
+		assert.ErrorIs(t, err, jwt.ErrTokenInvalidAudience)
+	})
+
 	t.Run("Fail on Malformed Token", func(t *testing.T) {
 		malformedToken := "this.is.not.a.valid.jwt"
 
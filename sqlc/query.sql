@@ -11,9 +11,10 @@ INSERT INTO events (
   number_of_rooms,
   guilds_per_room,
   room_naming_prefix,
-  original_request_id
+  original_request_id,
+  spectator_visibility
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 RETURNING *;
 
 -- name: GetEventByID :one
@@ -51,13 +52,25 @@ SET
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateEventRateLimits :one
+UPDATE events
+SET rate_limits = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateEventIntegrityRules :one
+UPDATE events
+SET integrity_rules = $2
+WHERE id = $1
+RETURNING *;
+
 -- name: DeleteEvent :exec
 DELETE FROM events WHERE id = $1;
 
 -- Languages
 -- name: CreateLanguage :one
-INSERT INTO languages (name, compile_cmd, run_cmd, temp_file_dir, temp_file_name)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO languages (name, compile_cmd, run_cmd, temp_file_dir, temp_file_name, aliases, code_placeholder, import_placeholder)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING *;
 
 -- name: GetLanguageByID :one
@@ -70,12 +83,16 @@ ORDER BY name
 LIMIT $1
 OFFSET $2;
 
+-- name: GetAllLanguages :many
+SELECT * FROM languages
+ORDER BY name;
+
 -- name: GetLanguageByName :one
 SELECT * FROM languages WHERE name = $1;
 
 -- name: UpdateLanguage :one
 UPDATE languages
-SET name = $2, compile_cmd = $3, run_cmd = $4, temp_file_dir = $5, temp_file_name = $6
+SET name = $2, compile_cmd = $3, run_cmd = $4, temp_file_dir = $5, temp_file_name = $6, aliases = $7, code_placeholder = $8, import_placeholder = $9
 WHERE id = $1
 RETURNING *;
 
@@ -138,13 +155,19 @@ SET title = $2, problem_statement = $3, difficulty = $4
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateCodeProblemSignature :one
+UPDATE code_problems
+SET signature = $2
+WHERE id = $1
+RETURNING *;
+
 -- name: DeleteCodeProblem :exec
 DELETE FROM code_problems WHERE id = $1;
 
 -- Code Problem Language Details
 -- name: CreateCodeProblemLanguageDetail :one
-INSERT INTO code_problem_language_details (code_problem_id, language_id, solution_stub, driver_code, time_constraint_ms, space_constraint_mb)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO code_problem_language_details (code_problem_id, language_id, solution_stub, driver_code, time_constraint_ms, space_constraint_mb, batch_mode, max_code_length, solution_mode, required_symbols)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 RETURNING *;
 
 -- name: GetCodeProblemLanguageDetail :one
@@ -175,10 +198,19 @@ WHERE cpld.code_problem_id = $1;
 
 -- name: UpdateCodeProblemLanguageDetail :one
 UPDATE code_problem_language_details
-SET solution_stub = $3, driver_code = $4, time_constraint_ms = $5, space_constraint_mb = $6
+SET solution_stub = $3, driver_code = $4, time_constraint_ms = $5, space_constraint_mb = $6, batch_mode = $7, max_code_length = $8, solution_mode = $9, required_symbols = $10
 WHERE code_problem_id = $1 AND language_id = $2
 RETURNING *;
 
+-- name: UpsertCodeProblemLanguageDetail :one
+-- Generated drivers read a single test case, so batch mode is turned off.
+INSERT INTO code_problem_language_details (code_problem_id, language_id, solution_stub, driver_code, solution_mode, required_symbols)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (language_id, code_problem_id) DO UPDATE
+SET solution_stub = EXCLUDED.solution_stub, driver_code = EXCLUDED.driver_code, solution_mode = EXCLUDED.solution_mode, required_symbols = EXCLUDED.required_symbols,
+    batch_mode = false
+RETURNING *;
+
 -- name: DeleteCodeProblemLanguageDetail :exec
 DELETE FROM code_problem_language_details
 WHERE code_problem_id = $1 AND language_id = $2;
@@ -266,8 +298,8 @@ DELETE FROM rooms WHERE id = $1;
 
 -- Room Players
 -- name: CreateRoomPlayer :one
-INSERT INTO room_players (room_id, user_id, username, score, place, state)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO room_players (room_id, user_id, username, score, place, state, guild_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 
 -- name: GetRoomPlayer :one
@@ -301,6 +333,11 @@ SET state = $3
 WHERE room_id = $1 AND user_id = $2
 RETURNING *;
 
+-- name: UpdateRoomPlayerFlag :exec
+UPDATE room_players
+SET flagged = $3, flag_reason = $4
+WHERE room_id = $1 AND user_id = $2;
+
 -- name: DisconnectRoomPlayer :one
 UPDATE room_players
 SET disconnected_at = NOW()
@@ -341,8 +378,8 @@ WHERE event_id = $1 AND code_problem_id = $2;
 
 -- Event Guild Participants
 -- name: CreateEventGuildParticipant :one
-INSERT INTO event_guild_participants (event_id, guild_id, room_id)
-VALUES ($1, $2, $3)
+INSERT INTO event_guild_participants (event_id, guild_id, room_id, guild_name)
+VALUES ($1, $2, $3, $4)
 RETURNING *;
 
 -- name: GetEventGuildParticipant :one
@@ -371,8 +408,8 @@ WHERE event_id = $1 AND guild_id = $2;
 
 -- Submissions
 -- name: CreateSubmission :one
-INSERT INTO submissions (user_id, code_problem_id, language_id, room_id, code_submitted, status, execution_time_ms, submitted_guild_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+INSERT INTO submissions (user_id, code_problem_id, language_id, room_id, code_submitted, status, execution_time_ms, submitted_guild_id, sections)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING *;
 
 -- name: GetSubmissionByID :one
@@ -417,6 +454,12 @@ JOIN languages l ON s.language_id = l.id
 WHERE s.status = $1
 ORDER BY s.submitted_at DESC;
 
+-- name: UpdateSubmissionResult :one
+UPDATE submissions
+SET status = $2, execution_time_ms = $3, memory_used_kb = $4
+WHERE id = $1
+RETURNING *;
+
 -- name: UpdateSubmissionStatus :one
 UPDATE submissions
 SET status = $2
@@ -426,6 +469,196 @@ RETURNING *;
 -- name: DeleteSubmission :exec
 DELETE FROM submissions WHERE id = $1;
 
+-- Submission Similarities
+-- name: GetLatestSubmissionsForSimilarity :many
+SELECT DISTINCT ON (s.user_id, s.language_id)
+  s.id, s.user_id, s.language_id, s.code_submitted, s.sections, s.submitted_guild_id, l.name as language_name
+FROM submissions s
+JOIN languages l ON s.language_id = l.id
+LEFT JOIN rooms r ON s.room_id = r.id
+WHERE s.code_problem_id = sqlc.arg(code_problem_id)
+  AND (sqlc.narg(event_id)::uuid IS NULL OR r.event_id = sqlc.narg(event_id))
+ORDER BY s.user_id, s.language_id, s.submitted_at DESC;
+
+-- name: DeleteSubmissionSimilaritiesByScope :exec
+DELETE FROM submission_similarities
+WHERE code_problem_id = sqlc.arg(code_problem_id)
+  AND event_id IS NOT DISTINCT FROM sqlc.narg(event_id);
+
+-- name: UpsertSubmissionSimilarity :one
+INSERT INTO submission_similarities (event_id, code_problem_id, submission_a_id, submission_b_id, score, matches)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (submission_a_id, submission_b_id)
+DO UPDATE SET score = EXCLUDED.score, matches = EXCLUDED.matches, detected_at = now()
+RETURNING *;
+
+-- name: ListSubmissionSimilarities :many
+SELECT ss.*,
+  sa.user_id as user_a_id, sa.code_submitted as code_a, sa.sections as sections_a, sa.submitted_guild_id as guild_a_id,
+  sb.user_id as user_b_id, sb.code_submitted as code_b, sb.sections as sections_b, sb.submitted_guild_id as guild_b_id,
+  l.name as language_name
+FROM submission_similarities ss
+JOIN submissions sa ON ss.submission_a_id = sa.id
+JOIN submissions sb ON ss.submission_b_id = sb.id
+JOIN languages l ON sa.language_id = l.id
+WHERE (sqlc.narg(event_id)::uuid IS NULL OR ss.event_id = sqlc.narg(event_id))
+  AND (sqlc.narg(code_problem_id)::uuid IS NULL OR ss.code_problem_id = sqlc.narg(code_problem_id))
+  AND ss.score >= sqlc.arg(min_score)::double precision
+ORDER BY ss.score DESC, ss.detected_at DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- Integrity Signals
+-- name: CreateIntegritySignals :exec
+INSERT INTO integrity_signals (event_id, room_id, user_id, kind, value, occurred_at)
+SELECT sqlc.arg(event_id), sqlc.arg(room_id), sqlc.arg(user_id), s.kind, s.value, s.occurred_at
+FROM unnest(sqlc.arg(kinds)::text[], sqlc.arg(values)::integer[], sqlc.arg(occurred_ats)::timestamptz[]) AS s(kind, value, occurred_at);
+
+-- name: GetPlayerIntegritySignals :many
+SELECT * FROM integrity_signals
+WHERE room_id = $1 AND user_id = $2
+ORDER BY occurred_at;
+
+-- name: GetIntegritySummariesByEvent :many
+SELECT s.room_id, s.user_id, s.kind,
+  count(*)::integer as signal_count,
+  max(s.value)::integer as max_value,
+  sum(s.value)::bigint as total_value,
+  max(s.occurred_at)::timestamptz as last_occurred_at,
+  rp.username, rp.flagged, rp.flag_reason
+FROM integrity_signals s
+LEFT JOIN room_players rp ON rp.room_id = s.room_id AND rp.user_id = s.user_id
+WHERE s.event_id = $1
+GROUP BY s.room_id, s.user_id, s.kind, rp.username, rp.flagged, rp.flag_reason
+ORDER BY s.room_id, s.user_id, s.kind;
+
+-- Audit Logs
+-- name: CreateAuditLog :one
+INSERT INTO audit_logs (actor_id, action, target_type, target_id, event_id, before, after, request_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: ListAuditLogs :many
+SELECT * FROM audit_logs
+WHERE (sqlc.narg(actor_id)::uuid IS NULL OR actor_id = sqlc.narg(actor_id))
+  AND (sqlc.narg(action)::text IS NULL OR action = sqlc.narg(action))
+  AND (sqlc.narg(target_type)::text IS NULL OR target_type = sqlc.narg(target_type))
+  AND (sqlc.narg(target_id)::text IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(event_id)::uuid IS NULL OR event_id = sqlc.narg(event_id))
+  AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+ORDER BY created_at DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- Standings
+-- name: CreateScoreAdjustment :one
+INSERT INTO score_adjustments (event_id, room_id, user_id, points, reason, created_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListScoreAdjustmentsByEvent :many
+SELECT * FROM score_adjustments
+WHERE event_id = $1
+ORDER BY created_at DESC;
+
+-- name: CreateDisqualification :one
+INSERT INTO disqualifications (event_id, user_id, guild_id, reason, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListDisqualificationsByEvent :many
+SELECT * FROM disqualifications
+WHERE event_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeleteDisqualification :one
+DELETE FROM disqualifications
+WHERE id = $1 AND event_id = $2
+RETURNING *;
+
+-- name: RecalculateRoomScores :exec
+-- A player scores the points of each problem they solved once, plus their adjustments.
+-- Disqualified players, alone or through their guild, score nothing.
+WITH room AS (
+  SELECT id, event_id FROM rooms WHERE id = sqlc.arg(room_id)
+),
+solved AS (
+  SELECT DISTINCT s.user_id, s.code_problem_id
+  FROM submissions s
+  WHERE s.room_id = sqlc.arg(room_id) AND s.status = 'accepted'
+),
+problem_points AS (
+  SELECT solved.user_id, SUM(COALESCE(NULLIF(ecp.score, 0), sqlc.arg(default_points)::integer)) AS points
+  FROM solved
+  CROSS JOIN room
+  LEFT JOIN event_code_problems ecp ON ecp.event_id = room.event_id AND ecp.code_problem_id = solved.code_problem_id
+  GROUP BY solved.user_id
+),
+adjusted_points AS (
+  SELECT user_id, SUM(points) AS points
+  FROM score_adjustments
+  WHERE room_id = sqlc.arg(room_id)
+  GROUP BY user_id
+),
+disqualified AS (
+  SELECT rp.user_id
+  FROM room_players rp
+  CROSS JOIN room
+  JOIN disqualifications d ON d.event_id = room.event_id
+    AND (d.user_id = rp.user_id OR d.guild_id = rp.guild_id)
+  WHERE rp.room_id = sqlc.arg(room_id)
+)
+UPDATE room_players rp
+SET
+  disqualified = rp.user_id IN (SELECT user_id FROM disqualified),
+  score = CASE
+    WHEN rp.user_id IN (SELECT user_id FROM disqualified) THEN 0
+    ELSE (
+      COALESCE((SELECT pp.points FROM problem_points pp WHERE pp.user_id = rp.user_id), 0) +
+      COALESCE((SELECT ap.points FROM adjusted_points ap WHERE ap.user_id = rp.user_id), 0)
+    )::integer
+  END
+WHERE rp.room_id = sqlc.arg(room_id);
+
+-- name: SnapshotGuildLeaderboard :exec
+-- Ranks the guilds of an event by the scores of their players in every room. Each guild has
+-- one entry per event that is updated in place, guilds without players or disqualified ones
+-- are dropped. Names come from the guild's registration.
+WITH guild_scores AS (
+  SELECT rp.guild_id, SUM(rp.score)::integer AS total_score
+  FROM room_players rp
+  JOIN rooms r ON rp.room_id = r.id
+  WHERE r.event_id = $1 AND rp.guild_id IS NOT NULL
+    AND NOT EXISTS (
+      SELECT 1 FROM disqualifications d
+      WHERE d.event_id = $1 AND d.guild_id = rp.guild_id
+    )
+  GROUP BY rp.guild_id
+),
+dropped AS (
+  DELETE FROM guild_leaderboard_entries gle
+  WHERE gle.event_id = $1 AND gle.guild_id NOT IN (SELECT guild_id FROM guild_scores)
+)
+INSERT INTO guild_leaderboard_entries (guild_id, guild_name, event_id, rank, total_score)
+SELECT gs.guild_id, COALESCE(egp.guild_name, ''), $1,
+  RANK() OVER (ORDER BY gs.total_score DESC), gs.total_score
+FROM guild_scores gs
+LEFT JOIN event_guild_participants egp ON egp.event_id = $1 AND egp.guild_id = gs.guild_id
+ON CONFLICT (event_id, guild_id) DO UPDATE
+SET guild_name = COALESCE(NULLIF(EXCLUDED.guild_name, ''), guild_leaderboard_entries.guild_name),
+  rank = EXCLUDED.rank,
+  total_score = EXCLUDED.total_score,
+  snapshot_date = EXCLUDED.snapshot_date;
+
+-- name: ListSubmissionsForRejudge :many
+SELECT s.id, s.user_id, s.room_id, s.code_submitted, s.sections, s.status, r.event_id, l.name as language_name
+FROM submissions s
+JOIN languages l ON s.language_id = l.id
+LEFT JOIN rooms r ON s.room_id = r.id
+WHERE s.code_problem_id = sqlc.arg(code_problem_id)
+  AND (sqlc.narg(event_id)::uuid IS NULL OR r.event_id = sqlc.narg(event_id))
+  AND s.status <> 'pending'
+ORDER BY s.submitted_at;
+
 -- Leaderboard Entries
 -- name: CreateLeaderboardEntry :one
 INSERT INTO leaderboard_entries (user_id, username, event_id, rank, score)
@@ -455,12 +688,24 @@ AND le1.snapshot_date = (
 ORDER BY le1.rank ASC;
 
 -- name: CalculateRoomLeaderboard :exec
-WITH ranked_players AS (
+WITH best_runtimes AS (
+  SELECT user_id, code_problem_id, MIN(execution_time_ms) AS best_ms
+  FROM submissions
+  WHERE room_id = $1 AND status = 'accepted'
+  GROUP BY user_id, code_problem_id
+),
+player_runtimes AS (
+  SELECT user_id, SUM(best_ms) AS total_ms
+  FROM best_runtimes
+  GROUP BY user_id
+),
+ranked_players AS (
   SELECT
-    user_id,
-    RANK() OVER (ORDER BY score DESC, joined_at ASC) as new_place
-  FROM room_players
-  WHERE room_id = $1
+    rp.user_id,
+    RANK() OVER (ORDER BY rp.score DESC, COALESCE(pr.total_ms, 0) ASC, rp.joined_at ASC) as new_place
+  FROM room_players rp
+  LEFT JOIN player_runtimes pr ON pr.user_id = rp.user_id
+  WHERE rp.room_id = $1
 )
 UPDATE room_players rp
 SET place = rp_ranked.new_place
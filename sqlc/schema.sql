@@ -35,6 +35,10 @@ CREATE TABLE public.code_problem_language_details (
   driver_code text NOT NULL DEFAULT ''::text,
   time_constraint_ms integer NOT NULL DEFAULT 1000,
   space_constraint_mb integer NOT NULL DEFAULT 16,
+  batch_mode boolean NOT NULL DEFAULT false,
+  max_code_length integer,
+  solution_mode text NOT NULL DEFAULT 'snippet',
+  required_symbols text[],
   CONSTRAINT code_problem_language_details_pkey PRIMARY KEY (language_id, code_problem_id),
   CONSTRAINT code_problem_language_details_code_problem_id_fkey FOREIGN KEY (code_problem_id) REFERENCES public.code_problems(id),
   CONSTRAINT code_problem_language_details_language_id_fkey FOREIGN KEY (language_id) REFERENCES public.languages(id)
@@ -52,6 +56,7 @@ CREATE TABLE public.code_problems (
   problem_statement text NOT NULL DEFAULT ''::text,
   difficulty integer NOT NULL DEFAULT 1,
   created_at timestamp with time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'::text),
+  signature jsonb,
   CONSTRAINT code_problems_pkey PRIMARY KEY (id)
 );
 CREATE TABLE public.event_code_problems (
@@ -67,6 +72,7 @@ CREATE TABLE public.event_guild_participants (
   guild_id uuid NOT NULL,
   joined_at timestamp with time zone DEFAULT (now() AT TIME ZONE 'utc'::text),
   room_id uuid,
+  guild_name text NOT NULL DEFAULT ''::text,
   CONSTRAINT event_guild_participants_pkey PRIMARY KEY (guild_id, event_id),
   CONSTRAINT event_guild_participants_event_id_fkey FOREIGN KEY (event_id) REFERENCES public.events(id),
   CONSTRAINT event_guild_participants_room_id_fkey FOREIGN KEY (room_id) REFERENCES public.rooms(id)
@@ -84,6 +90,9 @@ CREATE TABLE public.events (
   guilds_per_room integer,
   room_naming_prefix text,
   original_request_id uuid,
+  spectator_visibility text NOT NULL DEFAULT 'public'::text,
+  rate_limits jsonb,
+  integrity_rules jsonb,
   CONSTRAINT events_pkey PRIMARY KEY (id),
   CONSTRAINT events_original_request_id_fkey FOREIGN KEY (original_request_id) REFERENCES public.event_requests(id)
 );
@@ -98,6 +107,20 @@ CREATE TABLE public.guild_leaderboard_entries (
   CONSTRAINT guild_leaderboard_entries_pkey PRIMARY KEY (id),
   CONSTRAINT guild_leaderboard_entries_event_id_fkey FOREIGN KEY (event_id) REFERENCES public.events(id)
 );
+CREATE UNIQUE INDEX guild_leaderboard_entries_event_guild_idx ON public.guild_leaderboard_entries (event_id, guild_id);
+CREATE TABLE public.integrity_signals (
+  id uuid NOT NULL DEFAULT gen_random_uuid(),
+  event_id uuid NOT NULL,
+  room_id uuid NOT NULL,
+  user_id uuid NOT NULL,
+  kind text NOT NULL,
+  value integer NOT NULL DEFAULT 0,
+  occurred_at timestamp with time zone NOT NULL DEFAULT now(),
+  received_at timestamp with time zone NOT NULL DEFAULT now(),
+  CONSTRAINT integrity_signals_pkey PRIMARY KEY (id),
+  CONSTRAINT integrity_signals_event_id_fkey FOREIGN KEY (event_id) REFERENCES public.events(id),
+  CONSTRAINT integrity_signals_room_id_fkey FOREIGN KEY (room_id) REFERENCES public.rooms(id)
+);
 CREATE TABLE public.languages (
   id uuid NOT NULL DEFAULT gen_random_uuid(),
   name text NOT NULL,
@@ -105,6 +128,9 @@ CREATE TABLE public.languages (
   run_cmd text NOT NULL,
   temp_file_dir text,
   temp_file_name text,
+  aliases text[] NOT NULL DEFAULT '{}'::text[],
+  code_placeholder text NOT NULL DEFAULT ''::text,
+  import_placeholder text NOT NULL DEFAULT ''::text,
   CONSTRAINT languages_pkey PRIMARY KEY (id)
 );
 CREATE TABLE public.leaderboard_entries (
@@ -127,6 +153,10 @@ CREATE TABLE public.room_players (
   state room_player_state NOT NULL DEFAULT 'present'::room_player_state,
   disconnected_at timestamp with time zone,
   joined_at timestamp with time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'::text),
+  flagged boolean NOT NULL DEFAULT false,
+  flag_reason text,
+  guild_id uuid,
+  disqualified boolean NOT NULL DEFAULT false,
   CONSTRAINT room_players_pkey PRIMARY KEY (room_id, user_id),
   CONSTRAINT room_players_room_id_fkey FOREIGN KEY (room_id) REFERENCES public.rooms(id)
 );
@@ -150,11 +180,29 @@ CREATE TABLE public.submissions (
   execution_time_ms integer,
   submitted_at timestamp with time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'::text),
   submitted_guild_id uuid,
+  memory_used_kb integer,
+  sections jsonb NOT NULL DEFAULT '{}'::jsonb,
   CONSTRAINT submissions_pkey PRIMARY KEY (id),
   CONSTRAINT submissions_code_problem_id_fkey FOREIGN KEY (code_problem_id) REFERENCES public.code_problems(id),
   CONSTRAINT submissions_language_id_fkey FOREIGN KEY (language_id) REFERENCES public.languages(id),
   CONSTRAINT submissions_room_id_fkey FOREIGN KEY (room_id) REFERENCES public.rooms(id)
 );
+CREATE TABLE public.submission_similarities (
+  id uuid NOT NULL DEFAULT gen_random_uuid(),
+  event_id uuid,
+  code_problem_id uuid NOT NULL,
+  submission_a_id uuid NOT NULL,
+  submission_b_id uuid NOT NULL,
+  score double precision NOT NULL,
+  matches jsonb NOT NULL DEFAULT '[]'::jsonb,
+  detected_at timestamp with time zone NOT NULL DEFAULT now(),
+  CONSTRAINT submission_similarities_pkey PRIMARY KEY (id),
+  CONSTRAINT submission_similarities_pair_key UNIQUE (submission_a_id, submission_b_id),
+  CONSTRAINT submission_similarities_event_id_fkey FOREIGN KEY (event_id) REFERENCES public.events(id),
+  CONSTRAINT submission_similarities_code_problem_id_fkey FOREIGN KEY (code_problem_id) REFERENCES public.code_problems(id),
+  CONSTRAINT submission_similarities_submission_a_id_fkey FOREIGN KEY (submission_a_id) REFERENCES public.submissions(id),
+  CONSTRAINT submission_similarities_submission_b_id_fkey FOREIGN KEY (submission_b_id) REFERENCES public.submissions(id)
+);
 CREATE TABLE public.tags (
   id uuid NOT NULL DEFAULT gen_random_uuid(),
   name text NOT NULL DEFAULT ''::text UNIQUE,
@@ -192,3 +240,53 @@ CREATE TABLE public.event_requests (
   CONSTRAINT event_requests_pkey PRIMARY KEY (id),
   CONSTRAINT event_requests_approved_event_id_fkey FOREIGN KEY (approved_event_id) REFERENCES public.events(id)
 );
+
+CREATE TABLE public.audit_logs (
+  id uuid NOT NULL DEFAULT gen_random_uuid(),
+  actor_id uuid,
+  action text NOT NULL,
+  target_type text NOT NULL,
+  target_id text NOT NULL,
+  event_id uuid,
+  before jsonb,
+  after jsonb,
+  request_id text,
+  created_at timestamp with time zone NOT NULL DEFAULT now(),
+  CONSTRAINT audit_logs_pkey PRIMARY KEY (id)
+);
+CREATE INDEX audit_logs_created_at_idx ON public.audit_logs (created_at DESC);
+CREATE INDEX audit_logs_event_id_idx ON public.audit_logs (event_id, created_at DESC);
+-- audit logs are append-only
+CREATE RULE audit_logs_no_update AS ON UPDATE TO public.audit_logs DO INSTEAD NOTHING;
+CREATE RULE audit_logs_no_delete AS ON DELETE TO public.audit_logs DO INSTEAD NOTHING;
+
+CREATE TABLE public.score_adjustments (
+  id uuid NOT NULL DEFAULT gen_random_uuid(),
+  event_id uuid NOT NULL,
+  room_id uuid NOT NULL,
+  user_id uuid NOT NULL,
+  points integer NOT NULL,
+  reason text NOT NULL,
+  created_by uuid,
+  created_at timestamp with time zone NOT NULL DEFAULT now(),
+  CONSTRAINT score_adjustments_pkey PRIMARY KEY (id),
+  CONSTRAINT score_adjustments_event_id_fkey FOREIGN KEY (event_id) REFERENCES public.events(id),
+  CONSTRAINT score_adjustments_room_id_fkey FOREIGN KEY (room_id) REFERENCES public.rooms(id)
+);
+CREATE INDEX score_adjustments_room_id_idx ON public.score_adjustments (room_id, user_id);
+
+CREATE TABLE public.disqualifications (
+  id uuid NOT NULL DEFAULT gen_random_uuid(),
+  event_id uuid NOT NULL,
+  user_id uuid,
+  guild_id uuid,
+  reason text NOT NULL,
+  created_by uuid,
+  created_at timestamp with time zone NOT NULL DEFAULT now(),
+  CONSTRAINT disqualifications_pkey PRIMARY KEY (id),
+  CONSTRAINT disqualifications_event_id_fkey FOREIGN KEY (event_id) REFERENCES public.events(id),
+  -- a player or a whole guild, never both
+  CONSTRAINT disqualifications_target_check CHECK ((user_id IS NULL) <> (guild_id IS NULL))
+);
+CREATE UNIQUE INDEX disqualifications_user_idx ON public.disqualifications (event_id, user_id) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX disqualifications_guild_idx ON public.disqualifications (event_id, guild_id) WHERE guild_id IS NOT NULL;
@@ -0,0 +1,59 @@
+-- Seed data for the languages supported by the worker image. The languages table is where
+-- their aliases and placeholders live.
+
+-- The Go, Python and JavaScript rows predate the aliases and placeholder columns. Their aliases
+-- keep the misspellings clients already send.
+UPDATE languages
+SET aliases = ARRAY['go', 'golang', 'gol', 'goo', 'g o', 'golangg'],
+    code_placeholder = '// USER_CODE_HERE', import_placeholder = '// IMPORTS_HERE'
+WHERE name = 'Golang' AND code_placeholder = '';
+
+UPDATE languages
+SET aliases = ARRAY['python', 'pyt', 'pyn', 'pythn', 'phyton', 'py', 'py thon', 'pthon'],
+    code_placeholder = '# USER_CODE_HERE'
+WHERE name = 'Python' AND code_placeholder = '';
+
+UPDATE languages
+SET aliases = ARRAY['js', 'jscript', 'javscript', 'javsscript', 'javascipt', 'javasript', 'javascript', 'java script', 'jscipt'],
+    code_placeholder = '// USER_CODE_HERE'
+WHERE name = 'Javascript' AND code_placeholder = '';
+
+-- Compile commands must write a single artifact to /app/temp/exe, which is what the
+-- run command executes and what the build cache stores.
+-- Placeholders {{temp_file_dir}} and {{temp_file_name}} are replaced by the worker.
+
+INSERT INTO languages (name, compile_cmd, run_cmd, temp_file_dir, temp_file_name, aliases, code_placeholder, import_placeholder)
+SELECT 'C++',
+       'g++ -O2 -std=c++17 -pipe -o /app/temp/exe {{temp_file_dir}}/{{temp_file_name}}',
+       '/app/temp/exe',
+       '/app/temp/cpp', 'code.cpp',
+       ARRAY['cpp', 'c++', 'cplusplus', 'cxx'], '// USER_CODE_HERE', ''
+WHERE NOT EXISTS (SELECT 1 FROM languages WHERE name = 'C++');
+
+INSERT INTO languages (name, compile_cmd, run_cmd, temp_file_dir, temp_file_name, aliases, code_placeholder, import_placeholder)
+SELECT 'Java',
+       'cd {{temp_file_dir}} && rm -rf classes && javac -encoding UTF-8 -d classes {{temp_file_name}} && jar cfe /app/temp/exe Main -C classes .',
+       'java -XX:+UseSerialGC -XX:TieredStopAtLevel=1 -Xss64m -jar /app/temp/exe',
+       '/app/temp/java', 'Main.java',
+       ARRAY['java'], '// USER_CODE_HERE', ''
+WHERE NOT EXISTS (SELECT 1 FROM languages WHERE name = 'Java');
+
+INSERT INTO languages (name, compile_cmd, run_cmd, temp_file_dir, temp_file_name, aliases, code_placeholder, import_placeholder)
+SELECT 'Rust',
+       'rustc --edition 2021 -O --color never -o /app/temp/exe {{temp_file_dir}}/{{temp_file_name}}',
+       '/app/temp/exe',
+       '/app/temp/rust', 'code.rs',
+       ARRAY['rust', 'rs'], '// USER_CODE_HERE', ''
+WHERE NOT EXISTS (SELECT 1 FROM languages WHERE name = 'Rust');
+
+-- TypeScript is transpiled to a single CommonJS file; --noEmitOnError turns type errors
+-- into compilation errors instead of running the emitted JavaScript anyway. tsc drops types
+-- and blank lines, so the emitted file carries an inline source map and Node reports
+-- runtime errors against the lines of code.ts.
+INSERT INTO languages (name, compile_cmd, run_cmd, temp_file_dir, temp_file_name, aliases, code_placeholder, import_placeholder)
+SELECT 'TypeScript',
+       'cd {{temp_file_dir}} && rm -rf out && tsc --pretty false --noEmitOnError --target es2020 --module commonjs --types node --typeRoots /usr/local/lib/node_modules/@types --inlineSourceMap --inlineSources --sourceRoot /app/temp/ts/ --outDir out {{temp_file_name}} && cp out/code.js /app/temp/exe',
+       'node --enable-source-maps /app/temp/exe',
+       '/app/temp/ts', 'code.ts',
+       ARRAY['ts', 'typescript'], '// USER_CODE_HERE', ''
+WHERE NOT EXISTS (SELECT 1 FROM languages WHERE name = 'TypeScript');